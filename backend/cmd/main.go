@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 	"time"
 	"vendas/docs"
+	"vendas/internal/cep"
 	"vendas/internal/database"
 	"vendas/internal/domain"
 	"vendas/internal/repository"
@@ -50,10 +52,71 @@ func main() {
 	// Inicializa os repositories
 	produtoRepo := repository.NewProdutoRepository(database.DB)
 	vendaRepo := repository.NewVendaRepository(database.DB)
+	devolucaoRepo := repository.NewDevolucaoRepository(database.DB)
+	pagamentoRepo := repository.NewPagamentoRepository(database.DB)
+	parcelaRepo := repository.NewParcelaRepository(database.DB)
+	movimentacaoRepo := repository.NewMovimentacaoRepository(database.DB)
+	fornecedorRepo := repository.NewFornecedorRepository(database.DB)
+	pedidoCompraRepo := repository.NewPedidoCompraRepository(database.DB)
+	localRepo := repository.NewLocalRepository(database.DB)
+	transferenciaRepo := repository.NewTransferenciaRepository(database.DB)
+	clienteRepo := repository.NewClienteRepository(database.DB)
+	enderecoRepo := repository.NewEnderecoRepository(database.DB)
+	fidelidadeRepo := repository.NewFidelidadeRepository(database.DB)
+	usuarioRepo := repository.NewUsuarioRepository(database.DB)
+	auditoriaRepo := repository.NewAuditoriaRepository(database.DB)
+	privacidadeRepo := repository.NewPrivacidadeRepository(database.DB)
+	mesclagemRepo := repository.NewMesclagemRepository(database.DB)
+	sessaoRepo := repository.NewSessaoRepository(database.DB)
+	comissaoRepo := repository.NewComissaoRepository(database.DB)
+	metaRepo := repository.NewMetaRepository(database.DB)
+	orcamentoRepo := repository.NewOrcamentoRepository(database.DB)
+	reservaRepo := repository.NewReservaRepository(database.DB)
+	permissaoRepo := repository.NewPermissaoRepository(database.DB)
 
 	// Inicializa os services
 	produtoService := service.NewProdutoService(produtoRepo)
-	vendaService := service.NewVendaService(vendaRepo, produtoRepo)
+	vendaService := service.NewVendaService(vendaRepo, produtoRepo, pagamentoRepo, localRepo, clienteRepo, usuarioRepo, fidelidadeRepo)
+	devolucaoService := service.NewDevolucaoService(vendaRepo, devolucaoRepo)
+	pagamentoService := service.NewPagamentoService(pagamentoRepo)
+	contasReceberService := service.NewContasReceberService(parcelaRepo, vendaRepo)
+	estoqueService := service.NewEstoqueService(movimentacaoRepo, produtoRepo, vendaRepo, pedidoCompraRepo, localRepo, transferenciaRepo)
+	fornecedorService := service.NewFornecedorService(fornecedorRepo)
+	compraService := service.NewCompraService(pedidoCompraRepo, fornecedorRepo, produtoRepo, localRepo)
+	localService := service.NewLocalService(localRepo)
+	transferenciaService := service.NewTransferenciaService(transferenciaRepo, localRepo, produtoRepo)
+	clienteService := service.NewClienteService(clienteRepo, usuarioRepo)
+	// CEP_API_URL permite apontar a consulta de CEP para outro serviço compatível com o ViaCEP
+	enderecoService := service.NewEnderecoService(enderecoRepo, clienteRepo, cep.NewViaCEP(os.Getenv("CEP_API_URL")))
+	fidelidadeService := service.NewFidelidadeService(fidelidadeRepo, clienteRepo)
+	privacidadeService := service.NewPrivacidadeService(privacidadeRepo, auditoriaRepo, clienteRepo, usuarioRepo, enderecoRepo,
+		vendaRepo, pagamentoRepo, parcelaRepo, devolucaoRepo, fidelidadeRepo, mesclagemRepo, orcamentoRepo)
+	mesclagemService := service.NewMesclagemService(clienteRepo, mesclagemRepo)
+	sessaoService := service.NewSessaoService(sessaoRepo, usuarioRepo, os.Getenv("JWT_SECRET_KEY"))
+	comissaoService := service.NewComissaoService(comissaoRepo, produtoRepo, usuarioRepo)
+	metaService := service.NewMetaService(metaRepo, usuarioRepo, localRepo)
+	orcamentoService := service.NewOrcamentoService(orcamentoRepo, produtoRepo, clienteRepo, localRepo, vendaService)
+	// RESERVA_ESTOQUE_MINUTOS define por quanto tempo as reservas de estoque valem quando o
+	// pedido não informa o prazo
+	reservaService := service.NewReservaService(reservaRepo, vendaService, orcamentoService, prazoReservaEstoque())
+	permissaoService := service.NewPermissaoService(permissaoRepo)
+
+	// As rotas passam a consultar as permissões gravadas no banco no lugar das padrão
+	if err := permissaoService.Carregar(); err != nil {
+		log.Fatalf("Erro ao carregar as permissões: %v", err)
+	}
+
+	// Aponta no log os produtos cujo estoque não bate com o histórico de movimentações ou com
+	// os saldos por local
+	avisarDivergenciasEstoque(estoqueService)
+
+	// Registra periodicamente a expiração dos pontos de fidelidade vencidos, refaz a
+	// segmentação RFM dos clientes, apaga os refresh tokens vencidos e encerra as reservas de
+	// estoque vencidas
+	go expirarPontosPeriodicamente(fidelidadeService, time.Hour)
+	go segmentarClientesPeriodicamente(clienteService, 24*time.Hour)
+	go removerTokensVencidosPeriodicamente(sessaoService, 24*time.Hour)
+	go expirarReservasPeriodicamente(reservaService, time.Minute)
 
 	// Inicializa o router
 	router := gin.Default()
@@ -62,7 +125,7 @@ func main() {
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Autorizacao-Credito"},
 		ExposeHeaders:    []string{"Content-Length"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
@@ -75,7 +138,30 @@ func main() {
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 
 	// Configura as rotas
-	web.SetupRoutes(router, produtoService, vendaService)
+	web.SetupRoutes(
+		router,
+		produtoService,
+		vendaService,
+		devolucaoService,
+		pagamentoService,
+		contasReceberService,
+		estoqueService,
+		fornecedorService,
+		compraService,
+		localService,
+		transferenciaService,
+		clienteService,
+		enderecoService,
+		fidelidadeService,
+		privacidadeService,
+		mesclagemService,
+		sessaoService,
+		comissaoService,
+		metaService,
+		orcamentoService,
+		reservaService,
+		permissaoService,
+	)
 
 	// Inicia o servidor
 	if err := router.Run(":8080"); err != nil {
@@ -83,6 +169,99 @@ func main() {
 	}
 }
 
+// expirarPontosPeriodicamente lança a expiração dos pontos vencidos ao iniciar e depois a
+// cada intervalo
+func expirarPontosPeriodicamente(fidelidadeService *service.FidelidadeService, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		if expirados, err := fidelidadeService.ExpirarPontos(time.Now()); err != nil {
+			log.Printf("Erro ao expirar pontos de fidelidade: %v", err)
+		} else if expirados > 0 {
+			log.Printf("%d lotes de pontos de fidelidade expirados", expirados)
+		}
+		<-ticker.C
+	}
+}
+
+// segmentarClientesPeriodicamente refaz a segmentação RFM dos clientes ao iniciar e depois
+// a cada intervalo
+func segmentarClientesPeriodicamente(clienteService *service.ClienteService, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		if resultado, err := clienteService.SegmentarClientes(time.Now()); err != nil {
+			log.Printf("Erro ao segmentar clientes: %v", err)
+		} else {
+			log.Printf("%d clientes segmentados", resultado.Total)
+		}
+		<-ticker.C
+	}
+}
+
+// removerTokensVencidosPeriodicamente apaga os refresh tokens vencidos ao iniciar e depois a
+// cada intervalo
+func removerTokensVencidosPeriodicamente(sessaoService *service.SessaoService, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		if removidos, err := sessaoService.RemoverVencidos(time.Now()); err != nil {
+			log.Printf("Erro ao remover refresh tokens vencidos: %v", err)
+		} else if removidos > 0 {
+			log.Printf("%d refresh tokens vencidos removidos", removidos)
+		}
+		<-ticker.C
+	}
+}
+
+// avisarDivergenciasEstoque registra no log as divergências de estoque encontradas ao iniciar;
+// elas ficam disponíveis em /estoque/divergencias até serem acertadas com ajustes
+func avisarDivergenciasEstoque(estoqueService *service.EstoqueService) {
+	divergencias, err := estoqueService.GetDivergencias()
+	if err != nil {
+		log.Printf("Erro ao conferir o estoque: %v", err)
+		return
+	}
+	for _, d := range divergencias {
+		log.Printf("Estoque divergente do produto %s (%s): quantidade %d, movimentações %d, locais %d", d.Nome, d.ProdutoID,
+			d.Quantidade, d.SaldoMovimentacoes, d.SaldoLocais)
+	}
+}
+
+// expirarReservasPeriodicamente registra o fim das reservas de estoque vencidas ao iniciar
+// e depois a cada intervalo
+func expirarReservasPeriodicamente(reservaService *service.ReservaService, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		if expiradas, err := reservaService.ExpirarVencidas(time.Now()); err != nil {
+			log.Printf("Erro ao expirar reservas de estoque: %v", err)
+		} else if expiradas > 0 {
+			log.Printf("%d reservas de estoque expiradas", expiradas)
+		}
+		<-ticker.C
+	}
+}
+
+// prazoReservaEstoque lê de RESERVA_ESTOQUE_MINUTOS o prazo padrão das reservas de estoque;
+// sem a variável ou com valor inválido, vale domain.PrazoReservaPadrao
+func prazoReservaEstoque() time.Duration {
+	valor := os.Getenv("RESERVA_ESTOQUE_MINUTOS")
+	if valor == "" {
+		return domain.PrazoReservaPadrao
+	}
+	minutos, err := strconv.Atoi(valor)
+	if err != nil || minutos <= 0 {
+		log.Printf("RESERVA_ESTOQUE_MINUTOS inválido (%q), usando %v", valor, domain.PrazoReservaPadrao)
+		return domain.PrazoReservaPadrao
+	}
+	return time.Duration(minutos) * time.Minute
+}
+
 func loadInitialProducts() error {
 	file, err := os.ReadFile("productsCreate.json")
 	if err != nil {
@@ -97,7 +276,7 @@ func loadInitialProducts() error {
 	produtoRepo := repository.NewProdutoRepository(database.DB)
 	for _, produto := range produtos {
 		produto.DataCriacao = time.Now()
-		if err := produtoRepo.Create(&produto); err != nil {
+		if err := produtoRepo.Create(&produto, ""); err != nil {
 			return fmt.Errorf("erro ao criar produto %s: %v", produto.Nome, err)
 		}
 	}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -188,46 +189,40 @@ func main() {
 		userIDs[user.Email] = usuario.ID
 	}
 
-	// Insere clientes como usuários
+	// Insere clientes
+	var clientes []string
 	for _, client := range seedData.Clients {
-		// Verifica se o cliente já existe como usuário
-		var count int
-		err := database.DB.QueryRow("SELECT COUNT(*) FROM usuarios WHERE email = ?", client.Email).Scan(&count)
-		if err != nil {
-			log.Fatalf("Erro ao verificar cliente existente: %v", err)
-		}
-
-		if count > 0 {
-			log.Printf("Cliente %s já existe como usuário, pulando...", client.Email)
+		// Verifica se o cliente já existe
+		var clienteID string
+		err := database.DB.QueryRow("SELECT id FROM clientes WHERE email = ?", client.Email).Scan(&clienteID)
+		if err == nil {
+			log.Printf("Cliente %s já existe, pulando...", client.Email)
+			clientes = append(clientes, clienteID)
 			continue
 		}
-
-		// Gera uma senha aleatória para o cliente
-		senha := uuid.New().String()
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(senha), bcrypt.DefaultCost)
-		if err != nil {
-			log.Fatalf("Erro ao gerar hash da senha: %v", err)
+		if err != sql.ErrNoRows {
+			log.Fatalf("Erro ao verificar cliente existente: %v", err)
 		}
 
-		usuario := &domain.Usuario{
+		cliente := &domain.Cliente{
 			ID:          uuid.New().String(),
 			Nome:        client.Nome,
 			Email:       client.Email,
-			Senha:       string(hashedPassword),
-			Role:        domain.RoleCliente,
-			Ativo:       true,
+			Telefone:    client.Telefone,
+			Endereco:    client.Endereco,
+			CPF:         client.CPF,
 			DataCriacao: time.Now(),
 		}
 
 		_, err = database.DB.Exec(
-			"INSERT INTO usuarios (id, nome, email, senha, role, ativo, data_criacao) VALUES (?, ?, ?, ?, ?, ?, ?)",
-			usuario.ID, usuario.Nome, usuario.Email, usuario.Senha, usuario.Role, usuario.Ativo, usuario.DataCriacao,
+			"INSERT INTO clientes (id, nome, email, telefone, endereco, cpf, data_criacao) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			cliente.ID, cliente.Nome, cliente.Email, cliente.Telefone, cliente.Endereco, cliente.CPF, cliente.DataCriacao,
 		)
 		if err != nil {
 			log.Fatalf("Erro ao inserir cliente: %v", err)
 		}
 
-		userIDs[client.Email] = usuario.ID
+		clientes = append(clientes, cliente.ID)
 	}
 
 	// Insere produtos
@@ -250,6 +245,26 @@ func main() {
 			log.Fatalf("Erro ao inserir produto: %v", err)
 		}
 
+		// O estoque inicial entra no histórico de movimentações e no saldo do local principal
+		if produto.Quantidade > 0 {
+			_, err = database.DB.Exec(
+				"INSERT INTO movimentacoes_estoque (id, produto_id, local_id, tipo, quantidade, saldo_apos, motivo, data) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+				uuid.New().String(), produto.ID, domain.LocalPrincipal, domain.MovimentacaoEntrada, produto.Quantidade, produto.Quantidade,
+				"saldo inicial", produto.DataCriacao,
+			)
+			if err != nil {
+				log.Fatalf("Erro ao inserir movimentação de estoque: %v", err)
+			}
+
+			_, err = database.DB.Exec(
+				"INSERT INTO saldos_estoque (local_id, produto_id, quantidade) VALUES (?, ?, ?)",
+				domain.LocalPrincipal, produto.ID, produto.Quantidade,
+			)
+			if err != nil {
+				log.Fatalf("Erro ao inserir saldo de estoque: %v", err)
+			}
+		}
+
 		productIDs[product.Nome] = produto.ID
 	}
 
@@ -266,19 +281,6 @@ func main() {
 		}
 	}
 
-	// Lista de clientes disponíveis
-	var clientes []string
-	for _, id := range userIDs {
-		var role domain.Role
-		err := database.DB.QueryRow("SELECT role FROM usuarios WHERE id = ?", id).Scan(&role)
-		if err != nil {
-			log.Fatalf("Erro ao verificar role do usuário: %v", err)
-		}
-		if role == domain.RoleCliente {
-			clientes = append(clientes, id)
-		}
-	}
-
 	// Insere vendas usando os IDs reais
 	for _, sale := range seedData.Sales {
 		// Seleciona um cliente aleatório
@@ -302,8 +304,8 @@ func main() {
 
 		// Insere a venda
 		_, err = database.DB.Exec(
-			"INSERT INTO vendas (id, cliente_id, vendedor_id, data_venda, valor_total, data_criacao) VALUES (?, ?, ?, ?, ?, ?)",
-			venda.ID, venda.ClienteID, venda.VendedorID, venda.DataVenda, venda.ValorTotal, venda.DataCriacao,
+			"INSERT INTO vendas (id, cliente_id, vendedor_id, data_venda, subtotal, valor_total, data_criacao) VALUES (?, ?, ?, ?, ?, ?, ?)",
+			venda.ID, venda.ClienteID, venda.VendedorID, venda.DataVenda, venda.ValorTotal, venda.ValorTotal, venda.DataCriacao,
 		)
 		if err != nil {
 			log.Fatalf("Erro ao inserir venda: %v", err)
@@ -319,9 +321,10 @@ func main() {
 				PrecoUnitario: item.PrecoUnitario,
 			}
 
+			valor := float64(itemVenda.Quantidade) * itemVenda.PrecoUnitario
 			_, err = database.DB.Exec(
-				"INSERT INTO itens_venda (id, venda_id, produto_id, quantidade, preco_unitario) VALUES (?, ?, ?, ?, ?)",
-				itemVenda.ID, itemVenda.VendaID, itemVenda.ProdutoID, itemVenda.Quantidade, itemVenda.PrecoUnitario,
+				"INSERT INTO itens_venda (id, venda_id, produto_id, quantidade, preco_unitario, subtotal, total) VALUES (?, ?, ?, ?, ?, ?, ?)",
+				itemVenda.ID, itemVenda.VendaID, itemVenda.ProdutoID, itemVenda.Quantidade, itemVenda.PrecoUnitario, valor, valor,
 			)
 			if err != nil {
 				log.Fatalf("Erro ao inserir item de venda: %v", err)
@@ -0,0 +1,66 @@
+// Package cep resolve um CEP no logradouro, bairro, cidade e UF correspondentes.
+// O Resolver é uma interface para que a origem dos dados possa ser trocada: a Tabela
+// responde a partir de uma lista fixa, sem acesso à rede, e o ViaCEP consulta um
+// serviço HTTP no formato da API pública do ViaCEP.
+package cep
+
+import (
+	"errors"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+var (
+	// ErrCEPInvalido indica um CEP que não tem oito dígitos
+	ErrCEPInvalido = errors.New("CEP inválido")
+	// ErrCEPNaoEncontrado indica um CEP válido que a origem consultada não conhece
+	ErrCEPNaoEncontrado = errors.New("CEP não encontrado")
+	// ErrConsultaCEP indica que a origem dos dados não pôde ser consultada
+	ErrConsultaCEP = errors.New("falha ao consultar o CEP")
+)
+
+// Resolver busca o endereço de um CEP. O endereço retornado preenche apenas CEP,
+// logradouro, complemento, bairro, cidade e UF.
+type Resolver interface {
+	Buscar(cep string) (*domain.Endereco, error)
+}
+
+// Normalizar remove a pontuação do CEP e confere se restaram oito dígitos
+func Normalizar(cep string) (string, error) {
+	cep = utils.NormalizeDocument(cep)
+	if len(cep) != 8 {
+		return "", ErrCEPInvalido
+	}
+	return cep, nil
+}
+
+// Tabela resolve CEPs a partir de uma lista fixa em memória, útil em testes e em
+// ambientes sem acesso à rede
+type Tabela struct {
+	enderecos map[string]domain.Endereco
+}
+
+// NewTabela monta a tabela a partir dos endereços informados, indexados pelo CEP
+func NewTabela(enderecos []domain.Endereco) *Tabela {
+	tabela := &Tabela{enderecos: make(map[string]domain.Endereco)}
+	for _, endereco := range enderecos {
+		if cep, err := Normalizar(endereco.CEP); err == nil {
+			endereco.CEP = cep
+			tabela.enderecos[cep] = endereco
+		}
+	}
+	return tabela
+}
+
+func (t *Tabela) Buscar(cep string) (*domain.Endereco, error) {
+	cep, err := Normalizar(cep)
+	if err != nil {
+		return nil, err
+	}
+
+	endereco, ok := t.enderecos[cep]
+	if !ok {
+		return nil, ErrCEPNaoEncontrado
+	}
+	return &endereco, nil
+}
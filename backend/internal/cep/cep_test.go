@@ -0,0 +1,149 @@
+package cep
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"vendas/internal/domain"
+)
+
+func TestNormalizar(t *testing.T) {
+	casos := []struct {
+		cep      string
+		esperado string
+		err      error
+	}{
+		{"01310-100", "01310100", nil},
+		{"01310100", "01310100", nil},
+		{" 20.040-020 ", "20040020", nil},
+		{"0131010", "", ErrCEPInvalido},
+		{"013101000", "", ErrCEPInvalido},
+		{"abcde-fgh", "", ErrCEPInvalido},
+		{"", "", ErrCEPInvalido},
+	}
+
+	for _, caso := range casos {
+		obtido, err := Normalizar(caso.cep)
+		if !errors.Is(err, caso.err) {
+			t.Errorf("Normalizar(%q) retornou o erro %v, esperado %v", caso.cep, err, caso.err)
+			continue
+		}
+		if obtido != caso.esperado {
+			t.Errorf("Normalizar(%q) = %q, esperado %q", caso.cep, obtido, caso.esperado)
+		}
+	}
+}
+
+func TestTabelaBuscar(t *testing.T) {
+	tabela := NewTabela([]domain.Endereco{
+		{CEP: "01310-100", Logradouro: "Avenida Paulista", Bairro: "Bela Vista", Cidade: "São Paulo", UF: "SP"},
+		{CEP: "20040020", Logradouro: "Avenida Rio Branco", Bairro: "Centro", Cidade: "Rio de Janeiro", UF: "RJ"},
+		// Endereços com CEP inválido não entram na tabela
+		{CEP: "123", Logradouro: "Rua Sem CEP", Cidade: "Lugar Nenhum", UF: "SP"},
+	})
+
+	casos := []struct {
+		nome       string
+		cep        string
+		logradouro string
+		err        error
+	}{
+		{"cadastrado com pontuação", "01310-100", "Avenida Paulista", nil},
+		{"cadastrado sem pontuação", "01310100", "Avenida Paulista", nil},
+		{"buscado com pontuação", "20.040-020", "Avenida Rio Branco", nil},
+		{"não cadastrado", "99999999", "", ErrCEPNaoEncontrado},
+		{"inválido", "123", "", ErrCEPInvalido},
+		{"vazio", "", "", ErrCEPInvalido},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			endereco, err := tabela.Buscar(caso.cep)
+			if !errors.Is(err, caso.err) {
+				t.Fatalf("Buscar(%q) retornou o erro %v, esperado %v", caso.cep, err, caso.err)
+			}
+			if err != nil {
+				return
+			}
+			if endereco.Logradouro != caso.logradouro {
+				t.Errorf("Buscar(%q) retornou o logradouro %q, esperado %q", caso.cep, endereco.Logradouro, caso.logradouro)
+			}
+			if len(endereco.CEP) != 8 {
+				t.Errorf("Buscar(%q) retornou o CEP %q sem normalizar", caso.cep, endereco.CEP)
+			}
+		})
+	}
+}
+
+// O endereço retornado é uma cópia; alterá-lo não muda a tabela
+func TestTabelaBuscarRetornaCopia(t *testing.T) {
+	tabela := NewTabela([]domain.Endereco{{CEP: "01310100", Logradouro: "Avenida Paulista", Cidade: "São Paulo", UF: "SP"}})
+
+	endereco, err := tabela.Buscar("01310100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	endereco.Logradouro = "Alterado"
+
+	endereco, err = tabela.Buscar("01310100")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if endereco.Logradouro != "Avenida Paulista" {
+		t.Errorf("a tabela foi alterada pelo endereço retornado: %q", endereco.Logradouro)
+	}
+}
+
+func TestViaCEPBuscar(t *testing.T) {
+	servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/01310100/json":
+			w.Write([]byte(`{"cep":"01310-100","logradouro":"Avenida Paulista","complemento":"até 610 - lado par",
+				"bairro":"Bela Vista","localidade":"São Paulo","uf":"SP"}`))
+		case "/99999999/json":
+			w.Write([]byte(`{"erro":true}`))
+		case "/88888888/json":
+			w.Write([]byte(`{"erro":"true"}`))
+		case "/77777777/json":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/66666666/json":
+			w.Write([]byte(`não é json`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer servidor.Close()
+
+	resolver := NewViaCEP(servidor.URL + "/")
+
+	casos := []struct {
+		nome   string
+		cep    string
+		cidade string
+		err    error
+	}{
+		{"encontrado", "01310-100", "São Paulo", nil},
+		{"erro booleano", "99999-999", "", ErrCEPNaoEncontrado},
+		{"erro em texto", "88888888", "", ErrCEPNaoEncontrado},
+		{"não encontrado", "55555555", "", ErrCEPNaoEncontrado},
+		{"falha do serviço", "77777777", "", ErrConsultaCEP},
+		{"resposta inválida", "66666666", "", ErrConsultaCEP},
+		{"CEP inválido não consulta o serviço", "1234", "", ErrCEPInvalido},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			endereco, err := resolver.Buscar(caso.cep)
+			if !errors.Is(err, caso.err) {
+				t.Fatalf("Buscar(%q) retornou o erro %v, esperado %v", caso.cep, err, caso.err)
+			}
+			if err != nil {
+				return
+			}
+			if endereco.Cidade != caso.cidade || endereco.CEP != "01310100" || endereco.UF != "SP" {
+				t.Errorf("Buscar(%q) retornou %+v", caso.cep, endereco)
+			}
+		})
+	}
+}
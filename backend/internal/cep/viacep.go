@@ -0,0 +1,82 @@
+package cep
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"vendas/internal/domain"
+)
+
+// URLViaCEP é o endereço da API pública do ViaCEP
+const URLViaCEP = "https://viacep.com.br/ws"
+
+// ViaCEP consulta um serviço HTTP que responde em GET {url}/{cep}/json no formato do ViaCEP
+type ViaCEP struct {
+	url    string
+	client *http.Client
+}
+
+// NewViaCEP cria o resolver para o serviço informado, ou para a API pública quando a
+// URL for vazia
+func NewViaCEP(url string) *ViaCEP {
+	if url == "" {
+		url = URLViaCEP
+	}
+	return &ViaCEP{
+		url:    strings.TrimRight(url, "/"),
+		client: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// respostaViaCEP é o corpo retornado pelo serviço. CEPs inexistentes retornam o campo
+// erro, que já foi enviado tanto como booleano quanto como texto.
+type respostaViaCEP struct {
+	CEP         string          `json:"cep"`
+	Logradouro  string          `json:"logradouro"`
+	Complemento string          `json:"complemento"`
+	Bairro      string          `json:"bairro"`
+	Localidade  string          `json:"localidade"`
+	UF          string          `json:"uf"`
+	Erro        json.RawMessage `json:"erro"`
+}
+
+func (v *ViaCEP) Buscar(cep string) (*domain.Endereco, error) {
+	cep, err := Normalizar(cep)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.client.Get(fmt.Sprintf("%s/%s/json", v.url, cep))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrConsultaCEP, err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusBadRequest:
+		return nil, ErrCEPInvalido
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrCEPNaoEncontrado
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%w: serviço respondeu %d", ErrConsultaCEP, resp.StatusCode)
+	}
+
+	var resposta respostaViaCEP
+	if err := json.NewDecoder(resp.Body).Decode(&resposta); err != nil {
+		return nil, fmt.Errorf("%w: resposta inválida: %v", ErrConsultaCEP, err)
+	}
+	if erro := strings.Trim(string(resposta.Erro), `"`); erro != "" && erro != "false" {
+		return nil, ErrCEPNaoEncontrado
+	}
+
+	return &domain.Endereco{
+		CEP:         cep,
+		Logradouro:  resposta.Logradouro,
+		Complemento: resposta.Complemento,
+		Bairro:      resposta.Bairro,
+		Cidade:      resposta.Localidade,
+		UF:          resposta.UF,
+	}, nil
+}
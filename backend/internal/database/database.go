@@ -2,6 +2,11 @@ package database
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -20,6 +25,11 @@ func InitDB() error {
 		return err
 	}
 
+	// Aplica as alterações de schema em bancos já existentes
+	if err := migrateTables(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -40,6 +50,54 @@ func createTables() error {
 		return err
 	}
 
+	// Cria a tabela de clientes. O usuário vinculado é opcional e identifica o
+	// acesso do próprio cliente ao sistema.
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS clientes (
+			id TEXT PRIMARY KEY,
+			nome TEXT NOT NULL,
+			email TEXT NOT NULL DEFAULT '',
+			telefone TEXT NOT NULL DEFAULT '',
+			endereco TEXT NOT NULL DEFAULT '',
+			cpf TEXT NOT NULL DEFAULT '',
+			usuario_id TEXT,
+			data_criacao DATETIME NOT NULL,
+			FOREIGN KEY (usuario_id) REFERENCES usuarios(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_clientes_cpf ON clientes (cpf) WHERE cpf <> ''`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de endereços dos clientes
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS enderecos (
+			id TEXT PRIMARY KEY,
+			cliente_id TEXT NOT NULL,
+			tipo TEXT NOT NULL,
+			cep TEXT NOT NULL,
+			logradouro TEXT NOT NULL,
+			numero TEXT NOT NULL DEFAULT '',
+			complemento TEXT NOT NULL DEFAULT '',
+			bairro TEXT NOT NULL DEFAULT '',
+			cidade TEXT NOT NULL,
+			uf TEXT NOT NULL,
+			data_criacao DATETIME NOT NULL,
+			FOREIGN KEY (cliente_id) REFERENCES clientes(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_enderecos_cliente ON enderecos (cliente_id)`)
+	if err != nil {
+		return err
+	}
+
 	// Cria a tabela de produtos
 	_, err = DB.Exec(`
 		CREATE TABLE IF NOT EXISTS produtos (
@@ -65,7 +123,7 @@ func createTables() error {
 			data_venda DATETIME NOT NULL,
 			valor_total REAL NOT NULL,
 			data_criacao DATETIME NOT NULL,
-			FOREIGN KEY (cliente_id) REFERENCES usuarios(id),
+			FOREIGN KEY (cliente_id) REFERENCES clientes(id),
 			FOREIGN KEY (vendedor_id) REFERENCES usuarios(id)
 		)
 	`)
@@ -89,5 +147,1106 @@ func createTables() error {
 		return err
 	}
 
-	return nil
+	// Cria a tabela de histórico de status das vendas
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS historico_status_venda (
+			id TEXT PRIMARY KEY,
+			venda_id TEXT NOT NULL,
+			status_anterior TEXT NOT NULL,
+			status_novo TEXT NOT NULL,
+			usuario_id TEXT NOT NULL,
+			motivo TEXT NOT NULL DEFAULT '',
+			data DATETIME NOT NULL,
+			FOREIGN KEY (venda_id) REFERENCES vendas(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de devoluções
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS devolucoes (
+			id TEXT PRIMARY KEY,
+			venda_id TEXT NOT NULL,
+			usuario_id TEXT NOT NULL,
+			motivo TEXT NOT NULL DEFAULT '',
+			valor_reembolso REAL NOT NULL,
+			data_devolucao DATETIME NOT NULL,
+			FOREIGN KEY (venda_id) REFERENCES vendas(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de itens devolvidos
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS itens_devolucao (
+			id TEXT PRIMARY KEY,
+			devolucao_id TEXT NOT NULL,
+			item_venda_id TEXT NOT NULL,
+			produto_id TEXT NOT NULL,
+			quantidade INTEGER NOT NULL,
+			valor_reembolso REAL NOT NULL,
+			FOREIGN KEY (devolucao_id) REFERENCES devolucoes(id),
+			FOREIGN KEY (item_venda_id) REFERENCES itens_venda(id),
+			FOREIGN KEY (produto_id) REFERENCES produtos(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de pagamentos
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS pagamentos (
+			id TEXT PRIMARY KEY,
+			venda_id TEXT NOT NULL,
+			forma TEXT NOT NULL,
+			valor REAL NOT NULL,
+			valor_recebido REAL NOT NULL,
+			troco REAL NOT NULL DEFAULT 0,
+			parcelas INTEGER NOT NULL DEFAULT 1,
+			codigo_autorizacao TEXT NOT NULL DEFAULT '',
+			usuario_id TEXT NOT NULL,
+			data_pagamento DATETIME NOT NULL,
+			FOREIGN KEY (venda_id) REFERENCES vendas(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de parcelas a receber
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS parcelas (
+			id TEXT PRIMARY KEY,
+			venda_id TEXT NOT NULL,
+			cliente_id TEXT NOT NULL,
+			numero INTEGER NOT NULL,
+			valor REAL NOT NULL,
+			data_vencimento DATETIME NOT NULL,
+			status TEXT NOT NULL DEFAULT 'aberta',
+			valor_pago REAL NOT NULL DEFAULT 0,
+			data_pagamento DATETIME,
+			forma_pagamento TEXT NOT NULL DEFAULT '',
+			FOREIGN KEY (venda_id) REFERENCES vendas(id),
+			FOREIGN KEY (cliente_id) REFERENCES clientes(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de movimentações de estoque
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS movimentacoes_estoque (
+			id TEXT PRIMARY KEY,
+			produto_id TEXT NOT NULL,
+			tipo TEXT NOT NULL,
+			quantidade INTEGER NOT NULL,
+			saldo_apos INTEGER NOT NULL,
+			usuario_id TEXT NOT NULL DEFAULT '',
+			motivo TEXT NOT NULL DEFAULT '',
+			venda_id TEXT,
+			data DATETIME NOT NULL,
+			FOREIGN KEY (produto_id) REFERENCES produtos(id),
+			FOREIGN KEY (venda_id) REFERENCES vendas(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_movimentacoes_estoque_produto ON movimentacoes_estoque (produto_id, data)`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de fornecedores
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS fornecedores (
+			id TEXT PRIMARY KEY,
+			nome TEXT NOT NULL,
+			cnpj TEXT NOT NULL DEFAULT '',
+			email TEXT NOT NULL DEFAULT '',
+			telefone TEXT NOT NULL DEFAULT '',
+			contato TEXT NOT NULL DEFAULT '',
+			ativo INTEGER NOT NULL DEFAULT 1,
+			data_criacao DATETIME NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de pedidos de compra
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS pedidos_compra (
+			id TEXT PRIMARY KEY,
+			fornecedor_id TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'aberto',
+			observacao TEXT NOT NULL DEFAULT '',
+			usuario_id TEXT NOT NULL,
+			valor_total REAL NOT NULL,
+			previsao_entrega DATETIME,
+			data_pedido DATETIME NOT NULL,
+			FOREIGN KEY (fornecedor_id) REFERENCES fornecedores(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de itens dos pedidos de compra
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS itens_pedido_compra (
+			id TEXT PRIMARY KEY,
+			pedido_compra_id TEXT NOT NULL,
+			produto_id TEXT NOT NULL,
+			quantidade INTEGER NOT NULL,
+			quantidade_recebida INTEGER NOT NULL DEFAULT 0,
+			custo_unitario REAL NOT NULL,
+			total REAL NOT NULL,
+			FOREIGN KEY (pedido_compra_id) REFERENCES pedidos_compra(id),
+			FOREIGN KEY (produto_id) REFERENCES produtos(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de locais de estoque (depósitos e lojas)
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS locais_estoque (
+			id TEXT PRIMARY KEY,
+			nome TEXT NOT NULL,
+			tipo TEXT NOT NULL,
+			ativo INTEGER NOT NULL DEFAULT 1,
+			data_criacao DATETIME NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de saldos de cada produto por local
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS saldos_estoque (
+			local_id TEXT NOT NULL,
+			produto_id TEXT NOT NULL,
+			quantidade INTEGER NOT NULL DEFAULT 0,
+			PRIMARY KEY (local_id, produto_id),
+			FOREIGN KEY (local_id) REFERENCES locais_estoque(id),
+			FOREIGN KEY (produto_id) REFERENCES produtos(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de transferências entre locais
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS transferencias (
+			id TEXT PRIMARY KEY,
+			origem_id TEXT NOT NULL,
+			destino_id TEXT NOT NULL,
+			status TEXT NOT NULL DEFAULT 'em_transito',
+			observacao TEXT NOT NULL DEFAULT '',
+			usuario_id TEXT NOT NULL,
+			data_envio DATETIME NOT NULL,
+			data_recebimento DATETIME,
+			FOREIGN KEY (origem_id) REFERENCES locais_estoque(id),
+			FOREIGN KEY (destino_id) REFERENCES locais_estoque(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de itens das transferências
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS itens_transferencia (
+			id TEXT PRIMARY KEY,
+			transferencia_id TEXT NOT NULL,
+			produto_id TEXT NOT NULL,
+			quantidade INTEGER NOT NULL,
+			FOREIGN KEY (transferencia_id) REFERENCES transferencias(id),
+			FOREIGN KEY (produto_id) REFERENCES produtos(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de lançamentos de pontos de fidelidade. Os créditos guardam em
+	// restante o que ainda pode ser resgatado até a data de expiração.
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS pontos_fidelidade (
+			id TEXT PRIMARY KEY,
+			cliente_id TEXT NOT NULL,
+			venda_id TEXT,
+			tipo TEXT NOT NULL,
+			pontos INTEGER NOT NULL,
+			restante INTEGER NOT NULL DEFAULT 0,
+			data_expiracao DATETIME,
+			descricao TEXT NOT NULL DEFAULT '',
+			usuario_id TEXT,
+			data_lancamento DATETIME NOT NULL,
+			FOREIGN KEY (cliente_id) REFERENCES clientes(id),
+			FOREIGN KEY (venda_id) REFERENCES vendas(id),
+			FOREIGN KEY (usuario_id) REFERENCES usuarios(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_pontos_fidelidade_cliente ON pontos_fidelidade (cliente_id, data_expiracao)`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de regras de pontuação do programa de fidelidade
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS regras_fidelidade (
+			id TEXT PRIMARY KEY,
+			descricao TEXT NOT NULL,
+			tipo TEXT NOT NULL,
+			categoria TEXT NOT NULL DEFAULT '',
+			pontos_por_real REAL NOT NULL DEFAULT 0,
+			multiplicador REAL NOT NULL DEFAULT 0,
+			inicio DATETIME,
+			fim DATETIME,
+			ativa INTEGER NOT NULL DEFAULT 1,
+			data_criacao DATETIME NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela com o resultado da última segmentação RFM dos clientes
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS segmentos_clientes (
+			cliente_id TEXT PRIMARY KEY,
+			segmento TEXT NOT NULL,
+			recencia INTEGER NOT NULL DEFAULT 0,
+			frequencia INTEGER NOT NULL DEFAULT 0,
+			monetario REAL NOT NULL DEFAULT 0,
+			ultima_compra DATETIME,
+			nota_r INTEGER NOT NULL DEFAULT 0,
+			nota_f INTEGER NOT NULL DEFAULT 0,
+			nota_m INTEGER NOT NULL DEFAULT 0,
+			data_calculo DATETIME NOT NULL,
+			FOREIGN KEY (cliente_id) REFERENCES clientes(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_segmentos_clientes_segmento ON segmentos_clientes (segmento)`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a trilha de auditoria das operações sobre dados pessoais
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS auditoria (
+			id TEXT PRIMARY KEY,
+			acao TEXT NOT NULL,
+			entidade TEXT NOT NULL,
+			entidade_id TEXT NOT NULL,
+			usuario_id TEXT,
+			detalhes TEXT NOT NULL DEFAULT '',
+			data DATETIME NOT NULL,
+			FOREIGN KEY (usuario_id) REFERENCES usuarios(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_auditoria_entidade ON auditoria (entidade, entidade_id)`)
+	if err != nil {
+		return err
+	}
+
+	// Cria o registro das mesclagens de clientes duplicados
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS mesclagens_clientes (
+			id TEXT PRIMARY KEY,
+			cliente_id TEXT NOT NULL,
+			duplicado_id TEXT NOT NULL,
+			dados_duplicado TEXT NOT NULL,
+			referencias TEXT NOT NULL,
+			usuario_id TEXT,
+			data DATETIME NOT NULL,
+			FOREIGN KEY (cliente_id) REFERENCES clientes(id),
+			FOREIGN KEY (usuario_id) REFERENCES usuarios(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de refresh tokens; cada sessão é a família dos tokens emitidos a partir de um login
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS refresh_tokens (
+			id TEXT PRIMARY KEY,
+			usuario_id TEXT NOT NULL,
+			sessao_id TEXT NOT NULL,
+			token_hash TEXT NOT NULL UNIQUE,
+			data_criacao DATETIME NOT NULL,
+			expira_em DATETIME NOT NULL,
+			substituido_por TEXT,
+			revogado_em DATETIME,
+			FOREIGN KEY (usuario_id) REFERENCES usuarios(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_sessao ON refresh_tokens (sessao_id)`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_refresh_tokens_usuario ON refresh_tokens (usuario_id)`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de regras de comissão de vendedores
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS regras_comissao (
+			id TEXT PRIMARY KEY,
+			descricao TEXT NOT NULL,
+			tipo TEXT NOT NULL,
+			produto_id TEXT NOT NULL DEFAULT '',
+			categoria TEXT NOT NULL DEFAULT '',
+			percentual REAL NOT NULL DEFAULT 0,
+			meta_mensal REAL NOT NULL DEFAULT 0,
+			inicio DATETIME,
+			fim DATETIME,
+			ativa INTEGER NOT NULL DEFAULT 1,
+			data_criacao DATETIME NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de extratos mensais de comissão fechados; os meses em aberto não são gravados
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS extratos_comissao (
+			id TEXT PRIMARY KEY,
+			vendedor_id TEXT NOT NULL,
+			mes TEXT NOT NULL,
+			status TEXT NOT NULL,
+			total_vendas REAL NOT NULL DEFAULT 0,
+			percentual_base REAL NOT NULL DEFAULT 0,
+			valor_comissao REAL NOT NULL DEFAULT 0,
+			valor_ajustes REAL NOT NULL DEFAULT 0,
+			data_fechamento DATETIME NOT NULL,
+			fechado_por TEXT NOT NULL,
+			data_aprovacao DATETIME,
+			aprovado_por TEXT,
+			UNIQUE (vendedor_id, mes),
+			FOREIGN KEY (vendedor_id) REFERENCES usuarios(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela das linhas dos extratos de comissão fechados
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS itens_extrato_comissao (
+			id TEXT PRIMARY KEY,
+			extrato_id TEXT NOT NULL,
+			venda_id TEXT NOT NULL,
+			item_venda_id TEXT NOT NULL,
+			produto_id TEXT NOT NULL DEFAULT '',
+			data_venda DATETIME NOT NULL,
+			base REAL NOT NULL,
+			percentual REAL NOT NULL,
+			valor REAL NOT NULL,
+			ajuste INTEGER NOT NULL DEFAULT 0,
+			FOREIGN KEY (extrato_id) REFERENCES extratos_comissao(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_itens_extrato_comissao_extrato ON itens_extrato_comissao (extrato_id)`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de metas de vendas por vendedor, loja ou categoria
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS metas (
+			id TEXT PRIMARY KEY,
+			descricao TEXT NOT NULL DEFAULT '',
+			tipo TEXT NOT NULL,
+			vendedor_id TEXT NOT NULL DEFAULT '',
+			local_id TEXT NOT NULL DEFAULT '',
+			categoria TEXT NOT NULL DEFAULT '',
+			inicio DATETIME NOT NULL,
+			fim DATETIME NOT NULL,
+			valor REAL NOT NULL,
+			data_criacao DATETIME NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de orçamentos
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS orcamentos (
+			id TEXT PRIMARY KEY,
+			cliente_id TEXT NOT NULL,
+			vendedor_id TEXT NOT NULL,
+			local_id TEXT NOT NULL,
+			status TEXT NOT NULL,
+			valido_ate DATETIME NOT NULL,
+			tipo_desconto TEXT NOT NULL DEFAULT '',
+			desconto REAL NOT NULL DEFAULT 0,
+			subtotal REAL NOT NULL DEFAULT 0,
+			valor_desconto REAL NOT NULL DEFAULT 0,
+			valor_total REAL NOT NULL DEFAULT 0,
+			observacoes TEXT NOT NULL DEFAULT '',
+			venda_id TEXT,
+			data_conversao DATETIME,
+			data_criacao DATETIME NOT NULL,
+			FOREIGN KEY (cliente_id) REFERENCES clientes(id),
+			FOREIGN KEY (vendedor_id) REFERENCES usuarios(id),
+			FOREIGN KEY (venda_id) REFERENCES vendas(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de itens de orçamento, com os descontos solicitados para refazer o
+	// cálculo na conversão em venda
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS itens_orcamento (
+			id TEXT PRIMARY KEY,
+			orcamento_id TEXT NOT NULL,
+			produto_id TEXT NOT NULL,
+			quantidade INTEGER NOT NULL,
+			preco_unitario REAL NOT NULL,
+			tipo_desconto TEXT NOT NULL DEFAULT '',
+			desconto REAL NOT NULL DEFAULT 0,
+			subtotal REAL NOT NULL DEFAULT 0,
+			valor_desconto REAL NOT NULL DEFAULT 0,
+			total REAL NOT NULL DEFAULT 0,
+			FOREIGN KEY (orcamento_id) REFERENCES orcamentos(id),
+			FOREIGN KEY (produto_id) REFERENCES produtos(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_itens_orcamento_orcamento ON itens_orcamento (orcamento_id)`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a tabela de reservas de estoque para vendas em rascunho e orçamentos
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS reservas_estoque (
+			id TEXT PRIMARY KEY,
+			local_id TEXT NOT NULL,
+			produto_id TEXT NOT NULL,
+			quantidade INTEGER NOT NULL,
+			venda_id TEXT,
+			orcamento_id TEXT,
+			usuario_id TEXT NOT NULL,
+			status TEXT NOT NULL,
+			data_criacao DATETIME NOT NULL,
+			expira_em DATETIME NOT NULL,
+			data_liberacao DATETIME,
+			FOREIGN KEY (local_id) REFERENCES locais_estoque(id),
+			FOREIGN KEY (produto_id) REFERENCES produtos(id),
+			FOREIGN KEY (venda_id) REFERENCES vendas(id),
+			FOREIGN KEY (orcamento_id) REFERENCES orcamentos(id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE INDEX IF NOT EXISTS idx_reservas_estoque_saldo ON reservas_estoque (local_id, produto_id, status)`)
+	if err != nil {
+		return err
+	}
+
+	// Cria o registro das migrações de dados que só podem rodar uma vez
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS migracoes (
+			nome TEXT PRIMARY KEY,
+			data_execucao DATETIME NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cria a matriz de permissões concedidas a cada papel
+	_, err = DB.Exec(`
+		CREATE TABLE IF NOT EXISTS permissoes_roles (
+			role TEXT NOT NULL,
+			permissao TEXT NOT NULL,
+			PRIMARY KEY (role, permissao)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// migrateTables aplica as colunas incluídas depois da criação inicial das tabelas.
+// Cada alteração é idempotente, de modo que pode rodar a cada inicialização.
+func migrateTables() error {
+	// Valores de subtotal e desconto da venda
+	added, err := addColumn("vendas", "subtotal", "REAL NOT NULL DEFAULT 0")
+	if err != nil {
+		return err
+	}
+	if added {
+		if _, err := DB.Exec(`UPDATE vendas SET subtotal = valor_total`); err != nil {
+			return err
+		}
+	}
+	if _, err := addColumn("vendas", "valor_desconto", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	// Valores de subtotal, desconto e total de cada item
+	added, err = addColumn("itens_venda", "subtotal", "REAL NOT NULL DEFAULT 0")
+	if err != nil {
+		return err
+	}
+	if _, err := addColumn("itens_venda", "valor_desconto", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if _, err := addColumn("itens_venda", "total", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if added {
+		_, err := DB.Exec(`
+			UPDATE itens_venda
+			SET subtotal = quantidade * preco_unitario,
+				total = quantidade * preco_unitario
+		`)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Status da venda; as vendas já registradas foram efetivadas e baixaram o estoque
+	if _, err := addColumn("vendas", "status", "TEXT NOT NULL DEFAULT 'confirmada'"); err != nil {
+		return err
+	}
+
+	// Plano de parcelamento das vendas a prazo
+	if _, err := addColumn("vendas", "numero_parcelas", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if _, err := addColumn("vendas", "taxa_juros", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if _, err := addColumn("vendas", "primeiro_vencimento", "DATETIME"); err != nil {
+		return err
+	}
+
+	// Custo dos produtos e referência das entradas aos pedidos de compra
+	if _, err := addColumn("produtos", "custo", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if _, err := addColumn("movimentacoes_estoque", "pedido_compra_id", "TEXT REFERENCES pedidos_compra(id)"); err != nil {
+		return err
+	}
+
+	// Níveis de reposição dos produtos
+	if _, err := addColumn("produtos", "estoque_minimo", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if _, err := addColumn("produtos", "estoque_maximo", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if _, err := addColumn("produtos", "prazo_entrega_dias", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	// Locais de estoque; o que já existia pertence ao local principal
+	_, err = DB.Exec(`INSERT OR IGNORE INTO locais_estoque (id, nome, tipo, ativo, data_criacao) VALUES (?, ?, ?, 1, ?)`,
+		domain.LocalPrincipal, "Depósito principal", domain.LocalDeposito, time.Now())
+	if err != nil {
+		return err
+	}
+	localPadrao := fmt.Sprintf("TEXT NOT NULL DEFAULT '%s'", domain.LocalPrincipal)
+	if _, err := addColumn("vendas", "local_id", localPadrao); err != nil {
+		return err
+	}
+	if _, err := addColumn("pedidos_compra", "local_id", localPadrao); err != nil {
+		return err
+	}
+	if _, err := addColumn("movimentacoes_estoque", "local_id", localPadrao); err != nil {
+		return err
+	}
+	if _, err := addColumn("movimentacoes_estoque", "transferencia_id", "TEXT REFERENCES transferencias(id)"); err != nil {
+		return err
+	}
+
+	// Custo das entradas e custo unitário dos itens vendidos. As vendas anteriores
+	// recebem o custo atual dos produtos, a melhor estimativa disponível.
+	if _, err := addColumn("movimentacoes_estoque", "custo_unitario", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	added, err = addColumn("itens_venda", "custo_unitario", "REAL NOT NULL DEFAULT 0")
+	if err != nil {
+		return err
+	}
+	if added {
+		_, err := DB.Exec(`UPDATE itens_venda SET custo_unitario = COALESCE((SELECT custo FROM produtos WHERE id = itens_venda.produto_id), 0)`)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Clientes das vendas antigas, que eram usuários com o papel cliente
+	if err := migrarClientes(); err != nil {
+		return err
+	}
+
+	// Clientes pessoa jurídica. Os documentos passam a ser gravados apenas com os dígitos;
+	// um CPF que colida com outro já normalizado fica como está para revisão manual.
+	tipoPadrao := fmt.Sprintf("TEXT NOT NULL DEFAULT '%s'", domain.PessoaFisica)
+	if _, err := addColumn("clientes", "tipo_pessoa", tipoPadrao); err != nil {
+		return err
+	}
+	if _, err := addColumn("clientes", "cnpj", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if _, err := addColumn("clientes", "inscricao_estadual", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if _, err := addColumn("clientes", "isento_ie", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_clientes_cnpj ON clientes (cnpj) WHERE cnpj <> ''`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`
+		UPDATE OR IGNORE clientes
+		SET cpf = REPLACE(REPLACE(REPLACE(REPLACE(cpf, '.', ''), '-', ''), '/', ''), ' ', '')
+		WHERE cpf GLOB '*[^0-9]*'
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Cada usuário de acesso pertence a um único cliente. Vínculos repetidos gravados antes
+	// do índice ficam apenas no cliente mais antigo.
+	_, err = DB.Exec(`
+		UPDATE clientes SET usuario_id = NULL
+		WHERE usuario_id IS NOT NULL AND EXISTS (
+			SELECT 1 FROM clientes c
+			WHERE c.usuario_id = clientes.usuario_id
+				AND (c.data_criacao < clientes.data_criacao
+					OR (c.data_criacao = clientes.data_criacao AND c.rowid < clientes.rowid))
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec(`CREATE UNIQUE INDEX IF NOT EXISTS idx_clientes_usuario ON clientes (usuario_id) WHERE usuario_id IS NOT NULL`)
+	if err != nil {
+		return err
+	}
+
+	// Limite de crédito dos clientes e administrador que liberou uma venda além do limite
+	if _, err := addColumn("clientes", "limite_credito", "REAL NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if _, err := addColumn("vendas", "liberacao_credito", "TEXT REFERENCES usuarios(id)"); err != nil {
+		return err
+	}
+
+	// Categoria dos produtos e pontos resgatados nos pagamentos com pontos de fidelidade
+	if _, err := addColumn("produtos", "categoria", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if _, err := addColumn("pagamentos", "pontos", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	// Data em que os dados pessoais do cliente foram anonimizados
+	if _, err := addColumn("clientes", "data_anonimizacao", "DATETIME"); err != nil {
+		return err
+	}
+
+	// Orçamento convertido na venda
+	if _, err := addColumn("vendas", "orcamento_id", "TEXT REFERENCES orcamentos(id)"); err != nil {
+		return err
+	}
+
+	if err := executarUmaVez("saldo_inicial_estoque", registrarSaldoInicial); err != nil {
+		return err
+	}
+	if err := executarUmaVez("saldos_locais_iniciais", registrarSaldosLocaisIniciais); err != nil {
+		return err
+	}
+	return executarUmaVez("permissoes_padrao", registrarPermissoesPadrao)
+}
+
+// registrarPermissoesPadrao grava a matriz de permissões padrão. Roda uma única vez, para
+// não desfazer as alterações feitas depois pela API.
+func registrarPermissoesPadrao(tx *sql.Tx) error {
+	for role, permissoes := range domain.PermissoesPadrao {
+		for _, permissao := range permissoes {
+			_, err := tx.Exec(`INSERT OR IGNORE INTO permissoes_roles (role, permissao) VALUES (?, ?)`, role, permissao)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// executarUmaVez aplica a migração de dados na primeira inicialização e a registra em
+// migracoes na mesma transação, para que não volte a rodar nas seguintes
+func executarUmaVez(nome string, migracao func(tx *sql.Tx) error) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var executada int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM migracoes WHERE nome = ?`, nome).Scan(&executada); err != nil {
+		return err
+	}
+	if executada > 0 {
+		return nil
+	}
+
+	if err := migracao(tx); err != nil {
+		return fmt.Errorf("migração %s: %w", nome, err)
+	}
+	if _, err := tx.Exec(`INSERT INTO migracoes (nome, data_execucao) VALUES (?, ?)`, nome, time.Now()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// migrarClientes cria um cliente para cada usuário com o papel cliente ou referenciado
+// como cliente de uma venda, mantendo o mesmo ID para que as vendas e parcelas continuem
+// apontando para ele. Em seguida reconstrói as tabelas cuja chave estrangeira do cliente
+// ainda aponta para usuarios.
+func migrarClientes() error {
+	_, err := DB.Exec(`
+		INSERT INTO clientes (id, nome, email, usuario_id, data_criacao)
+		SELECT u.id, u.nome, u.email, CASE WHEN u.role = ? THEN u.id END, u.data_criacao
+		FROM usuarios u
+		WHERE (u.role = ? OR u.id IN (SELECT cliente_id FROM vendas))
+			AND NOT EXISTS (SELECT 1 FROM clientes c WHERE c.id = u.id)
+	`, domain.RoleCliente, domain.RoleCliente)
+	if err != nil {
+		return err
+	}
+
+	for _, tabela := range []string{"vendas", "parcelas"} {
+		if err := apontarClienteParaClientes(tabela); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apontarClienteParaClientes troca a referência de cliente_id de usuarios para clientes.
+// O SQLite não altera chaves estrangeiras, então a tabela é recriada com a mesma definição,
+// montada coluna a coluna a partir do catálogo, e os dados e índices são copiados na mesma
+// transação. Falha quando a tabela não tem a chave estrangeira de cliente esperada ou
+// quando a tabela recriada não ficou apontando para clientes.
+func apontarClienteParaClientes(tabela string) error {
+	colunas, err := colunasTabela(tabela)
+	if err != nil {
+		return err
+	}
+	chaves, err := chavesEstrangeiras(tabela)
+	if err != nil {
+		return err
+	}
+
+	var chaveCliente *chaveEstrangeira
+	for i := range chaves {
+		if len(chaves[i].origem) == 1 && chaves[i].origem[0] == "cliente_id" {
+			chaveCliente = &chaves[i]
+		}
+	}
+	if chaveCliente == nil {
+		return fmt.Errorf("tabela %s não tem chave estrangeira de cliente_id", tabela)
+	}
+	switch chaveCliente.destino {
+	case "clientes":
+		return nil
+	case "usuarios":
+		chaveCliente.destino = "clientes"
+	default:
+		return fmt.Errorf("chave estrangeira de cliente_id da tabela %s aponta para %s", tabela, chaveCliente.destino)
+	}
+
+	var indices []string
+	rows, err := DB.Query(`SELECT sql FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND sql IS NOT NULL`, tabela)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var indice string
+		if err := rows.Scan(&indice); err != nil {
+			rows.Close()
+			return err
+		}
+		indices = append(indices, indice)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	temporaria := tabela + "_migracao"
+	var definicoes, nomes []string
+	chavePrimaria := make([]string, len(colunas))
+	for _, coluna := range colunas {
+		definicao := coluna.nome + " " + coluna.tipo
+		if coluna.notNull {
+			definicao += " NOT NULL"
+		}
+		if coluna.padrao.Valid {
+			definicao += " DEFAULT (" + coluna.padrao.String + ")"
+		}
+		definicoes = append(definicoes, definicao)
+		nomes = append(nomes, coluna.nome)
+		// A posição na chave primária começa em 1; zero indica coluna fora da chave
+		if coluna.chavePrimaria > 0 {
+			chavePrimaria[coluna.chavePrimaria-1] = coluna.nome
+		}
+	}
+	var colunasChave []string
+	for _, nome := range chavePrimaria {
+		if nome != "" {
+			colunasChave = append(colunasChave, nome)
+		}
+	}
+	if len(colunasChave) > 0 {
+		definicoes = append(definicoes, "PRIMARY KEY ("+strings.Join(colunasChave, ", ")+")")
+	}
+	for _, chave := range chaves {
+		definicao := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s", strings.Join(chave.origem, ", "), chave.destino)
+		if len(chave.para) > 0 {
+			definicao += "(" + strings.Join(chave.para, ", ") + ")"
+		}
+		definicoes = append(definicoes, definicao)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	listaColunas := strings.Join(nomes, ", ")
+	comandos := []string{
+		fmt.Sprintf("CREATE TABLE %s (\n\t%s\n)", temporaria, strings.Join(definicoes, ",\n\t")),
+		fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", temporaria, listaColunas, listaColunas, tabela),
+		fmt.Sprintf("DROP TABLE %s", tabela),
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", temporaria, tabela),
+	}
+	comandos = append(comandos, indices...)
+	for _, comando := range comandos {
+		if _, err := tx.Exec(comando); err != nil {
+			return fmt.Errorf("migração da chave de cliente da tabela %s: %w", tabela, err)
+		}
+	}
+
+	var apontaClientes int
+	err = tx.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM pragma_foreign_key_list('%s') WHERE "from" = 'cliente_id' AND "table" = 'clientes'`,
+		tabela)).Scan(&apontaClientes)
+	if err != nil {
+		return err
+	}
+	if apontaClientes == 0 {
+		return fmt.Errorf("tabela %s recriada sem a chave estrangeira de cliente_id para clientes", tabela)
+	}
+	return tx.Commit()
+}
+
+// colunaTabela descreve uma coluna como registrada no catálogo do SQLite
+type colunaTabela struct {
+	nome          string
+	tipo          string
+	notNull       bool
+	padrao        sql.NullString
+	chavePrimaria int
+}
+
+func colunasTabela(tabela string) ([]colunaTabela, error) {
+	rows, err := DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", tabela))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var colunas []colunaTabela
+	for rows.Next() {
+		var cid int
+		var coluna colunaTabela
+		if err := rows.Scan(&cid, &coluna.nome, &coluna.tipo, &coluna.notNull, &coluna.padrao, &coluna.chavePrimaria); err != nil {
+			return nil, err
+		}
+		colunas = append(colunas, coluna)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(colunas) == 0 {
+		return nil, fmt.Errorf("tabela %s não encontrada", tabela)
+	}
+	return colunas, nil
+}
+
+// chaveEstrangeira descreve uma chave estrangeira, possivelmente composta, de uma tabela
+type chaveEstrangeira struct {
+	destino string
+	origem  []string
+	para    []string
+}
+
+func chavesEstrangeiras(tabela string) ([]chaveEstrangeira, error) {
+	rows, err := DB.Query(fmt.Sprintf("PRAGMA foreign_key_list(%s)", tabela))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var chaves []chaveEstrangeira
+	posicao := make(map[int]int)
+	for rows.Next() {
+		var id, seq int
+		var destino, origem string
+		var para, onUpdate, onDelete, match sql.NullString
+		if err := rows.Scan(&id, &seq, &destino, &origem, &para, &onUpdate, &onDelete, &match); err != nil {
+			return nil, err
+		}
+		i, ok := posicao[id]
+		if !ok {
+			i = len(chaves)
+			posicao[id] = i
+			chaves = append(chaves, chaveEstrangeira{destino: destino})
+		}
+		chaves[i].origem = append(chaves[i].origem, origem)
+		if para.Valid && para.String != "" {
+			chaves[i].para = append(chaves[i].para, para.String)
+		}
+	}
+	return chaves, rows.Err()
+}
+
+// registrarSaldosLocaisIniciais leva para o local principal o estoque que existia antes dos
+// saldos por local. Roda uma única vez; diferenças que surgirem depois entre a soma dos
+// locais e a quantidade do produto são apontadas pela conferência de estoque.
+func registrarSaldosLocaisIniciais(tx *sql.Tx) error {
+	_, err := tx.Exec(`
+		INSERT INTO saldos_estoque (local_id, produto_id, quantidade)
+		SELECT ?, p.id, p.quantidade - COALESCE(s.total, 0)
+		FROM produtos p
+		LEFT JOIN (SELECT produto_id, SUM(quantidade) AS total FROM saldos_estoque GROUP BY produto_id) s
+			ON s.produto_id = p.id
+		WHERE p.quantidade <> COALESCE(s.total, 0)
+		ON CONFLICT (local_id, produto_id) DO UPDATE SET quantidade = quantidade + excluded.quantidade
+	`, domain.LocalPrincipal)
+	return err
+}
+
+// registrarSaldoInicial lança como ajuste a quantidade dos produtos cadastrados antes do
+// histórico de movimentações, para que o histórico parta do estoque que já existia. Roda
+// uma única vez; diferenças que surgirem depois são apontadas pela conferência de estoque
+// e não corrigidas automaticamente.
+func registrarSaldoInicial(tx *sql.Tx) error {
+	rows, err := tx.Query(`
+		SELECT p.id, p.quantidade, p.quantidade - COALESCE(SUM(m.quantidade), 0)
+		FROM produtos p
+		LEFT JOIN movimentacoes_estoque m ON m.produto_id = p.id
+		GROUP BY p.id, p.quantidade
+		HAVING p.quantidade <> COALESCE(SUM(m.quantidade), 0)
+	`)
+	if err != nil {
+		return err
+	}
+
+	type divergencia struct {
+		produtoID  string
+		quantidade int
+		diferenca  int
+	}
+	var divergencias []divergencia
+	for rows.Next() {
+		var d divergencia
+		if err := rows.Scan(&d.produtoID, &d.quantidade, &d.diferenca); err != nil {
+			rows.Close()
+			return err
+		}
+		divergencias = append(divergencias, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, d := range divergencias {
+		query := `INSERT INTO movimentacoes_estoque (id, produto_id, tipo, quantidade, saldo_apos, motivo, data)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`
+		_, err := tx.Exec(query, utils.GenerateUUID(), d.produtoID, domain.MovimentacaoAjuste, d.diferenca, d.quantidade, "saldo inicial", time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addColumn adiciona a coluna na tabela caso ela ainda não exista.
+// Retorna true quando a coluna foi criada nesta chamada.
+func addColumn(table, column, definition string) (bool, error) {
+	rows, err := DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return false, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return false, err
+	}
+	rows.Close()
+
+	_, err = DB.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return false, err
+	}
+	return true, nil
 }
@@ -1,8 +1,42 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
-// Cliente representa um cliente do sistema
+// ErrClienteInvalido indica que os dados informados para o cliente não passaram na validação
+var ErrClienteInvalido = errors.New("dados do cliente inválidos")
+
+// ErrDocumentoJaCadastrado indica que o CPF ou CNPJ já pertence a outro cliente
+var ErrDocumentoJaCadastrado = errors.New("documento já cadastrado")
+
+// ErrUsuarioJaVinculado indica que o usuário de acesso já pertence a outro cliente
+var ErrUsuarioJaVinculado = errors.New("usuário já vinculado a outro cliente")
+
+// ErrClienteComVendas indica que o cliente não pode ser removido por estar referenciado em vendas
+var ErrClienteComVendas = errors.New("cliente possui vendas e não pode ser removido")
+
+// TipoPessoa distingue clientes pessoa física, identificados pelo CPF, de clientes
+// pessoa jurídica, identificados pelo CNPJ
+type TipoPessoa string
+
+const (
+	PessoaFisica   TipoPessoa = "fisica"
+	PessoaJuridica TipoPessoa = "juridica"
+)
+
+// Valida informa se o tipo de pessoa é um dos aceitos pelo sistema
+func (t TipoPessoa) Valida() bool {
+	return t == PessoaFisica || t == PessoaJuridica
+}
+
+// Cliente representa um cliente do sistema. O usuário vinculado é opcional e existe
+// apenas quando o próprio cliente tem acesso ao sistema. CPF e CNPJ são gravados
+// somente com os dígitos; a inscrição estadual se aplica apenas a pessoas jurídicas,
+// que informam o número ou se declaram isentas. O campo Endereco é o texto livre e opcional
+// do cadastro original; os endereços de entrega e cobrança ficam na tabela enderecos.
 type Cliente struct {
 	ID          string    `json:"id"`
 	Nome        string    `json:"nome"`
@@ -12,6 +46,59 @@ type Cliente struct {
 	CPF         string    `json:"cpf"`
 	UsuarioID   string    `json:"usuario_id"`
 	DataCriacao time.Time `json:"data_criacao"`
+
+	TipoPessoa        TipoPessoa `json:"tipo_pessoa"`
+	CNPJ              string     `json:"cnpj"`
+	InscricaoEstadual string     `json:"inscricao_estadual"`
+	IsentoIE          bool       `json:"isento_ie"`
+
+	// LimiteCredito limita o saldo em aberto das vendas do cliente; zero significa sem limite
+	LimiteCredito float64 `json:"limite_credito"`
+
+	// DataAnonimizacao indica quando os dados pessoais do cliente foram anonimizados a
+	// pedido do titular; a partir daí o cadastro não pode mais ser alterado
+	DataAnonimizacao *time.Time `json:"data_anonimizacao,omitempty"`
+
+	// DocumentoPendente marca os cadastros sem CPF ou CNPJ, como os migrados dos usuários.
+	// Eles continuam podendo ser alterados sem o documento até que ele seja informado.
+	DocumentoPendente bool `json:"documento_pendente"`
+}
+
+// SemDocumento informa se o cliente não tem o documento exigido pelo seu tipo de pessoa
+func (c *Cliente) SemDocumento() bool {
+	if c.TipoPessoa == PessoaJuridica {
+		return c.CNPJ == ""
+	}
+	return c.CPF == ""
+}
+
+// CreditoCliente resume a situação de crédito do cliente. O saldo em aberto soma o que
+// falta receber das vendas confirmadas: as parcelas em aberto das vendas a prazo e o
+// total ainda não pago, descontadas as devoluções, das demais. O valor disponível fica
+// nulo quando o cliente não tem limite.
+type CreditoCliente struct {
+	ClienteID   string   `json:"cliente_id"`
+	Limite      float64  `json:"limite"`
+	SaldoAberto float64  `json:"saldo_aberto"`
+	Disponivel  *float64 `json:"disponivel"`
+}
+
+// CodigoLimiteCredito identifica, na resposta da API, a recusa por limite de crédito
+const CodigoLimiteCredito = "limite_credito_excedido"
+
+// ErroLimiteCredito indica que a venda levaria o saldo em aberto do cliente além do seu
+// limite de crédito. Traz os valores envolvidos para que o front end possa exibi-los.
+type ErroLimiteCredito struct {
+	ClienteID   string  `json:"cliente_id"`
+	Limite      float64 `json:"limite"`
+	SaldoAberto float64 `json:"saldo_aberto"`
+	ValorVenda  float64 `json:"valor_venda"`
+	Excedente   float64 `json:"excedente"`
+}
+
+func (e *ErroLimiteCredito) Error() string {
+	return fmt.Sprintf("venda de %.2f ultrapassa em %.2f o limite de crédito de %.2f do cliente, que tem %.2f em aberto; "+
+		"é necessária a autorização de um administrador", e.ValorVenda, e.Excedente, e.Limite, e.SaldoAberto)
 }
 
 // ClienteRepository define as operações que podem ser realizadas com clientes
@@ -19,9 +106,18 @@ type ClienteRepository interface {
 	Create(cliente *Cliente) error
 	GetByID(id string) (*Cliente, error)
 	GetByCPF(cpf string) (*Cliente, error)
+	GetByCNPJ(cnpj string) (*Cliente, error)
+	GetByUsuario(usuarioID string) (*Cliente, error)
 	GetAll() ([]Cliente, error)
 	Update(cliente *Cliente) error
 	Delete(id string) error
+	GetSaldoAberto(clienteID, exceto string) (float64, error)
+	GetResumoCompras(clienteID string) (*ResumoCliente, error)
+	GetProdutosFavoritos(clienteID string, limite int) ([]ProdutoFavorito, error)
+	GetMetricasRFM(agora time.Time) ([]MetricasRFM, error)
+	SalvarSegmentos(segmentos []SegmentoCliente) error
+	GetSegmento(clienteID string) (*SegmentoCliente, error)
+	GetSegmentos(segmento SegmentoRFM) ([]SegmentoCliente, error)
 }
 
 // ClienteService define a lógica de negócio relacionada a clientes
@@ -32,4 +128,8 @@ type ClienteService interface {
 	ListClientes() ([]Cliente, error)
 	UpdateCliente(cliente *Cliente) error
 	DeleteCliente(id string) error
+	GetCredito(id string) (*CreditoCliente, error)
+	GetResumo(id string) (*ResumoCliente, error)
+	SegmentarClientes(agora time.Time) (*ResultadoSegmentacao, error)
+	ListSegmentos(segmento SegmentoRFM) ([]SegmentoCliente, error)
 }
@@ -0,0 +1,129 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrRegraComissaoInvalida indica uma regra de comissão com dados inconsistentes
+	ErrRegraComissaoInvalida = errors.New("regra de comissão inválida")
+	// ErrFechamentoComissao indica uma operação que o status do extrato de comissão não
+	// permite, como fechar um mês que ainda não terminou ou aprovar um extrato em aberto
+	ErrFechamentoComissao = errors.New("operação não permitida no extrato de comissão")
+	// ErrMesComissaoInvalido indica um mês de referência fora do formato AAAA-MM
+	ErrMesComissaoInvalido = errors.New("mês de referência inválido")
+)
+
+// TipoRegraComissao define como uma regra participa do cálculo da comissão de um item
+type TipoRegraComissao string
+
+const (
+	// ComissaoPercentual paga um percentual sobre qualquer produto vendido
+	ComissaoPercentual TipoRegraComissao = "percentual"
+	// ComissaoProduto paga um percentual próprio sobre as vendas do produto
+	ComissaoProduto TipoRegraComissao = "produto"
+	// ComissaoCategoria paga um percentual próprio sobre as vendas dos produtos da categoria
+	ComissaoCategoria TipoRegraComissao = "categoria"
+	// ComissaoMeta é uma faixa: o percentual passa a valer no mês em que as vendas
+	// líquidas do vendedor atingem a meta mensal
+	ComissaoMeta TipoRegraComissao = "meta"
+)
+
+// Valida informa se o tipo de regra é um dos aceitos pelo sistema
+func (t TipoRegraComissao) Valida() bool {
+	switch t {
+	case ComissaoPercentual, ComissaoProduto, ComissaoCategoria, ComissaoMeta:
+		return true
+	}
+	return false
+}
+
+// RegraComissao é uma regra de comissão de vendedores. Inicio e Fim limitam o período em
+// que a regra vale; sem eles, a regra vale enquanto estiver ativa.
+type RegraComissao struct {
+	ID          string            `json:"id"`
+	Descricao   string            `json:"descricao"`
+	Tipo        TipoRegraComissao `json:"tipo"`
+	ProdutoID   string            `json:"produto_id,omitempty"`
+	Categoria   string            `json:"categoria,omitempty"`
+	Percentual  float64           `json:"percentual"`
+	MetaMensal  float64           `json:"meta_mensal,omitempty"`
+	Inicio      *time.Time        `json:"inicio,omitempty"`
+	Fim         *time.Time        `json:"fim,omitempty"`
+	Ativa       bool              `json:"ativa"`
+	DataCriacao time.Time         `json:"data_criacao"`
+}
+
+// Vigente informa se a regra está ativa e vale na data informada
+func (r RegraComissao) Vigente(data time.Time) bool {
+	if !r.Ativa {
+		return false
+	}
+	if r.Inicio != nil && data.Before(*r.Inicio) {
+		return false
+	}
+	if r.Fim != nil && data.After(*r.Fim) {
+		return false
+	}
+	return true
+}
+
+// StatusExtratoComissao indica a etapa do extrato mensal de comissão
+type StatusExtratoComissao string
+
+const (
+	// ExtratoAberto é o extrato do mês ainda não fechado, recalculado a cada consulta
+	ExtratoAberto StatusExtratoComissao = "aberto"
+	// ExtratoFechado é o extrato congelado pelo fechamento, aguardando aprovação
+	ExtratoFechado StatusExtratoComissao = "fechado"
+	// ExtratoAprovado é o extrato aprovado para pagamento
+	ExtratoAprovado StatusExtratoComissao = "aprovado"
+)
+
+// BaseComissao é um item de venda que gera comissão, com o valor líquido das devoluções
+// já registradas
+type BaseComissao struct {
+	VendaID     string
+	ItemVendaID string
+	ProdutoID   string
+	Categoria   string
+	DataVenda   time.Time
+	Valor       float64
+}
+
+// ItemComissao é uma linha do extrato de comissão. As linhas de ajuste corrigem a
+// comissão de vendas de meses já fechados que foram devolvidas, canceladas, alteradas
+// ou trocadas de vendedor depois do fechamento; a base delas é a diferença apurada.
+type ItemComissao struct {
+	VendaID     string    `json:"venda_id"`
+	ItemVendaID string    `json:"item_venda_id"`
+	ProdutoID   string    `json:"produto_id"`
+	DataVenda   time.Time `json:"data_venda"`
+	Base        float64   `json:"base"`
+	Percentual  float64   `json:"percentual"`
+	Valor       float64   `json:"valor"`
+	Ajuste      bool      `json:"ajuste"`
+}
+
+// ExtratoComissao é a comissão do vendedor em um mês (AAAA-MM). TotalVendas soma as
+// vendas líquidas do mês, que definem a faixa de meta atingida; PercentualBase é o
+// percentual aplicado aos itens sem regra de produto ou categoria. ValorComissao já inclui
+// os ajustes. Enquanto aberto, o extrato é recalculado a cada consulta; o fechamento
+// congela as linhas.
+type ExtratoComissao struct {
+	ID             string                `json:"id,omitempty"`
+	VendedorID     string                `json:"vendedor_id"`
+	VendedorNome   string                `json:"vendedor_nome"`
+	Mes            string                `json:"mes"`
+	Status         StatusExtratoComissao `json:"status"`
+	TotalVendas    float64               `json:"total_vendas"`
+	PercentualBase float64               `json:"percentual_base"`
+	ValorComissao  float64               `json:"valor_comissao"`
+	ValorAjustes   float64               `json:"valor_ajustes"`
+	Itens          []ItemComissao        `json:"itens,omitempty"`
+	DataFechamento *time.Time            `json:"data_fechamento,omitempty"`
+	FechadoPor     string                `json:"fechado_por,omitempty"`
+	DataAprovacao  *time.Time            `json:"data_aprovacao,omitempty"`
+	AprovadoPor    string                `json:"aprovado_por,omitempty"`
+}
@@ -0,0 +1,58 @@
+package domain
+
+import "time"
+
+// StatusPedidoCompra representa a etapa de um pedido de compra
+type StatusPedidoCompra string
+
+const (
+	PedidoCompraAberto               StatusPedidoCompra = "aberto"
+	PedidoCompraParcialmenteRecebido StatusPedidoCompra = "parcialmente_recebido"
+	PedidoCompraRecebido             StatusPedidoCompra = "recebido"
+	PedidoCompraCancelado            StatusPedidoCompra = "cancelado"
+)
+
+// Pendente indica se o pedido ainda aguarda mercadorias do fornecedor
+func (s StatusPedidoCompra) Pendente() bool {
+	return s == PedidoCompraAberto || s == PedidoCompraParcialmenteRecebido
+}
+
+// PedidoCompra representa uma compra de produtos junto a um fornecedor
+type PedidoCompra struct {
+	ID              string             `json:"id"`
+	FornecedorID    string             `json:"fornecedor_id"`
+	LocalID         string             `json:"local_id"`
+	Fornecedor      *Fornecedor        `json:"fornecedor,omitempty"`
+	Status          StatusPedidoCompra `json:"status"`
+	Observacao      string             `json:"observacao"`
+	UsuarioID       string             `json:"usuario_id"`
+	ValorTotal      float64            `json:"valor_total"`
+	PrevisaoEntrega *time.Time         `json:"previsao_entrega,omitempty"`
+	DataPedido      time.Time          `json:"data_pedido"`
+	Itens           []ItemPedidoCompra `json:"itens"`
+}
+
+// ItemPedidoCompra representa um produto comprado, com o custo previsto
+// e a quantidade já recebida
+type ItemPedidoCompra struct {
+	ID                 string  `json:"id"`
+	PedidoCompraID     string  `json:"pedido_compra_id"`
+	ProdutoID          string  `json:"produto_id"`
+	Quantidade         int     `json:"quantidade"`
+	QuantidadeRecebida int     `json:"quantidade_recebida"`
+	CustoUnitario      float64 `json:"custo_unitario"`
+	Total              float64 `json:"total"`
+}
+
+// Pendente retorna quanto do item ainda falta receber
+func (i ItemPedidoCompra) Pendente() int {
+	return i.Quantidade - i.QuantidadeRecebida
+}
+
+// RecebimentoItem informa a quantidade recebida de um item do pedido e o custo efetivo
+type RecebimentoItem struct {
+	ItemID        string  `json:"item_id"`
+	ProdutoID     string  `json:"-"`
+	Quantidade    int     `json:"quantidade"`
+	CustoUnitario float64 `json:"custo_unitario"`
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// Devolucao registra o retorno de parte ou de todos os itens de uma venda
+// e o valor reembolsado ao cliente
+type Devolucao struct {
+	ID             string          `json:"id"`
+	VendaID        string          `json:"venda_id"`
+	UsuarioID      string          `json:"usuario_id"`
+	Motivo         string          `json:"motivo"`
+	ValorReembolso float64         `json:"valor_reembolso"`
+	DataDevolucao  time.Time       `json:"data_devolucao"`
+	Itens          []ItemDevolucao `json:"itens"`
+}
+
+// ItemDevolucao representa a quantidade devolvida de um item da venda
+type ItemDevolucao struct {
+	ID             string  `json:"id"`
+	DevolucaoID    string  `json:"devolucao_id"`
+	ItemVendaID    string  `json:"item_venda_id"`
+	ProdutoID      string  `json:"produto_id"`
+	Quantidade     int     `json:"quantidade"`
+	ValorReembolso float64 `json:"valor_reembolso"`
+}
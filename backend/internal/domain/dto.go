@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 // CreateProdutoDTO representa os dados necessários para criar um produto
 type CreateProdutoDTO struct {
 	Nome       string  `json:"nome" validate:"required"`
@@ -16,18 +18,201 @@ type UpdateProdutoDTO struct {
 	Quantidade int     `json:"quantidade" validate:"required,gte=0"`
 }
 
+// CreateMovimentacaoDTO representa um lançamento manual no estoque. Entradas e perdas
+// informam a quantidade positiva; ajustes informam a diferença, positiva ou negativa.
+// O custo unitário é opcional e só é aceito em entradas.
+type CreateMovimentacaoDTO struct {
+	Tipo       TipoMovimentacao `json:"tipo" validate:"required,oneof=entrada ajuste perda"`
+	Quantidade int              `json:"quantidade" validate:"required"`
+	Motivo     string           `json:"motivo"`
+	LocalID    string           `json:"local_id,omitempty"`
+
+	CustoUnitario float64 `json:"custo_unitario,omitempty" validate:"gte=0"`
+}
+
 type CreateItemVendaDTO struct {
-	ProdutoID  string `json:"produto_id" validate:"required"`
-	Quantidade int    `json:"quantidade" validate:"required,gt=0"`
+	ProdutoID  string    `json:"produto_id" validate:"required"`
+	Quantidade int       `json:"quantidade" validate:"required,gt=0"`
+	Desconto   *Desconto `json:"desconto,omitempty"`
 }
 
 type CreateVendaDTO struct {
-	Cliente  string               `json:"cliente" validate:"required"`
-	Itens    []CreateItemVendaDTO `json:"itens" validate:"required,dive"`
-	Desconto float64              `json:"desconto,omitempty" validate:"gte=0,lte=100"`
+	Cliente      string               `json:"cliente" validate:"required"`
+	Itens        []CreateItemVendaDTO `json:"itens" validate:"required,dive"`
+	Desconto     float64              `json:"desconto,omitempty" validate:"gte=0"`
+	TipoDesconto TipoDesconto         `json:"tipo_desconto,omitempty" validate:"omitempty,oneof=percentual valor"`
+	Rascunho     bool                 `json:"rascunho,omitempty"`
+	Parcelamento *PlanoParcelamento   `json:"parcelamento,omitempty"`
+	LocalID      string               `json:"local_id,omitempty"`
+	VendedorID   string               `json:"vendedor_id,omitempty"`
 }
 
 type UpdateVendaDTO struct {
 	Cliente string      `json:"cliente" validate:"required"`
 	Itens   []ItemVenda `json:"itens" validate:"required,dive"`
 }
+
+type AlterarStatusVendaDTO struct {
+	Motivo string `json:"motivo"`
+}
+
+type CreateItemDevolucaoDTO struct {
+	ItemVendaID string `json:"item_venda_id" validate:"required"`
+	Quantidade  int    `json:"quantidade" validate:"required,gt=0"`
+}
+
+type CreateDevolucaoDTO struct {
+	Motivo string                   `json:"motivo"`
+	Itens  []CreateItemDevolucaoDTO `json:"itens" validate:"required,dive"`
+}
+
+type CreatePagamentoDTO struct {
+	Forma             FormaPagamento `json:"forma" validate:"required"`
+	Valor             float64        `json:"valor" validate:"required,gt=0"`
+	ValorRecebido     float64        `json:"valor_recebido,omitempty" validate:"gte=0"`
+	Parcelas          int            `json:"parcelas,omitempty" validate:"gte=0"`
+	CodigoAutorizacao string         `json:"codigo_autorizacao,omitempty"`
+}
+
+type RegistrarPagamentosDTO struct {
+	Pagamentos []CreatePagamentoDTO `json:"pagamentos" validate:"required,dive"`
+}
+
+type QuitarParcelaDTO struct {
+	Forma     FormaPagamento `json:"forma" validate:"required"`
+	ValorPago float64        `json:"valor_pago,omitempty" validate:"gte=0"`
+}
+
+type CreateFornecedorDTO struct {
+	Nome     string `json:"nome" validate:"required"`
+	CNPJ     string `json:"cnpj"`
+	Email    string `json:"email" validate:"omitempty,email"`
+	Telefone string `json:"telefone"`
+	Contato  string `json:"contato"`
+}
+
+// UpdateFornecedorDTO representa os dados para atualizar um fornecedor. Sem o campo ativo,
+// a situação gravada do fornecedor é mantida.
+type UpdateFornecedorDTO struct {
+	Nome     string `json:"nome" validate:"required"`
+	CNPJ     string `json:"cnpj"`
+	Email    string `json:"email" validate:"omitempty,email"`
+	Telefone string `json:"telefone"`
+	Contato  string `json:"contato"`
+	Ativo    *bool  `json:"ativo,omitempty"`
+}
+
+type CreateItemPedidoCompraDTO struct {
+	ProdutoID     string  `json:"produto_id" validate:"required"`
+	Quantidade    int     `json:"quantidade" validate:"required,gt=0"`
+	CustoUnitario float64 `json:"custo_unitario" validate:"required,gt=0"`
+}
+
+type CreatePedidoCompraDTO struct {
+	FornecedorID    string                      `json:"fornecedor_id" validate:"required"`
+	LocalID         string                      `json:"local_id,omitempty"`
+	Observacao      string                      `json:"observacao"`
+	PrevisaoEntrega *time.Time                  `json:"previsao_entrega,omitempty"`
+	Itens           []CreateItemPedidoCompraDTO `json:"itens" validate:"required,dive"`
+}
+
+// ReceberPedidoCompraDTO informa os itens recebidos; sem itens, recebe todo o saldo pendente
+// pelo custo previsto no pedido
+type ReceberPedidoCompraDTO struct {
+	Itens []RecebimentoItem `json:"itens"`
+}
+
+type CreateLocalEstoqueDTO struct {
+	Nome string    `json:"nome" validate:"required"`
+	Tipo TipoLocal `json:"tipo" validate:"required,oneof=deposito loja"`
+}
+
+// SalvarRegraFidelidadeDTO cria ou substitui uma regra de pontuação. Sem Ativa informado,
+// a regra é criada ativa.
+type SalvarRegraFidelidadeDTO struct {
+	Descricao     string              `json:"descricao" validate:"required"`
+	Tipo          TipoRegraFidelidade `json:"tipo" validate:"required,oneof=valor categoria multiplicador"`
+	Categoria     string              `json:"categoria,omitempty"`
+	PontosPorReal float64             `json:"pontos_por_real,omitempty" validate:"gte=0"`
+	Multiplicador float64             `json:"multiplicador,omitempty" validate:"gte=0"`
+	Inicio        *time.Time          `json:"inicio,omitempty"`
+	Fim           *time.Time          `json:"fim,omitempty"`
+	Ativa         *bool               `json:"ativa,omitempty"`
+}
+
+// SalvarRegraComissaoDTO cria ou substitui uma regra de comissão. Percentual é em pontos
+// percentuais (5 = 5%). Sem Ativa informado, a regra é criada ativa.
+type SalvarRegraComissaoDTO struct {
+	Descricao  string            `json:"descricao" validate:"required"`
+	Tipo       TipoRegraComissao `json:"tipo" validate:"required,oneof=percentual produto categoria meta"`
+	ProdutoID  string            `json:"produto_id,omitempty"`
+	Categoria  string            `json:"categoria,omitempty"`
+	Percentual float64           `json:"percentual" validate:"gt=0,lte=100"`
+	MetaMensal float64           `json:"meta_mensal,omitempty" validate:"gte=0"`
+	Inicio     *time.Time        `json:"inicio,omitempty"`
+	Fim        *time.Time        `json:"fim,omitempty"`
+	Ativa      *bool             `json:"ativa,omitempty"`
+}
+
+// SalvarMetaDTO cria ou substitui uma meta de vendas. O período é o mês informado em Mes
+// (AAAA-MM) ou, sem ele, os dias de Inicio a Fim.
+type SalvarMetaDTO struct {
+	Descricao  string     `json:"descricao,omitempty"`
+	Tipo       TipoMeta   `json:"tipo" validate:"required,oneof=vendedor loja categoria"`
+	VendedorID string     `json:"vendedor_id,omitempty"`
+	LocalID    string     `json:"local_id,omitempty"`
+	Categoria  string     `json:"categoria,omitempty"`
+	Mes        string     `json:"mes,omitempty"`
+	Inicio     *time.Time `json:"inicio,omitempty"`
+	Fim        *time.Time `json:"fim,omitempty"`
+	Valor      float64    `json:"valor" validate:"gt=0"`
+}
+
+// CreateOrcamentoDTO registra um orçamento com os mesmos itens e descontos de uma venda.
+// Sem ValidoAte, o orçamento vale por ValidadeOrcamentoPadrao dias.
+type CreateOrcamentoDTO struct {
+	Cliente      string               `json:"cliente" validate:"required"`
+	Itens        []CreateItemVendaDTO `json:"itens" validate:"required,dive"`
+	Desconto     float64              `json:"desconto,omitempty" validate:"gte=0"`
+	TipoDesconto TipoDesconto         `json:"tipo_desconto,omitempty" validate:"omitempty,oneof=percentual valor"`
+	LocalID      string               `json:"local_id,omitempty"`
+	VendedorID   string               `json:"vendedor_id,omitempty"`
+	ValidoAte    *time.Time           `json:"valido_ate,omitempty"`
+	Observacoes  string               `json:"observacoes,omitempty"`
+}
+
+// ConverterOrcamentoDTO completa a venda gerada a partir do orçamento. Sem LocalID, os
+// itens saem do local do orçamento.
+type ConverterOrcamentoDTO struct {
+	Rascunho     bool               `json:"rascunho,omitempty"`
+	Parcelamento *PlanoParcelamento `json:"parcelamento,omitempty"`
+	LocalID      string             `json:"local_id,omitempty"`
+}
+
+// ReservarEstoqueDTO define por quantos minutos a reserva segura o estoque; sem ele, vale o
+// prazo configurado no servidor
+type ReservarEstoqueDTO struct {
+	Minutos int `json:"minutos,omitempty" validate:"gte=0"`
+}
+
+// MesclarClientesDTO indica o cliente duplicado que será incorporado ao cliente da rota
+type MesclarClientesDTO struct {
+	DuplicadoID string `json:"duplicado_id" validate:"required"`
+}
+
+type CreateItemTransferenciaDTO struct {
+	ProdutoID  string `json:"produto_id" validate:"required"`
+	Quantidade int    `json:"quantidade" validate:"required,gt=0"`
+}
+
+type CreateTransferenciaDTO struct {
+	OrigemID   string                       `json:"origem_id" validate:"required"`
+	DestinoID  string                       `json:"destino_id" validate:"required"`
+	Observacao string                       `json:"observacao"`
+	Itens      []CreateItemTransferenciaDTO `json:"itens" validate:"required,dive"`
+}
+
+// AlterarPermissoesDTO substitui todas as permissões do papel da rota
+type AlterarPermissoesDTO struct {
+	Permissoes []Permissao `json:"permissoes"`
+}
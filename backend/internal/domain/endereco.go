@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrEnderecoInvalido indica que os dados informados para o endereço não passaram na validação
+var ErrEnderecoInvalido = errors.New("dados do endereço inválidos")
+
+// TipoEndereco indica o uso do endereço do cliente
+type TipoEndereco string
+
+const (
+	EnderecoCobranca TipoEndereco = "cobranca"
+	EnderecoEntrega  TipoEndereco = "entrega"
+)
+
+// Valida informa se o tipo de endereço é um dos aceitos pelo sistema
+func (t TipoEndereco) Valida() bool {
+	return t == EnderecoCobranca || t == EnderecoEntrega
+}
+
+// Endereco representa um endereço estruturado do cliente, usado na entrega e na
+// emissão de documentos. Um cliente pode ter vários endereços de cada tipo. O CEP é
+// gravado somente com os dígitos e a UF com a sigla em maiúsculas.
+type Endereco struct {
+	ID          string       `json:"id"`
+	ClienteID   string       `json:"cliente_id"`
+	Tipo        TipoEndereco `json:"tipo"`
+	CEP         string       `json:"cep"`
+	Logradouro  string       `json:"logradouro"`
+	Numero      string       `json:"numero"`
+	Complemento string       `json:"complemento"`
+	Bairro      string       `json:"bairro"`
+	Cidade      string       `json:"cidade"`
+	UF          string       `json:"uf"`
+	DataCriacao time.Time    `json:"data_criacao"`
+}
+
+// EnderecoService define a lógica de negócio relacionada aos endereços dos clientes
+type EnderecoService interface {
+	CreateEndereco(endereco *Endereco) error
+	GetEndereco(clienteID, id string) (*Endereco, error)
+	ListEnderecos(clienteID string) ([]Endereco, error)
+	UpdateEndereco(endereco *Endereco) error
+	DeleteEndereco(clienteID, id string) error
+	BuscarCEP(cep string) (*Endereco, error)
+}
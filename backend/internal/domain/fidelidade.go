@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrPontosInsuficientes indica um resgate maior que o saldo de pontos válidos do cliente
+	ErrPontosInsuficientes = errors.New("saldo de pontos insuficiente")
+	// ErrRegraFidelidadeInvalida indica uma regra de pontuação com dados inconsistentes
+	ErrRegraFidelidadeInvalida = errors.New("regra de fidelidade inválida")
+)
+
+// ProgramaFidelidade reúne os parâmetros do programa: quanto vale cada ponto no resgate
+// e por quantos dias os pontos acumulados permanecem válidos
+type ProgramaFidelidade struct {
+	ValorPonto   float64 `json:"valor_ponto"`
+	ValidadeDias int     `json:"validade_dias"`
+}
+
+// TipoMovimentoPontos identifica a origem de um lançamento no extrato de pontos
+type TipoMovimentoPontos string
+
+const (
+	// PontosAcumulo são os pontos ganhos na confirmação de uma venda
+	PontosAcumulo TipoMovimentoPontos = "acumulo"
+	// PontosResgate são os pontos usados como pagamento de uma venda
+	PontosResgate TipoMovimentoPontos = "resgate"
+	// PontosExpiracao são os pontos que venceram sem ser usados
+	PontosExpiracao TipoMovimentoPontos = "expiracao"
+	// PontosEstorno são os pontos retirados pelo cancelamento ou devolução da venda que os gerou
+	PontosEstorno TipoMovimentoPontos = "estorno"
+	// PontosDevolucaoResgate são os pontos devolvidos ao cliente quando a venda paga com eles é cancelada
+	PontosDevolucaoResgate TipoMovimentoPontos = "devolucao_resgate"
+)
+
+// MovimentoPontos é um lançamento no extrato de pontos do cliente. Créditos (acúmulo e
+// devolução de resgate) formam lotes com data de expiração; Restante é o que ainda não
+// foi consumido do lote, sempre do lote que vence primeiro. Débitos têm pontos negativos.
+type MovimentoPontos struct {
+	ID             string              `json:"id"`
+	ClienteID      string              `json:"cliente_id"`
+	VendaID        string              `json:"venda_id,omitempty"`
+	Tipo           TipoMovimentoPontos `json:"tipo"`
+	Pontos         int                 `json:"pontos"`
+	Restante       int                 `json:"restante"`
+	DataExpiracao  *time.Time          `json:"data_expiracao,omitempty"`
+	Descricao      string              `json:"descricao"`
+	UsuarioID      string              `json:"usuario_id,omitempty"`
+	DataLancamento time.Time           `json:"data_lancamento"`
+}
+
+// DiasAvisoExpiracao é a janela usada para informar os pontos prestes a vencer
+const DiasAvisoExpiracao = 30
+
+// ExtratoPontos resume a situação do cliente no programa de fidelidade: o saldo de pontos
+// válidos, quanto ele vale em reais, quantos pontos vencem nos próximos DiasAvisoExpiracao
+// dias e o histórico de lançamentos, do mais recente para o mais antigo
+type ExtratoPontos struct {
+	ClienteID  string            `json:"cliente_id"`
+	Saldo      int               `json:"saldo"`
+	ValorSaldo float64           `json:"valor_saldo"`
+	AExpirar   int               `json:"a_expirar"`
+	Movimentos []MovimentoPontos `json:"movimentos"`
+}
+
+// TipoRegraFidelidade define como uma regra participa do cálculo dos pontos de uma venda
+type TipoRegraFidelidade string
+
+const (
+	// RegraPorValor concede pontos por real gasto em qualquer produto
+	RegraPorValor TipoRegraFidelidade = "valor"
+	// RegraPorCategoria concede pontos por real gasto nos produtos da categoria, no lugar da regra por valor
+	RegraPorCategoria TipoRegraFidelidade = "categoria"
+	// RegraMultiplicador multiplica os pontos das vendas feitas no período, opcionalmente só de uma categoria
+	RegraMultiplicador TipoRegraFidelidade = "multiplicador"
+)
+
+// Valida informa se o tipo de regra é um dos aceitos pelo sistema
+func (t TipoRegraFidelidade) Valida() bool {
+	switch t {
+	case RegraPorValor, RegraPorCategoria, RegraMultiplicador:
+		return true
+	}
+	return false
+}
+
+// RegraFidelidade é uma regra de pontuação. Inicio e Fim limitam o período em que a
+// regra vale; sem eles, a regra vale enquanto estiver ativa.
+type RegraFidelidade struct {
+	ID            string              `json:"id"`
+	Descricao     string              `json:"descricao"`
+	Tipo          TipoRegraFidelidade `json:"tipo"`
+	Categoria     string              `json:"categoria,omitempty"`
+	PontosPorReal float64             `json:"pontos_por_real,omitempty"`
+	Multiplicador float64             `json:"multiplicador,omitempty"`
+	Inicio        *time.Time          `json:"inicio,omitempty"`
+	Fim           *time.Time          `json:"fim,omitempty"`
+	Ativa         bool                `json:"ativa"`
+	DataCriacao   time.Time           `json:"data_criacao"`
+}
+
+// Vigente informa se a regra está ativa e vale na data informada
+func (r RegraFidelidade) Vigente(data time.Time) bool {
+	if !r.Ativa {
+		return false
+	}
+	if r.Inicio != nil && data.Before(*r.Inicio) {
+		return false
+	}
+	if r.Fim != nil && data.After(*r.Fim) {
+		return false
+	}
+	return true
+}
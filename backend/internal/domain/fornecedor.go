@@ -0,0 +1,15 @@
+package domain
+
+import "time"
+
+// Fornecedor representa uma empresa da qual os produtos são comprados
+type Fornecedor struct {
+	ID          string    `json:"id"`
+	Nome        string    `json:"nome"`
+	CNPJ        string    `json:"cnpj"`
+	Email       string    `json:"email"`
+	Telefone    string    `json:"telefone"`
+	Contato     string    `json:"contato"`
+	Ativo       bool      `json:"ativo"`
+	DataCriacao time.Time `json:"data_criacao"`
+}
@@ -0,0 +1,68 @@
+package domain
+
+import "time"
+
+// LocalPrincipal é o local criado na migração, que recebeu o estoque existente
+// e é usado quando nenhum local é informado
+const LocalPrincipal = "principal"
+
+// TipoLocal diferencia depósitos de lojas
+type TipoLocal string
+
+const (
+	LocalDeposito TipoLocal = "deposito"
+	LocalLoja     TipoLocal = "loja"
+)
+
+// LocalEstoque representa um depósito ou uma loja que guarda produtos
+type LocalEstoque struct {
+	ID          string    `json:"id"`
+	Nome        string    `json:"nome"`
+	Tipo        TipoLocal `json:"tipo"`
+	Ativo       bool      `json:"ativo"`
+	DataCriacao time.Time `json:"data_criacao"`
+}
+
+// SaldoEstoque representa a quantidade de um produto em um local. A soma dos saldos
+// é a quantidade do produto; mercadorias em transferência não estão em nenhum local.
+// Disponivel desconta da quantidade as reservas ativas de vendas e orçamentos.
+type SaldoEstoque struct {
+	LocalID     string `json:"local_id"`
+	LocalNome   string `json:"local_nome,omitempty"`
+	ProdutoID   string `json:"produto_id"`
+	ProdutoNome string `json:"produto_nome,omitempty"`
+	Quantidade  int    `json:"quantidade"`
+	Reservado   int    `json:"reservado"`
+	Disponivel  int    `json:"disponivel"`
+}
+
+// StatusTransferencia representa a etapa de uma transferência entre locais
+type StatusTransferencia string
+
+const (
+	TransferenciaEmTransito StatusTransferencia = "em_transito"
+	TransferenciaRecebida   StatusTransferencia = "recebida"
+	TransferenciaCancelada  StatusTransferencia = "cancelada"
+)
+
+// Transferencia representa o envio de produtos de um local para outro. Os itens saem
+// da origem no envio e só entram no destino quando o recebimento é registrado.
+type Transferencia struct {
+	ID              string              `json:"id"`
+	OrigemID        string              `json:"origem_id"`
+	DestinoID       string              `json:"destino_id"`
+	Status          StatusTransferencia `json:"status"`
+	Observacao      string              `json:"observacao"`
+	UsuarioID       string              `json:"usuario_id"`
+	DataEnvio       time.Time           `json:"data_envio"`
+	DataRecebimento *time.Time          `json:"data_recebimento,omitempty"`
+	Itens           []ItemTransferencia `json:"itens"`
+}
+
+// ItemTransferencia representa a quantidade transferida de um produto
+type ItemTransferencia struct {
+	ID              string `json:"id"`
+	TransferenciaID string `json:"transferencia_id"`
+	ProdutoID       string `json:"produto_id"`
+	Quantidade      int    `json:"quantidade"`
+}
@@ -0,0 +1,37 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrMesclagemInvalida indica clientes que não podem ser mesclados, seja por serem o mesmo
+// registro ou por terem dados que os identificam como pessoas diferentes
+var ErrMesclagemInvalida = errors.New("mesclagem de clientes inválida")
+
+// PontuacaoMinimaDuplicidade é a pontuação a partir da qual dois clientes são sugeridos
+// como duplicados quando a consulta não informa outra
+const PontuacaoMinimaDuplicidade = 50
+
+// CandidatoDuplicado é um par de clientes que provavelmente são a mesma pessoa. A
+// pontuação vai de 0 a 100 e os motivos explicam de onde ela veio.
+type CandidatoDuplicado struct {
+	Cliente   Cliente  `json:"cliente"`
+	Duplicado Cliente  `json:"duplicado"`
+	Pontuacao int      `json:"pontuacao"`
+	Motivos   []string `json:"motivos"`
+}
+
+// MesclagemCliente registra a incorporação de um cliente duplicado: o cadastro dele como
+// estava antes da mesclagem e quantos registros de cada tabela passaram a apontar para o
+// cliente mantido
+type MesclagemCliente struct {
+	ID             string          `json:"id"`
+	ClienteID      string          `json:"cliente_id"`
+	DuplicadoID    string          `json:"duplicado_id"`
+	DadosDuplicado json.RawMessage `json:"dados_duplicado"`
+	Referencias    map[string]int  `json:"referencias"`
+	UsuarioID      string          `json:"usuario_id"`
+	Data           time.Time       `json:"data"`
+}
@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrMetaInvalida indica uma meta de vendas com dados inconsistentes ou que se sobrepõe a
+// outra meta do mesmo alvo
+var ErrMetaInvalida = errors.New("meta inválida")
+
+// TipoMeta define a quem a meta de vendas se aplica
+type TipoMeta string
+
+const (
+	// MetaVendedor é a meta das vendas registradas por um vendedor
+	MetaVendedor TipoMeta = "vendedor"
+	// MetaLoja é a meta das vendas que saem de um local de estoque
+	MetaLoja TipoMeta = "loja"
+	// MetaCategoria é a meta das vendas dos produtos de uma categoria
+	MetaCategoria TipoMeta = "categoria"
+)
+
+// Valida informa se o tipo de meta é um dos aceitos pelo sistema
+func (t TipoMeta) Valida() bool {
+	switch t {
+	case MetaVendedor, MetaLoja, MetaCategoria:
+		return true
+	}
+	return false
+}
+
+// Meta é o valor de vendas esperado de um vendedor, loja ou categoria em um período.
+// Inicio e Fim são dias inteiros: a meta vale do começo de Inicio ao fim de Fim.
+type Meta struct {
+	ID           string    `json:"id"`
+	Descricao    string    `json:"descricao,omitempty"`
+	Tipo         TipoMeta  `json:"tipo"`
+	VendedorID   string    `json:"vendedor_id,omitempty"`
+	VendedorNome string    `json:"vendedor_nome,omitempty"`
+	LocalID      string    `json:"local_id,omitempty"`
+	LocalNome    string    `json:"local_nome,omitempty"`
+	Categoria    string    `json:"categoria,omitempty"`
+	Inicio       time.Time `json:"inicio"`
+	Fim          time.Time `json:"fim"`
+	Valor        float64   `json:"valor"`
+	DataCriacao  time.Time `json:"data_criacao"`
+}
+
+// FiltroMetas restringe a listagem de metas. Campos vazios não filtram; Data seleciona as
+// metas cujo período inclui o dia informado.
+type FiltroMetas struct {
+	Tipo       TipoMeta
+	VendedorID string
+	LocalID    string
+	Categoria  string
+	Data       *time.Time
+}
+
+// ProgressoMeta compara as vendas realizadas no período da meta, líquidas de devoluções,
+// com o valor esperado. A projeção estende o ritmo diário atual até o fim do período;
+// depois do fim, é o próprio realizado.
+type ProgressoMeta struct {
+	Meta                Meta    `json:"meta"`
+	Realizado           float64 `json:"realizado"`
+	PercentualAtingido  float64 `json:"percentual_atingido"`
+	Projecao            float64 `json:"projecao"`
+	PercentualProjetado float64 `json:"percentual_projetado"`
+	DiasDecorridos      int     `json:"dias_decorridos"`
+	DiasTotais          int     `json:"dias_totais"`
+}
@@ -0,0 +1,79 @@
+package domain
+
+import "time"
+
+// TipoMovimentacao representa a origem de uma movimentação de estoque
+type TipoMovimentacao string
+
+const (
+	MovimentacaoEntrada      TipoMovimentacao = "entrada"
+	MovimentacaoSaidaVenda   TipoMovimentacao = "saida_venda"
+	MovimentacaoEstornoVenda TipoMovimentacao = "estorno_venda"
+	MovimentacaoDevolucao    TipoMovimentacao = "devolucao"
+	MovimentacaoAjuste       TipoMovimentacao = "ajuste"
+	MovimentacaoPerda        TipoMovimentacao = "perda"
+
+	MovimentacaoTransferenciaSaida   TipoMovimentacao = "transferencia_saida"
+	MovimentacaoTransferenciaEntrada TipoMovimentacao = "transferencia_entrada"
+)
+
+// Manual indica se a movimentação pode ser lançada diretamente pelo estoque.
+// Saídas, estornos e devoluções só são gerados pelas operações de venda e as
+// transferências pelos documentos de transferência.
+func (t TipoMovimentacao) Manual() bool {
+	switch t {
+	case MovimentacaoEntrada, MovimentacaoAjuste, MovimentacaoPerda:
+		return true
+	}
+	return false
+}
+
+// RespeitaReservas indica se a saída só pode usar o estoque não reservado. Perdas e
+// ajustes registram o que já aconteceu com a mercadoria e por isso não são barrados.
+func (t TipoMovimentacao) RespeitaReservas() bool {
+	return t == MovimentacaoSaidaVenda || t == MovimentacaoTransferenciaSaida
+}
+
+// MovimentacaoEstoque registra uma alteração na quantidade de um produto em um local.
+// A quantidade é positiva nas entradas e negativa nas saídas; SaldoApos guarda
+// a quantidade total do produto logo após a movimentação. Entradas com CustoUnitario
+// recalculam o custo médio do produto.
+type MovimentacaoEstoque struct {
+	ID         string           `json:"id"`
+	ProdutoID  string           `json:"produto_id"`
+	LocalID    string           `json:"local_id"`
+	Tipo       TipoMovimentacao `json:"tipo"`
+	Quantidade int              `json:"quantidade"`
+	SaldoApos  int              `json:"saldo_apos"`
+	UsuarioID  string           `json:"usuario_id"`
+	Motivo     string           `json:"motivo"`
+	VendaID    string           `json:"venda_id,omitempty"`
+
+	PedidoCompraID  string    `json:"pedido_compra_id,omitempty"`
+	TransferenciaID string    `json:"transferencia_id,omitempty"`
+	CustoUnitario   float64   `json:"custo_unitario,omitempty"`
+	Data            time.Time `json:"data"`
+}
+
+// DivergenciaEstoque aponta um produto cuja quantidade registrada não bate com a soma das
+// suas movimentações ou com a soma dos saldos por local. A diferença é o que falta no
+// histórico para chegar à quantidade.
+type DivergenciaEstoque struct {
+	ProdutoID          string `json:"produto_id"`
+	Nome               string `json:"nome"`
+	Quantidade         int    `json:"quantidade"`
+	SaldoMovimentacoes int    `json:"saldo_movimentacoes"`
+	Diferenca          int    `json:"diferenca"`
+	SaldoLocais        int    `json:"saldo_locais"`
+}
+
+// ExtratoEstoque reúne as movimentações de um produto e confere o saldo delas e
+// a soma dos saldos por local com a quantidade registrada no produto
+type ExtratoEstoque struct {
+	ProdutoID          string                `json:"produto_id"`
+	Quantidade         int                   `json:"quantidade"`
+	SaldoMovimentacoes int                   `json:"saldo_movimentacoes"`
+	Conciliado         bool                  `json:"conciliado"`
+	Saldos             []SaldoEstoque        `json:"saldos"`
+	Movimentacoes      []MovimentacaoEstoque `json:"movimentacoes"`
+}
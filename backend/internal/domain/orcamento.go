@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrOrcamentoIndisponivel indica uma operação sobre um orçamento já convertido, cancelado
+// ou vencido
+var ErrOrcamentoIndisponivel = errors.New("orçamento indisponível")
+
+// ValidadeOrcamentoPadrao é o número de dias em que o orçamento vale quando a validade não
+// é informada
+const ValidadeOrcamentoPadrao = 15
+
+// StatusOrcamento representa a situação de um orçamento
+type StatusOrcamento string
+
+const (
+	// OrcamentoAberto é o orçamento que ainda pode ser convertido em venda
+	OrcamentoAberto StatusOrcamento = "aberto"
+	// OrcamentoConvertido é o orçamento que originou uma venda
+	OrcamentoConvertido StatusOrcamento = "convertido"
+	// OrcamentoCancelado é o orçamento descartado pelo vendedor
+	OrcamentoCancelado StatusOrcamento = "cancelado"
+	// OrcamentoVencido é o orçamento aberto cuja validade já passou. Não é gravado: é
+	// derivado da validade na consulta.
+	OrcamentoVencido StatusOrcamento = "vencido"
+)
+
+// Orcamento é uma proposta de preços para um cliente que não reserva nem baixa o estoque.
+// Os itens têm a mesma estrutura dos itens de venda; ValidoAte é um dia inteiro, e o
+// orçamento vale até o fim desse dia. Ao ser convertido, VendaID aponta a venda gerada.
+type Orcamento struct {
+	ID            string          `json:"id"`
+	ClienteID     string          `json:"cliente_id"`
+	ClienteNome   string          `json:"cliente_nome,omitempty"`
+	VendedorID    string          `json:"vendedor_id"`
+	VendedorNome  string          `json:"vendedor_nome,omitempty"`
+	LocalID       string          `json:"local_id"`
+	LocalNome     string          `json:"local_nome,omitempty"`
+	Status        StatusOrcamento `json:"status"`
+	ValidoAte     time.Time       `json:"valido_ate"`
+	Desconto      *Desconto       `json:"desconto,omitempty"`
+	Subtotal      float64         `json:"subtotal"`
+	ValorDesconto float64         `json:"valor_desconto"`
+	ValorTotal    float64         `json:"valor_total"`
+	Observacoes   string          `json:"observacoes,omitempty"`
+	VendaID       string          `json:"venda_id,omitempty"`
+	DataConversao *time.Time      `json:"data_conversao,omitempty"`
+	DataCriacao   time.Time       `json:"data_criacao"`
+	Items         []ItemVenda     `json:"items"`
+}
+
+// Vencido informa se o orçamento aberto já passou da validade no momento informado
+func (o *Orcamento) Vencido(agora time.Time) bool {
+	return o.Status == OrcamentoAberto && !agora.Before(o.ValidoAte.AddDate(0, 0, 1))
+}
+
+// ConversaoOrcamento é o resultado da conversão de um orçamento em venda. Os preços são
+// conferidos de novo na conversão; a diferença compara o total da venda com o orçado.
+type ConversaoOrcamento struct {
+	Orcamento      *Orcamento `json:"orcamento"`
+	Venda          *Venda     `json:"venda"`
+	DiferencaTotal float64    `json:"diferenca_total"`
+}
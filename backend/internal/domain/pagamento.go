@@ -0,0 +1,55 @@
+package domain
+
+import "time"
+
+// FormaPagamento representa o meio usado pelo cliente para pagar uma venda
+type FormaPagamento string
+
+const (
+	FormaDinheiro      FormaPagamento = "dinheiro"
+	FormaCartaoCredito FormaPagamento = "cartao_credito"
+	FormaCartaoDebito  FormaPagamento = "cartao_debito"
+	FormaPix           FormaPagamento = "pix"
+	FormaBoleto        FormaPagamento = "boleto"
+	// FormaPontos paga a venda com pontos do programa de fidelidade do cliente
+	FormaPontos FormaPagamento = "pontos_fidelidade"
+)
+
+// Valida informa se a forma de pagamento é uma das aceitas pelo sistema
+func (f FormaPagamento) Valida() bool {
+	switch f {
+	case FormaDinheiro, FormaCartaoCredito, FormaCartaoDebito, FormaPix, FormaBoleto, FormaPontos:
+		return true
+	}
+	return false
+}
+
+// Pagamento representa uma parte do valor de uma venda quitada por uma forma de pagamento.
+// Uma venda pode receber vários pagamentos, que juntos devem somar o seu total.
+type Pagamento struct {
+	ID                string         `json:"id"`
+	VendaID           string         `json:"venda_id"`
+	Forma             FormaPagamento `json:"forma"`
+	Valor             float64        `json:"valor"`
+	ValorRecebido     float64        `json:"valor_recebido"`
+	Troco             float64        `json:"troco"`
+	Parcelas          int            `json:"parcelas"`
+	CodigoAutorizacao string         `json:"codigo_autorizacao"`
+	UsuarioID         string         `json:"usuario_id"`
+	DataPagamento     time.Time      `json:"data_pagamento"`
+
+	// Pontos de fidelidade resgatados, nos pagamentos com pontos
+	Pontos int `json:"pontos,omitempty"`
+}
+
+// SaldoVenda resume quanto da venda já foi pago e quanto ainda falta pagar.
+// Reembolsos de devoluções reduzem o valor devido.
+type SaldoVenda struct {
+	VendaID        string      `json:"venda_id"`
+	Status         StatusVenda `json:"status"`
+	ValorTotal     float64     `json:"valor_total"`
+	ValorDevolvido float64     `json:"valor_devolvido"`
+	ValorPago      float64     `json:"valor_pago"`
+	Saldo          float64     `json:"saldo"`
+	Pagamentos     []Pagamento `json:"pagamentos"`
+}
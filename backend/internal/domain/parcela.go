@@ -0,0 +1,44 @@
+package domain
+
+import "time"
+
+// StatusParcela representa a situação de uma parcela a receber
+type StatusParcela string
+
+const (
+	ParcelaAberta    StatusParcela = "aberta"
+	ParcelaPaga      StatusParcela = "paga"
+	ParcelaCancelada StatusParcela = "cancelada"
+)
+
+// PlanoParcelamento define como o valor de uma venda a prazo é dividido.
+// A taxa de juros é mensal, em percentual, aplicada pela tabela Price.
+type PlanoParcelamento struct {
+	NumeroParcelas     int       `json:"numero_parcelas"`
+	TaxaJuros          float64   `json:"taxa_juros"`
+	PrimeiroVencimento time.Time `json:"primeiro_vencimento"`
+}
+
+// Parcela representa um valor a receber de uma venda parcelada
+type Parcela struct {
+	ID             string         `json:"id"`
+	VendaID        string         `json:"venda_id"`
+	ClienteID      string         `json:"cliente_id"`
+	Numero         int            `json:"numero"`
+	Valor          float64        `json:"valor"`
+	DataVencimento time.Time      `json:"data_vencimento"`
+	Status         StatusParcela  `json:"status"`
+	ValorPago      float64        `json:"valor_pago"`
+	DataPagamento  *time.Time     `json:"data_pagamento,omitempty"`
+	FormaPagamento FormaPagamento `json:"forma_pagamento,omitempty"`
+	DiasAtraso     int            `json:"dias_atraso"`
+}
+
+// FiltroContasReceber restringe a listagem de parcelas a receber
+type FiltroContasReceber struct {
+	ClienteID string
+	// VendedorID restringe as parcelas às vendas registradas pelo vendedor
+	VendedorID      string
+	Status          StatusParcela
+	SomenteVencidas bool
+}
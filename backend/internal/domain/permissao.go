@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrAcessoNegado indica uma operação sobre registros que o usuário não pode acessar
+var ErrAcessoNegado = errors.New("acesso negado")
+
+// ErrPermissoesInvalidas indica uma alteração da matriz de permissões com papel ou
+// permissão desconhecidos, ou que tiraria do administrador o acesso à própria matriz
+var ErrPermissoesInvalidas = errors.New("permissões inválidas")
+
+// Permissao é uma ação do sistema que pode ser concedida a um papel. Cada rota declara a
+// permissão que exige, e a matriz de permissões, gravada no banco e alterável pela API,
+// define as permissões de cada papel.
+type Permissao string
+
+const (
+	// PermUsuariosGerenciar permite consultar e alterar o cadastro de usuários
+	PermUsuariosGerenciar Permissao = "usuarios:gerenciar"
+	// PermClientesLer permite consultar clientes, endereços, resumos e segmentos
+	PermClientesLer Permissao = "clientes:ler"
+	// PermClientesEditar permite cadastrar, alterar e remover clientes e endereços
+	PermClientesEditar Permissao = "clientes:editar"
+	// PermClientesAdministrar permite recalcular a segmentação e mesclar duplicados
+	PermClientesAdministrar Permissao = "clientes:administrar"
+	// PermCreditoGerenciar permite definir limites de crédito e liberar vendas além do limite
+	PermCreditoGerenciar Permissao = "credito:gerenciar"
+	// PermPrivacidadeGerenciar permite exportar e anonimizar dados pessoais e consultar a auditoria
+	PermPrivacidadeGerenciar Permissao = "privacidade:gerenciar"
+	// PermFidelidadeLer permite consultar as regras e os pontos de fidelidade
+	PermFidelidadeLer Permissao = "fidelidade:ler"
+	// PermFidelidadeConfigurar permite alterar as regras do programa de fidelidade
+	PermFidelidadeConfigurar Permissao = "fidelidade:configurar"
+	// PermProdutosLer permite consultar o catálogo de produtos
+	PermProdutosLer Permissao = "produtos:ler"
+	// PermProdutosEditar permite cadastrar, alterar e remover produtos
+	PermProdutosEditar Permissao = "produtos:editar"
+	// PermEstoqueLer permite consultar saldos, movimentações, locais e transferências
+	PermEstoqueLer Permissao = "estoque:ler"
+	// PermEstoqueMovimentar permite movimentar o estoque e manter locais e transferências
+	PermEstoqueMovimentar Permissao = "estoque:movimentar"
+	// PermVendasLer permite consultar vendas; sem PermVendasTodas, quem registra vendas vê
+	// apenas as que registrou e os demais apenas as do próprio cliente
+	PermVendasLer Permissao = "vendas:ler"
+	// PermVendasTodas estende as consultas e alterações às vendas de todos os vendedores e
+	// clientes e permite registrar vendas em nome de outro vendedor
+	PermVendasTodas Permissao = "vendas:todas"
+	// PermVendasRegistrar permite criar e alterar vendas, confirmá-las, marcá-las como pagas e
+	// registrar pagamentos e devoluções
+	PermVendasRegistrar Permissao = "vendas:registrar"
+	// PermVendasCancelar permite cancelar vendas, tanto pela transição de status quanto pela
+	// rota de remoção mantida por compatibilidade
+	PermVendasCancelar Permissao = "vendas:cancelar"
+	// PermComprasGerenciar permite manter fornecedores e pedidos de compra
+	PermComprasGerenciar Permissao = "compras:gerenciar"
+	// PermFinanceiroLer permite consultar as contas a receber; sem PermVendasTodas, apenas
+	// as parcelas das vendas que o usuário registrou
+	PermFinanceiroLer Permissao = "financeiro:ler"
+	// PermFinanceiroBaixar permite quitar parcelas
+	PermFinanceiroBaixar Permissao = "financeiro:baixar"
+	// PermRelatoriosLer permite consultar os relatórios gerenciais
+	PermRelatoriosLer Permissao = "relatorios:ler"
+	// PermComissoesLer permite consultar as regras de comissão e o próprio extrato de comissão
+	PermComissoesLer Permissao = "comissoes:ler"
+	// PermComissoesGerenciar permite manter as regras de comissão, consultar os extratos de
+	// todos os vendedores e fechar e aprovar os extratos mensais
+	PermComissoesGerenciar Permissao = "comissoes:gerenciar"
+	// PermMetasLer permite acompanhar as metas de vendas; sem PermMetasGerenciar, as metas
+	// individuais de outros vendedores ficam ocultas
+	PermMetasLer Permissao = "metas:ler"
+	// PermMetasGerenciar permite definir as metas de vendedores, lojas e categorias
+	PermMetasGerenciar Permissao = "metas:gerenciar"
+	// PermOrcamentosGerenciar permite elaborar, consultar e cancelar orçamentos; sem
+	// PermVendasTodas, apenas os próprios. A conversão em venda exige também PermVendasRegistrar
+	PermOrcamentosGerenciar Permissao = "orcamentos:gerenciar"
+	// PermPermissoesGerenciar permite consultar e alterar as permissões de cada papel
+	PermPermissoesGerenciar Permissao = "permissoes:gerenciar"
+)
+
+// Permissoes lista todas as permissões que podem ser concedidas
+var Permissoes = []Permissao{
+	PermUsuariosGerenciar,
+	PermClientesLer, PermClientesEditar, PermClientesAdministrar,
+	PermCreditoGerenciar,
+	PermPrivacidadeGerenciar,
+	PermFidelidadeLer, PermFidelidadeConfigurar,
+	PermProdutosLer, PermProdutosEditar,
+	PermEstoqueLer, PermEstoqueMovimentar,
+	PermVendasLer, PermVendasTodas, PermVendasRegistrar, PermVendasCancelar,
+	PermComprasGerenciar,
+	PermFinanceiroLer, PermFinanceiroBaixar,
+	PermRelatoriosLer,
+	PermComissoesLer, PermComissoesGerenciar,
+	PermMetasLer, PermMetasGerenciar,
+	PermOrcamentosGerenciar,
+	PermPermissoesGerenciar,
+}
+
+// Roles lista os papéis que recebem permissões
+var Roles = []Role{RoleAdmin, RoleVendedor, RoleCliente}
+
+// PermissoesPadrao são as permissões de cada papel gravadas no banco na primeira
+// inicialização. Depois disso vale a matriz do banco.
+var PermissoesPadrao = map[Role][]Permissao{
+	RoleAdmin: {
+		PermUsuariosGerenciar,
+		PermClientesLer, PermClientesEditar, PermClientesAdministrar,
+		PermCreditoGerenciar,
+		PermPrivacidadeGerenciar,
+		PermFidelidadeLer, PermFidelidadeConfigurar,
+		PermProdutosLer, PermProdutosEditar,
+		PermEstoqueLer, PermEstoqueMovimentar,
+		PermVendasLer, PermVendasTodas, PermVendasRegistrar, PermVendasCancelar,
+		PermComprasGerenciar,
+		PermFinanceiroLer, PermFinanceiroBaixar,
+		PermRelatoriosLer,
+		PermComissoesLer, PermComissoesGerenciar,
+		PermMetasLer, PermMetasGerenciar,
+		PermOrcamentosGerenciar,
+		PermPermissoesGerenciar,
+	},
+	RoleVendedor: {
+		PermClientesLer, PermClientesEditar,
+		PermFidelidadeLer,
+		PermProdutosLer,
+		PermEstoqueLer,
+		PermVendasLer, PermVendasRegistrar,
+		PermFinanceiroLer,
+		PermComissoesLer,
+		PermMetasLer,
+		PermOrcamentosGerenciar,
+	},
+	RoleCliente: {
+		PermFidelidadeLer,
+		PermProdutosLer,
+		PermVendasLer,
+	},
+}
+
+// matrizPermissoes é a matriz em uso, com as permissões padrão até que a do banco seja
+// carregada por DefinirPermissoes
+var matrizPermissoes = struct {
+	sync.RWMutex
+	porRole map[Role][]Permissao
+}{porRole: PermissoesPadrao}
+
+// Valida informa se a permissão existe
+func (p Permissao) Valida() bool {
+	for _, permissao := range Permissoes {
+		if permissao == p {
+			return true
+		}
+	}
+	return false
+}
+
+// DefinirPermissoes troca a matriz de permissões em uso. É chamada na inicialização, com a
+// matriz gravada no banco, e a cada alteração feita pela API.
+func DefinirPermissoes(porRole map[Role][]Permissao) {
+	matrizPermissoes.Lock()
+	defer matrizPermissoes.Unlock()
+	matrizPermissoes.porRole = porRole
+}
+
+// PermissoesPorRole retorna uma cópia da matriz de permissões em uso
+func PermissoesPorRole() map[Role][]Permissao {
+	matrizPermissoes.RLock()
+	defer matrizPermissoes.RUnlock()
+	copia := make(map[Role][]Permissao, len(matrizPermissoes.porRole))
+	for role, permissoes := range matrizPermissoes.porRole {
+		copia[role] = append([]Permissao(nil), permissoes...)
+	}
+	return copia
+}
+
+// TemPermissao informa se o papel concede a permissão na matriz em uso. Papéis
+// desconhecidos não têm nenhuma permissão.
+func (r Role) TemPermissao(permissao Permissao) bool {
+	matrizPermissoes.RLock()
+	defer matrizPermissoes.RUnlock()
+	for _, p := range matrizPermissoes.porRole[r] {
+		if p == permissao {
+			return true
+		}
+	}
+	return false
+}
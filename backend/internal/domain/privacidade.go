@@ -0,0 +1,82 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrClienteAnonimizado indica uma operação sobre um cliente cujos dados pessoais já foram anonimizados
+	ErrClienteAnonimizado = errors.New("cliente anonimizado")
+	// ErrAnonimizacaoBloqueada indica que os dados ainda são necessários e não podem ser anonimizados
+	ErrAnonimizacaoBloqueada = errors.New("anonimização não permitida")
+)
+
+// NomeClienteAnonimizado substitui o nome dos clientes anonimizados
+const NomeClienteAnonimizado = "Cliente anonimizado"
+
+// NomeUsuarioAnonimizado substitui o nome dos usuários anonimizados
+const NomeUsuarioAnonimizado = "Usuário anonimizado"
+
+// AcaoAuditoria identifica a operação registrada na trilha de auditoria
+type AcaoAuditoria string
+
+const (
+	// AuditoriaExportacao registra a exportação dos dados pessoais de um titular
+	AuditoriaExportacao AcaoAuditoria = "exportacao_dados"
+	// AuditoriaAnonimizacao registra a anonimização dos dados pessoais de um titular
+	AuditoriaAnonimizacao AcaoAuditoria = "anonimizacao"
+	// AuditoriaMesclagem registra a incorporação de um cliente duplicado a outro cadastro
+	AuditoriaMesclagem AcaoAuditoria = "mesclagem"
+)
+
+// Entidades registradas na trilha de auditoria
+const (
+	EntidadeCliente = "cliente"
+	EntidadeUsuario = "usuario"
+)
+
+// RegistroAuditoria é uma entrada da trilha de auditoria: quem fez o quê, sobre qual
+// registro e quando. Detalhes descreve a operação em texto livre.
+type RegistroAuditoria struct {
+	ID         string        `json:"id"`
+	Acao       AcaoAuditoria `json:"acao"`
+	Entidade   string        `json:"entidade"`
+	EntidadeID string        `json:"entidade_id"`
+	UsuarioID  string        `json:"usuario_id"`
+	Detalhes   string        `json:"detalhes"`
+	Data       time.Time     `json:"data"`
+}
+
+// FiltroAuditoria restringe a consulta da trilha de auditoria; campos vazios não filtram
+type FiltroAuditoria struct {
+	Entidade   string
+	EntidadeID string
+	Acao       AcaoAuditoria
+}
+
+// VendaTitular é uma venda do titular com os pagamentos, parcelas, devoluções e o
+// histórico de status vinculados a ela
+type VendaTitular struct {
+	Venda
+	Pagamentos []Pagamento            `json:"pagamentos"`
+	Parcelas   []Parcela              `json:"parcelas"`
+	Devolucoes []Devolucao            `json:"devolucoes"`
+	Historico  []HistoricoStatusVenda `json:"historico"`
+}
+
+// DadosTitular reúne tudo o que o sistema guarda sobre um cliente ou usuário, para
+// atender aos pedidos de acesso previstos na LGPD. Cliente fica nulo quando o usuário não
+// tem cadastro de cliente, e Usuario quando o cliente não tem acesso ao sistema.
+type DadosTitular struct {
+	GeradoEm   time.Time           `json:"gerado_em"`
+	Cliente    *Cliente            `json:"cliente"`
+	Usuario    *Usuario            `json:"usuario"`
+	Enderecos  []Endereco          `json:"enderecos"`
+	Vendas     []VendaTitular      `json:"vendas"`
+	Fidelidade []MovimentoPontos   `json:"fidelidade"`
+	Orcamentos []Orcamento         `json:"orcamentos"`
+	Segmento   *SegmentoCliente    `json:"segmento"`
+	Mesclagens []MesclagemCliente  `json:"mesclagens"`
+	Auditoria  []RegistroAuditoria `json:"auditoria"`
+}
@@ -2,15 +2,37 @@ package domain
 
 import "time"
 
-// Produto representa um item que pode ser vendido
+// Produto representa um item que pode ser vendido. Custo é o custo médio ponderado,
+// recalculado a cada entrada de estoque com custo informado.
 type Produto struct {
 	ID          string    `json:"id"`
 	Nome        string    `json:"nome"`
 	Descricao   string    `json:"descricao"`
 	Preco       float64   `json:"preco"`
+	Custo       float64   `json:"custo"`
 	Quantidade  int       `json:"quantidade"`
 	ImagemURL   string    `json:"imagem_url"`
 	DataCriacao time.Time `json:"data_criacao"`
+
+	// Níveis de reposição: estoque mínimo (ponto de segurança), máximo desejado
+	// e prazo de entrega do fornecedor em dias
+	EstoqueMinimo    int `json:"estoque_minimo"`
+	EstoqueMaximo    int `json:"estoque_maximo"`
+	PrazoEntregaDias int `json:"prazo_entrega_dias"`
+
+	// Categoria agrupa os produtos nas regras do programa de fidelidade
+	Categoria string `json:"categoria"`
+}
+
+// EstoqueMinimoPadrao é o estoque mínimo dos produtos que não têm um mínimo definido
+const EstoqueMinimoPadrao = 10
+
+// LimiteEstoqueBaixo retorna a quantidade a partir da qual o produto precisa de reposição
+func (p Produto) LimiteEstoqueBaixo() int {
+	if p.EstoqueMinimo > 0 {
+		return p.EstoqueMinimo
+	}
+	return EstoqueMinimoPadrao
 }
 
 // ProdutoRepository define as operações que podem ser realizadas com produtos
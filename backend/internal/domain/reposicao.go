@@ -0,0 +1,19 @@
+package domain
+
+// SugestaoReposicao indica quanto comprar de um produto, considerando o ritmo de vendas
+// do período analisado, o prazo de entrega, o que já está pedido aos fornecedores e o que
+// está em trânsito entre os locais
+type SugestaoReposicao struct {
+	ProdutoID          string  `json:"produto_id"`
+	Nome               string  `json:"nome"`
+	Quantidade         int     `json:"quantidade"`
+	QuantidadePedida   int     `json:"quantidade_pedida"`
+	QuantidadeTransito int     `json:"quantidade_transito"`
+	EstoqueMinimo      int     `json:"estoque_minimo"`
+	EstoqueMaximo      int     `json:"estoque_maximo"`
+	PrazoEntregaDias   int     `json:"prazo_entrega_dias"`
+	VendidoPeriodo     int     `json:"vendido_periodo"`
+	MediaDiaria        float64 `json:"media_diaria"`
+	PontoReposicao     int     `json:"ponto_reposicao"`
+	QuantidadeSugerida int     `json:"quantidade_sugerida"`
+}
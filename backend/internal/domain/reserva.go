@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrReservaInvalida indica uma reserva pedida para uma venda ou um orçamento que não pode
+// mais reservar estoque
+var ErrReservaInvalida = errors.New("reserva inválida")
+
+// ErrEstoqueIndisponivel indica que o saldo do local, descontadas as reservas de outras
+// vendas e orçamentos, não atende à quantidade pedida
+var ErrEstoqueIndisponivel = errors.New("estoque disponível insuficiente")
+
+// PrazoReservaPadrao é por quanto tempo a reserva segura o estoque quando nem o pedido nem
+// a configuração do servidor informam o prazo
+const PrazoReservaPadrao = 60 * time.Minute
+
+// StatusReserva representa a situação de uma reserva de estoque
+type StatusReserva string
+
+const (
+	// ReservaAtiva segura a quantidade até vencer; depois de ExpiraEm ela deixa de contar
+	// mesmo antes de a rotina de limpeza marcá-la como expirada
+	ReservaAtiva StatusReserva = "ativa"
+	// ReservaConsumida é a reserva cuja venda foi confirmada e baixou o estoque
+	ReservaConsumida StatusReserva = "consumida"
+	// ReservaLiberada é a reserva desfeita antes de vencer
+	ReservaLiberada StatusReserva = "liberada"
+	// ReservaExpirada é a reserva que venceu sem ser consumida
+	ReservaExpirada StatusReserva = "expirada"
+)
+
+// ReservaEstoque segura uma quantidade de um produto em um local para uma venda em
+// rascunho ou um orçamento. O estoque disponível é o saldo do local menos as reservas ativas.
+// Na conversão do orçamento, as reservas passam para a venda gerada.
+type ReservaEstoque struct {
+	ID            string        `json:"id"`
+	LocalID       string        `json:"local_id"`
+	ProdutoID     string        `json:"produto_id"`
+	ProdutoNome   string        `json:"produto_nome,omitempty"`
+	Quantidade    int           `json:"quantidade"`
+	VendaID       string        `json:"venda_id,omitempty"`
+	OrcamentoID   string        `json:"orcamento_id,omitempty"`
+	UsuarioID     string        `json:"usuario_id"`
+	Status        StatusReserva `json:"status"`
+	DataCriacao   time.Time     `json:"data_criacao"`
+	ExpiraEm      time.Time     `json:"expira_em"`
+	DataLiberacao *time.Time    `json:"data_liberacao,omitempty"`
+}
+
+// OrigemReserva identifica a venda ou o orçamento dono das reservas. Ao conferir o estoque
+// de uma operação, as reservas da própria origem não reduzem o disponível.
+type OrigemReserva struct {
+	VendaID     string
+	OrcamentoID string
+}
+
+// FiltroReservas restringe a listagem das reservas ativas. Campos vazios não filtram.
+type FiltroReservas struct {
+	LocalID   string
+	ProdutoID string
+}
@@ -0,0 +1,92 @@
+package domain
+
+import "time"
+
+// SegmentoRFM classifica o cliente pela recência, frequência e valor das suas compras
+type SegmentoRFM string
+
+const (
+	// SegmentoCampeoes compraram há pouco tempo, compram com frequência e gastam mais
+	SegmentoCampeoes SegmentoRFM = "campeoes"
+	// SegmentoFieis compram com regularidade e ainda estão ativos
+	SegmentoFieis SegmentoRFM = "fieis"
+	// SegmentoNovos fizeram a primeira compra há pouco tempo
+	SegmentoNovos SegmentoRFM = "novos"
+	// SegmentoPromissores compraram recentemente, mas ainda pouco
+	SegmentoPromissores SegmentoRFM = "promissores"
+	// SegmentoNaoPodePerder eram dos melhores clientes e deixaram de comprar
+	SegmentoNaoPodePerder SegmentoRFM = "nao_pode_perder"
+	// SegmentoEmRisco compravam com regularidade e estão há algum tempo sem comprar
+	SegmentoEmRisco SegmentoRFM = "em_risco"
+	// SegmentoHibernando compraram pouco e há algum tempo
+	SegmentoHibernando SegmentoRFM = "hibernando"
+	// SegmentoPerdidos compraram pouco e há muito tempo
+	SegmentoPerdidos SegmentoRFM = "perdidos"
+	// SegmentoSemCompras ainda não têm vendas efetivadas
+	SegmentoSemCompras SegmentoRFM = "sem_compras"
+)
+
+// Valido informa se o segmento é um dos calculados pelo sistema
+func (s SegmentoRFM) Valido() bool {
+	switch s {
+	case SegmentoCampeoes, SegmentoFieis, SegmentoNovos, SegmentoPromissores, SegmentoNaoPodePerder,
+		SegmentoEmRisco, SegmentoHibernando, SegmentoPerdidos, SegmentoSemCompras:
+		return true
+	}
+	return false
+}
+
+// MetricasRFM são os números de compra de um cliente usados na segmentação: dias desde a
+// última compra, quantidade de vendas e valor gasto, descontadas as devoluções
+type MetricasRFM struct {
+	ClienteID    string     `json:"cliente_id"`
+	UltimaCompra *time.Time `json:"ultima_compra,omitempty"`
+	Recencia     int        `json:"recencia_dias"`
+	Frequencia   int        `json:"frequencia"`
+	Monetario    float64    `json:"monetario"`
+}
+
+// SegmentoCliente é o resultado da última segmentação de um cliente. As notas R, F e M vão
+// de 1 a 5 conforme o quintil do cliente entre os que já compraram; clientes sem compras
+// ficam com notas zero.
+type SegmentoCliente struct {
+	MetricasRFM
+	Nome        string      `json:"nome,omitempty"`
+	Email       string      `json:"email,omitempty"`
+	Telefone    string      `json:"telefone,omitempty"`
+	NotaR       int         `json:"nota_r"`
+	NotaF       int         `json:"nota_f"`
+	NotaM       int         `json:"nota_m"`
+	Segmento    SegmentoRFM `json:"segmento"`
+	DataCalculo time.Time   `json:"data_calculo"`
+}
+
+// ProdutoFavorito é um dos produtos que o cliente mais comprou
+type ProdutoFavorito struct {
+	ProdutoID  string  `json:"produto_id"`
+	Nome       string  `json:"nome"`
+	Quantidade int     `json:"quantidade"`
+	Valor      float64 `json:"valor"`
+}
+
+// ResumoCliente consolida o histórico de compras do cliente. Contam as vendas confirmadas,
+// pagas e devolvidas, com os reembolsos descontados do valor total; o segmento é o da
+// última segmentação e fica nulo enquanto ela não incluir o cliente.
+type ResumoCliente struct {
+	ClienteID         string            `json:"cliente_id"`
+	TotalVendas       int               `json:"total_vendas"`
+	ValorTotal        float64           `json:"valor_total"`
+	TicketMedio       float64           `json:"ticket_medio"`
+	PrimeiraCompra    *time.Time        `json:"primeira_compra,omitempty"`
+	UltimaCompra      *time.Time        `json:"ultima_compra,omitempty"`
+	DiasSemComprar    *int              `json:"dias_sem_comprar,omitempty"`
+	ProdutosFavoritos []ProdutoFavorito `json:"produtos_favoritos"`
+	Segmento          *SegmentoCliente  `json:"segmento"`
+}
+
+// ResultadoSegmentacao informa quantos clientes ficaram em cada segmento
+type ResultadoSegmentacao struct {
+	DataCalculo time.Time           `json:"data_calculo"`
+	Total       int                 `json:"total"`
+	Segmentos   map[SegmentoRFM]int `json:"segmentos"`
+}
@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrSessaoInvalida indica um refresh token desconhecido, vencido ou revogado, ou uma
+	// sessão encerrada
+	ErrSessaoInvalida = errors.New("sessão inválida")
+	// ErrRefreshTokenReutilizado indica o uso de um refresh token que já foi trocado por
+	// outro; como ele pode ter sido roubado, a sessão inteira é revogada
+	ErrRefreshTokenReutilizado = errors.New("refresh token reutilizado")
+)
+
+// DuracaoRefreshToken é a validade de cada refresh token. A cada renovação o token é
+// trocado por outro com a validade completa.
+const DuracaoRefreshToken = 7 * 24 * time.Hour
+
+// RefreshToken é um dos tokens de renovação de uma sessão. Todos os tokens emitidos a
+// partir do mesmo login formam uma família identificada por SessaoID; apenas o hash do
+// token é guardado. SubstituidoPor aponta para o token emitido na renovação.
+type RefreshToken struct {
+	ID             string     `json:"id"`
+	UsuarioID      string     `json:"usuario_id"`
+	SessaoID       string     `json:"sessao_id"`
+	TokenHash      string     `json:"-"`
+	DataCriacao    time.Time  `json:"data_criacao"`
+	ExpiraEm       time.Time  `json:"expira_em"`
+	SubstituidoPor string     `json:"substituido_por,omitempty"`
+	RevogadoEm     *time.Time `json:"revogado_em,omitempty"`
+}
+
+// TokensSessao é o par de tokens entregue no login e em cada renovação. O access token
+// vence em ExpiraEm e deve ser renovado com o refresh token.
+type TokensSessao struct {
+	AccessToken  string    `json:"token"`
+	RefreshToken string    `json:"refresh_token"`
+	ExpiraEm     time.Time `json:"expira_em"`
+}
+
+// SessaoService define o ciclo de vida das sessões autenticadas
+type SessaoService interface {
+	Iniciar(usuario *Usuario) (*TokensSessao, error)
+	Renovar(refreshToken string) (*TokensSessao, *Usuario, error)
+	Encerrar(usuarioID, sessaoID string) error
+	EncerrarTodas(usuarioID string) error
+	Verificar(usuarioID, sessaoID string) (*Usuario, error)
+}
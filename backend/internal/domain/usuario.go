@@ -1,6 +1,12 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"time"
+)
+
+// ErrUsuarioNaoEncontrado indica que não existe usuário com o identificador informado
+var ErrUsuarioNaoEncontrado = errors.New("usuário não encontrado")
 
 // Role representa o papel do usuário no sistema
 type Role string
@@ -22,6 +28,15 @@ type Usuario struct {
 	DataCriacao time.Time `json:"data_criacao"`
 }
 
+// Operador identifica o usuário autenticado que executa uma operação. LiberacaoCredito
+// é o administrador que autorizou a operação a ultrapassar o limite de crédito do
+// cliente, quando houver.
+type Operador struct {
+	UsuarioID        string
+	Role             Role
+	LiberacaoCredito string
+}
+
 // UsuarioRepository define as operações que podem ser realizadas com usuários
 type UsuarioRepository interface {
 	Create(usuario *Usuario) error
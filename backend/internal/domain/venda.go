@@ -1,28 +1,142 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// StatusVenda representa a etapa do ciclo de vida de uma venda
+type StatusVenda string
+
+const (
+	StatusRascunho   StatusVenda = "rascunho"
+	StatusConfirmada StatusVenda = "confirmada"
+	StatusPaga       StatusVenda = "paga"
+	StatusCancelada  StatusVenda = "cancelada"
+	StatusDevolvida  StatusVenda = "devolvida"
+)
+
+// ErrTransicaoStatusInvalida indica uma mudança de status não prevista no ciclo de vida da venda
+var ErrTransicaoStatusInvalida = errors.New("transição de status não permitida")
+
+// ErrVendedorInvalido indica um vendedor informado para a venda que não existe, está inativo
+// ou não tem permissão para registrar vendas
+var ErrVendedorInvalido = errors.New("vendedor inválido")
+
+// ErrDescontoInvalido indica um desconto negativo, de tipo desconhecido ou maior que o valor
+// sobre o qual incide
+var ErrDescontoInvalido = errors.New("desconto inválido")
+
+// ErroLimiteDesconto indica um desconto acima do percentual que o perfil do operador pode
+// conceder
+type ErroLimiteDesconto struct {
+	Percentual float64 `json:"percentual"`
+	Limite     float64 `json:"limite"`
+	Role       Role    `json:"role"`
+}
+
+func (e *ErroLimiteDesconto) Error() string {
+	return fmt.Sprintf("desconto de %.2f%% excede o limite de %.2f%% permitido para o perfil %s",
+		e.Percentual, e.Limite, e.Role)
+}
+
+// transicoesVenda define para quais status uma venda pode seguir a partir do status atual
+var transicoesVenda = map[StatusVenda][]StatusVenda{
+	StatusRascunho:   {StatusConfirmada, StatusCancelada},
+	StatusConfirmada: {StatusPaga, StatusCancelada, StatusDevolvida},
+	StatusPaga:       {StatusCancelada, StatusDevolvida},
+}
+
+// PodeMudarPara informa se a transição do status atual para o novo é permitida
+func (s StatusVenda) PodeMudarPara(novo StatusVenda) bool {
+	for _, permitido := range transicoesVenda[s] {
+		if permitido == novo {
+			return true
+		}
+	}
+	return false
+}
+
+// BaixaEstoque informa se os itens de uma venda neste status já saíram do estoque
+func (s StatusVenda) BaixaEstoque() bool {
+	return s == StatusConfirmada || s == StatusPaga
+}
+
+// TipoDesconto define como o valor de um desconto deve ser interpretado
+type TipoDesconto string
+
+const (
+	DescontoPercentual TipoDesconto = "percentual"
+	DescontoValor      TipoDesconto = "valor"
+)
+
+// Desconto representa um desconto solicitado para um item ou para a venda inteira.
+// Quando o tipo não é informado o valor é tratado como percentual.
+type Desconto struct {
+	Tipo  TipoDesconto `json:"tipo"`
+	Valor float64      `json:"valor"`
+}
 
 // ItemVenda representa um item individual em uma venda
 type ItemVenda struct {
-	ID            string   `json:"id"`
-	VendaID       string   `json:"venda_id"`
-	ProdutoID     string   `json:"produto_id"`
-	Quantidade    int      `json:"quantidade"`
-	PrecoUnitario float64  `json:"preco_unitario"`
-	Produto       *Produto `json:"produto"`
+	ID            string    `json:"id"`
+	VendaID       string    `json:"venda_id"`
+	ProdutoID     string    `json:"produto_id"`
+	Quantidade    int       `json:"quantidade"`
+	PrecoUnitario float64   `json:"preco_unitario"`
+	CustoUnitario float64   `json:"custo_unitario"`
+	Desconto      *Desconto `json:"desconto,omitempty"`
+	Subtotal      float64   `json:"subtotal"`
+	ValorDesconto float64   `json:"valor_desconto"`
+	Total         float64   `json:"total"`
+	Produto       *Produto  `json:"produto"`
 }
 
 // Venda representa uma transação de venda
 type Venda struct {
-	ID          string      `json:"id"`
-	ClienteID   string      `json:"cliente_id"`
-	VendedorID  string      `json:"vendedor_id"`
-	DataVenda   time.Time   `json:"data_venda"`
-	ValorTotal  float64     `json:"valor_total"`
-	DataCriacao time.Time   `json:"data_criacao"`
-	Items       []ItemVenda `json:"items"`
-	Cliente     *Usuario    `json:"cliente"`
-	Vendedor    *Usuario    `json:"vendedor"`
+	ID            string      `json:"id"`
+	ClienteID     string      `json:"cliente_id"`
+	VendedorID    string      `json:"vendedor_id"`
+	DataVenda     time.Time   `json:"data_venda"`
+	Status        StatusVenda `json:"status"`
+	Desconto      *Desconto   `json:"desconto,omitempty"`
+	Subtotal      float64     `json:"subtotal"`
+	ValorDesconto float64     `json:"valor_desconto"`
+	ValorTotal    float64     `json:"valor_total"`
+	DataCriacao   time.Time   `json:"data_criacao"`
+	Items         []ItemVenda `json:"items"`
+
+	// Parcelamento é opcional; as parcelas são geradas quando a venda é confirmada
+	Parcelamento *PlanoParcelamento `json:"parcelamento,omitempty"`
+	Parcelas     []Parcela          `json:"parcelas,omitempty"`
+
+	// LocalID é o depósito ou loja de onde os itens saem
+	LocalID string `json:"local_id"`
+
+	// OrcamentoID é o orçamento convertido na venda, cujas reservas de estoque passam para ela
+	OrcamentoID string `json:"orcamento_id,omitempty"`
+
+	// LiberacaoCredito é o administrador que autorizou a venda além do limite de crédito do cliente
+	LiberacaoCredito string `json:"liberacao_credito,omitempty"`
+
+	// Pontos de fidelidade creditados ao cliente na confirmação da venda e a data em que vencem
+	PontosFidelidade int        `json:"pontos_fidelidade,omitempty"`
+	ExpiracaoPontos  *time.Time `json:"expiracao_pontos,omitempty"`
+
+	Cliente  *Cliente `json:"cliente"`
+	Vendedor *Usuario `json:"vendedor"`
+}
+
+// HistoricoStatusVenda registra cada mudança de status de uma venda
+type HistoricoStatusVenda struct {
+	ID             string      `json:"id"`
+	VendaID        string      `json:"venda_id"`
+	StatusAnterior StatusVenda `json:"status_anterior"`
+	StatusNovo     StatusVenda `json:"status_novo"`
+	UsuarioID      string      `json:"usuario_id"`
+	Motivo         string      `json:"motivo"`
+	Data           time.Time   `json:"data"`
 }
 
 // VendaRepository define as operações que podem ser realizadas com vendas
@@ -1,11 +1,28 @@
 package dto
 
+import "vendas/internal/domain"
+
 type CreateClienteDTO struct {
 	Nome     string `json:"nome" binding:"required"`
 	Email    string `json:"email" binding:"required,email"`
 	Telefone string `json:"telefone" binding:"required"`
-	Endereco string `json:"endereco" binding:"required"`
-	CPF      string `json:"cpf" binding:"required"`
+	// Endereço em texto livre do cadastro original, opcional: os endereços estruturados
+	// são cadastrados em /clientes/{id}/enderecos
+	Endereco string `json:"endereco"`
+	CPF      string `json:"cpf"`
+	// Usuário de acesso do próprio cliente, quando houver; apenas administradores o vinculam
+	UsuarioID string `json:"usuario_id"`
+
+	// Pessoa física (padrão) informa o CPF; pessoa jurídica informa o CNPJ e a
+	// inscrição estadual, ou se declara isenta
+	TipoPessoa        domain.TipoPessoa `json:"tipo_pessoa" binding:"omitempty,oneof=fisica juridica"`
+	CNPJ              string            `json:"cnpj"`
+	InscricaoEstadual string            `json:"inscricao_estadual"`
+	IsentoIE          bool              `json:"isento_ie"`
+
+	// Limite de crédito para vendas em aberto; zero indica cliente sem limite. Apenas
+	// administradores podem definir o limite.
+	LimiteCredito float64 `json:"limite_credito" binding:"gte=0"`
 }
 
 type UpdateClienteDTO struct {
@@ -14,4 +31,28 @@ type UpdateClienteDTO struct {
 	Telefone string `json:"telefone"`
 	Endereco string `json:"endereco"`
 	CPF      string `json:"cpf"`
+	// Usuário de acesso do próprio cliente, quando houver; apenas administradores o vinculam
+	UsuarioID string `json:"usuario_id"`
+
+	// Ao trocar o tipo de pessoa, os documentos do tipo anterior são descartados
+	TipoPessoa        domain.TipoPessoa `json:"tipo_pessoa" binding:"omitempty,oneof=fisica juridica"`
+	CNPJ              string            `json:"cnpj"`
+	InscricaoEstadual string            `json:"inscricao_estadual"`
+	IsentoIE          *bool             `json:"isento_ie"`
+
+	// Limite de crédito para vendas em aberto; apenas administradores podem alterá-lo
+	LimiteCredito *float64 `json:"limite_credito" binding:"omitempty,gte=0"`
+}
+
+// EnderecoDTO traz os dados de um endereço do cliente. Logradouro, bairro, cidade e UF
+// não informados são preenchidos a partir do CEP.
+type EnderecoDTO struct {
+	Tipo        domain.TipoEndereco `json:"tipo" binding:"required,oneof=cobranca entrega"`
+	CEP         string              `json:"cep" binding:"required"`
+	Logradouro  string              `json:"logradouro"`
+	Numero      string              `json:"numero" binding:"required"`
+	Complemento string              `json:"complemento"`
+	Bairro      string              `json:"bairro"`
+	Cidade      string              `json:"cidade"`
+	UF          string              `json:"uf"`
 }
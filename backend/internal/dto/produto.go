@@ -6,6 +6,7 @@ type CreateProdutoDTO struct {
 	Preco      float64 `json:"preco" binding:"required,gt=0"`
 	Quantidade int     `json:"quantidade" binding:"required,gte=0"`
 	ImagemURL  string  `json:"imagem_url"`
+	Categoria  string  `json:"categoria"`
 }
 
 type UpdateProdutoDTO struct {
@@ -14,4 +15,5 @@ type UpdateProdutoDTO struct {
 	Preco      float64 `json:"preco" binding:"omitempty,gt=0"`
 	Quantidade int     `json:"quantidade" binding:"omitempty,gte=0"`
 	ImagemURL  string  `json:"imagem_url"`
+	Categoria  string  `json:"categoria"`
 }
@@ -21,3 +21,7 @@ type LoginDTO struct {
 	Email string `json:"email" binding:"required,email"`
 	Senha string `json:"senha" binding:"required"`
 }
+
+type RefreshTokenDTO struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
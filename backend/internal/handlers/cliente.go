@@ -1,11 +1,12 @@
 package handlers
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 
 	"vendas/internal/domain"
 	"vendas/internal/dto"
@@ -28,33 +29,32 @@ func (h *ClienteHandler) CreateCliente(c *gin.Context) {
 		return
 	}
 
-	// Verifica se já existe um cliente com o mesmo CPF
-	existingCliente, _ := h.clienteService.GetClienteByCPF(dto.CPF)
-	if existingCliente != nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "CPF já cadastrado"})
+	if dto.LimiteCredito != 0 && !domain.Role(c.GetString("role")).TemPermissao(domain.PermCreditoGerenciar) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "apenas administradores podem definir o limite de crédito"})
 		return
 	}
-
-	// TODO: Obter o ID do usuário autenticado
-	usuarioID := c.GetString("usuario_id")
-	if usuarioID == "" {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "usuário não autenticado"})
+	if dto.UsuarioID != "" && !domain.Role(c.GetString("role")).TemPermissao(domain.PermUsuariosGerenciar) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "apenas administradores podem vincular o usuário de acesso"})
 		return
 	}
 
+	// O serviço normaliza e valida o CPF ou CNPJ antes de conferir se já está cadastrado
 	cliente := &domain.Cliente{
-		ID:          uuid.New().String(),
-		Nome:        dto.Nome,
-		Email:       dto.Email,
-		Telefone:    dto.Telefone,
-		Endereco:    dto.Endereco,
-		CPF:         dto.CPF,
-		UsuarioID:   usuarioID,
-		DataCriacao: time.Now(),
+		Nome:              dto.Nome,
+		Email:             dto.Email,
+		Telefone:          dto.Telefone,
+		Endereco:          dto.Endereco,
+		CPF:               dto.CPF,
+		UsuarioID:         dto.UsuarioID,
+		TipoPessoa:        dto.TipoPessoa,
+		CNPJ:              dto.CNPJ,
+		InscricaoEstadual: dto.InscricaoEstadual,
+		IsentoIE:          dto.IsentoIE,
+		LimiteCredito:     dto.LimiteCredito,
 	}
 
 	if err := h.clienteService.CreateCliente(cliente); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(statusErroCliente(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -90,11 +90,21 @@ func (h *ClienteHandler) UpdateCliente(c *gin.Context) {
 		return
 	}
 
+	if dto.LimiteCredito != nil && !domain.Role(c.GetString("role")).TemPermissao(domain.PermCreditoGerenciar) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "apenas administradores podem alterar o limite de crédito"})
+		return
+	}
+
 	cliente, err := h.clienteService.GetCliente(id)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "cliente não encontrado"})
 		return
 	}
+	if dto.UsuarioID != "" && dto.UsuarioID != cliente.UsuarioID &&
+		!domain.Role(c.GetString("role")).TemPermissao(domain.PermUsuariosGerenciar) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "apenas administradores podem alterar o usuário de acesso"})
+		return
+	}
 
 	// Atualiza apenas os campos fornecidos
 	if dto.Nome != "" {
@@ -112,21 +122,119 @@ func (h *ClienteHandler) UpdateCliente(c *gin.Context) {
 	if dto.CPF != "" {
 		cliente.CPF = dto.CPF
 	}
+	if dto.UsuarioID != "" {
+		cliente.UsuarioID = dto.UsuarioID
+	}
+	if dto.TipoPessoa != "" && dto.TipoPessoa != cliente.TipoPessoa {
+		cliente.TipoPessoa = dto.TipoPessoa
+		cliente.CPF = dto.CPF
+		cliente.CNPJ = ""
+		cliente.InscricaoEstadual = ""
+		cliente.IsentoIE = false
+	}
+	if dto.CNPJ != "" {
+		cliente.CNPJ = dto.CNPJ
+	}
+	if dto.InscricaoEstadual != "" {
+		cliente.InscricaoEstadual = dto.InscricaoEstadual
+	}
+	if dto.IsentoIE != nil {
+		cliente.IsentoIE = *dto.IsentoIE
+		if cliente.IsentoIE && dto.InscricaoEstadual == "" {
+			cliente.InscricaoEstadual = ""
+		}
+	}
+	if dto.LimiteCredito != nil {
+		cliente.LimiteCredito = *dto.LimiteCredito
+	}
 
 	if err := h.clienteService.UpdateCliente(cliente); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(statusErroCliente(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, cliente)
 }
 
+// GetCredito retorna o limite, o saldo em aberto e o crédito disponível do cliente
+func (h *ClienteHandler) GetCredito(c *gin.Context) {
+	credito, err := h.clienteService.GetCredito(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "cliente não encontrado"})
+			return
+		}
+		c.JSON(statusErroCliente(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, credito)
+}
+
+// GetResumo retorna o histórico de compras consolidado e o segmento do cliente
+func (h *ClienteHandler) GetResumo(c *gin.Context) {
+	resumo, err := h.clienteService.GetResumo(c.Param("id"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "cliente não encontrado"})
+			return
+		}
+		c.JSON(statusErroCliente(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resumo)
+}
+
+// ListSegmentos lista os clientes da última segmentação RFM, filtrando pelo segmento
+// informado na query string
+func (h *ClienteHandler) ListSegmentos(c *gin.Context) {
+	segmentos, err := h.clienteService.ListSegmentos(domain.SegmentoRFM(c.Query("segmento")))
+	if err != nil {
+		c.JSON(statusErroCliente(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, segmentos)
+}
+
+// SegmentarClientes refaz a segmentação RFM de todos os clientes sem esperar a rotina periódica
+func (h *ClienteHandler) SegmentarClientes(c *gin.Context) {
+	resultado, err := h.clienteService.SegmentarClientes(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, resultado)
+}
+
 func (h *ClienteHandler) DeleteCliente(c *gin.Context) {
 	id := c.Param("id")
 	if err := h.clienteService.DeleteCliente(id); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, sql.ErrNoRows) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "cliente não encontrado"})
+			return
+		}
+		c.JSON(statusErroCliente(err), gin.H{"error": err.Error()})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "cliente deletado com sucesso"})
 }
+
+// statusErroCliente traduz os erros do cadastro de clientes para o código HTTP adequado
+func statusErroCliente(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrClienteInvalido):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrDocumentoJaCadastrado), errors.Is(err, domain.ErrUsuarioJaVinculado),
+		errors.Is(err, domain.ErrClienteComVendas),
+		errors.Is(err, domain.ErrClienteAnonimizado):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"vendas/internal/cep"
+	"vendas/internal/domain"
+	"vendas/internal/dto"
+)
+
+type EnderecoHandler struct {
+	enderecoService domain.EnderecoService
+}
+
+func NewEnderecoHandler(service domain.EnderecoService) *EnderecoHandler {
+	return &EnderecoHandler{
+		enderecoService: service,
+	}
+}
+
+func (h *EnderecoHandler) ListEnderecos(c *gin.Context) {
+	enderecos, err := h.enderecoService.ListEnderecos(c.Param("id"))
+	if err != nil {
+		c.JSON(statusErroEndereco(err), gin.H{"error": mensagemErroEndereco(err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, enderecos)
+}
+
+func (h *EnderecoHandler) GetEndereco(c *gin.Context) {
+	endereco, err := h.enderecoService.GetEndereco(c.Param("id"), c.Param("enderecoId"))
+	if err != nil {
+		c.JSON(statusErroEndereco(err), gin.H{"error": mensagemErroEndereco(err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, endereco)
+}
+
+func (h *EnderecoHandler) CreateEndereco(c *gin.Context) {
+	var dto dto.EnderecoDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	endereco := novoEndereco(dto)
+	endereco.ClienteID = c.Param("id")
+
+	if err := h.enderecoService.CreateEndereco(endereco); err != nil {
+		c.JSON(statusErroEndereco(err), gin.H{"error": mensagemErroEndereco(err)})
+		return
+	}
+
+	c.JSON(http.StatusCreated, endereco)
+}
+
+// UpdateEndereco substitui todos os dados do endereço
+func (h *EnderecoHandler) UpdateEndereco(c *gin.Context) {
+	var dto dto.EnderecoDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	endereco := novoEndereco(dto)
+	endereco.ID = c.Param("enderecoId")
+	endereco.ClienteID = c.Param("id")
+
+	if err := h.enderecoService.UpdateEndereco(endereco); err != nil {
+		c.JSON(statusErroEndereco(err), gin.H{"error": mensagemErroEndereco(err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, endereco)
+}
+
+func (h *EnderecoHandler) DeleteEndereco(c *gin.Context) {
+	if err := h.enderecoService.DeleteEndereco(c.Param("id"), c.Param("enderecoId")); err != nil {
+		c.JSON(statusErroEndereco(err), gin.H{"error": mensagemErroEndereco(err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "endereço removido com sucesso"})
+}
+
+// BuscarCEP consulta o endereço de um CEP para preencher formulários
+func (h *EnderecoHandler) BuscarCEP(c *gin.Context) {
+	endereco, err := h.enderecoService.BuscarCEP(c.Param("cep"))
+	if err != nil {
+		c.JSON(statusErroEndereco(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, endereco)
+}
+
+func novoEndereco(dto dto.EnderecoDTO) *domain.Endereco {
+	return &domain.Endereco{
+		Tipo:        dto.Tipo,
+		CEP:         dto.CEP,
+		Logradouro:  dto.Logradouro,
+		Numero:      dto.Numero,
+		Complemento: dto.Complemento,
+		Bairro:      dto.Bairro,
+		Cidade:      dto.Cidade,
+		UF:          dto.UF,
+	}
+}
+
+// statusErroEndereco traduz os erros de endereços e da consulta de CEP para o código HTTP
+// adequado. Falhas do serviço de CEP são respondidas como erro do gateway.
+func statusErroEndereco(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, cep.ErrCEPNaoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrEnderecoInvalido), errors.Is(err, cep.ErrCEPInvalido):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrClienteAnonimizado):
+		return http.StatusConflict
+	case errors.Is(err, cep.ErrConsultaCEP):
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func mensagemErroEndereco(err error) string {
+	if errors.Is(err, sql.ErrNoRows) {
+		return "endereço ou cliente não encontrado"
+	}
+	return err.Error()
+}
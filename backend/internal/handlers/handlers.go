@@ -5,20 +5,23 @@ import (
 )
 
 type Handlers struct {
-	Usuario *UsuarioHandler
-	Cliente *ClienteHandler
-	Produto *ProdutoHandler
+	Usuario  *UsuarioHandler
+	Cliente  *ClienteHandler
+	Endereco *EnderecoHandler
+	Produto  *ProdutoHandler
 }
 
 func NewHandlers(
 	usuarioService domain.UsuarioService,
 	clienteService domain.ClienteService,
+	enderecoService domain.EnderecoService,
 	produtoService domain.ProdutoService,
-	jwtSecretKey string,
+	sessaoService domain.SessaoService,
 ) *Handlers {
 	return &Handlers{
-		Usuario: NewUsuarioHandler(usuarioService, jwtSecretKey),
-		Cliente: NewClienteHandler(clienteService),
-		Produto: NewProdutoHandler(produtoService),
+		Usuario:  NewUsuarioHandler(usuarioService, sessaoService),
+		Cliente:  NewClienteHandler(clienteService),
+		Endereco: NewEnderecoHandler(enderecoService),
+		Produto:  NewProdutoHandler(produtoService),
 	}
 }
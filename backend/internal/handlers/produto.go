@@ -35,6 +35,7 @@ func (h *ProdutoHandler) CreateProduto(c *gin.Context) {
 		Preco:       dto.Preco,
 		Quantidade:  dto.Quantidade,
 		ImagemURL:   dto.ImagemURL,
+		Categoria:   dto.Categoria,
 		DataCriacao: time.Now(),
 	}
 
@@ -97,6 +98,9 @@ func (h *ProdutoHandler) UpdateProduto(c *gin.Context) {
 	if dto.ImagemURL != "" {
 		produto.ImagemURL = dto.ImagemURL
 	}
+	if dto.Categoria != "" {
+		produto.Categoria = dto.Categoria
+	}
 
 	if err := h.produtoService.UpdateProduto(produto); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -2,11 +2,80 @@ package handlers
 
 import (
 	"database/sql"
+	"fmt"
+	"math"
 	"net/http"
+	"time"
+	"vendas/internal/domain"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mattn/go-sqlite3"
 )
 
+// vendasContabilizadas filtra as vendas que entram nos totais dos relatórios.
+// Rascunhos ainda não foram efetivados e vendas canceladas não geram receita. As vendas
+// devolvidas continuam na conta porque os seus reembolsos são descontados da receita.
+const vendasContabilizadas = "v.status IN ('confirmada', 'paga', 'devolvida')"
+
+// devolvidoPorItem soma, por item de venda, a quantidade e o valor já devolvidos
+const devolvidoPorItem = `
+	SELECT item_venda_id, SUM(quantidade) as quantidade, SUM(valor_reembolso) as valor
+	FROM itens_devolucao
+	GROUP BY item_venda_id
+`
+
+// pendenteCompraPorProduto soma, por produto, as quantidades ainda não recebidas dos
+// pedidos de compra pendentes e a previsão de entrega mais próxima
+const pendenteCompraPorProduto = `
+	SELECT i.produto_id, SUM(i.quantidade - i.quantidade_recebida) as quantidade,
+		MIN(pc.previsao_entrega) as previsao_entrega
+	FROM itens_pedido_compra i
+	JOIN pedidos_compra pc ON pc.id = i.pedido_compra_id
+	WHERE pc.status IN ('aberto', 'parcialmente_recebido')
+	GROUP BY i.produto_id
+`
+
+// reembolsadoPorVenda soma o valor reembolsado em devoluções de cada venda
+const reembolsadoPorVenda = `
+	SELECT venda_id, SUM(valor_reembolso) as valor
+	FROM devolucoes
+	GROUP BY venda_id
+`
+
+// parseDataSQLite converte as datas retornadas por funções de agregação, que o driver
+// entrega como texto em vez de time.Time
+func parseDataSQLite(valor string) (time.Time, error) {
+	for _, formato := range sqlite3.SQLiteTimestampFormats {
+		if data, err := time.Parse(formato, valor); err == nil {
+			return data, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("data inválida: %s", valor)
+}
+
+// Margem reúne a receita líquida de devoluções, o custo das mercadorias vendidas e a
+// margem bruta de um agrupamento do relatório
+type Margem struct {
+	ID         string  `json:"id,omitempty"`
+	Nome       string  `json:"nome,omitempty"`
+	Mes        string  `json:"mes,omitempty"`
+	Quantidade int     `json:"quantidade"`
+	Receita    float64 `json:"receita"`
+	Custo      float64 `json:"custo"`
+	Margem     float64 `json:"margem"`
+	Percentual float64 `json:"percentual"`
+}
+
+// calcular arredonda os valores e preenche a margem e o seu percentual sobre a receita
+func (m *Margem) calcular() {
+	m.Receita = math.Round(m.Receita*100) / 100
+	m.Custo = math.Round(m.Custo*100) / 100
+	m.Margem = math.Round((m.Receita-m.Custo)*100) / 100
+	if m.Receita != 0 {
+		m.Percentual = math.Round(m.Margem/m.Receita*10000) / 100
+	}
+}
+
 type RelatorioHandler struct {
 	db *sql.DB
 }
@@ -16,7 +85,9 @@ func NewRelatorioHandler(db *sql.DB) *RelatorioHandler {
 }
 
 // @Summary Obtém relatório geral
-// @Description Retorna dados gerais do sistema como vendas do dia, total de clientes e produtos
+// @Description Retorna dados gerais do sistema como vendas do dia, total de clientes e produtos.
+// @Description Os valores de venda são líquidos dos reembolsos de devoluções. As margens usam
+// @Description o custo médio registrado em cada item no momento da venda
 // @Tags relatorios
 // @Accept json
 // @Produce json
@@ -26,21 +97,34 @@ func (h *RelatorioHandler) GetRelatorio(c *gin.Context) {
 	// Vendas do dia
 	var vendasDia float64
 	err := h.db.QueryRow(`
-		SELECT COALESCE(SUM(valor_total), 0)
-		FROM vendas
-		WHERE date(data_venda) = date('now')
-	`).Scan(&vendasDia)
+		SELECT COALESCE(SUM(v.valor_total), 0)
+		FROM vendas v
+		WHERE date(v.data_venda) = date('now')
+		AND ` + vendasContabilizadas).Scan(&vendasDia)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao obter vendas do dia"})
 		return
 	}
 
+	// Reembolsos do dia
+	var devolucoesDia float64
+	err = h.db.QueryRow(`
+		SELECT COALESCE(SUM(d.valor_reembolso), 0)
+		FROM devolucoes d
+		JOIN vendas v ON v.id = d.venda_id
+		WHERE date(d.data_devolucao) = date('now')
+		AND ` + vendasContabilizadas).Scan(&devolucoesDia)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao obter devoluções do dia"})
+		return
+	}
+	vendasDia -= devolucoesDia
+
 	// Total de clientes
 	var totalClientes int
 	err = h.db.QueryRow(`
 		SELECT COUNT(*)
-		FROM usuarios
-		WHERE role = 'cliente'
+		FROM clientes
 	`).Scan(&totalClientes)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao obter total de clientes"})
@@ -58,15 +142,26 @@ func (h *RelatorioHandler) GetRelatorio(c *gin.Context) {
 		return
 	}
 
-	// Vendas por mês (últimos 12 meses)
+	// Vendas por mês (últimos 12 meses), descontando os reembolsos no mês da devolução
 	rows, err := h.db.Query(`
 		SELECT 
-			strftime('%Y-%m', data_venda) as mes,
-			COUNT(*) as quantidade,
-			SUM(valor_total) as total
-		FROM vendas
-		WHERE data_venda >= datetime('now', '-12 months')
-		GROUP BY strftime('%Y-%m', data_venda)
+			mes,
+			SUM(quantidade) as quantidade,
+			SUM(total) as total,
+			SUM(devolvido) as devolvido
+		FROM (
+			SELECT strftime('%Y-%m', v.data_venda) as mes, 1 as quantidade, v.valor_total as total, 0 as devolvido
+			FROM vendas v
+			WHERE v.data_venda >= datetime('now', '-12 months')
+			AND ` + vendasContabilizadas + `
+			UNION ALL
+			SELECT strftime('%Y-%m', d.data_devolucao), 0, -d.valor_reembolso, d.valor_reembolso
+			FROM devolucoes d
+			JOIN vendas v ON v.id = d.venda_id
+			WHERE d.data_devolucao >= datetime('now', '-12 months')
+			AND ` + vendasContabilizadas + `
+		)
+		GROUP BY mes
 		ORDER BY mes DESC
 	`)
 	if err != nil {
@@ -79,12 +174,13 @@ func (h *RelatorioHandler) GetRelatorio(c *gin.Context) {
 		Mes        string  `json:"mes"`
 		Quantidade int     `json:"quantidade"`
 		Total      float64 `json:"total"`
+		Devolvido  float64 `json:"devolvido"`
 	}
 
 	var vendasPorMes []VendaMes
 	for rows.Next() {
 		var v VendaMes
-		err := rows.Scan(&v.Mes, &v.Quantidade, &v.Total)
+		err := rows.Scan(&v.Mes, &v.Quantidade, &v.Total, &v.Devolvido)
 		if err != nil {
 			continue
 		}
@@ -96,12 +192,14 @@ func (h *RelatorioHandler) GetRelatorio(c *gin.Context) {
 		SELECT 
 			p.id,
 			p.nome,
-			SUM(iv.quantidade) as quantidade,
-			SUM(iv.quantidade * iv.preco_unitario) as total
+			SUM(iv.quantidade - COALESCE(dv.quantidade, 0)) as quantidade,
+			SUM(iv.total - COALESCE(dv.valor, 0)) as total
 		FROM itens_venda iv
 		JOIN produtos p ON iv.produto_id = p.id
 		JOIN vendas v ON iv.venda_id = v.id
+		LEFT JOIN (` + devolvidoPorItem + `) dv ON dv.item_venda_id = iv.id
 		WHERE v.data_venda >= datetime('now', '-30 days')
+		AND ` + vendasContabilizadas + `
 		GROUP BY p.id, p.nome
 		ORDER BY quantidade DESC
 		LIMIT 5
@@ -132,14 +230,17 @@ func (h *RelatorioHandler) GetRelatorio(c *gin.Context) {
 	// Vendas por vendedor (últimos 30 dias)
 	rows, err = h.db.Query(`
 		SELECT 
-			u.id,
-			u.nome,
+			COALESCE(v.vendedor_id, '') as id,
+			COALESCE(u.nome, '') as nome,
 			COUNT(*) as quantidade,
-			SUM(v.valor_total) as total
+			SUM(v.valor_total - COALESCE(dv.valor, 0)) as total
 		FROM vendas v
-		JOIN usuarios u ON v.vendedor_id = u.id
+		-- Vendas antigas podem não ter vendedor registrado
+		LEFT JOIN usuarios u ON v.vendedor_id = u.id
+		LEFT JOIN (` + reembolsadoPorVenda + `) dv ON dv.venda_id = v.id
 		WHERE v.data_venda >= datetime('now', '-30 days')
-		GROUP BY u.id, u.nome
+		AND ` + vendasContabilizadas + `
+		GROUP BY COALESCE(v.vendedor_id, ''), u.nome
 		ORDER BY total DESC
 	`)
 	if err != nil {
@@ -158,18 +259,26 @@ func (h *RelatorioHandler) GetRelatorio(c *gin.Context) {
 		vendasPorVendedor = append(vendasPorVendedor, v)
 	}
 
-	// Produtos com estoque baixo
+	// Produtos abaixo do estoque mínimo e o que já está pedido aos fornecedores
 	rows, err = h.db.Query(`
 		SELECT 
-			id,
-			nome,
-			quantidade,
-			preco
-		FROM produtos
-		WHERE quantidade <= 10
-		ORDER BY quantidade ASC
+			p.id,
+			p.nome,
+			p.quantidade,
+			p.preco,
+			estoque_minimo,
+			COALESCE(pc.quantidade, 0) as quantidade_pedida,
+			pc.previsao_entrega
+		FROM (
+			SELECT id, nome, quantidade, preco,
+				CASE WHEN estoque_minimo > 0 THEN estoque_minimo ELSE ? END as estoque_minimo
+			FROM produtos
+		) p
+		LEFT JOIN (`+pendenteCompraPorProduto+`) pc ON pc.produto_id = p.id
+		WHERE p.quantidade <= p.estoque_minimo
+		ORDER BY p.quantidade - p.estoque_minimo ASC
 		LIMIT 5
-	`)
+	`, domain.EstoqueMinimoPadrao)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao obter produtos com estoque baixo"})
 		return
@@ -177,29 +286,285 @@ func (h *RelatorioHandler) GetRelatorio(c *gin.Context) {
 	defer rows.Close()
 
 	type ProdutoEstoque struct {
-		ID         string  `json:"id"`
-		Nome       string  `json:"nome"`
-		Quantidade int     `json:"quantidade"`
-		Preco      float64 `json:"preco"`
+		ID               string     `json:"id"`
+		Nome             string     `json:"nome"`
+		Quantidade       int        `json:"quantidade"`
+		Preco            float64    `json:"preco"`
+		EstoqueMinimo    int        `json:"estoque_minimo"`
+		QuantidadePedida int        `json:"quantidade_pedida"`
+		PrevisaoEntrega  *time.Time `json:"previsao_entrega,omitempty"`
 	}
 
 	var produtosEstoqueBaixo []ProdutoEstoque
 	for rows.Next() {
 		var p ProdutoEstoque
-		err := rows.Scan(&p.ID, &p.Nome, &p.Quantidade, &p.Preco)
+		var previsao sql.NullString
+		err := rows.Scan(&p.ID, &p.Nome, &p.Quantidade, &p.Preco, &p.EstoqueMinimo, &p.QuantidadePedida, &previsao)
 		if err != nil {
 			continue
 		}
+		if previsao.Valid {
+			if data, err := parseDataSQLite(previsao.String); err == nil {
+				p.PrevisaoEntrega = &data
+			}
+		}
 		produtosEstoqueBaixo = append(produtosEstoqueBaixo, p)
 	}
 
+	// Recebimentos por forma de pagamento (últimos 30 dias)
+	rows, err = h.db.Query(`
+		SELECT 
+			p.forma,
+			COUNT(*) as quantidade,
+			SUM(p.valor) as total
+		FROM pagamentos p
+		JOIN vendas v ON v.id = p.venda_id
+		WHERE p.data_pagamento >= datetime('now', '-30 days')
+		AND ` + vendasContabilizadas + `
+		GROUP BY p.forma
+		ORDER BY total DESC
+	`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao obter pagamentos por forma"})
+		return
+	}
+	defer rows.Close()
+
+	type PagamentoForma struct {
+		Forma      string  `json:"forma"`
+		Quantidade int     `json:"quantidade"`
+		Total      float64 `json:"total"`
+	}
+
+	var pagamentosPorForma []PagamentoForma
+	for rows.Next() {
+		var p PagamentoForma
+		err := rows.Scan(&p.Forma, &p.Quantidade, &p.Total)
+		if err != nil {
+			continue
+		}
+		pagamentosPorForma = append(pagamentosPorForma, p)
+	}
+
+	// Margem por produto (últimos 30 dias)
+	margemPorProduto, err := h.buscarMargens(`
+		SELECT
+			p.id,
+			p.nome,
+			'' as mes,
+			SUM(iv.quantidade - COALESCE(dv.quantidade, 0)) as quantidade,
+			SUM(iv.total - COALESCE(dv.valor, 0)) as receita,
+			SUM((iv.quantidade - COALESCE(dv.quantidade, 0)) * iv.custo_unitario) as custo
+		FROM itens_venda iv
+		JOIN produtos p ON iv.produto_id = p.id
+		JOIN vendas v ON iv.venda_id = v.id
+		LEFT JOIN (` + devolvidoPorItem + `) dv ON dv.item_venda_id = iv.id
+		WHERE v.data_venda >= datetime('now', '-30 days')
+		AND ` + vendasContabilizadas + `
+		GROUP BY p.id, p.nome
+		ORDER BY receita - custo DESC
+	`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao obter margem por produto"})
+		return
+	}
+
+	// Margem por vendedor (últimos 30 dias)
+	margemPorVendedor, err := h.buscarMargens(`
+		SELECT
+			COALESCE(v.vendedor_id, '') as id,
+			COALESCE(u.nome, '') as nome,
+			'' as mes,
+			SUM(iv.quantidade - COALESCE(dv.quantidade, 0)) as quantidade,
+			SUM(iv.total - COALESCE(dv.valor, 0)) as receita,
+			SUM((iv.quantidade - COALESCE(dv.quantidade, 0)) * iv.custo_unitario) as custo
+		FROM itens_venda iv
+		JOIN vendas v ON iv.venda_id = v.id
+		-- Vendas antigas podem não ter vendedor registrado
+		LEFT JOIN usuarios u ON v.vendedor_id = u.id
+		LEFT JOIN (` + devolvidoPorItem + `) dv ON dv.item_venda_id = iv.id
+		WHERE v.data_venda >= datetime('now', '-30 days')
+		AND ` + vendasContabilizadas + `
+		GROUP BY COALESCE(v.vendedor_id, ''), u.nome
+		ORDER BY receita - custo DESC
+	`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao obter margem por vendedor"})
+		return
+	}
+
+	// Margem por mês (últimos 12 meses); as devoluções saem da receita e do custo no mês
+	// em que ocorreram, como nas vendas por mês
+	margemPorMes, err := h.buscarMargens(`
+		SELECT
+			'' as id,
+			'' as nome,
+			mes,
+			SUM(quantidade) as quantidade,
+			SUM(receita) as receita,
+			SUM(custo) as custo
+		FROM (
+			SELECT strftime('%Y-%m', v.data_venda) as mes, iv.quantidade as quantidade, iv.total as receita,
+				iv.quantidade * iv.custo_unitario as custo
+			FROM itens_venda iv
+			JOIN vendas v ON v.id = iv.venda_id
+			WHERE v.data_venda >= datetime('now', '-12 months')
+			AND ` + vendasContabilizadas + `
+			UNION ALL
+			SELECT strftime('%Y-%m', d.data_devolucao), -idv.quantidade, -idv.valor_reembolso,
+				-idv.quantidade * iv.custo_unitario
+			FROM itens_devolucao idv
+			JOIN devolucoes d ON d.id = idv.devolucao_id
+			JOIN itens_venda iv ON iv.id = idv.item_venda_id
+			JOIN vendas v ON v.id = d.venda_id
+			WHERE d.data_devolucao >= datetime('now', '-12 months')
+			AND ` + vendasContabilizadas + `
+		)
+		GROUP BY mes
+		ORDER BY mes DESC
+	`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao obter margem por mês"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{
 		"vendas_dia":             vendasDia,
+		"devolucoes_dia":         devolucoesDia,
 		"total_clientes":         totalClientes,
 		"total_produtos":         totalProdutos,
 		"vendas_por_mes":         vendasPorMes,
 		"produtos_mais_vendidos": produtosMaisVendidos,
 		"vendas_por_vendedor":    vendasPorVendedor,
 		"produtos_estoque_baixo": produtosEstoqueBaixo,
+		"pagamentos_por_forma":   pagamentosPorForma,
+		"margem_por_produto":     margemPorProduto,
+		"margem_por_vendedor":    margemPorVendedor,
+		"margem_por_mes":         margemPorMes,
+	})
+}
+
+// buscarMargens executa uma consulta que retorna id, nome, mês, quantidade, receita e custo
+// e calcula a margem de cada linha
+func (h *RelatorioHandler) buscarMargens(query string) ([]Margem, error) {
+	rows, err := h.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	margens := []Margem{}
+	for rows.Next() {
+		var m Margem
+		if err := rows.Scan(&m.ID, &m.Nome, &m.Mes, &m.Quantidade, &m.Receita, &m.Custo); err != nil {
+			return nil, err
+		}
+		m.calcular()
+		margens = append(margens, m)
+	}
+	return margens, rows.Err()
+}
+
+// faixasAging define as faixas de atraso do relatório de contas a receber
+var faixasAging = []string{"a_vencer", "1_30", "31_60", "61_90", "acima_90"}
+
+// faixaAtrasoParcela classifica as parcelas em aberto pelos dias de atraso até hoje
+const faixaAtrasoParcela = `
+	CASE
+		WHEN julianday(date('now')) - julianday(date(p.data_vencimento)) <= 0 THEN 'a_vencer'
+		WHEN julianday(date('now')) - julianday(date(p.data_vencimento)) <= 30 THEN '1_30'
+		WHEN julianday(date('now')) - julianday(date(p.data_vencimento)) <= 60 THEN '31_60'
+		WHEN julianday(date('now')) - julianday(date(p.data_vencimento)) <= 90 THEN '61_90'
+		ELSE 'acima_90'
+	END
+`
+
+// @Summary Obtém o aging das contas a receber
+// @Description Agrupa as parcelas em aberto por faixa de atraso (a vencer, 1-30, 31-60,
+// @Description 61-90 e acima de 90 dias), no total e por cliente
+// @Tags relatorios
+// @Accept json
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /relatorios/aging [get]
+func (h *RelatorioHandler) GetAgingRecebiveis(c *gin.Context) {
+	rows, err := h.db.Query(`
+		SELECT
+			p.cliente_id,
+			COALESCE(c.nome, '') as cliente_nome,
+			` + faixaAtrasoParcela + ` as faixa,
+			COUNT(*) as quantidade,
+			SUM(p.valor) as total
+		FROM parcelas p
+		LEFT JOIN clientes c ON c.id = p.cliente_id
+		WHERE p.status = 'aberta'
+		GROUP BY p.cliente_id, cliente_nome, faixa
+		ORDER BY cliente_nome
+	`)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Erro ao obter contas a receber"})
+		return
+	}
+	defer rows.Close()
+
+	type FaixaAging struct {
+		Faixa      string  `json:"faixa"`
+		Quantidade int     `json:"quantidade"`
+		Total      float64 `json:"total"`
+	}
+
+	type ClienteAging struct {
+		ID      string             `json:"id"`
+		Nome    string             `json:"nome"`
+		Faixas  map[string]float64 `json:"faixas"`
+		Vencido float64            `json:"vencido"`
+		Total   float64            `json:"total"`
+	}
+
+	faixas := make(map[string]*FaixaAging, len(faixasAging))
+	for _, faixa := range faixasAging {
+		faixas[faixa] = &FaixaAging{Faixa: faixa}
+	}
+
+	var porCliente []*ClienteAging
+	clientes := make(map[string]*ClienteAging)
+	var totalAberto, totalVencido float64
+	for rows.Next() {
+		var clienteID, clienteNome, faixa string
+		var quantidade int
+		var total float64
+		if err := rows.Scan(&clienteID, &clienteNome, &faixa, &quantidade, &total); err != nil {
+			continue
+		}
+
+		faixas[faixa].Quantidade += quantidade
+		faixas[faixa].Total += total
+
+		cliente, ok := clientes[clienteID]
+		if !ok {
+			cliente = &ClienteAging{ID: clienteID, Nome: clienteNome, Faixas: make(map[string]float64)}
+			clientes[clienteID] = cliente
+			porCliente = append(porCliente, cliente)
+		}
+		cliente.Faixas[faixa] += total
+		cliente.Total += total
+
+		totalAberto += total
+		if faixa != "a_vencer" {
+			cliente.Vencido += total
+			totalVencido += total
+		}
+	}
+
+	resumo := make([]FaixaAging, len(faixasAging))
+	for i, faixa := range faixasAging {
+		resumo[i] = *faixas[faixa]
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"total_aberto":  totalAberto,
+		"total_vencido": totalVencido,
+		"faixas":        resumo,
+		"por_cliente":   porCliente,
 	})
 }
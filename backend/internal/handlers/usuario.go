@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -10,18 +11,17 @@ import (
 
 	"vendas/internal/domain"
 	"vendas/internal/dto"
-	"vendas/internal/utils"
 )
 
 type UsuarioHandler struct {
 	usuarioService domain.UsuarioService
-	jwtSecretKey   string
+	sessaoService  domain.SessaoService
 }
 
-func NewUsuarioHandler(service domain.UsuarioService, jwtSecretKey string) *UsuarioHandler {
+func NewUsuarioHandler(service domain.UsuarioService, sessaoService domain.SessaoService) *UsuarioHandler {
 	return &UsuarioHandler{
 		usuarioService: service,
-		jwtSecretKey:   jwtSecretKey,
+		sessaoService:  sessaoService,
 	}
 }
 
@@ -77,20 +77,56 @@ func (h *UsuarioHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// Gera o token JWT
-	token, err := utils.GenerateToken(usuario.ID, string(usuario.Role), h.jwtSecretKey)
+	// Abre a sessão e gera o access token e o refresh token
+	tokens, err := h.sessaoService.Iniciar(usuario)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "erro ao gerar token"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "login realizado com sucesso",
-		"token":   token,
-		"usuario": usuario,
+		"message":       "login realizado com sucesso",
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expira_em":     tokens.ExpiraEm,
+		"usuario":       usuario,
 	})
 }
 
+// RenovarToken troca o refresh token por um novo par de tokens. O refresh token usado deixa
+// de valer; reapresentá-lo encerra a sessão.
+func (h *UsuarioHandler) RenovarToken(c *gin.Context) {
+	var dto dto.RefreshTokenDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, usuario, err := h.sessaoService.Renovar(dto.RefreshToken)
+	if err != nil {
+		c.JSON(statusErroSessao(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":         tokens.AccessToken,
+		"refresh_token": tokens.RefreshToken,
+		"expira_em":     tokens.ExpiraEm,
+		"usuario":       usuario,
+	})
+}
+
+// Logout encerra a sessão do token usado na requisição, revogando todos os refresh tokens
+// dela e os access tokens já emitidos
+func (h *UsuarioHandler) Logout(c *gin.Context) {
+	if err := h.sessaoService.Encerrar(c.GetString("usuario_id"), c.GetString("sessao_id")); err != nil {
+		c.JSON(statusErroSessao(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logout realizado com sucesso"})
+}
+
 func (h *UsuarioHandler) GetUsuario(c *gin.Context) {
 	id := c.Param("id")
 	usuario, err := h.usuarioService.GetUsuario(id)
@@ -133,6 +169,7 @@ func (h *UsuarioHandler) UpdateUsuario(c *gin.Context) {
 	if dto.Email != "" {
 		usuario.Email = dto.Email
 	}
+	encerrarSessoes := false
 	if dto.Senha != "" {
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(dto.Senha), bcrypt.DefaultCost)
 		if err != nil {
@@ -140,12 +177,14 @@ func (h *UsuarioHandler) UpdateUsuario(c *gin.Context) {
 			return
 		}
 		usuario.Senha = string(hashedPassword)
+		encerrarSessoes = true
 	}
 	if dto.Role != "" {
 		usuario.Role = dto.Role
 	}
 	if dto.Ativo != nil {
 		usuario.Ativo = *dto.Ativo
+		encerrarSessoes = encerrarSessoes || !usuario.Ativo
 	}
 
 	if err := h.usuarioService.UpdateUsuario(usuario); err != nil {
@@ -153,6 +192,14 @@ func (h *UsuarioHandler) UpdateUsuario(c *gin.Context) {
 		return
 	}
 
+	// A troca de senha e a desativação derrubam as sessões abertas do usuário
+	if encerrarSessoes {
+		if err := h.sessaoService.EncerrarTodas(usuario.ID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	c.JSON(http.StatusOK, usuario)
 }
 
@@ -162,6 +209,10 @@ func (h *UsuarioHandler) DeleteUsuario(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	if err := h.sessaoService.EncerrarTodas(id); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "usuário deletado com sucesso"})
 }
@@ -176,3 +227,11 @@ func (h *UsuarioHandler) GetUsuarioAtual(c *gin.Context) {
 
 	c.JSON(http.StatusOK, usuario)
 }
+
+// statusErroSessao responde com 401 aos refresh tokens e sessões que não valem mais
+func statusErroSessao(err error) int {
+	if errors.Is(err, domain.ErrSessaoInvalida) || errors.Is(err, domain.ErrRefreshTokenReutilizado) {
+		return http.StatusUnauthorized
+	}
+	return http.StatusInternalServerError
+}
@@ -1,20 +1,29 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-type Claims struct {
-	UserID string `json:"user_id"`
-	Role   string `json:"role"`
-	jwt.RegisteredClaims
+// HeaderLiberacaoCredito carrega o token de um administrador que autoriza a operação
+// a ultrapassar o limite de crédito do cliente
+const HeaderLiberacaoCredito = "X-Autorizacao-Credito"
+
+// VerificadorSessao confirma que a sessão de um access token continua aberta e devolve o
+// usuário com os dados atuais
+type VerificadorSessao interface {
+	Verificar(usuarioID, sessaoID string) (*domain.Usuario, error)
 }
 
-func AuthMiddleware(secretKey string) gin.HandlerFunc {
+// AuthMiddleware aceita apenas access tokens válidos de sessões abertas, de usuários que
+// continuam ativos. O papel registrado no contexto é o atual do usuário, de modo que
+// desativar o cadastro ou trocar o papel vale já na próxima requisição.
+func AuthMiddleware(secretKey string, sessoes VerificadorSessao) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -25,32 +34,64 @@ func AuthMiddleware(secretKey string) gin.HandlerFunc {
 
 		// Remove o prefixo "Bearer " do token
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(secretKey), nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := utils.ValidateToken(tokenString, secretKey)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "token inválido"})
 			c.Abort()
 			return
 		}
 
-		claims, ok := token.Claims.(*Claims)
-		if !ok {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "token inválido"})
+		usuario, err := sessoes.Verificar(claims.UserID, claims.SessaoID)
+		if errors.Is(err, domain.ErrSessaoInvalida) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+			c.Abort()
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			c.Abort()
 			return
 		}
 
 		// Adiciona as informações do usuário ao contexto
-		c.Set("usuario_id", claims.UserID)
-		c.Set("role", claims.Role)
+		c.Set("usuario_id", usuario.ID)
+		c.Set("role", string(usuario.Role))
+		c.Set("sessao_id", claims.SessaoID)
 
 		c.Next()
 	}
 }
 
-func RequireRole(roles ...string) gin.HandlerFunc {
+// LiberacaoCredito valida o token enviado em X-Autorizacao-Credito, que deve pertencer a um
+// administrador com sessão aberta, e registra no contexto quem liberou o limite de crédito.
+// Requisições sem o header seguem sem liberação.
+func LiberacaoCredito(secretKey string, sessoes VerificadorSessao) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString := strings.TrimPrefix(c.GetHeader(HeaderLiberacaoCredito), "Bearer ")
+		if tokenString == "" {
+			c.Next()
+			return
+		}
+
+		claims, err := utils.ValidateToken(tokenString, secretKey)
+		var usuario *domain.Usuario
+		if err == nil {
+			usuario, err = sessoes.Verificar(claims.UserID, claims.SessaoID)
+		}
+		if err != nil || !usuario.Role.TemPermissao(domain.PermCreditoGerenciar) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "autorização de crédito inválida: é necessário o token de um administrador"})
+			c.Abort()
+			return
+		}
+
+		c.Set("liberacao_credito", usuario.ID)
+		c.Next()
+	}
+}
+
+// RequirePermission libera a rota apenas para os papéis que concedem a permissão na matriz
+// de permissões em uso, consultada a cada requisição
+func RequirePermission(permissao domain.Permissao) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole := c.GetString("role")
 		if userRole == "" {
@@ -59,15 +100,7 @@ func RequireRole(roles ...string) gin.HandlerFunc {
 			return
 		}
 
-		hasRole := false
-		for _, role := range roles {
-			if role == userRole {
-				hasRole = true
-				break
-			}
-		}
-
-		if !hasRole {
+		if !domain.Role(userRole).TemPermissao(permissao) {
 			c.JSON(http.StatusForbidden, gin.H{"error": "acesso negado"})
 			c.Abort()
 			return
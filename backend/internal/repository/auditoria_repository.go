@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"database/sql"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type AuditoriaRepository interface {
+	Registrar(registro *domain.RegistroAuditoria) error
+	Listar(filtro domain.FiltroAuditoria) ([]domain.RegistroAuditoria, error)
+}
+
+type AuditoriaRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewAuditoriaRepository(db *sql.DB) *AuditoriaRepositoryImpl {
+	return &AuditoriaRepositoryImpl{db: db}
+}
+
+func (r *AuditoriaRepositoryImpl) Registrar(registro *domain.RegistroAuditoria) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := registrarAuditoria(tx, registro); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Listar retorna os registros da trilha de auditoria, do mais recente para o mais antigo
+func (r *AuditoriaRepositoryImpl) Listar(filtro domain.FiltroAuditoria) ([]domain.RegistroAuditoria, error) {
+	query := `SELECT id, acao, entidade, entidade_id, usuario_id, detalhes, data
+		FROM auditoria
+		WHERE (? = '' OR entidade = ?) AND (? = '' OR entidade_id = ?) AND (? = '' OR acao = ?)
+		ORDER BY data DESC`
+	rows, err := r.db.Query(query, filtro.Entidade, filtro.Entidade, filtro.EntidadeID, filtro.EntidadeID,
+		filtro.Acao, filtro.Acao)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	registros := []domain.RegistroAuditoria{}
+	for rows.Next() {
+		var registro domain.RegistroAuditoria
+		var usuarioID sql.NullString
+		err := rows.Scan(&registro.ID, &registro.Acao, &registro.Entidade, &registro.EntidadeID, &usuarioID,
+			&registro.Detalhes, &registro.Data)
+		if err != nil {
+			return nil, err
+		}
+		registro.UsuarioID = usuarioID.String
+		registros = append(registros, registro)
+	}
+	return registros, rows.Err()
+}
+
+// registrarAuditoria grava o registro na transação da operação auditada, de modo que a
+// operação e o seu registro sejam gravados juntos
+func registrarAuditoria(tx *sql.Tx, registro *domain.RegistroAuditoria) error {
+	registro.ID = utils.GenerateUUID()
+	_, err := tx.Exec(`INSERT INTO auditoria (id, acao, entidade, entidade_id, usuario_id, detalhes, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		registro.ID, registro.Acao, registro.Entidade, registro.EntidadeID, referencia(registro.UsuarioID),
+		registro.Detalhes, registro.Data)
+	return err
+}
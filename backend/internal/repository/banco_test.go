@@ -0,0 +1,31 @@
+package repository
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"vendas/internal/database"
+)
+
+// bancoDeTeste cria o banco com o schema completo em um diretório temporário. InitDB
+// abre o vendas.db do diretório atual, por isso o teste muda de diretório enquanto roda.
+func bancoDeTeste(t *testing.T) *sql.DB {
+	t.Helper()
+
+	original, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(original) })
+
+	if err := database.InitDB(); err != nil {
+		t.Fatalf("erro ao criar o banco de teste: %v", err)
+	}
+	db := database.DB
+	database.DB = nil
+	t.Cleanup(func() { db.Close() })
+	return db
+}
@@ -2,166 +2,378 @@ package repository
 
 import (
 	"database/sql"
-	"errors"
+	"math"
+	"time"
 	"vendas/internal/domain"
+	"vendas/internal/utils"
 )
 
-type ClienteRepository struct {
+type ClienteRepository interface {
+	Create(cliente *domain.Cliente) error
+	GetByID(id string) (*domain.Cliente, error)
+	GetByCPF(cpf string) (*domain.Cliente, error)
+	GetByCNPJ(cnpj string) (*domain.Cliente, error)
+	GetByUsuario(usuarioID string) (*domain.Cliente, error)
+	GetAll() ([]domain.Cliente, error)
+	Update(cliente *domain.Cliente) error
+	Delete(id string) error
+	GetSaldoAberto(clienteID, exceto string) (float64, error)
+	GetResumoCompras(clienteID string) (*domain.ResumoCliente, error)
+	GetProdutosFavoritos(clienteID string, limite int) ([]domain.ProdutoFavorito, error)
+	GetMetricasRFM(agora time.Time) ([]domain.MetricasRFM, error)
+	SalvarSegmentos(segmentos []domain.SegmentoCliente) error
+	GetSegmento(clienteID string) (*domain.SegmentoCliente, error)
+	GetSegmentos(segmento domain.SegmentoRFM) ([]domain.SegmentoCliente, error)
+}
+
+type ClienteRepositoryImpl struct {
 	db *sql.DB
 }
 
-func NewClienteRepository(db *sql.DB) *ClienteRepository {
-	return &ClienteRepository{db: db}
-}
-
-func (r *ClienteRepository) Create(cliente *domain.Cliente) error {
-	query := `
-        INSERT INTO clientes (id, nome, email, telefone, endereco, cpf, usuario_id, data_criacao)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-    `
-	_, err := r.db.Exec(query,
-		cliente.ID,
-		cliente.Nome,
-		cliente.Email,
-		cliente.Telefone,
-		cliente.Endereco,
-		cliente.CPF,
-		cliente.UsuarioID,
-		cliente.DataCriacao,
-	)
+func NewClienteRepository(db *sql.DB) *ClienteRepositoryImpl {
+	return &ClienteRepositoryImpl{db: db}
+}
+
+const selectClientes = `SELECT id, nome, email, telefone, endereco, cpf, usuario_id, data_criacao, tipo_pessoa, cnpj,
+		inscricao_estadual, isento_ie, limite_credito, data_anonimizacao
+	FROM clientes`
+
+func (r *ClienteRepositoryImpl) Create(cliente *domain.Cliente) error {
+	// Gera UUID para o cliente
+	cliente.ID = utils.GenerateUUID()
+
+	query := `INSERT INTO clientes (id, nome, email, telefone, endereco, cpf, usuario_id, data_criacao, tipo_pessoa, cnpj,
+			inscricao_estadual, isento_ie, limite_credito)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, cliente.ID, cliente.Nome, cliente.Email, cliente.Telefone, cliente.Endereco, cliente.CPF,
+		referencia(cliente.UsuarioID), cliente.DataCriacao, cliente.TipoPessoa, cliente.CNPJ, cliente.InscricaoEstadual,
+		cliente.IsentoIE, cliente.LimiteCredito)
 	return err
 }
 
-func (r *ClienteRepository) GetByID(id string) (*domain.Cliente, error) {
-	var cliente domain.Cliente
-	query := `
-        SELECT id, nome, email, telefone, endereco, cpf, usuario_id, data_criacao
-        FROM clientes
-        WHERE id = $1
-    `
-	err := r.db.QueryRow(query, id).Scan(
-		&cliente.ID,
-		&cliente.Nome,
-		&cliente.Email,
-		&cliente.Telefone,
-		&cliente.Endereco,
-		&cliente.CPF,
-		&cliente.UsuarioID,
-		&cliente.DataCriacao,
-	)
-	if err == sql.ErrNoRows {
-		return nil, errors.New("cliente não encontrado")
-	}
+func (r *ClienteRepositoryImpl) GetByID(id string) (*domain.Cliente, error) {
+	return buscarCliente(r.db, selectClientes+` WHERE id = ?`, id)
+}
+
+func (r *ClienteRepositoryImpl) GetByCPF(cpf string) (*domain.Cliente, error) {
+	return buscarCliente(r.db, selectClientes+` WHERE cpf = ?`, cpf)
+}
+
+func (r *ClienteRepositoryImpl) GetByCNPJ(cnpj string) (*domain.Cliente, error) {
+	return buscarCliente(r.db, selectClientes+` WHERE cnpj = ?`, cnpj)
+}
+
+func (r *ClienteRepositoryImpl) GetByUsuario(usuarioID string) (*domain.Cliente, error) {
+	return buscarCliente(r.db, selectClientes+` WHERE usuario_id = ?`, usuarioID)
+}
+
+func (r *ClienteRepositoryImpl) GetAll() ([]domain.Cliente, error) {
+	rows, err := r.db.Query(selectClientes + ` ORDER BY nome`)
 	if err != nil {
 		return nil, err
 	}
-	return &cliente, nil
+	defer rows.Close()
+
+	var clientes []domain.Cliente
+	for rows.Next() {
+		cliente, err := lerCliente(rows)
+		if err != nil {
+			return nil, err
+		}
+		clientes = append(clientes, *cliente)
+	}
+	return clientes, rows.Err()
 }
 
-func (r *ClienteRepository) GetByCPF(cpf string) (*domain.Cliente, error) {
-	var cliente domain.Cliente
-	query := `
-        SELECT id, nome, email, telefone, endereco, cpf, usuario_id, data_criacao
-        FROM clientes
-        WHERE cpf = $1
-    `
-	err := r.db.QueryRow(query, cpf).Scan(
-		&cliente.ID,
-		&cliente.Nome,
-		&cliente.Email,
-		&cliente.Telefone,
-		&cliente.Endereco,
-		&cliente.CPF,
-		&cliente.UsuarioID,
-		&cliente.DataCriacao,
-	)
-	if err == sql.ErrNoRows {
-		return nil, errors.New("cliente não encontrado")
+func (r *ClienteRepositoryImpl) Update(cliente *domain.Cliente) error {
+	query := `UPDATE clientes SET nome = ?, email = ?, telefone = ?, endereco = ?, cpf = ?, usuario_id = ?, tipo_pessoa = ?,
+			cnpj = ?, inscricao_estadual = ?, isento_ie = ?, limite_credito = ?
+		WHERE id = ?`
+	result, err := r.db.Exec(query, cliente.Nome, cliente.Email, cliente.Telefone, cliente.Endereco, cliente.CPF,
+		referencia(cliente.UsuarioID), cliente.TipoPessoa, cliente.CNPJ, cliente.InscricaoEstadual, cliente.IsentoIE,
+		cliente.LimiteCredito, cliente.ID)
+	if err != nil {
+		return err
+	}
+	return verificarAlteracao(result)
+}
+
+// Delete remove o cliente, os seus endereços e os orçamentos. Clientes com vendas são mantidos porque
+// continuam referenciados pelas vendas e parcelas.
+func (r *ClienteRepositoryImpl) Delete(id string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var vendas int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM vendas WHERE cliente_id = ?`, id).Scan(&vendas); err != nil {
+		return err
+	}
+	if vendas > 0 {
+		return domain.ErrClienteComVendas
+	}
+
+	if _, err := tx.Exec(`DELETE FROM enderecos WHERE cliente_id = ?`, id); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM segmentos_clientes WHERE cliente_id = ?`, id); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM itens_orcamento WHERE orcamento_id IN (SELECT id FROM orcamentos WHERE cliente_id = ?)`, id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`DELETE FROM reservas_estoque WHERE orcamento_id IN (SELECT id FROM orcamentos WHERE cliente_id = ?)`, id)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM orcamentos WHERE cliente_id = ?`, id); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`DELETE FROM clientes WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if err := verificarAlteracao(result); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetSaldoAberto soma o que falta receber das vendas confirmadas do cliente, ignorando a
+// venda informada em exceto. Vendas a prazo contam as parcelas em aberto, das quais as
+// devoluções já foram abatidas; as demais contam o total menos os pagamentos e as devoluções.
+func (r *ClienteRepositoryImpl) GetSaldoAberto(clienteID, exceto string) (float64, error) {
+	query := `SELECT COALESCE(SUM(
+			CASE WHEN EXISTS (SELECT 1 FROM parcelas p WHERE p.venda_id = v.id)
+				THEN (SELECT COALESCE(SUM(p.valor), 0) FROM parcelas p WHERE p.venda_id = v.id AND p.status = ?)
+				ELSE MAX(v.valor_total
+					- COALESCE((SELECT SUM(d.valor_reembolso) FROM devolucoes d WHERE d.venda_id = v.id), 0)
+					- COALESCE((SELECT SUM(pg.valor) FROM pagamentos pg WHERE pg.venda_id = v.id), 0), 0)
+			END), 0)
+		FROM vendas v
+		WHERE v.cliente_id = ? AND v.status = ? AND v.id <> ?`
+	var saldo float64
+	err := r.db.QueryRow(query, domain.ParcelaAberta, clienteID, domain.StatusConfirmada, exceto).Scan(&saldo)
+	return saldo, err
+}
+
+// vendasDoHistorico filtra as vendas que compõem o histórico de compras do cliente: as
+// efetivadas, inclusive as devolvidas, cujos reembolsos são descontados dos valores
+const vendasDoHistorico = `v.status IN (?, ?, ?)`
+
+// reembolsoDaVenda soma o valor reembolsado nas devoluções da venda v
+const reembolsoDaVenda = `COALESCE((SELECT SUM(d.valor_reembolso) FROM devolucoes d WHERE d.venda_id = v.id), 0)`
+
+// GetResumoCompras soma as vendas efetivadas do cliente, descontando os reembolsos, e
+// informa as datas da primeira e da última compra
+func (r *ClienteRepositoryImpl) GetResumoCompras(clienteID string) (*domain.ResumoCliente, error) {
+	query := `SELECT COUNT(v.id), COALESCE(SUM(v.valor_total - ` + reembolsoDaVenda + `), 0),
+			MIN(julianday(v.data_venda)), MAX(julianday(v.data_venda))
+		FROM vendas v
+		WHERE v.cliente_id = ? AND ` + vendasDoHistorico
+	resumo := &domain.ResumoCliente{ClienteID: clienteID}
+	var primeira, ultima sql.NullFloat64
+	err := r.db.QueryRow(query, clienteID, domain.StatusConfirmada, domain.StatusPaga, domain.StatusDevolvida).
+		Scan(&resumo.TotalVendas, &resumo.ValorTotal, &primeira, &ultima)
+	if err != nil {
+		return nil, err
 	}
+	resumo.PrimeiraCompra = dataJuliana(primeira)
+	resumo.UltimaCompra = dataJuliana(ultima)
+	return resumo, nil
+}
+
+// GetProdutosFavoritos retorna os produtos que o cliente mais comprou, pela quantidade
+// líquida de devoluções e, no empate, pelo valor gasto
+func (r *ClienteRepositoryImpl) GetProdutosFavoritos(clienteID string, limite int) ([]domain.ProdutoFavorito, error) {
+	query := `SELECT iv.produto_id, COALESCE(p.nome, ''),
+			SUM(iv.quantidade - COALESCE(dv.quantidade, 0)) AS quantidade_liquida,
+			SUM(iv.total - COALESCE(dv.valor, 0)) AS valor_liquido
+		FROM itens_venda iv
+		JOIN vendas v ON v.id = iv.venda_id
+		LEFT JOIN produtos p ON p.id = iv.produto_id
+		LEFT JOIN (
+			SELECT item_venda_id, SUM(quantidade) AS quantidade, SUM(valor_reembolso) AS valor
+			FROM itens_devolucao
+			GROUP BY item_venda_id
+		) dv ON dv.item_venda_id = iv.id
+		WHERE v.cliente_id = ? AND iv.produto_id <> '' AND ` + vendasDoHistorico + `
+		GROUP BY iv.produto_id
+		HAVING quantidade_liquida > 0
+		ORDER BY quantidade_liquida DESC, valor_liquido DESC
+		LIMIT ?`
+	rows, err := r.db.Query(query, clienteID, domain.StatusConfirmada, domain.StatusPaga, domain.StatusDevolvida, limite)
 	if err != nil {
 		return nil, err
 	}
-	return &cliente, nil
+	defer rows.Close()
+
+	favoritos := []domain.ProdutoFavorito{}
+	for rows.Next() {
+		var favorito domain.ProdutoFavorito
+		if err := rows.Scan(&favorito.ProdutoID, &favorito.Nome, &favorito.Quantidade, &favorito.Valor); err != nil {
+			return nil, err
+		}
+		favoritos = append(favoritos, favorito)
+	}
+	return favoritos, rows.Err()
 }
 
-func (r *ClienteRepository) GetAll() ([]domain.Cliente, error) {
-	query := `
-        SELECT id, nome, email, telefone, endereco, cpf, usuario_id, data_criacao
-        FROM clientes
-    `
-	rows, err := r.db.Query(query)
+// GetMetricasRFM calcula a recência, a frequência e o valor das compras de todos os
+// clientes cadastrados, exceto os anonimizados. Clientes sem vendas efetivadas vêm com
+// frequência zero.
+func (r *ClienteRepositoryImpl) GetMetricasRFM(agora time.Time) ([]domain.MetricasRFM, error) {
+	query := `SELECT c.id, COUNT(v.id), COALESCE(SUM(v.valor_total - ` + reembolsoDaVenda + `), 0),
+			MAX(julianday(v.data_venda)), CAST(julianday(?) - MAX(julianday(v.data_venda)) AS INTEGER)
+		FROM clientes c
+		LEFT JOIN vendas v ON v.cliente_id = c.id AND ` + vendasDoHistorico + `
+		WHERE c.data_anonimizacao IS NULL
+		GROUP BY c.id`
+	rows, err := r.db.Query(query, agora, domain.StatusConfirmada, domain.StatusPaga, domain.StatusDevolvida)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var clientes []domain.Cliente
+	var metricas []domain.MetricasRFM
 	for rows.Next() {
-		var cliente domain.Cliente
-		err := rows.Scan(
-			&cliente.ID,
-			&cliente.Nome,
-			&cliente.Email,
-			&cliente.Telefone,
-			&cliente.Endereco,
-			&cliente.CPF,
-			&cliente.UsuarioID,
-			&cliente.DataCriacao,
-		)
-		if err != nil {
+		var m domain.MetricasRFM
+		var ultima sql.NullFloat64
+		var recencia sql.NullInt64
+		if err := rows.Scan(&m.ClienteID, &m.Frequencia, &m.Monetario, &ultima, &recencia); err != nil {
 			return nil, err
 		}
-		clientes = append(clientes, cliente)
-	}
-	return clientes, nil
-}
-
-func (r *ClienteRepository) Update(cliente *domain.Cliente) error {
-	query := `
-        UPDATE clientes
-        SET nome = $1, email = $2, telefone = $3, endereco = $4, cpf = $5, usuario_id = $6
-        WHERE id = $7
-    `
-	result, err := r.db.Exec(query,
-		cliente.Nome,
-		cliente.Email,
-		cliente.Telefone,
-		cliente.Endereco,
-		cliente.CPF,
-		cliente.UsuarioID,
-		cliente.ID,
-	)
+		m.UltimaCompra = dataJuliana(ultima)
+		m.Recencia = int(recencia.Int64)
+		metricas = append(metricas, m)
+	}
+	return metricas, rows.Err()
+}
+
+// SalvarSegmentos substitui o resultado da segmentação anterior pelo informado
+func (r *ClienteRepositoryImpl) SalvarSegmentos(segmentos []domain.SegmentoCliente) error {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM segmentos_clientes`); err != nil {
+		return err
+	}
 
-	rowsAffected, err := result.RowsAffected()
+	stmt, err := tx.Prepare(`INSERT INTO segmentos_clientes (cliente_id, segmento, recencia, frequencia, monetario,
+			ultima_compra, nota_r, nota_f, nota_m, data_calculo)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return err
 	}
+	defer stmt.Close()
 
-	if rowsAffected == 0 {
-		return errors.New("cliente não encontrado")
+	for _, s := range segmentos {
+		_, err := stmt.Exec(s.ClienteID, s.Segmento, s.Recencia, s.Frequencia, s.Monetario, s.UltimaCompra,
+			s.NotaR, s.NotaF, s.NotaM, s.DataCalculo)
+		if err != nil {
+			return err
+		}
 	}
+	return tx.Commit()
+}
+
+const selectSegmentos = `SELECT s.cliente_id, c.nome, c.email, c.telefone, s.segmento, s.recencia, s.frequencia,
+		s.monetario, s.ultima_compra, s.nota_r, s.nota_f, s.nota_m, s.data_calculo
+	FROM segmentos_clientes s
+	JOIN clientes c ON c.id = s.cliente_id`
 
-	return nil
+// GetSegmento retorna o segmento do cliente na última segmentação
+func (r *ClienteRepositoryImpl) GetSegmento(clienteID string) (*domain.SegmentoCliente, error) {
+	segmentos, err := r.buscarSegmentos(selectSegmentos+` WHERE s.cliente_id = ?`, clienteID)
+	if err != nil {
+		return nil, err
+	}
+	if len(segmentos) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &segmentos[0], nil
+}
+
+// GetSegmentos lista os clientes da última segmentação, dos mais valiosos para os menos;
+// com o segmento vazio, lista todos
+func (r *ClienteRepositoryImpl) GetSegmentos(segmento domain.SegmentoRFM) ([]domain.SegmentoCliente, error) {
+	query := selectSegmentos + ` WHERE ? = '' OR s.segmento = ?
+		ORDER BY s.nota_r + s.nota_f + s.nota_m DESC, s.monetario DESC, c.nome`
+	return r.buscarSegmentos(query, segmento, segmento)
 }
 
-func (r *ClienteRepository) Delete(id string) error {
-	query := `DELETE FROM clientes WHERE id = $1`
-	result, err := r.db.Exec(query, id)
+func (r *ClienteRepositoryImpl) buscarSegmentos(query string, args ...interface{}) ([]domain.SegmentoCliente, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	rowsAffected, err := result.RowsAffected()
+	segmentos := []domain.SegmentoCliente{}
+	for rows.Next() {
+		var s domain.SegmentoCliente
+		var ultima sql.NullTime
+		err := rows.Scan(&s.ClienteID, &s.Nome, &s.Email, &s.Telefone, &s.Segmento, &s.Recencia, &s.Frequencia,
+			&s.Monetario, &ultima, &s.NotaR, &s.NotaF, &s.NotaM, &s.DataCalculo)
+		if err != nil {
+			return nil, err
+		}
+		if ultima.Valid {
+			s.UltimaCompra = &ultima.Time
+		}
+		segmentos = append(segmentos, s)
+	}
+	return segmentos, rows.Err()
+}
+
+// dataJuliana converte um dia juliano calculado pelo SQLite em data, ou nil quando nulo.
+// As agregações sobre datas são feitas com julianday porque o driver entrega o resultado
+// de MIN e MAX como texto.
+func dataJuliana(dia sql.NullFloat64) *time.Time {
+	if !dia.Valid {
+		return nil
+	}
+	data := time.UnixMilli(int64(math.Round((dia.Float64 - 2440587.5) * 86400000))).UTC()
+	return &data
+}
+
+func buscarCliente(db *sql.DB, query string, args ...interface{}) (*domain.Cliente, error) {
+	rows, err := db.Query(query, args...)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer rows.Close()
 
-	if rowsAffected == 0 {
-		return errors.New("cliente não encontrado")
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return nil, err
+		}
+		return nil, sql.ErrNoRows
 	}
+	return lerCliente(rows)
+}
 
-	return nil
+func lerCliente(rows *sql.Rows) (*domain.Cliente, error) {
+	var cliente domain.Cliente
+	var usuarioID sql.NullString
+	var anonimizacao sql.NullTime
+	err := rows.Scan(&cliente.ID, &cliente.Nome, &cliente.Email, &cliente.Telefone, &cliente.Endereco, &cliente.CPF,
+		&usuarioID, &cliente.DataCriacao, &cliente.TipoPessoa, &cliente.CNPJ, &cliente.InscricaoEstadual, &cliente.IsentoIE,
+		&cliente.LimiteCredito, &anonimizacao)
+	if err != nil {
+		return nil, err
+	}
+	cliente.UsuarioID = usuarioID.String
+	if anonimizacao.Valid {
+		cliente.DataAnonimizacao = &anonimizacao.Time
+	}
+	cliente.DocumentoPendente = cliente.DataAnonimizacao == nil && cliente.SemDocumento()
+	return &cliente, nil
 }
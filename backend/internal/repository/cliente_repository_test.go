@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"testing"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+// O banco recusa vincular o mesmo usuário de acesso a dois clientes
+func TestUsuarioVinculadoAUmCliente(t *testing.T) {
+	db := bancoDeTeste(t)
+	usuario := &domain.Usuario{ID: utils.GenerateUUID(), Nome: "Ana", Email: "ana@cliente.com", Role: domain.RoleCliente,
+		Ativo: true, DataCriacao: time.Now()}
+	if err := NewUsuarioRepository(db).Create(usuario); err != nil {
+		t.Fatal(err)
+	}
+
+	clientes := NewClienteRepository(db)
+	primeiro := &domain.Cliente{Nome: "Ana", UsuarioID: usuario.ID, TipoPessoa: domain.PessoaFisica, DataCriacao: time.Now()}
+	if err := clientes.Create(primeiro); err != nil {
+		t.Fatal(err)
+	}
+	segundo := &domain.Cliente{Nome: "Ana Souza", UsuarioID: usuario.ID, TipoPessoa: domain.PessoaFisica, DataCriacao: time.Now()}
+	if err := clientes.Create(segundo); err == nil {
+		t.Error("o segundo cliente foi gravado com o usuário já vinculado ao primeiro")
+	}
+
+	// Clientes sem usuário de acesso não entram no índice
+	for _, nome := range []string{"Bruno", "Carla"} {
+		if err := clientes.Create(&domain.Cliente{Nome: nome, TipoPessoa: domain.PessoaFisica, DataCriacao: time.Now()}); err != nil {
+			t.Fatalf("cliente %s sem usuário: %v", nome, err)
+		}
+	}
+}
@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+type ComissaoRepository interface {
+	CreateRegra(regra *domain.RegraComissao) error
+	GetRegra(id string) (*domain.RegraComissao, error)
+	GetRegras() ([]domain.RegraComissao, error)
+	UpdateRegra(regra *domain.RegraComissao) error
+	DeleteRegra(id string) error
+	GetBases(vendedorID string, inicio, fim time.Time) ([]domain.BaseComissao, error)
+	GetComissionado(vendedorID, antesDe string) ([]domain.ItemComissao, error)
+	GetExtratos(vendedorID, mes string) ([]domain.ExtratoComissao, error)
+	GetExtrato(vendedorID, mes string) (*domain.ExtratoComissao, error)
+	Fechar(extrato *domain.ExtratoComissao) error
+	Aprovar(id, usuarioID string, quando time.Time) error
+}
+
+type ComissaoRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewComissaoRepository(db *sql.DB) *ComissaoRepositoryImpl {
+	return &ComissaoRepositoryImpl{db: db}
+}
+
+const selectRegrasComissao = `SELECT id, descricao, tipo, produto_id, categoria, percentual, meta_mensal, inicio, fim, ativa, data_criacao
+	FROM regras_comissao`
+
+// reembolsoPorItem soma, por item de venda, o valor já reembolsado em devoluções
+const reembolsoPorItem = `SELECT item_venda_id, SUM(valor_reembolso) AS valor FROM itens_devolucao GROUP BY item_venda_id`
+
+const selectExtratosComissao = `SELECT e.id, e.vendedor_id, COALESCE(u.nome, ''), e.mes, e.status, e.total_vendas, e.percentual_base,
+		e.valor_comissao, e.valor_ajustes, e.data_fechamento, e.fechado_por, e.data_aprovacao, e.aprovado_por
+	FROM extratos_comissao e
+	LEFT JOIN usuarios u ON u.id = e.vendedor_id`
+
+func (r *ComissaoRepositoryImpl) CreateRegra(regra *domain.RegraComissao) error {
+	regra.ID = utils.GenerateUUID()
+
+	query := `INSERT INTO regras_comissao (id, descricao, tipo, produto_id, categoria, percentual, meta_mensal, inicio, fim, ativa,
+			data_criacao)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, regra.ID, regra.Descricao, regra.Tipo, regra.ProdutoID, regra.Categoria, regra.Percentual,
+		regra.MetaMensal, regra.Inicio, regra.Fim, regra.Ativa, regra.DataCriacao)
+	return err
+}
+
+func (r *ComissaoRepositoryImpl) GetRegra(id string) (*domain.RegraComissao, error) {
+	regras, err := buscarRegrasComissao(r.db, selectRegrasComissao+` WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(regras) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &regras[0], nil
+}
+
+func (r *ComissaoRepositoryImpl) GetRegras() ([]domain.RegraComissao, error) {
+	return buscarRegrasComissao(r.db, selectRegrasComissao+` ORDER BY data_criacao`)
+}
+
+func (r *ComissaoRepositoryImpl) UpdateRegra(regra *domain.RegraComissao) error {
+	query := `UPDATE regras_comissao SET descricao = ?, tipo = ?, produto_id = ?, categoria = ?, percentual = ?, meta_mensal = ?,
+			inicio = ?, fim = ?, ativa = ?
+		WHERE id = ?`
+	result, err := r.db.Exec(query, regra.Descricao, regra.Tipo, regra.ProdutoID, regra.Categoria, regra.Percentual,
+		regra.MetaMensal, regra.Inicio, regra.Fim, regra.Ativa, regra.ID)
+	if err != nil {
+		return err
+	}
+	return verificarAlteracao(result)
+}
+
+func (r *ComissaoRepositoryImpl) DeleteRegra(id string) error {
+	result, err := r.db.Exec(`DELETE FROM regras_comissao WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return verificarAlteracao(result)
+}
+
+// GetBases retorna os itens das vendas confirmadas, pagas ou devolvidas registradas pelo
+// vendedor no período [inicio, fim), cada um com o seu total menos o valor já reembolsado
+// em devoluções. Vendas totalmente devolvidas ficam com base zero.
+func (r *ComissaoRepositoryImpl) GetBases(vendedorID string, inicio, fim time.Time) ([]domain.BaseComissao, error) {
+	query := `SELECT v.id, iv.id, iv.produto_id, COALESCE(p.categoria, ''), v.data_venda, iv.total - COALESCE(dv.valor, 0)
+		FROM itens_venda iv
+		JOIN vendas v ON v.id = iv.venda_id
+		LEFT JOIN produtos p ON p.id = iv.produto_id
+		LEFT JOIN (` + reembolsoPorItem + `) dv ON dv.item_venda_id = iv.id
+		WHERE v.vendedor_id = ? AND v.status IN (?, ?, ?)
+			AND julianday(v.data_venda) >= julianday(?) AND julianday(v.data_venda) < julianday(?)
+		ORDER BY julianday(v.data_venda), v.id, iv.id`
+	rows, err := r.db.Query(query, vendedorID, domain.StatusConfirmada, domain.StatusPaga, domain.StatusDevolvida, inicio, fim)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var bases []domain.BaseComissao
+	for rows.Next() {
+		var base domain.BaseComissao
+		err := rows.Scan(&base.VendaID, &base.ItemVendaID, &base.ProdutoID, &base.Categoria, &base.DataVenda, &base.Valor)
+		if err != nil {
+			return nil, err
+		}
+		bases = append(bases, base)
+	}
+	return bases, rows.Err()
+}
+
+// GetComissionado soma, por item de venda, a base e a comissão já lançadas nos extratos
+// fechados do vendedor anteriores ao mês informado, contando os ajustes. O percentual é
+// o da linha original do item.
+func (r *ComissaoRepositoryImpl) GetComissionado(vendedorID, antesDe string) ([]domain.ItemComissao, error) {
+	query := `SELECT i.item_venda_id, MAX(i.venda_id), MAX(i.produto_id), MIN(julianday(i.data_venda)), SUM(i.base), MAX(i.percentual),
+			SUM(i.valor)
+		FROM itens_extrato_comissao i
+		JOIN extratos_comissao e ON e.id = i.extrato_id
+		WHERE e.vendedor_id = ? AND e.mes < ?
+		GROUP BY i.item_venda_id`
+	rows, err := r.db.Query(query, vendedorID, antesDe)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var itens []domain.ItemComissao
+	for rows.Next() {
+		var item domain.ItemComissao
+		var dataVenda sql.NullFloat64
+		err := rows.Scan(&item.ItemVendaID, &item.VendaID, &item.ProdutoID, &dataVenda, &item.Base, &item.Percentual, &item.Valor)
+		if err != nil {
+			return nil, err
+		}
+		if data := dataJuliana(dataVenda); data != nil {
+			item.DataVenda = data.Local()
+		}
+		itens = append(itens, item)
+	}
+	return itens, rows.Err()
+}
+
+// GetExtratos lista os extratos fechados e aprovados, sem as linhas, filtrando pelo
+// vendedor e pelo mês quando informados
+func (r *ComissaoRepositoryImpl) GetExtratos(vendedorID, mes string) ([]domain.ExtratoComissao, error) {
+	query := selectExtratosComissao + ` WHERE (? = '' OR e.vendedor_id = ?) AND (? = '' OR e.mes = ?)
+		ORDER BY e.mes DESC, u.nome`
+	rows, err := r.db.Query(query, vendedorID, vendedorID, mes, mes)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var extratos []domain.ExtratoComissao
+	for rows.Next() {
+		extrato, err := scanExtratoComissao(rows)
+		if err != nil {
+			return nil, err
+		}
+		extratos = append(extratos, *extrato)
+	}
+	return extratos, rows.Err()
+}
+
+// GetExtrato retorna o extrato fechado do vendedor no mês com as suas linhas, ou
+// sql.ErrNoRows se o mês ainda não foi fechado
+func (r *ComissaoRepositoryImpl) GetExtrato(vendedorID, mes string) (*domain.ExtratoComissao, error) {
+	extrato, err := scanExtratoComissao(r.db.QueryRow(selectExtratosComissao+` WHERE e.vendedor_id = ? AND e.mes = ?`,
+		vendedorID, mes))
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.Query(`SELECT venda_id, item_venda_id, produto_id, data_venda, base, percentual, valor, ajuste
+		FROM itens_extrato_comissao
+		WHERE extrato_id = ?
+		ORDER BY ajuste, julianday(data_venda), venda_id, item_venda_id`, extrato.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item domain.ItemComissao
+		err := rows.Scan(&item.VendaID, &item.ItemVendaID, &item.ProdutoID, &item.DataVenda, &item.Base, &item.Percentual,
+			&item.Valor, &item.Ajuste)
+		if err != nil {
+			return nil, err
+		}
+		extrato.Itens = append(extrato.Itens, item)
+	}
+	return extrato, rows.Err()
+}
+
+// Fechar grava o extrato e as suas linhas. Um mês já fechado para o vendedor não é
+// gravado de novo: a restrição de unicidade rejeita o segundo fechamento, inclusive
+// quando dois fechamentos concorrentes passam pela verificação do serviço, e o erro
+// volta como domain.ErrFechamentoComissao.
+func (r *ComissaoRepositoryImpl) Fechar(extrato *domain.ExtratoComissao) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	extrato.ID = utils.GenerateUUID()
+	_, err = tx.Exec(`INSERT INTO extratos_comissao (id, vendedor_id, mes, status, total_vendas, percentual_base, valor_comissao,
+			valor_ajustes, data_fechamento, fechado_por)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		extrato.ID, extrato.VendedorID, extrato.Mes, extrato.Status, extrato.TotalVendas, extrato.PercentualBase,
+		extrato.ValorComissao, extrato.ValorAjustes, extrato.DataFechamento, extrato.FechadoPor)
+	if violaUnicidade(err) {
+		return fmt.Errorf("%w: o mês %s já foi fechado", domain.ErrFechamentoComissao, extrato.Mes)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, item := range extrato.Itens {
+		_, err := tx.Exec(`INSERT INTO itens_extrato_comissao (id, extrato_id, venda_id, item_venda_id, produto_id, data_venda, base,
+				percentual, valor, ajuste)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			utils.GenerateUUID(), extrato.ID, item.VendaID, item.ItemVendaID, item.ProdutoID, item.DataVenda, item.Base,
+			item.Percentual, item.Valor, item.Ajuste)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Aprovar aprova o extrato fechado. Se ele não estiver mais fechado, por exemplo por uma
+// aprovação concorrente, nada é alterado e sql.ErrNoRows é retornado.
+func (r *ComissaoRepositoryImpl) Aprovar(id, usuarioID string, quando time.Time) error {
+	result, err := r.db.Exec(`UPDATE extratos_comissao SET status = ?, data_aprovacao = ?, aprovado_por = ?
+		WHERE id = ? AND status = ?`, domain.ExtratoAprovado, quando, usuarioID, id, domain.ExtratoFechado)
+	if err != nil {
+		return err
+	}
+	return verificarAlteracao(result)
+}
+
+// violaUnicidade indica se o comando falhou por uma restrição UNIQUE do banco
+func violaUnicidade(err error) bool {
+	var erroSQLite sqlite3.Error
+	return errors.As(err, &erroSQLite) && erroSQLite.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+func buscarRegrasComissao(db *sql.DB, query string, args ...interface{}) ([]domain.RegraComissao, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var regras []domain.RegraComissao
+	for rows.Next() {
+		var regra domain.RegraComissao
+		var inicio, fim sql.NullTime
+		err := rows.Scan(&regra.ID, &regra.Descricao, &regra.Tipo, &regra.ProdutoID, &regra.Categoria, &regra.Percentual,
+			&regra.MetaMensal, &inicio, &fim, &regra.Ativa, &regra.DataCriacao)
+		if err != nil {
+			return nil, err
+		}
+		if inicio.Valid {
+			regra.Inicio = &inicio.Time
+		}
+		if fim.Valid {
+			regra.Fim = &fim.Time
+		}
+		regras = append(regras, regra)
+	}
+	return regras, rows.Err()
+}
+
+func scanExtratoComissao(row scanner) (*domain.ExtratoComissao, error) {
+	var extrato domain.ExtratoComissao
+	var dataFechamento time.Time
+	var dataAprovacao sql.NullTime
+	var aprovadoPor sql.NullString
+	err := row.Scan(&extrato.ID, &extrato.VendedorID, &extrato.VendedorNome, &extrato.Mes, &extrato.Status, &extrato.TotalVendas,
+		&extrato.PercentualBase, &extrato.ValorComissao, &extrato.ValorAjustes, &dataFechamento, &extrato.FechadoPor,
+		&dataAprovacao, &aprovadoPor)
+	if err != nil {
+		return nil, err
+	}
+	extrato.DataFechamento = &dataFechamento
+	if dataAprovacao.Valid {
+		extrato.DataAprovacao = &dataAprovacao.Time
+	}
+	extrato.AprovadoPor = aprovadoPor.String
+	return &extrato, nil
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+	"time"
+	"vendas/internal/domain"
+)
+
+// Um segundo fechamento do mesmo mês, como o de uma requisição concorrente que passou
+// pela verificação do serviço, esbarra na restrição de unicidade e volta como conflito
+func TestFecharMesJaFechado(t *testing.T) {
+	repo := NewComissaoRepository(bancoDeTeste(t))
+
+	fechar := func() error {
+		agora := time.Now()
+		return repo.Fechar(&domain.ExtratoComissao{
+			VendedorID:     "vendedor-1",
+			Mes:            "2026-01",
+			Status:         domain.ExtratoFechado,
+			TotalVendas:    1000,
+			PercentualBase: 5,
+			ValorComissao:  50,
+			DataFechamento: &agora,
+			FechadoPor:     "admin-1",
+			Itens: []domain.ItemComissao{
+				{VendaID: "venda-1", ItemVendaID: "item-1", ProdutoID: "produto-1", DataVenda: agora, Base: 1000,
+					Percentual: 5, Valor: 50},
+			},
+		})
+	}
+
+	if err := fechar(); err != nil {
+		t.Fatalf("primeiro fechamento: %v", err)
+	}
+	err := fechar()
+	if !errors.Is(err, domain.ErrFechamentoComissao) {
+		t.Fatalf("segundo fechamento retornou %v, esperado %v", err, domain.ErrFechamentoComissao)
+	}
+
+	extratos, err := repo.GetExtratos("vendedor-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(extratos) != 1 {
+		t.Errorf("%d extratos gravados, esperado 1", len(extratos))
+	}
+	itens, err := repo.GetComissionado("vendedor-1", "2026-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(itens) != 1 {
+		t.Errorf("%d linhas gravadas, esperado 1", len(itens))
+	}
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type DevolucaoRepository interface {
+	Create(devolucao *domain.Devolucao, statusVenda domain.StatusVenda, devolucaoTotal bool, expiracaoPontos *time.Time) error
+	GetByVenda(vendaID string) ([]domain.Devolucao, error)
+	GetItensDevolvidos(vendaID string) (map[string]domain.ItemDevolucao, error)
+}
+
+type DevolucaoRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewDevolucaoRepository(db *sql.DB) *DevolucaoRepositoryImpl {
+	return &DevolucaoRepositoryImpl{db: db}
+}
+
+// Create grava a devolução e devolve os itens ao estoque do local da venda na mesma transação.
+// Quando devolucaoTotal é verdadeiro a venda passa para o status devolvida, as parcelas em
+// aberto são canceladas, os pontos gerados pela venda são estornados e os usados para pagá-la
+// voltam ao cliente em um lote que vence em expiracaoPontos. Na devolução parcial, o
+// reembolso é abatido das parcelas em aberto e os pontos gerados são estornados na mesma
+// proporção.
+func (r *DevolucaoRepositoryImpl) Create(devolucao *domain.Devolucao, statusVenda domain.StatusVenda, devolucaoTotal bool,
+	expiracaoPontos *time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	localID, err := localVenda(tx, devolucao.VendaID)
+	if err != nil {
+		return err
+	}
+
+	// Gera UUID para a devolução
+	devolucao.ID = utils.GenerateUUID()
+
+	query := `INSERT INTO devolucoes (id, venda_id, usuario_id, motivo, valor_reembolso, data_devolucao)
+		VALUES (?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, devolucao.ID, devolucao.VendaID, devolucao.UsuarioID, devolucao.Motivo,
+		devolucao.ValorReembolso, devolucao.DataDevolucao)
+	if err != nil {
+		return err
+	}
+
+	for i := range devolucao.Itens {
+		item := &devolucao.Itens[i]
+		item.ID = utils.GenerateUUID()
+		item.DevolucaoID = devolucao.ID
+
+		// Confere novamente o saldo dentro da transação para evitar devoluções concorrentes
+		var restante int
+		err := tx.QueryRow(`
+			SELECT iv.quantidade - COALESCE((
+				SELECT SUM(idv.quantidade) FROM itens_devolucao idv WHERE idv.item_venda_id = iv.id
+			), 0)
+			FROM itens_venda iv
+			WHERE iv.id = ? AND iv.venda_id = ?
+		`, item.ItemVendaID, devolucao.VendaID).Scan(&restante)
+		if err != nil {
+			return err
+		}
+		if item.Quantidade > restante {
+			return fmt.Errorf("quantidade devolvida do item %s excede o saldo de %d", item.ItemVendaID, restante)
+		}
+
+		query = `INSERT INTO itens_devolucao (id, devolucao_id, item_venda_id, produto_id, quantidade, valor_reembolso)
+			VALUES (?, ?, ?, ?, ?, ?)`
+		_, err = tx.Exec(query, item.ID, item.DevolucaoID, item.ItemVendaID, item.ProdutoID, item.Quantidade, item.ValorReembolso)
+		if err != nil {
+			return err
+		}
+
+		err = movimentarEstoque(tx, &domain.MovimentacaoEstoque{
+			ProdutoID:  item.ProdutoID,
+			LocalID:    localID,
+			Tipo:       domain.MovimentacaoDevolucao,
+			Quantidade: item.Quantidade,
+			UsuarioID:  devolucao.UsuarioID,
+			Motivo:     devolucao.Motivo,
+			VendaID:    devolucao.VendaID,
+			Data:       devolucao.DataDevolucao,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if devolucaoTotal {
+		// Os pontos resgatados voltam antes do estorno dos pontos gerados, para que o estorno
+		// alcance também os pontos da venda que o cliente usou para pagá-la
+		err := devolverPontosResgatados(tx, devolucao.VendaID, expiracaoPontos, devolucao.UsuarioID, devolucao.Motivo)
+		if err != nil {
+			return err
+		}
+		err = mudarStatusVenda(tx, devolucao.VendaID, statusVenda, domain.StatusDevolvida, devolucao.UsuarioID, devolucao.Motivo)
+		if err != nil {
+			return err
+		}
+	} else {
+		err := abaterParcelas(tx, devolucao.VendaID, statusVenda, devolucao.ValorReembolso, devolucao.UsuarioID, devolucao.Motivo)
+		if err != nil {
+			return err
+		}
+		if err := estornarPontosDevolucao(tx, devolucao.VendaID, devolucao.UsuarioID, devolucao.Motivo); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *DevolucaoRepositoryImpl) GetByVenda(vendaID string) ([]domain.Devolucao, error) {
+	query := `SELECT id, venda_id, usuario_id, motivo, valor_reembolso, data_devolucao
+		FROM devolucoes WHERE venda_id = ? ORDER BY data_devolucao`
+	rows, err := r.db.Query(query, vendaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var devolucoes []domain.Devolucao
+	for rows.Next() {
+		var d domain.Devolucao
+		err := rows.Scan(&d.ID, &d.VendaID, &d.UsuarioID, &d.Motivo, &d.ValorReembolso, &d.DataDevolucao)
+		if err != nil {
+			return nil, err
+		}
+		devolucoes = append(devolucoes, d)
+	}
+	rows.Close()
+
+	for i := range devolucoes {
+		query = `SELECT id, devolucao_id, item_venda_id, produto_id, quantidade, valor_reembolso
+			FROM itens_devolucao WHERE devolucao_id = ?`
+		itemRows, err := r.db.Query(query, devolucoes[i].ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for itemRows.Next() {
+			var item domain.ItemDevolucao
+			err := itemRows.Scan(&item.ID, &item.DevolucaoID, &item.ItemVendaID, &item.ProdutoID, &item.Quantidade, &item.ValorReembolso)
+			if err != nil {
+				itemRows.Close()
+				return nil, err
+			}
+			devolucoes[i].Itens = append(devolucoes[i].Itens, item)
+		}
+		itemRows.Close()
+	}
+
+	return devolucoes, nil
+}
+
+// GetItensDevolvidos retorna, por item da venda, a quantidade e o valor já devolvidos
+func (r *DevolucaoRepositoryImpl) GetItensDevolvidos(vendaID string) (map[string]domain.ItemDevolucao, error) {
+	query := `
+		SELECT idv.item_venda_id, idv.produto_id, SUM(idv.quantidade), SUM(idv.valor_reembolso)
+		FROM itens_devolucao idv
+		JOIN devolucoes d ON d.id = idv.devolucao_id
+		WHERE d.venda_id = ?
+		GROUP BY idv.item_venda_id, idv.produto_id
+	`
+	rows, err := r.db.Query(query, vendaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	devolvidos := make(map[string]domain.ItemDevolucao)
+	for rows.Next() {
+		var item domain.ItemDevolucao
+		if err := rows.Scan(&item.ItemVendaID, &item.ProdutoID, &item.Quantidade, &item.ValorReembolso); err != nil {
+			return nil, err
+		}
+		devolvidos[item.ItemVendaID] = item
+	}
+	return devolvidos, nil
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"database/sql"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type EnderecoRepository interface {
+	Create(endereco *domain.Endereco) error
+	GetByID(id string) (*domain.Endereco, error)
+	GetByCliente(clienteID string) ([]domain.Endereco, error)
+	Update(endereco *domain.Endereco) error
+	Delete(id string) error
+}
+
+type EnderecoRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewEnderecoRepository(db *sql.DB) *EnderecoRepositoryImpl {
+	return &EnderecoRepositoryImpl{db: db}
+}
+
+const selectEnderecos = `SELECT id, cliente_id, tipo, cep, logradouro, numero, complemento, bairro, cidade, uf, data_criacao
+	FROM enderecos`
+
+func (r *EnderecoRepositoryImpl) Create(endereco *domain.Endereco) error {
+	// Gera UUID para o endereço
+	endereco.ID = utils.GenerateUUID()
+
+	query := `INSERT INTO enderecos (id, cliente_id, tipo, cep, logradouro, numero, complemento, bairro, cidade, uf, data_criacao)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, endereco.ID, endereco.ClienteID, endereco.Tipo, endereco.CEP, endereco.Logradouro,
+		endereco.Numero, endereco.Complemento, endereco.Bairro, endereco.Cidade, endereco.UF, endereco.DataCriacao)
+	return err
+}
+
+func (r *EnderecoRepositoryImpl) GetByID(id string) (*domain.Endereco, error) {
+	enderecos, err := buscarEnderecos(r.db, selectEnderecos+` WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(enderecos) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &enderecos[0], nil
+}
+
+func (r *EnderecoRepositoryImpl) GetByCliente(clienteID string) ([]domain.Endereco, error) {
+	return buscarEnderecos(r.db, selectEnderecos+` WHERE cliente_id = ? ORDER BY tipo, data_criacao`, clienteID)
+}
+
+func (r *EnderecoRepositoryImpl) Update(endereco *domain.Endereco) error {
+	query := `UPDATE enderecos SET tipo = ?, cep = ?, logradouro = ?, numero = ?, complemento = ?, bairro = ?, cidade = ?, uf = ?
+		WHERE id = ?`
+	result, err := r.db.Exec(query, endereco.Tipo, endereco.CEP, endereco.Logradouro, endereco.Numero, endereco.Complemento,
+		endereco.Bairro, endereco.Cidade, endereco.UF, endereco.ID)
+	if err != nil {
+		return err
+	}
+	return verificarAlteracao(result)
+}
+
+func (r *EnderecoRepositoryImpl) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM enderecos WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return verificarAlteracao(result)
+}
+
+func buscarEnderecos(db *sql.DB, query string, args ...interface{}) ([]domain.Endereco, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var enderecos []domain.Endereco
+	for rows.Next() {
+		var e domain.Endereco
+		err := rows.Scan(&e.ID, &e.ClienteID, &e.Tipo, &e.CEP, &e.Logradouro, &e.Numero, &e.Complemento, &e.Bairro,
+			&e.Cidade, &e.UF, &e.DataCriacao)
+		if err != nil {
+			return nil, err
+		}
+		enderecos = append(enderecos, e)
+	}
+	return enderecos, rows.Err()
+}
@@ -0,0 +1,416 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type FidelidadeRepository interface {
+	CreateRegra(regra *domain.RegraFidelidade) error
+	GetRegra(id string) (*domain.RegraFidelidade, error)
+	GetRegras() ([]domain.RegraFidelidade, error)
+	UpdateRegra(regra *domain.RegraFidelidade) error
+	DeleteRegra(id string) error
+	GetSaldo(clienteID string, agora, aviso time.Time) (saldo, aExpirar int, err error)
+	GetMovimentos(clienteID string) ([]domain.MovimentoPontos, error)
+	Expirar(agora time.Time) (int, error)
+}
+
+type FidelidadeRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewFidelidadeRepository(db *sql.DB) *FidelidadeRepositoryImpl {
+	return &FidelidadeRepositoryImpl{db: db}
+}
+
+const selectRegrasFidelidade = `SELECT id, descricao, tipo, categoria, pontos_por_real, multiplicador, inicio, fim, ativa, data_criacao
+	FROM regras_fidelidade`
+
+// loteValido filtra os créditos com pontos a consumir que ainda não venceram na data informada
+const loteValido = `restante > 0 AND (data_expiracao IS NULL OR julianday(data_expiracao) > julianday(?))`
+
+func (r *FidelidadeRepositoryImpl) CreateRegra(regra *domain.RegraFidelidade) error {
+	regra.ID = utils.GenerateUUID()
+
+	query := `INSERT INTO regras_fidelidade (id, descricao, tipo, categoria, pontos_por_real, multiplicador, inicio, fim, ativa, data_criacao)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, regra.ID, regra.Descricao, regra.Tipo, regra.Categoria, regra.PontosPorReal,
+		regra.Multiplicador, regra.Inicio, regra.Fim, regra.Ativa, regra.DataCriacao)
+	return err
+}
+
+func (r *FidelidadeRepositoryImpl) GetRegra(id string) (*domain.RegraFidelidade, error) {
+	regras, err := buscarRegrasFidelidade(r.db, selectRegrasFidelidade+` WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(regras) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &regras[0], nil
+}
+
+func (r *FidelidadeRepositoryImpl) GetRegras() ([]domain.RegraFidelidade, error) {
+	return buscarRegrasFidelidade(r.db, selectRegrasFidelidade+` ORDER BY data_criacao`)
+}
+
+func (r *FidelidadeRepositoryImpl) UpdateRegra(regra *domain.RegraFidelidade) error {
+	query := `UPDATE regras_fidelidade SET descricao = ?, tipo = ?, categoria = ?, pontos_por_real = ?, multiplicador = ?,
+			inicio = ?, fim = ?, ativa = ?
+		WHERE id = ?`
+	result, err := r.db.Exec(query, regra.Descricao, regra.Tipo, regra.Categoria, regra.PontosPorReal, regra.Multiplicador,
+		regra.Inicio, regra.Fim, regra.Ativa, regra.ID)
+	if err != nil {
+		return err
+	}
+	return verificarAlteracao(result)
+}
+
+func (r *FidelidadeRepositoryImpl) DeleteRegra(id string) error {
+	result, err := r.db.Exec(`DELETE FROM regras_fidelidade WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return verificarAlteracao(result)
+}
+
+// GetSaldo soma os pontos ainda válidos do cliente e, entre eles, os que vencem até a
+// data de aviso
+func (r *FidelidadeRepositoryImpl) GetSaldo(clienteID string, agora, aviso time.Time) (int, int, error) {
+	query := `SELECT COALESCE(SUM(restante), 0),
+			COALESCE(SUM(CASE WHEN julianday(data_expiracao) <= julianday(?) THEN restante ELSE 0 END), 0)
+		FROM pontos_fidelidade
+		WHERE cliente_id = ? AND ` + loteValido
+	var saldo, aExpirar int
+	err := r.db.QueryRow(query, aviso, clienteID, agora).Scan(&saldo, &aExpirar)
+	return saldo, aExpirar, err
+}
+
+// GetMovimentos retorna o extrato de pontos do cliente, do lançamento mais recente para o mais antigo
+func (r *FidelidadeRepositoryImpl) GetMovimentos(clienteID string) ([]domain.MovimentoPontos, error) {
+	query := `SELECT id, cliente_id, venda_id, tipo, pontos, restante, data_expiracao, descricao, usuario_id, data_lancamento
+		FROM pontos_fidelidade WHERE cliente_id = ? ORDER BY data_lancamento DESC`
+	rows, err := r.db.Query(query, clienteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movimentos []domain.MovimentoPontos
+	for rows.Next() {
+		var m domain.MovimentoPontos
+		var vendaID, usuarioID sql.NullString
+		var expiracao sql.NullTime
+		err := rows.Scan(&m.ID, &m.ClienteID, &vendaID, &m.Tipo, &m.Pontos, &m.Restante, &expiracao, &m.Descricao,
+			&usuarioID, &m.DataLancamento)
+		if err != nil {
+			return nil, err
+		}
+		m.VendaID = vendaID.String
+		m.UsuarioID = usuarioID.String
+		if expiracao.Valid {
+			m.DataExpiracao = &expiracao.Time
+		}
+		movimentos = append(movimentos, m)
+	}
+	return movimentos, rows.Err()
+}
+
+// Expirar zera os lotes de pontos vencidos até a data informada, lançando a expiração no
+// extrato de cada cliente. Retorna quantos lotes expiraram.
+func (r *FidelidadeRepositoryImpl) Expirar(agora time.Time) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id, cliente_id, venda_id, restante FROM pontos_fidelidade
+		WHERE restante > 0 AND julianday(data_expiracao) <= julianday(?)`, agora)
+	if err != nil {
+		return 0, err
+	}
+	var vencidos []domain.MovimentoPontos
+	for rows.Next() {
+		var lote domain.MovimentoPontos
+		var vendaID sql.NullString
+		if err := rows.Scan(&lote.ID, &lote.ClienteID, &vendaID, &lote.Restante); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		lote.VendaID = vendaID.String
+		vencidos = append(vencidos, lote)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, lote := range vencidos {
+		if _, err := tx.Exec(`UPDATE pontos_fidelidade SET restante = 0 WHERE id = ?`, lote.ID); err != nil {
+			return 0, err
+		}
+		err := lancarPontos(tx, &domain.MovimentoPontos{
+			ClienteID:      lote.ClienteID,
+			VendaID:        lote.VendaID,
+			Tipo:           domain.PontosExpiracao,
+			Pontos:         -lote.Restante,
+			Descricao:      "pontos vencidos",
+			DataLancamento: agora,
+		})
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return len(vencidos), tx.Commit()
+}
+
+func buscarRegrasFidelidade(db *sql.DB, query string, args ...interface{}) ([]domain.RegraFidelidade, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var regras []domain.RegraFidelidade
+	for rows.Next() {
+		var regra domain.RegraFidelidade
+		var inicio, fim sql.NullTime
+		err := rows.Scan(&regra.ID, &regra.Descricao, &regra.Tipo, &regra.Categoria, &regra.PontosPorReal,
+			&regra.Multiplicador, &inicio, &fim, &regra.Ativa, &regra.DataCriacao)
+		if err != nil {
+			return nil, err
+		}
+		if inicio.Valid {
+			regra.Inicio = &inicio.Time
+		}
+		if fim.Valid {
+			regra.Fim = &fim.Time
+		}
+		regras = append(regras, regra)
+	}
+	return regras, rows.Err()
+}
+
+// lancarPontos grava um lançamento no extrato de pontos. Créditos ficam com todos os
+// pontos disponíveis para resgate.
+func lancarPontos(tx *sql.Tx, movimento *domain.MovimentoPontos) error {
+	movimento.ID = utils.GenerateUUID()
+	if movimento.Pontos > 0 {
+		movimento.Restante = movimento.Pontos
+	}
+
+	query := `INSERT INTO pontos_fidelidade (id, cliente_id, venda_id, tipo, pontos, restante, data_expiracao, descricao, usuario_id,
+			data_lancamento)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := tx.Exec(query, movimento.ID, movimento.ClienteID, referencia(movimento.VendaID), movimento.Tipo, movimento.Pontos,
+		movimento.Restante, movimento.DataExpiracao, movimento.Descricao, referencia(movimento.UsuarioID), movimento.DataLancamento)
+	return err
+}
+
+// consumirPontos retira pontos dos lotes válidos do cliente, começando pelos que vencem
+// primeiro. Com parcial, retira o que houver e retorna quanto foi retirado; sem parcial,
+// falha com ErrPontosInsuficientes quando o saldo não cobre o total.
+func consumirPontos(tx *sql.Tx, clienteID string, pontos int, agora time.Time, parcial bool) (int, error) {
+	rows, err := tx.Query(`SELECT id, restante FROM pontos_fidelidade WHERE cliente_id = ? AND `+loteValido+`
+		ORDER BY data_expiracao IS NULL, julianday(data_expiracao), data_lancamento`, clienteID, agora)
+	if err != nil {
+		return 0, err
+	}
+	var lotes []domain.MovimentoPontos
+	for rows.Next() {
+		var lote domain.MovimentoPontos
+		if err := rows.Scan(&lote.ID, &lote.Restante); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		lotes = append(lotes, lote)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	return consumirLotes(tx, lotes, clienteID, pontos, parcial)
+}
+
+// consumirLotes retira os pontos dos lotes na ordem recebida
+func consumirLotes(tx *sql.Tx, lotes []domain.MovimentoPontos, clienteID string, pontos int, parcial bool) (int, error) {
+	retirados := 0
+	for _, lote := range lotes {
+		if retirados == pontos {
+			break
+		}
+		retirar := lote.Restante
+		if retirar > pontos-retirados {
+			retirar = pontos - retirados
+		}
+		if _, err := tx.Exec(`UPDATE pontos_fidelidade SET restante = restante - ? WHERE id = ?`, retirar, lote.ID); err != nil {
+			return 0, err
+		}
+		retirados += retirar
+	}
+
+	if retirados < pontos && !parcial {
+		return 0, fmt.Errorf("%w: o cliente %s tem %d pontos válidos e o resgate pede %d", domain.ErrPontosInsuficientes,
+			clienteID, retirados, pontos)
+	}
+	return retirados, nil
+}
+
+// acumularPontosVenda credita ao cliente os pontos gerados pela venda confirmada
+func acumularPontosVenda(tx *sql.Tx, venda *domain.Venda, usuarioID string) error {
+	if venda.PontosFidelidade <= 0 {
+		return nil
+	}
+	return lancarPontos(tx, &domain.MovimentoPontos{
+		ClienteID:      venda.ClienteID,
+		VendaID:        venda.ID,
+		Tipo:           domain.PontosAcumulo,
+		Pontos:         venda.PontosFidelidade,
+		DataExpiracao:  venda.ExpiracaoPontos,
+		Descricao:      "pontos da venda",
+		UsuarioID:      usuarioID,
+		DataLancamento: time.Now(),
+	})
+}
+
+// estornarPontosVenda retira os pontos gerados por uma venda cancelada, devolvida ou
+// editada. Eles saem primeiro dos lotes da própria venda e, se o cliente já os usou, dos
+// demais lotes válidos, sem deixar o saldo negativo.
+func estornarPontosVenda(tx *sql.Tx, vendaID, usuarioID, motivo string) error {
+	var clienteID string
+	var acumulados int
+	err := tx.QueryRow(`
+		SELECT v.cliente_id,
+			COALESCE((SELECT SUM(p.pontos) FROM pontos_fidelidade p WHERE p.venda_id = v.id AND p.tipo IN (?, ?)), 0)
+		FROM vendas v WHERE v.id = ?
+	`, domain.PontosAcumulo, domain.PontosEstorno, vendaID).Scan(&clienteID, &acumulados)
+	if err != nil {
+		return err
+	}
+	if acumulados <= 0 {
+		return nil
+	}
+
+	// O estorno lança todos os pontos que restam da venda, para que uma nova confirmação
+	// não os credite em dobro
+	return retirarPontosVenda(tx, vendaID, clienteID, acumulados, usuarioID, "estorno dos pontos da venda: "+motivo)
+}
+
+// estornarPontosDevolucao retira os pontos da venda na proporção do valor reembolsado nas
+// devoluções parciais: a venda fica apenas com os pontos do valor que o cliente manteve.
+// A conta parte sempre dos pontos gerados e do total já reembolsado, para que os
+// arredondamentos de várias devoluções não se acumulem.
+func estornarPontosDevolucao(tx *sql.Tx, vendaID, usuarioID, motivo string) error {
+	var clienteID string
+	var valorTotal, reembolsado float64
+	var gerados, acumulados int
+	err := tx.QueryRow(`
+		SELECT v.cliente_id, v.valor_total,
+			COALESCE((SELECT SUM(d.valor_reembolso) FROM devolucoes d WHERE d.venda_id = v.id), 0),
+			COALESCE((SELECT p.pontos FROM pontos_fidelidade p WHERE p.venda_id = v.id AND p.tipo = ?
+				ORDER BY p.data_lancamento DESC LIMIT 1), 0),
+			COALESCE((SELECT SUM(p.pontos) FROM pontos_fidelidade p WHERE p.venda_id = v.id AND p.tipo IN (?, ?)), 0)
+		FROM vendas v WHERE v.id = ?
+	`, domain.PontosAcumulo, domain.PontosAcumulo, domain.PontosEstorno, vendaID).Scan(&clienteID, &valorTotal, &reembolsado,
+		&gerados, &acumulados)
+	if err != nil {
+		return err
+	}
+	if acumulados <= 0 || valorTotal <= 0 {
+		return nil
+	}
+
+	mantidos := int(math.Round(float64(gerados) * math.Max(valorTotal-reembolsado, 0) / valorTotal))
+	if acumulados <= mantidos {
+		return nil
+	}
+	return retirarPontosVenda(tx, vendaID, clienteID, acumulados-mantidos, usuarioID,
+		"estorno dos pontos da devolução parcial: "+motivo)
+}
+
+// retirarPontosVenda lança o estorno de pontos da venda. Eles saem primeiro dos lotes da
+// própria venda, inclusive vencidos, e depois dos demais lotes válidos do cliente; os que
+// o cliente já havia usado não são cobrados.
+func retirarPontosVenda(tx *sql.Tx, vendaID, clienteID string, pontos int, usuarioID, descricao string) error {
+	rows, err := tx.Query(`SELECT id, restante FROM pontos_fidelidade WHERE venda_id = ? AND tipo = ? AND restante > 0`,
+		vendaID, domain.PontosAcumulo)
+	if err != nil {
+		return err
+	}
+	var lotes []domain.MovimentoPontos
+	for rows.Next() {
+		var lote domain.MovimentoPontos
+		if err := rows.Scan(&lote.ID, &lote.Restante); err != nil {
+			rows.Close()
+			return err
+		}
+		lotes = append(lotes, lote)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	agora := time.Now()
+	retirados, err := consumirLotes(tx, lotes, clienteID, pontos, true)
+	if err != nil {
+		return err
+	}
+	if retirados < pontos {
+		outros, err := consumirPontos(tx, clienteID, pontos-retirados, agora, true)
+		if err != nil {
+			return err
+		}
+		retirados += outros
+	}
+
+	if retirados < pontos {
+		descricao = fmt.Sprintf("%s (%d pontos já haviam sido usados)", descricao, pontos-retirados)
+	}
+	return lancarPontos(tx, &domain.MovimentoPontos{
+		ClienteID:      clienteID,
+		VendaID:        vendaID,
+		Tipo:           domain.PontosEstorno,
+		Pontos:         -pontos,
+		Descricao:      descricao,
+		UsuarioID:      usuarioID,
+		DataLancamento: agora,
+	})
+}
+
+// devolverPontosResgatados credita de volta, em um novo lote que vence em expiracao, os
+// pontos usados para pagar uma venda cancelada ou totalmente devolvida
+func devolverPontosResgatados(tx *sql.Tx, vendaID string, expiracao *time.Time, usuarioID, motivo string) error {
+	var clienteID string
+	var resgatados int
+	err := tx.QueryRow(`
+		SELECT v.cliente_id,
+			COALESCE((SELECT -SUM(p.pontos) FROM pontos_fidelidade p WHERE p.venda_id = v.id AND p.tipo IN (?, ?)), 0)
+		FROM vendas v WHERE v.id = ?
+	`, domain.PontosResgate, domain.PontosDevolucaoResgate, vendaID).Scan(&clienteID, &resgatados)
+	if err != nil {
+		return err
+	}
+	if resgatados <= 0 {
+		return nil
+	}
+
+	return lancarPontos(tx, &domain.MovimentoPontos{
+		ClienteID:      clienteID,
+		VendaID:        vendaID,
+		Tipo:           domain.PontosDevolucaoResgate,
+		Pontos:         resgatados,
+		DataExpiracao:  expiracao,
+		Descricao:      "pontos usados no pagamento da venda: " + motivo,
+		UsuarioID:      usuarioID,
+		DataLancamento: time.Now(),
+	})
+}
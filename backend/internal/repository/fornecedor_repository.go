@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"database/sql"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type FornecedorRepository interface {
+	Create(fornecedor *domain.Fornecedor) error
+	GetByID(id string) (*domain.Fornecedor, error)
+	GetAll() ([]domain.Fornecedor, error)
+	Update(fornecedor *domain.Fornecedor) error
+	Delete(id string) error
+}
+
+type FornecedorRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewFornecedorRepository(db *sql.DB) *FornecedorRepositoryImpl {
+	return &FornecedorRepositoryImpl{db: db}
+}
+
+func (r *FornecedorRepositoryImpl) Create(fornecedor *domain.Fornecedor) error {
+	// Gera UUID para o fornecedor
+	fornecedor.ID = utils.GenerateUUID()
+
+	query := `INSERT INTO fornecedores (id, nome, cnpj, email, telefone, contato, ativo, data_criacao) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, fornecedor.ID, fornecedor.Nome, fornecedor.CNPJ, fornecedor.Email, fornecedor.Telefone,
+		fornecedor.Contato, fornecedor.Ativo, fornecedor.DataCriacao)
+	return err
+}
+
+func (r *FornecedorRepositoryImpl) GetByID(id string) (*domain.Fornecedor, error) {
+	fornecedor := &domain.Fornecedor{}
+	query := `SELECT id, nome, cnpj, email, telefone, contato, ativo, data_criacao FROM fornecedores WHERE id = ?`
+	err := r.db.QueryRow(query, id).Scan(&fornecedor.ID, &fornecedor.Nome, &fornecedor.CNPJ, &fornecedor.Email,
+		&fornecedor.Telefone, &fornecedor.Contato, &fornecedor.Ativo, &fornecedor.DataCriacao)
+	if err != nil {
+		return nil, err
+	}
+	return fornecedor, nil
+}
+
+func (r *FornecedorRepositoryImpl) GetAll() ([]domain.Fornecedor, error) {
+	query := `SELECT id, nome, cnpj, email, telefone, contato, ativo, data_criacao FROM fornecedores ORDER BY nome`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var fornecedores []domain.Fornecedor
+	for rows.Next() {
+		var fornecedor domain.Fornecedor
+		err := rows.Scan(&fornecedor.ID, &fornecedor.Nome, &fornecedor.CNPJ, &fornecedor.Email,
+			&fornecedor.Telefone, &fornecedor.Contato, &fornecedor.Ativo, &fornecedor.DataCriacao)
+		if err != nil {
+			return nil, err
+		}
+		fornecedores = append(fornecedores, fornecedor)
+	}
+	return fornecedores, nil
+}
+
+func (r *FornecedorRepositoryImpl) Update(fornecedor *domain.Fornecedor) error {
+	query := `UPDATE fornecedores SET nome = ?, cnpj = ?, email = ?, telefone = ?, contato = ?, ativo = ? WHERE id = ?`
+	result, err := r.db.Exec(query, fornecedor.Nome, fornecedor.CNPJ, fornecedor.Email, fornecedor.Telefone,
+		fornecedor.Contato, fornecedor.Ativo, fornecedor.ID)
+	if err != nil {
+		return err
+	}
+	return verificarAlteracao(result)
+}
+
+// Delete inativa o fornecedor. O cadastro é mantido porque continua referenciado
+// pelos pedidos de compra já emitidos.
+func (r *FornecedorRepositoryImpl) Delete(id string) error {
+	result, err := r.db.Exec(`UPDATE fornecedores SET ativo = 0 WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return verificarAlteracao(result)
+}
+
+// verificarAlteracao retorna sql.ErrNoRows quando o comando não alterou nenhum registro
+func verificarAlteracao(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type LocalRepository interface {
+	Create(local *domain.LocalEstoque) error
+	GetByID(id string) (*domain.LocalEstoque, error)
+	GetAll() ([]domain.LocalEstoque, error)
+	Update(local *domain.LocalEstoque) error
+	GetSaldo(localID, produtoID string) (int, error)
+	GetDisponivel(localID, produtoID string, propria domain.OrigemReserva) (int, error)
+	GetSaldosLocal(localID string) ([]domain.SaldoEstoque, error)
+	GetSaldosProduto(produtoID string) ([]domain.SaldoEstoque, error)
+}
+
+type LocalRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewLocalRepository(db *sql.DB) *LocalRepositoryImpl {
+	return &LocalRepositoryImpl{db: db}
+}
+
+func (r *LocalRepositoryImpl) Create(local *domain.LocalEstoque) error {
+	// Gera UUID para o local
+	local.ID = utils.GenerateUUID()
+
+	query := `INSERT INTO locais_estoque (id, nome, tipo, ativo, data_criacao) VALUES (?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, local.ID, local.Nome, local.Tipo, local.Ativo, local.DataCriacao)
+	return err
+}
+
+func (r *LocalRepositoryImpl) GetByID(id string) (*domain.LocalEstoque, error) {
+	local := &domain.LocalEstoque{}
+	query := `SELECT id, nome, tipo, ativo, data_criacao FROM locais_estoque WHERE id = ?`
+	err := r.db.QueryRow(query, id).Scan(&local.ID, &local.Nome, &local.Tipo, &local.Ativo, &local.DataCriacao)
+	if err != nil {
+		return nil, err
+	}
+	return local, nil
+}
+
+func (r *LocalRepositoryImpl) GetAll() ([]domain.LocalEstoque, error) {
+	query := `SELECT id, nome, tipo, ativo, data_criacao FROM locais_estoque ORDER BY nome`
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var locais []domain.LocalEstoque
+	for rows.Next() {
+		var local domain.LocalEstoque
+		if err := rows.Scan(&local.ID, &local.Nome, &local.Tipo, &local.Ativo, &local.DataCriacao); err != nil {
+			return nil, err
+		}
+		locais = append(locais, local)
+	}
+	return locais, rows.Err()
+}
+
+func (r *LocalRepositoryImpl) Update(local *domain.LocalEstoque) error {
+	result, err := r.db.Exec(`UPDATE locais_estoque SET nome = ?, tipo = ?, ativo = ? WHERE id = ?`,
+		local.Nome, local.Tipo, local.Ativo, local.ID)
+	if err != nil {
+		return err
+	}
+	return verificarAlteracao(result)
+}
+
+// GetSaldo retorna a quantidade do produto no local; sem registro o saldo é zero
+func (r *LocalRepositoryImpl) GetSaldo(localID, produtoID string) (int, error) {
+	var saldo int
+	err := r.db.QueryRow(`SELECT COALESCE(SUM(quantidade), 0) FROM saldos_estoque WHERE local_id = ? AND produto_id = ?`,
+		localID, produtoID).Scan(&saldo)
+	return saldo, err
+}
+
+// GetDisponivel retorna o saldo do produto no local menos as reservas ativas, exceto as
+// da venda ou do orçamento informados
+func (r *LocalRepositoryImpl) GetDisponivel(localID, produtoID string, propria domain.OrigemReserva) (int, error) {
+	return estoqueDisponivel(r.db, localID, produtoID, propria, time.Now())
+}
+
+// GetSaldosLocal retorna os produtos com saldo no local
+func (r *LocalRepositoryImpl) GetSaldosLocal(localID string) ([]domain.SaldoEstoque, error) {
+	return r.buscarSaldos(`WHERE s.local_id = ? AND s.quantidade <> 0 ORDER BY p.nome`, localID)
+}
+
+// GetSaldosProduto retorna o saldo do produto em cada local onde ele já foi movimentado
+func (r *LocalRepositoryImpl) GetSaldosProduto(produtoID string) ([]domain.SaldoEstoque, error) {
+	return r.buscarSaldos(`WHERE s.produto_id = ? ORDER BY l.nome`, produtoID)
+}
+
+func (r *LocalRepositoryImpl) buscarSaldos(where string, args ...interface{}) ([]domain.SaldoEstoque, error) {
+	query := `
+		SELECT s.local_id, COALESCE(l.nome, ''), s.produto_id, COALESCE(p.nome, ''), s.quantidade,
+			(SELECT COALESCE(SUM(r.quantidade), 0) FROM reservas_estoque r
+				WHERE r.local_id = s.local_id AND r.produto_id = s.produto_id AND ` + condicaoReservaAtiva + `)
+		FROM saldos_estoque s
+		LEFT JOIN locais_estoque l ON l.id = s.local_id
+		LEFT JOIN produtos p ON p.id = s.produto_id
+		` + where
+	rows, err := r.db.Query(query, append([]interface{}{time.Now()}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var saldos []domain.SaldoEstoque
+	for rows.Next() {
+		var saldo domain.SaldoEstoque
+		err := rows.Scan(&saldo.LocalID, &saldo.LocalNome, &saldo.ProdutoID, &saldo.ProdutoNome, &saldo.Quantidade,
+			&saldo.Reservado)
+		if err != nil {
+			return nil, err
+		}
+		saldo.Disponivel = saldo.Quantidade - saldo.Reservado
+		saldos = append(saldos, saldo)
+	}
+	return saldos, rows.Err()
+}
@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type MesclagemRepository interface {
+	Mesclar(cliente *domain.Cliente, duplicadoID string, mesclagem *domain.MesclagemCliente, registro *domain.RegistroAuditoria) error
+	GetByCliente(clienteID string) ([]domain.MesclagemCliente, error)
+}
+
+type MesclagemRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewMesclagemRepository(db *sql.DB) *MesclagemRepositoryImpl {
+	return &MesclagemRepositoryImpl{db: db}
+}
+
+// tabelasComCliente são as tabelas cujos registros passam para o cliente mantido quando
+// um duplicado é incorporado a ele
+var tabelasComCliente = []string{
+	"vendas",
+	"parcelas",
+	"enderecos",
+	"pontos_fidelidade",
+	"mesclagens_clientes",
+	"orcamentos",
+}
+
+// Mesclar incorpora o duplicado ao cliente em uma única transação: aponta para o cliente
+// todos os registros do duplicado, remove o duplicado, grava o cadastro do cliente já
+// completado com os dados do duplicado e registra a mesclagem e a auditoria
+func (r *MesclagemRepositoryImpl) Mesclar(cliente *domain.Cliente, duplicadoID string, mesclagem *domain.MesclagemCliente, registro *domain.RegistroAuditoria) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	mesclagem.Referencias = make(map[string]int)
+	for _, tabela := range tabelasComCliente {
+		result, err := tx.Exec(fmt.Sprintf(`UPDATE %s SET cliente_id = ? WHERE cliente_id = ?`, tabela), cliente.ID, duplicadoID)
+		if err != nil {
+			return err
+		}
+		alterados, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		mesclagem.Referencias[tabela] = int(alterados)
+	}
+
+	// A segmentação do duplicado deixa de fazer sentido; a do cliente é refeita na próxima rotina
+	if _, err := tx.Exec(`DELETE FROM segmentos_clientes WHERE cliente_id = ?`, duplicadoID); err != nil {
+		return err
+	}
+
+	// O duplicado sai antes da gravação do cliente para liberar o CPF ou CNPJ que o cliente herda
+	result, err := tx.Exec(`DELETE FROM clientes WHERE id = ?`, duplicadoID)
+	if err != nil {
+		return err
+	}
+	if err := verificarAlteracao(result); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`UPDATE clientes SET email = ?, telefone = ?, endereco = ?, cpf = ?, usuario_id = ?, cnpj = ?,
+			inscricao_estadual = ?, isento_ie = ?
+		WHERE id = ?`,
+		cliente.Email, cliente.Telefone, cliente.Endereco, cliente.CPF, referencia(cliente.UsuarioID), cliente.CNPJ,
+		cliente.InscricaoEstadual, cliente.IsentoIE, cliente.ID)
+	if err != nil {
+		return err
+	}
+
+	referencias, err := json.Marshal(mesclagem.Referencias)
+	if err != nil {
+		return err
+	}
+	mesclagem.ID = utils.GenerateUUID()
+	_, err = tx.Exec(`INSERT INTO mesclagens_clientes (id, cliente_id, duplicado_id, dados_duplicado, referencias, usuario_id, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		mesclagem.ID, mesclagem.ClienteID, mesclagem.DuplicadoID, string(mesclagem.DadosDuplicado), string(referencias),
+		referencia(mesclagem.UsuarioID), mesclagem.Data)
+	if err != nil {
+		return err
+	}
+
+	if err := registrarAuditoria(tx, registro); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetByCliente lista as mesclagens que incorporaram duplicados ao cliente, da mais recente
+// para a mais antiga
+func (r *MesclagemRepositoryImpl) GetByCliente(clienteID string) ([]domain.MesclagemCliente, error) {
+	rows, err := r.db.Query(`SELECT id, cliente_id, duplicado_id, dados_duplicado, referencias, usuario_id, data
+		FROM mesclagens_clientes
+		WHERE cliente_id = ?
+		ORDER BY data DESC`, clienteID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mesclagens := []domain.MesclagemCliente{}
+	for rows.Next() {
+		var mesclagem domain.MesclagemCliente
+		var dados, referencias string
+		var usuarioID sql.NullString
+		err := rows.Scan(&mesclagem.ID, &mesclagem.ClienteID, &mesclagem.DuplicadoID, &dados, &referencias, &usuarioID,
+			&mesclagem.Data)
+		if err != nil {
+			return nil, err
+		}
+		mesclagem.DadosDuplicado = json.RawMessage(dados)
+		if err := json.Unmarshal([]byte(referencias), &mesclagem.Referencias); err != nil {
+			return nil, err
+		}
+		mesclagem.UsuarioID = usuarioID.String
+		mesclagens = append(mesclagens, mesclagem)
+	}
+	return mesclagens, rows.Err()
+}
@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type MetaRepository interface {
+	Create(meta *domain.Meta) error
+	GetByID(id string) (*domain.Meta, error)
+	GetAll(filtro domain.FiltroMetas) ([]domain.Meta, error)
+	Update(meta *domain.Meta) error
+	Delete(id string) error
+	ExisteSobreposicao(meta *domain.Meta) (bool, error)
+	GetRealizado(meta *domain.Meta, inicio, fim time.Time) (float64, error)
+}
+
+type MetaRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewMetaRepository(db *sql.DB) *MetaRepositoryImpl {
+	return &MetaRepositoryImpl{db: db}
+}
+
+const selectMetas = `SELECT m.id, m.descricao, m.tipo, m.vendedor_id, COALESCE(u.nome, ''), m.local_id, COALESCE(l.nome, ''),
+		m.categoria, m.inicio, m.fim, m.valor, m.data_criacao
+	FROM metas m
+	LEFT JOIN usuarios u ON u.id = m.vendedor_id
+	LEFT JOIN locais_estoque l ON l.id = m.local_id`
+
+func (r *MetaRepositoryImpl) Create(meta *domain.Meta) error {
+	meta.ID = utils.GenerateUUID()
+
+	query := `INSERT INTO metas (id, descricao, tipo, vendedor_id, local_id, categoria, inicio, fim, valor, data_criacao)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, meta.ID, meta.Descricao, meta.Tipo, meta.VendedorID, meta.LocalID, meta.Categoria,
+		meta.Inicio, meta.Fim, meta.Valor, meta.DataCriacao)
+	return err
+}
+
+func (r *MetaRepositoryImpl) GetByID(id string) (*domain.Meta, error) {
+	metas, err := buscarMetas(r.db, selectMetas+` WHERE m.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(metas) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &metas[0], nil
+}
+
+// GetAll lista as metas que atendem ao filtro, das mais recentes para as mais antigas
+func (r *MetaRepositoryImpl) GetAll(filtro domain.FiltroMetas) ([]domain.Meta, error) {
+	query := selectMetas + ` WHERE (? = '' OR m.tipo = ?) AND (? = '' OR m.vendedor_id = ?) AND (? = '' OR m.local_id = ?)
+			AND (? = '' OR m.categoria = ?)`
+	args := []interface{}{filtro.Tipo, filtro.Tipo, filtro.VendedorID, filtro.VendedorID, filtro.LocalID, filtro.LocalID,
+		filtro.Categoria, filtro.Categoria}
+	if filtro.Data != nil {
+		query += ` AND julianday(m.inicio) <= julianday(?) AND julianday(m.fim) >= julianday(?)`
+		args = append(args, *filtro.Data, *filtro.Data)
+	}
+	query += ` ORDER BY julianday(m.inicio) DESC, m.tipo, m.data_criacao`
+	return buscarMetas(r.db, query, args...)
+}
+
+func (r *MetaRepositoryImpl) Update(meta *domain.Meta) error {
+	query := `UPDATE metas SET descricao = ?, tipo = ?, vendedor_id = ?, local_id = ?, categoria = ?, inicio = ?, fim = ?, valor = ?
+		WHERE id = ?`
+	result, err := r.db.Exec(query, meta.Descricao, meta.Tipo, meta.VendedorID, meta.LocalID, meta.Categoria, meta.Inicio,
+		meta.Fim, meta.Valor, meta.ID)
+	if err != nil {
+		return err
+	}
+	return verificarAlteracao(result)
+}
+
+func (r *MetaRepositoryImpl) Delete(id string) error {
+	result, err := r.db.Exec(`DELETE FROM metas WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	return verificarAlteracao(result)
+}
+
+// ExisteSobreposicao informa se outra meta do mesmo tipo e alvo tem período em comum com
+// a meta informada
+func (r *MetaRepositoryImpl) ExisteSobreposicao(meta *domain.Meta) (bool, error) {
+	var existe bool
+	err := r.db.QueryRow(`SELECT EXISTS (
+			SELECT 1 FROM metas
+			WHERE id <> ? AND tipo = ? AND vendedor_id = ? AND local_id = ? AND categoria = ?
+				AND julianday(inicio) <= julianday(?) AND julianday(fim) >= julianday(?)
+		)`, meta.ID, meta.Tipo, meta.VendedorID, meta.LocalID, meta.Categoria, meta.Fim, meta.Inicio).Scan(&existe)
+	return existe, err
+}
+
+// GetRealizado soma o total dos itens das vendas confirmadas, pagas ou devolvidas no
+// período [inicio, fim) que pertencem ao alvo da meta, descontados os reembolsos
+func (r *MetaRepositoryImpl) GetRealizado(meta *domain.Meta, inicio, fim time.Time) (float64, error) {
+	query := `SELECT COALESCE(SUM(iv.total - COALESCE(dv.valor, 0)), 0)
+		FROM itens_venda iv
+		JOIN vendas v ON v.id = iv.venda_id
+		LEFT JOIN produtos p ON p.id = iv.produto_id
+		LEFT JOIN (` + reembolsoPorItem + `) dv ON dv.item_venda_id = iv.id
+		WHERE v.status IN (?, ?, ?)
+			AND julianday(v.data_venda) >= julianday(?) AND julianday(v.data_venda) < julianday(?)
+			AND (? = '' OR v.vendedor_id = ?) AND (? = '' OR v.local_id = ?) AND (? = '' OR p.categoria = ?)`
+	var realizado float64
+	err := r.db.QueryRow(query, domain.StatusConfirmada, domain.StatusPaga, domain.StatusDevolvida, inicio, fim,
+		meta.VendedorID, meta.VendedorID, meta.LocalID, meta.LocalID, meta.Categoria, meta.Categoria).Scan(&realizado)
+	return realizado, err
+}
+
+func buscarMetas(db *sql.DB, query string, args ...interface{}) ([]domain.Meta, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	metas := []domain.Meta{}
+	for rows.Next() {
+		var meta domain.Meta
+		err := rows.Scan(&meta.ID, &meta.Descricao, &meta.Tipo, &meta.VendedorID, &meta.VendedorNome, &meta.LocalID,
+			&meta.LocalNome, &meta.Categoria, &meta.Inicio, &meta.Fim, &meta.Valor, &meta.DataCriacao)
+		if err != nil {
+			return nil, err
+		}
+		metas = append(metas, meta)
+	}
+	return metas, rows.Err()
+}
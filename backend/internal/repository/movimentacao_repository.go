@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type MovimentacaoRepository interface {
+	Registrar(movimentacao *domain.MovimentacaoEstoque) error
+	GetByProduto(produtoID string) ([]domain.MovimentacaoEstoque, error)
+	GetSaldo(produtoID string) (int, error)
+	GetDivergencias() ([]domain.DivergenciaEstoque, error)
+}
+
+type MovimentacaoRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewMovimentacaoRepository(db *sql.DB) *MovimentacaoRepositoryImpl {
+	return &MovimentacaoRepositoryImpl{db: db}
+}
+
+// Registrar aplica uma movimentação avulsa no estoque do produto
+func (r *MovimentacaoRepositoryImpl) Registrar(movimentacao *domain.MovimentacaoEstoque) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := movimentarEstoque(tx, movimentacao); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *MovimentacaoRepositoryImpl) GetByProduto(produtoID string) ([]domain.MovimentacaoEstoque, error) {
+	query := `SELECT id, produto_id, local_id, tipo, quantidade, saldo_apos, custo_unitario, usuario_id, motivo, venda_id,
+			pedido_compra_id, transferencia_id, data
+		FROM movimentacoes_estoque WHERE produto_id = ? ORDER BY data, rowid`
+	rows, err := r.db.Query(query, produtoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movimentacoes []domain.MovimentacaoEstoque
+	for rows.Next() {
+		var m domain.MovimentacaoEstoque
+		var vendaID, pedidoCompraID, transferenciaID sql.NullString
+		err := rows.Scan(&m.ID, &m.ProdutoID, &m.LocalID, &m.Tipo, &m.Quantidade, &m.SaldoApos, &m.CustoUnitario, &m.UsuarioID,
+			&m.Motivo, &vendaID, &pedidoCompraID, &transferenciaID, &m.Data)
+		if err != nil {
+			return nil, err
+		}
+		m.VendaID = vendaID.String
+		m.PedidoCompraID = pedidoCompraID.String
+		m.TransferenciaID = transferenciaID.String
+		movimentacoes = append(movimentacoes, m)
+	}
+	return movimentacoes, rows.Err()
+}
+
+// GetSaldo soma as movimentações do produto
+func (r *MovimentacaoRepositoryImpl) GetSaldo(produtoID string) (int, error) {
+	var saldo int
+	err := r.db.QueryRow(`SELECT COALESCE(SUM(quantidade), 0) FROM movimentacoes_estoque WHERE produto_id = ?`, produtoID).Scan(&saldo)
+	return saldo, err
+}
+
+// GetDivergencias lista os produtos cuja quantidade não bate com a soma das movimentações
+// ou com a soma dos saldos por local, das maiores diferenças no histórico para as menores
+func (r *MovimentacaoRepositoryImpl) GetDivergencias() ([]domain.DivergenciaEstoque, error) {
+	rows, err := r.db.Query(`
+		SELECT p.id, p.nome, p.quantidade, COALESCE(m.total, 0), COALESCE(s.total, 0)
+		FROM produtos p
+		LEFT JOIN (SELECT produto_id, SUM(quantidade) AS total FROM movimentacoes_estoque GROUP BY produto_id) m
+			ON m.produto_id = p.id
+		LEFT JOIN (SELECT produto_id, SUM(quantidade) AS total FROM saldos_estoque GROUP BY produto_id) s
+			ON s.produto_id = p.id
+		WHERE p.quantidade <> COALESCE(m.total, 0) OR p.quantidade <> COALESCE(s.total, 0)
+		ORDER BY ABS(p.quantidade - COALESCE(m.total, 0)) DESC, p.nome
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	divergencias := []domain.DivergenciaEstoque{}
+	for rows.Next() {
+		var d domain.DivergenciaEstoque
+		if err := rows.Scan(&d.ProdutoID, &d.Nome, &d.Quantidade, &d.SaldoMovimentacoes, &d.SaldoLocais); err != nil {
+			return nil, err
+		}
+		d.Diferenca = d.Quantidade - d.SaldoMovimentacoes
+		divergencias = append(divergencias, d)
+	}
+	return divergencias, rows.Err()
+}
+
+// movimentarEstoque é o único ponto que altera produtos.quantidade e os saldos por local.
+// Atualiza o saldo do local (o principal quando não informado) e a quantidade total do
+// produto e grava a movimentação com o saldo resultante na mesma transação, falhando
+// quando uma saída deixaria o saldo do local negativo ou, nas vendas e transferências,
+// abaixo das reservas ativas. Entradas com custo unitário
+// recalculam o custo médio ponderado do produto antes de somar a quantidade.
+func movimentarEstoque(tx *sql.Tx, movimentacao *domain.MovimentacaoEstoque) error {
+	if movimentacao.LocalID == "" {
+		movimentacao.LocalID = domain.LocalPrincipal
+	}
+
+	var existe int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM produtos WHERE id = ?`, movimentacao.ProdutoID).Scan(&existe)
+	if err != nil {
+		return err
+	}
+	if existe == 0 {
+		return fmt.Errorf("produto %s não encontrado", movimentacao.ProdutoID)
+	}
+
+	_, err = tx.Exec(`INSERT OR IGNORE INTO saldos_estoque (local_id, produto_id, quantidade) VALUES (?, ?, 0)`,
+		movimentacao.LocalID, movimentacao.ProdutoID)
+	if err != nil {
+		return err
+	}
+
+	// Saídas de vendas e transferências não podem usar o que está reservado para outras
+	// vendas e orçamentos; as reservas da própria venda são as que ela vai consumir
+	var reservado int
+	if movimentacao.Quantidade < 0 && movimentacao.Tipo.RespeitaReservas() {
+		reservado, err = quantidadeReservada(tx, movimentacao.LocalID, movimentacao.ProdutoID,
+			domain.OrigemReserva{VendaID: movimentacao.VendaID}, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+
+	query := `UPDATE saldos_estoque SET quantidade = quantidade + ?
+		WHERE local_id = ? AND produto_id = ? AND quantidade + ? >= ?`
+	result, err := tx.Exec(query, movimentacao.Quantidade, movimentacao.LocalID, movimentacao.ProdutoID, movimentacao.Quantidade,
+		reservado)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 && reservado > 0 {
+		return fmt.Errorf("estoque insuficiente para o produto %s no local %s: %d unidades estão reservadas",
+			movimentacao.ProdutoID, movimentacao.LocalID, reservado)
+	}
+	if rows == 0 {
+		return fmt.Errorf("estoque insuficiente para o produto %s no local %s", movimentacao.ProdutoID, movimentacao.LocalID)
+	}
+
+	if movimentacao.Quantidade > 0 && movimentacao.CustoUnitario > 0 {
+		if err := atualizarCustoMedio(tx, movimentacao.ProdutoID, movimentacao.Quantidade, movimentacao.CustoUnitario); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE produtos SET quantidade = quantidade + ? WHERE id = ?`, movimentacao.Quantidade, movimentacao.ProdutoID)
+	if err != nil {
+		return err
+	}
+
+	err = tx.QueryRow(`SELECT quantidade FROM produtos WHERE id = ?`, movimentacao.ProdutoID).Scan(&movimentacao.SaldoApos)
+	if err != nil {
+		return err
+	}
+
+	movimentacao.ID = utils.GenerateUUID()
+	if movimentacao.Data.IsZero() {
+		movimentacao.Data = time.Now()
+	}
+
+	query = `INSERT INTO movimentacoes_estoque (id, produto_id, local_id, tipo, quantidade, saldo_apos, custo_unitario, usuario_id, motivo,
+			venda_id, pedido_compra_id, transferencia_id, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, movimentacao.ID, movimentacao.ProdutoID, movimentacao.LocalID, movimentacao.Tipo,
+		movimentacao.Quantidade, movimentacao.SaldoApos, movimentacao.CustoUnitario, movimentacao.UsuarioID, movimentacao.Motivo,
+		referencia(movimentacao.VendaID), referencia(movimentacao.PedidoCompraID), referencia(movimentacao.TransferenciaID),
+		movimentacao.Data)
+	return err
+}
+
+// atualizarCustoMedio pondera o custo atual do produto pela quantidade em estoque e o custo
+// da entrada pela quantidade recebida. Sem estoque ou sem custo conhecido, o custo da
+// entrada passa a ser o custo do produto.
+func atualizarCustoMedio(tx *sql.Tx, produtoID string, quantidade int, custoUnitario float64) error {
+	query := `UPDATE produtos SET custo = CASE
+			WHEN quantidade <= 0 OR custo <= 0 THEN ?
+			ELSE ROUND((quantidade * custo + ? * ?) / (quantidade + ?), 2)
+		END
+		WHERE id = ?`
+	_, err := tx.Exec(query, custoUnitario, quantidade, custoUnitario, quantidade, produtoID)
+	return err
+}
+
+// referencia grava NULL nas chaves estrangeiras opcionais não informadas
+func referencia(id string) interface{} {
+	if id == "" {
+		return nil
+	}
+	return id
+}
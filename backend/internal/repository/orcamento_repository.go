@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type OrcamentoRepository interface {
+	Create(orcamento *domain.Orcamento) error
+	GetByID(id string) (*domain.Orcamento, error)
+	GetAll(vendedorID string) ([]domain.Orcamento, error)
+	GetByCliente(clienteID string) ([]domain.Orcamento, error)
+	Cancelar(id string) (bool, error)
+}
+
+type OrcamentoRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewOrcamentoRepository(db *sql.DB) *OrcamentoRepositoryImpl {
+	return &OrcamentoRepositoryImpl{db: db}
+}
+
+const selectOrcamentos = `SELECT o.id, o.cliente_id, COALESCE(c.nome, ''), o.vendedor_id, COALESCE(u.nome, ''), o.local_id,
+		COALESCE(l.nome, ''), o.status, o.valido_ate, o.tipo_desconto, o.desconto, o.subtotal, o.valor_desconto, o.valor_total,
+		o.observacoes, o.venda_id, o.data_conversao, o.data_criacao
+	FROM orcamentos o
+	LEFT JOIN clientes c ON c.id = o.cliente_id
+	LEFT JOIN usuarios u ON u.id = o.vendedor_id
+	LEFT JOIN locais_estoque l ON l.id = o.local_id`
+
+// Create grava o orçamento e os itens em uma transação
+func (r *OrcamentoRepositoryImpl) Create(orcamento *domain.Orcamento) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	orcamento.ID = utils.GenerateUUID()
+	tipoDesconto, desconto := colunasDesconto(orcamento.Desconto)
+	query := `INSERT INTO orcamentos (id, cliente_id, vendedor_id, local_id, status, valido_ate, tipo_desconto, desconto, subtotal,
+			valor_desconto, valor_total, observacoes, data_criacao)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, orcamento.ID, orcamento.ClienteID, orcamento.VendedorID, orcamento.LocalID, orcamento.Status,
+		orcamento.ValidoAte, tipoDesconto, desconto, orcamento.Subtotal, orcamento.ValorDesconto, orcamento.ValorTotal,
+		orcamento.Observacoes, orcamento.DataCriacao)
+	if err != nil {
+		return err
+	}
+
+	for i := range orcamento.Items {
+		item := &orcamento.Items[i]
+		item.ID = utils.GenerateUUID()
+		tipoDesconto, desconto := colunasDesconto(item.Desconto)
+		_, err := tx.Exec(`INSERT INTO itens_orcamento (id, orcamento_id, produto_id, quantidade, preco_unitario, tipo_desconto,
+				desconto, subtotal, valor_desconto, total)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, item.ID, orcamento.ID, item.ProdutoID, item.Quantidade, item.PrecoUnitario,
+			tipoDesconto, desconto, item.Subtotal, item.ValorDesconto, item.Total)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *OrcamentoRepositoryImpl) GetByID(id string) (*domain.Orcamento, error) {
+	orcamentos, err := r.buscarOrcamentos(selectOrcamentos+` WHERE o.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(orcamentos) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &orcamentos[0], nil
+}
+
+// GetAll lista os orçamentos do vendedor informado ou, sem vendedor, de todos, dos mais
+// recentes para os mais antigos
+func (r *OrcamentoRepositoryImpl) GetAll(vendedorID string) ([]domain.Orcamento, error) {
+	return r.buscarOrcamentos(selectOrcamentos+` WHERE (? = '' OR o.vendedor_id = ?) ORDER BY o.data_criacao DESC`,
+		vendedorID, vendedorID)
+}
+
+// GetByCliente lista os orçamentos do cliente, dos mais recentes para os mais antigos
+func (r *OrcamentoRepositoryImpl) GetByCliente(clienteID string) ([]domain.Orcamento, error) {
+	return r.buscarOrcamentos(selectOrcamentos+` WHERE o.cliente_id = ? ORDER BY o.data_criacao DESC`, clienteID)
+}
+
+// converterOrcamento vincula o orçamento à venda gravada na mesma transação, se ele ainda
+// estiver aberto e dentro da validade. Como a venda e a conversão são gravadas juntas, duas
+// conversões simultâneas não geram duas vendas e uma venda recusada deixa o orçamento aberto.
+func converterOrcamento(tx *sql.Tx, orcamentoID, vendaID string, agora time.Time) error {
+	result, err := tx.Exec(`UPDATE orcamentos SET status = ?, venda_id = ?, data_conversao = ?
+		WHERE id = ? AND status = ? AND julianday(valido_ate, '+1 day') > julianday(?)`,
+		domain.OrcamentoConvertido, vendaID, agora, orcamentoID, domain.OrcamentoAberto, agora)
+	if err != nil {
+		return err
+	}
+	linhas, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if linhas == 0 {
+		return fmt.Errorf("%w: o orçamento acabou de ser convertido, cancelado ou venceu", domain.ErrOrcamentoIndisponivel)
+	}
+	return nil
+}
+
+// Cancelar descarta o orçamento se ele ainda estiver aberto e libera as reservas de estoque
+// dele; informa false quando o orçamento já foi convertido ou cancelado
+func (r *OrcamentoRepositoryImpl) Cancelar(id string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE orcamentos SET status = ? WHERE id = ? AND status = ?`,
+		domain.OrcamentoCancelado, id, domain.OrcamentoAberto)
+	if err != nil {
+		return false, err
+	}
+	linhas, err := result.RowsAffected()
+	if err != nil || linhas == 0 {
+		return false, err
+	}
+	if _, err := liberarReservas(tx, domain.OrigemReserva{OrcamentoID: id}, domain.ReservaLiberada, time.Now()); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func (r *OrcamentoRepositoryImpl) buscarOrcamentos(query string, args ...interface{}) ([]domain.Orcamento, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	orcamentos := []domain.Orcamento{}
+	for rows.Next() {
+		var orcamento domain.Orcamento
+		var tipoDesconto string
+		var desconto float64
+		var vendaID sql.NullString
+		var dataConversao sql.NullTime
+		err := rows.Scan(&orcamento.ID, &orcamento.ClienteID, &orcamento.ClienteNome, &orcamento.VendedorID,
+			&orcamento.VendedorNome, &orcamento.LocalID, &orcamento.LocalNome, &orcamento.Status, &orcamento.ValidoAte,
+			&tipoDesconto, &desconto, &orcamento.Subtotal, &orcamento.ValorDesconto, &orcamento.ValorTotal,
+			&orcamento.Observacoes, &vendaID, &dataConversao, &orcamento.DataCriacao)
+		if err != nil {
+			return nil, err
+		}
+		orcamento.Desconto = descontoGravado(tipoDesconto, desconto)
+		orcamento.VendaID = vendaID.String
+		if dataConversao.Valid {
+			orcamento.DataConversao = &dataConversao.Time
+		}
+		orcamentos = append(orcamentos, orcamento)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range orcamentos {
+		orcamentos[i].Items, err = r.buscarItens(orcamentos[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return orcamentos, nil
+}
+
+func (r *OrcamentoRepositoryImpl) buscarItens(orcamentoID string) ([]domain.ItemVenda, error) {
+	rows, err := r.db.Query(`
+		SELECT io.id, io.produto_id, io.quantidade, io.preco_unitario, io.tipo_desconto, io.desconto, io.subtotal,
+			io.valor_desconto, io.total, COALESCE(p.nome, 'Produto não encontrado'), COALESCE(p.descricao, '')
+		FROM itens_orcamento io
+		LEFT JOIN produtos p ON p.id = io.produto_id
+		WHERE io.orcamento_id = ?
+		ORDER BY io.rowid
+	`, orcamentoID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	itens := []domain.ItemVenda{}
+	for rows.Next() {
+		var item domain.ItemVenda
+		var tipoDesconto string
+		var desconto float64
+		produto := &domain.Produto{}
+		err := rows.Scan(&item.ID, &item.ProdutoID, &item.Quantidade, &item.PrecoUnitario, &tipoDesconto, &desconto,
+			&item.Subtotal, &item.ValorDesconto, &item.Total, &produto.Nome, &produto.Descricao)
+		if err != nil {
+			return nil, err
+		}
+		produto.ID = item.ProdutoID
+		item.Produto = produto
+		item.Desconto = descontoGravado(tipoDesconto, desconto)
+		itens = append(itens, item)
+	}
+	return itens, rows.Err()
+}
+
+// colunasDesconto separa o desconto solicitado nas colunas de tipo e valor
+func colunasDesconto(desconto *domain.Desconto) (domain.TipoDesconto, float64) {
+	if desconto == nil {
+		return "", 0
+	}
+	return desconto.Tipo, desconto.Valor
+}
+
+// descontoGravado remonta o desconto solicitado a partir das colunas de tipo e valor
+func descontoGravado(tipo string, valor float64) *domain.Desconto {
+	if valor == 0 {
+		return nil
+	}
+	return &domain.Desconto{Tipo: domain.TipoDesconto(tipo), Valor: valor}
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type PagamentoRepository interface {
+	Registrar(vendaID string, pagamentos []domain.Pagamento, usuarioID string) (bool, error)
+	GetByVenda(vendaID string) ([]domain.Pagamento, error)
+	GetSaldo(vendaID string) (*domain.SaldoVenda, error)
+}
+
+type PagamentoRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewPagamentoRepository(db *sql.DB) *PagamentoRepositoryImpl {
+	return &PagamentoRepositoryImpl{db: db}
+}
+
+// saldoVendaQuery calcula o total, o valor devolvido e o valor já pago de uma venda
+const saldoVendaQuery = `
+	SELECT v.status, v.valor_total,
+		COALESCE((SELECT SUM(d.valor_reembolso) FROM devolucoes d WHERE d.venda_id = v.id), 0),
+		COALESCE((SELECT SUM(p.valor) FROM pagamentos p WHERE p.venda_id = v.id), 0)
+	FROM vendas v
+	WHERE v.id = ?
+`
+
+// Registrar grava os pagamentos da venda. O saldo é conferido dentro da transação e,
+// quando os pagamentos quitam a venda, ela passa para o status paga.
+// Retorna true quando a venda foi quitada.
+func (r *PagamentoRepositoryImpl) Registrar(vendaID string, pagamentos []domain.Pagamento, usuarioID string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	var saldo domain.SaldoVenda
+	err = tx.QueryRow(saldoVendaQuery, vendaID).Scan(&saldo.Status, &saldo.ValorTotal, &saldo.ValorDevolvido, &saldo.ValorPago)
+	if err != nil {
+		return false, err
+	}
+	if saldo.Status != domain.StatusConfirmada {
+		return false, fmt.Errorf("%w: venda com status %s não aceita pagamentos", domain.ErrTransicaoStatusInvalida, saldo.Status)
+	}
+
+	// Vendas parceladas são recebidas pela baixa de cada parcela
+	var parcelas int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM parcelas WHERE venda_id = ?`, vendaID).Scan(&parcelas)
+	if err != nil {
+		return false, err
+	}
+	if parcelas > 0 {
+		return false, fmt.Errorf("%w: venda parcelada deve ser recebida pelas parcelas", domain.ErrTransicaoStatusInvalida)
+	}
+
+	restante := saldo.ValorTotal - saldo.ValorDevolvido - saldo.ValorPago
+	for i := range pagamentos {
+		pagamento := &pagamentos[i]
+		pagamento.ID = utils.GenerateUUID()
+		pagamento.VendaID = vendaID
+
+		query := `INSERT INTO pagamentos (id, venda_id, forma, valor, valor_recebido, troco, parcelas, codigo_autorizacao, usuario_id, data_pagamento,
+				pontos)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		_, err := tx.Exec(query, pagamento.ID, pagamento.VendaID, pagamento.Forma, pagamento.Valor, pagamento.ValorRecebido,
+			pagamento.Troco, pagamento.Parcelas, pagamento.CodigoAutorizacao, pagamento.UsuarioID, pagamento.DataPagamento,
+			pagamento.Pontos)
+		if err != nil {
+			return false, err
+		}
+		if pagamento.Forma == domain.FormaPontos {
+			if err := resgatarPontos(tx, pagamento); err != nil {
+				return false, err
+			}
+		}
+		restante -= pagamento.Valor
+	}
+
+	// Tolerância de meio centavo para diferenças de arredondamento
+	if restante < -0.005 {
+		return false, fmt.Errorf("pagamentos excedem o saldo da venda em %.2f", -restante)
+	}
+
+	quitada := restante < 0.005
+	if quitada {
+		err := mudarStatusVenda(tx, vendaID, domain.StatusConfirmada, domain.StatusPaga, usuarioID, "pagamento registrado")
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return quitada, tx.Commit()
+}
+
+// valorRecebidoVenda soma o que a venda já recebeu em dinheiro, cartão, pix ou boleto, nos
+// pagamentos à vista e nas parcelas quitadas. Os pagamentos com pontos ficam de fora porque
+// os pontos voltam ao cliente no cancelamento.
+func valorRecebidoVenda(tx *sql.Tx, vendaID string) (float64, error) {
+	var recebido float64
+	err := tx.QueryRow(`
+		SELECT COALESCE((SELECT SUM(valor) FROM pagamentos WHERE venda_id = ? AND forma <> ?), 0)
+			+ COALESCE((SELECT SUM(valor_pago) FROM parcelas WHERE venda_id = ? AND status = ?), 0)
+	`, vendaID, domain.FormaPontos, vendaID, domain.ParcelaPaga).Scan(&recebido)
+	return recebido, err
+}
+
+// resgatarPontos debita do cliente da venda os pontos usados no pagamento
+func resgatarPontos(tx *sql.Tx, pagamento *domain.Pagamento) error {
+	var clienteID string
+	if err := tx.QueryRow(`SELECT cliente_id FROM vendas WHERE id = ?`, pagamento.VendaID).Scan(&clienteID); err != nil {
+		return err
+	}
+	if _, err := consumirPontos(tx, clienteID, pagamento.Pontos, pagamento.DataPagamento, false); err != nil {
+		return err
+	}
+	return lancarPontos(tx, &domain.MovimentoPontos{
+		ClienteID:      clienteID,
+		VendaID:        pagamento.VendaID,
+		Tipo:           domain.PontosResgate,
+		Pontos:         -pagamento.Pontos,
+		Descricao:      fmt.Sprintf("pagamento de %.2f com pontos", pagamento.Valor),
+		UsuarioID:      pagamento.UsuarioID,
+		DataLancamento: pagamento.DataPagamento,
+	})
+}
+
+func (r *PagamentoRepositoryImpl) GetByVenda(vendaID string) ([]domain.Pagamento, error) {
+	query := `SELECT id, venda_id, forma, valor, valor_recebido, troco, parcelas, codigo_autorizacao, usuario_id, data_pagamento, pontos
+		FROM pagamentos WHERE venda_id = ? ORDER BY data_pagamento`
+	rows, err := r.db.Query(query, vendaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pagamentos []domain.Pagamento
+	for rows.Next() {
+		var p domain.Pagamento
+		err := rows.Scan(&p.ID, &p.VendaID, &p.Forma, &p.Valor, &p.ValorRecebido, &p.Troco, &p.Parcelas,
+			&p.CodigoAutorizacao, &p.UsuarioID, &p.DataPagamento, &p.Pontos)
+		if err != nil {
+			return nil, err
+		}
+		pagamentos = append(pagamentos, p)
+	}
+	return pagamentos, nil
+}
+
+func (r *PagamentoRepositoryImpl) GetSaldo(vendaID string) (*domain.SaldoVenda, error) {
+	saldo := &domain.SaldoVenda{VendaID: vendaID}
+	err := r.db.QueryRow(saldoVendaQuery, vendaID).Scan(&saldo.Status, &saldo.ValorTotal, &saldo.ValorDevolvido, &saldo.ValorPago)
+	if err != nil {
+		return nil, err
+	}
+	return saldo, nil
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type ParcelaRepository interface {
+	GetByID(id string) (*domain.Parcela, error)
+	GetByVenda(vendaID string) ([]domain.Parcela, error)
+	Listar(filtro domain.FiltroContasReceber) ([]domain.Parcela, error)
+	Quitar(parcela *domain.Parcela, usuarioID string) (bool, error)
+}
+
+type ParcelaRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewParcelaRepository(db *sql.DB) *ParcelaRepositoryImpl {
+	return &ParcelaRepositoryImpl{db: db}
+}
+
+const selectParcelas = `SELECT id, venda_id, cliente_id, numero, valor, data_vencimento, status, valor_pago, data_pagamento, forma_pagamento
+	FROM parcelas`
+
+func (r *ParcelaRepositoryImpl) GetByID(id string) (*domain.Parcela, error) {
+	parcelas, err := buscarParcelas(r.db, selectParcelas+` WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(parcelas) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &parcelas[0], nil
+}
+
+func (r *ParcelaRepositoryImpl) GetByVenda(vendaID string) ([]domain.Parcela, error) {
+	return buscarParcelas(r.db, selectParcelas+` WHERE venda_id = ? ORDER BY numero`, vendaID)
+}
+
+// Listar retorna as parcelas a receber, da mais antiga para a mais recente.
+// As vencidas são as parcelas em aberto com vencimento anterior à data atual.
+func (r *ParcelaRepositoryImpl) Listar(filtro domain.FiltroContasReceber) ([]domain.Parcela, error) {
+	var condicoes []string
+	var args []interface{}
+
+	if filtro.ClienteID != "" {
+		condicoes = append(condicoes, "cliente_id = ?")
+		args = append(args, filtro.ClienteID)
+	}
+	if filtro.VendedorID != "" {
+		condicoes = append(condicoes, "venda_id IN (SELECT id FROM vendas WHERE vendedor_id = ?)")
+		args = append(args, filtro.VendedorID)
+	}
+	if filtro.Status != "" {
+		condicoes = append(condicoes, "status = ?")
+		args = append(args, filtro.Status)
+	}
+	if filtro.SomenteVencidas {
+		condicoes = append(condicoes, "status = ?", "date(data_vencimento) < date('now')")
+		args = append(args, domain.ParcelaAberta)
+	}
+
+	query := selectParcelas
+	if len(condicoes) > 0 {
+		query += " WHERE " + strings.Join(condicoes, " AND ")
+	}
+	query += " ORDER BY data_vencimento, numero"
+
+	return buscarParcelas(r.db, query, args...)
+}
+
+// Quitar baixa a parcela em aberto com o valor, a forma e a data informados. Quando era a
+// última parcela em aberto, a venda confirmada passa para o status paga na mesma transação.
+// Retorna true quando a venda foi quitada.
+func (r *ParcelaRepositoryImpl) Quitar(parcela *domain.Parcela, usuarioID string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE parcelas SET status = ?, valor_pago = ?, data_pagamento = ?, forma_pagamento = ?
+		WHERE id = ? AND status = ?`
+	result, err := tx.Exec(query, domain.ParcelaPaga, parcela.ValorPago, parcela.DataPagamento, parcela.FormaPagamento,
+		parcela.ID, domain.ParcelaAberta)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rows == 0 {
+		return false, errors.New("a parcela não está mais em aberto")
+	}
+	parcela.Status = domain.ParcelaPaga
+
+	var abertas int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM parcelas WHERE venda_id = ? AND status = ?`,
+		parcela.VendaID, domain.ParcelaAberta).Scan(&abertas)
+	if err != nil {
+		return false, err
+	}
+
+	quitada := abertas == 0
+	if quitada {
+		motivo := fmt.Sprintf("parcela %d quitada", parcela.Numero)
+		err := mudarStatusVenda(tx, parcela.VendaID, domain.StatusConfirmada, domain.StatusPaga, usuarioID, motivo)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return quitada, tx.Commit()
+}
+
+func buscarParcelas(db *sql.DB, query string, args ...interface{}) ([]domain.Parcela, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var parcelas []domain.Parcela
+	for rows.Next() {
+		var p domain.Parcela
+		var dataPagamento sql.NullTime
+		err := rows.Scan(&p.ID, &p.VendaID, &p.ClienteID, &p.Numero, &p.Valor, &p.DataVencimento, &p.Status,
+			&p.ValorPago, &dataPagamento, &p.FormaPagamento)
+		if err != nil {
+			return nil, err
+		}
+		if dataPagamento.Valid {
+			p.DataPagamento = &dataPagamento.Time
+		}
+		parcelas = append(parcelas, p)
+	}
+	return parcelas, rows.Err()
+}
+
+// inserirParcelas grava as parcelas geradas para a venda
+func inserirParcelas(tx *sql.Tx, venda *domain.Venda) error {
+	for i := range venda.Parcelas {
+		parcela := &venda.Parcelas[i]
+		parcela.ID = utils.GenerateUUID()
+		parcela.VendaID = venda.ID
+		parcela.ClienteID = venda.ClienteID
+
+		query := `INSERT INTO parcelas (id, venda_id, cliente_id, numero, valor, data_vencimento, status)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`
+		_, err := tx.Exec(query, parcela.ID, parcela.VendaID, parcela.ClienteID, parcela.Numero, parcela.Valor,
+			parcela.DataVencimento, parcela.Status)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cancelarParcelasAbertas cancela o que ainda não foi recebido da venda
+func cancelarParcelasAbertas(tx *sql.Tx, vendaID string) error {
+	_, err := tx.Exec(`UPDATE parcelas SET status = ? WHERE venda_id = ? AND status = ?`,
+		domain.ParcelaCancelada, vendaID, domain.ParcelaAberta)
+	return err
+}
+
+// abaterParcelas desconta o valor reembolsado de uma devolução parcial das parcelas em
+// aberto da venda, da última para a primeira. A parcela abatida por inteiro é cancelada e,
+// quando não sobra parcela em aberto, a venda confirmada passa para o status paga.
+// O que exceder o valor em aberto é devolvido ao cliente e não altera as parcelas.
+func abaterParcelas(tx *sql.Tx, vendaID string, statusVenda domain.StatusVenda, valor float64, usuarioID, motivo string) error {
+	rows, err := tx.Query(`SELECT id, valor FROM parcelas WHERE venda_id = ? AND status = ? ORDER BY numero DESC`,
+		vendaID, domain.ParcelaAberta)
+	if err != nil {
+		return err
+	}
+	type parcelaAberta struct {
+		id    string
+		valor float64
+	}
+	var abertas []parcelaAberta
+	for rows.Next() {
+		var p parcelaAberta
+		if err := rows.Scan(&p.id, &p.valor); err != nil {
+			rows.Close()
+			return err
+		}
+		abertas = append(abertas, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(abertas) == 0 {
+		return nil
+	}
+
+	restantes := len(abertas)
+	for _, p := range abertas {
+		if valor < 0.005 {
+			break
+		}
+		abatido := math.Min(valor, p.valor)
+		novoValor := math.Round((p.valor-abatido)*100) / 100
+		if novoValor < 0.005 {
+			_, err = tx.Exec(`UPDATE parcelas SET valor = 0, status = ? WHERE id = ?`, domain.ParcelaCancelada, p.id)
+			restantes--
+		} else {
+			_, err = tx.Exec(`UPDATE parcelas SET valor = ? WHERE id = ?`, novoValor, p.id)
+		}
+		if err != nil {
+			return err
+		}
+		valor -= abatido
+	}
+
+	if restantes == 0 && statusVenda == domain.StatusConfirmada {
+		return mudarStatusVenda(tx, vendaID, domain.StatusConfirmada, domain.StatusPaga, usuarioID, "parcelas abatidas pela devolução: "+motivo)
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type PedidoCompraRepository interface {
+	Create(pedido *domain.PedidoCompra) error
+	GetByID(id string) (*domain.PedidoCompra, error)
+	GetAll(fornecedorID string, status domain.StatusPedidoCompra) ([]domain.PedidoCompra, error)
+	Receber(pedidoID string, itens []domain.RecebimentoItem, usuarioID string) (domain.StatusPedidoCompra, error)
+	Cancelar(pedidoID string) error
+	GetQuantidadesPendentes() (map[string]int, error)
+}
+
+type PedidoCompraRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewPedidoCompraRepository(db *sql.DB) *PedidoCompraRepositoryImpl {
+	return &PedidoCompraRepositoryImpl{db: db}
+}
+
+func (r *PedidoCompraRepositoryImpl) Create(pedido *domain.PedidoCompra) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Gera UUID para o pedido
+	pedido.ID = utils.GenerateUUID()
+
+	query := `INSERT INTO pedidos_compra (id, fornecedor_id, local_id, status, observacao, usuario_id, valor_total, previsao_entrega, data_pedido)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, pedido.ID, pedido.FornecedorID, pedido.LocalID, pedido.Status, pedido.Observacao, pedido.UsuarioID,
+		pedido.ValorTotal, pedido.PrevisaoEntrega, pedido.DataPedido)
+	if err != nil {
+		return err
+	}
+
+	for i := range pedido.Itens {
+		item := &pedido.Itens[i]
+		item.ID = utils.GenerateUUID()
+		item.PedidoCompraID = pedido.ID
+
+		query := `INSERT INTO itens_pedido_compra (id, pedido_compra_id, produto_id, quantidade, quantidade_recebida, custo_unitario, total)
+			VALUES (?, ?, ?, ?, ?, ?, ?)`
+		_, err := tx.Exec(query, item.ID, item.PedidoCompraID, item.ProdutoID, item.Quantidade, item.QuantidadeRecebida,
+			item.CustoUnitario, item.Total)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *PedidoCompraRepositoryImpl) GetByID(id string) (*domain.PedidoCompra, error) {
+	pedidos, err := r.buscar(`WHERE pc.id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(pedidos) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &pedidos[0], nil
+}
+
+func (r *PedidoCompraRepositoryImpl) GetAll(fornecedorID string, status domain.StatusPedidoCompra) ([]domain.PedidoCompra, error) {
+	var condicoes []string
+	var args []interface{}
+	if fornecedorID != "" {
+		condicoes = append(condicoes, "pc.fornecedor_id = ?")
+		args = append(args, fornecedorID)
+	}
+	if status != "" {
+		condicoes = append(condicoes, "pc.status = ?")
+		args = append(args, status)
+	}
+
+	var where string
+	if len(condicoes) > 0 {
+		where = "WHERE " + strings.Join(condicoes, " AND ")
+	}
+	return r.buscar(where, args...)
+}
+
+// Receber dá entrada no estoque do local de entrega das quantidades recebidas, levando o
+// custo de cada item ao custo médio dos produtos, e recalcula o status do pedido na mesma
+// transação. Retorna o novo status do pedido.
+func (r *PedidoCompraRepositoryImpl) Receber(pedidoID string, itens []domain.RecebimentoItem, usuarioID string) (domain.StatusPedidoCompra, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	var status domain.StatusPedidoCompra
+	var localID string
+	err = tx.QueryRow(`SELECT status, local_id FROM pedidos_compra WHERE id = ?`, pedidoID).Scan(&status, &localID)
+	if err != nil {
+		return "", err
+	}
+	if !status.Pendente() {
+		return "", fmt.Errorf("%w: pedido com status %s não aceita recebimentos", domain.ErrTransicaoStatusInvalida, status)
+	}
+
+	for _, item := range itens {
+		// A condição sobre o saldo pendente impede receber mais do que foi pedido
+		query := `UPDATE itens_pedido_compra SET quantidade_recebida = quantidade_recebida + ?
+			WHERE id = ? AND pedido_compra_id = ? AND quantidade_recebida + ? <= quantidade`
+		result, err := tx.Exec(query, item.Quantidade, item.ItemID, pedidoID, item.Quantidade)
+		if err != nil {
+			return "", err
+		}
+		if rows, err := result.RowsAffected(); err != nil {
+			return "", err
+		} else if rows == 0 {
+			return "", fmt.Errorf("quantidade recebida do item %s excede o saldo pendente", item.ItemID)
+		}
+
+		err = movimentarEstoque(tx, &domain.MovimentacaoEstoque{
+			ProdutoID:      item.ProdutoID,
+			LocalID:        localID,
+			Tipo:           domain.MovimentacaoEntrada,
+			Quantidade:     item.Quantidade,
+			UsuarioID:      usuarioID,
+			Motivo:         "recebimento de pedido de compra",
+			PedidoCompraID: pedidoID,
+			CustoUnitario:  item.CustoUnitario,
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var pendente int
+	err = tx.QueryRow(`SELECT COALESCE(SUM(quantidade - quantidade_recebida), 0) FROM itens_pedido_compra WHERE pedido_compra_id = ?`,
+		pedidoID).Scan(&pendente)
+	if err != nil {
+		return "", err
+	}
+
+	novo := domain.PedidoCompraParcialmenteRecebido
+	if pendente == 0 {
+		novo = domain.PedidoCompraRecebido
+	}
+	if _, err := tx.Exec(`UPDATE pedidos_compra SET status = ? WHERE id = ?`, novo, pedidoID); err != nil {
+		return "", err
+	}
+
+	return novo, tx.Commit()
+}
+
+// Cancelar encerra um pedido pendente. O que já foi recebido permanece no estoque.
+func (r *PedidoCompraRepositoryImpl) Cancelar(pedidoID string) error {
+	result, err := r.db.Exec(`UPDATE pedidos_compra SET status = ? WHERE id = ? AND status IN (?, ?)`,
+		domain.PedidoCompraCancelado, pedidoID, domain.PedidoCompraAberto, domain.PedidoCompraParcialmenteRecebido)
+	if err != nil {
+		return err
+	}
+	if err := verificarAlteracao(result); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: o pedido não está mais pendente", domain.ErrTransicaoStatusInvalida)
+		}
+		return err
+	}
+	return nil
+}
+
+// GetQuantidadesPendentes soma, por produto, o que ainda falta receber dos pedidos pendentes
+func (r *PedidoCompraRepositoryImpl) GetQuantidadesPendentes() (map[string]int, error) {
+	query := `
+		SELECT i.produto_id, SUM(i.quantidade - i.quantidade_recebida)
+		FROM itens_pedido_compra i
+		JOIN pedidos_compra pc ON pc.id = i.pedido_compra_id
+		WHERE pc.status IN (?, ?)
+		GROUP BY i.produto_id
+	`
+	rows, err := r.db.Query(query, domain.PedidoCompraAberto, domain.PedidoCompraParcialmenteRecebido)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pendentes := make(map[string]int)
+	for rows.Next() {
+		var produtoID string
+		var quantidade int
+		if err := rows.Scan(&produtoID, &quantidade); err != nil {
+			return nil, err
+		}
+		pendentes[produtoID] = quantidade
+	}
+	return pendentes, rows.Err()
+}
+
+// buscar carrega os pedidos que atendem à condição, com o fornecedor e os itens
+func (r *PedidoCompraRepositoryImpl) buscar(where string, args ...interface{}) ([]domain.PedidoCompra, error) {
+	query := `
+		SELECT pc.id, pc.fornecedor_id, pc.local_id, pc.status, pc.observacao, pc.usuario_id, pc.valor_total, pc.previsao_entrega, pc.data_pedido,
+			   COALESCE(f.nome, '') as fornecedor_nome
+		FROM pedidos_compra pc
+		LEFT JOIN fornecedores f ON f.id = pc.fornecedor_id
+		` + where + `
+		ORDER BY pc.data_pedido DESC
+	`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var pedidos []domain.PedidoCompra
+	for rows.Next() {
+		var pedido domain.PedidoCompra
+		var previsao sql.NullTime
+		var fornecedorNome string
+		err := rows.Scan(&pedido.ID, &pedido.FornecedorID, &pedido.LocalID, &pedido.Status, &pedido.Observacao, &pedido.UsuarioID,
+			&pedido.ValorTotal, &previsao, &pedido.DataPedido, &fornecedorNome)
+		if err != nil {
+			return nil, err
+		}
+		if previsao.Valid {
+			pedido.PrevisaoEntrega = &previsao.Time
+		}
+		pedido.Fornecedor = &domain.Fornecedor{ID: pedido.FornecedorID, Nome: fornecedorNome}
+		pedidos = append(pedidos, pedido)
+	}
+	rows.Close()
+
+	for i := range pedidos {
+		query := `SELECT id, pedido_compra_id, produto_id, quantidade, quantidade_recebida, custo_unitario, total
+			FROM itens_pedido_compra WHERE pedido_compra_id = ?`
+		itemRows, err := r.db.Query(query, pedidos[i].ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for itemRows.Next() {
+			var item domain.ItemPedidoCompra
+			err := itemRows.Scan(&item.ID, &item.PedidoCompraID, &item.ProdutoID, &item.Quantidade, &item.QuantidadeRecebida,
+				&item.CustoUnitario, &item.Total)
+			if err != nil {
+				itemRows.Close()
+				return nil, err
+			}
+			pedidos[i].Itens = append(pedidos[i].Itens, item)
+		}
+		itemRows.Close()
+	}
+
+	return pedidos, nil
+}
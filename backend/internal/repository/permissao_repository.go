@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"database/sql"
+	"vendas/internal/domain"
+)
+
+type PermissaoRepository interface {
+	GetMatriz() (map[domain.Role][]domain.Permissao, error)
+	SalvarRole(role domain.Role, permissoes []domain.Permissao) error
+}
+
+type PermissaoRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewPermissaoRepository(db *sql.DB) *PermissaoRepositoryImpl {
+	return &PermissaoRepositoryImpl{db: db}
+}
+
+// GetMatriz retorna as permissões gravadas para cada papel. Papéis sem nenhuma permissão
+// ficam fora do mapa.
+func (r *PermissaoRepositoryImpl) GetMatriz() (map[domain.Role][]domain.Permissao, error) {
+	rows, err := r.db.Query(`SELECT role, permissao FROM permissoes_roles ORDER BY role, permissao`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	matriz := make(map[domain.Role][]domain.Permissao)
+	for rows.Next() {
+		var role domain.Role
+		var permissao domain.Permissao
+		if err := rows.Scan(&role, &permissao); err != nil {
+			return nil, err
+		}
+		matriz[role] = append(matriz[role], permissao)
+	}
+	return matriz, rows.Err()
+}
+
+// SalvarRole substitui as permissões do papel em uma transação
+func (r *PermissaoRepositoryImpl) SalvarRole(role domain.Role, permissoes []domain.Permissao) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM permissoes_roles WHERE role = ?`, role); err != nil {
+		return err
+	}
+	for _, permissao := range permissoes {
+		_, err := tx.Exec(`INSERT OR IGNORE INTO permissoes_roles (role, permissao) VALUES (?, ?)`, role, permissao)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"vendas/internal/domain"
+)
+
+// O banco novo já sai com a matriz padrão, e a alteração de um papel não mexe nos demais
+func TestMatrizPermissoes(t *testing.T) {
+	repo := NewPermissaoRepository(bancoDeTeste(t))
+
+	matriz, err := repo.GetMatriz()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, role := range domain.Roles {
+		if !mesmasPermissoes(matriz[role], domain.PermissoesPadrao[role]) {
+			t.Errorf("permissões gravadas de %s = %v, esperado %v", role, matriz[role], domain.PermissoesPadrao[role])
+		}
+	}
+
+	vendedor := []domain.Permissao{domain.PermVendasLer, domain.PermVendasRegistrar}
+	if err := repo.SalvarRole(domain.RoleVendedor, vendedor); err != nil {
+		t.Fatal(err)
+	}
+	matriz, err = repo.GetMatriz()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !mesmasPermissoes(matriz[domain.RoleVendedor], vendedor) {
+		t.Errorf("permissões de vendedor = %v, esperado %v", matriz[domain.RoleVendedor], vendedor)
+	}
+	if !mesmasPermissoes(matriz[domain.RoleAdmin], domain.PermissoesPadrao[domain.RoleAdmin]) {
+		t.Errorf("alterar vendedor mudou as permissões de admin: %v", matriz[domain.RoleAdmin])
+	}
+}
+
+func mesmasPermissoes(a, b []domain.Permissao) bool {
+	ordenar := func(p []domain.Permissao) []domain.Permissao {
+		copia := append([]domain.Permissao(nil), p...)
+		sort.Slice(copia, func(i, j int) bool { return copia[i] < copia[j] })
+		return copia
+	}
+	return reflect.DeepEqual(ordenar(a), ordenar(b))
+}
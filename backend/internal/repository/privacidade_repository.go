@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+	"vendas/internal/domain"
+)
+
+type PrivacidadeRepository interface {
+	AnonimizarCliente(cliente *domain.Cliente, registro *domain.RegistroAuditoria) error
+	AnonimizarUsuario(usuarioID string, registro *domain.RegistroAuditoria) error
+}
+
+type PrivacidadeRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewPrivacidadeRepository(db *sql.DB) *PrivacidadeRepositoryImpl {
+	return &PrivacidadeRepositoryImpl{db: db}
+}
+
+// AnonimizarCliente substitui os dados pessoais do cliente, remove os seus endereços e a
+// sua segmentação, apaga o cadastro dos duplicados guardado nas mesclagens e as observações
+// dos orçamentos e anonimiza o usuário vinculado, registrando a operação na auditoria. As
+// vendas e os orçamentos continuam apontando para o cliente, preservando os registros
+// fiscais. CNPJ e inscrição estadual identificam a empresa, não uma pessoa, e são mantidos.
+func (r *PrivacidadeRepositoryImpl) AnonimizarCliente(cliente *domain.Cliente, registro *domain.RegistroAuditoria) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`UPDATE clientes SET nome = ?, email = '', telefone = '', endereco = '', cpf = '',
+			data_anonimizacao = ?
+		WHERE id = ? AND data_anonimizacao IS NULL`, domain.NomeClienteAnonimizado, registro.Data, cliente.ID)
+	if err != nil {
+		return err
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rows == 0 {
+		return domain.ErrClienteAnonimizado
+	}
+
+	if _, err := tx.Exec(`DELETE FROM enderecos WHERE cliente_id = ?`, cliente.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM segmentos_clientes WHERE cliente_id = ?`, cliente.ID); err != nil {
+		return err
+	}
+	// Das mesclagens restam apenas os IDs e as contagens de registros transferidos
+	if _, err := tx.Exec(`UPDATE mesclagens_clientes SET dados_duplicado = json_object('id', duplicado_id)
+		WHERE cliente_id = ?`, cliente.ID); err != nil {
+		return err
+	}
+	// As observações dos orçamentos são texto livre e podem conter dados pessoais
+	if _, err := tx.Exec(`UPDATE orcamentos SET observacoes = '' WHERE cliente_id = ?`, cliente.ID); err != nil {
+		return err
+	}
+	if cliente.UsuarioID != "" {
+		if err := anonimizarUsuario(tx, cliente.UsuarioID); err != nil {
+			return err
+		}
+	}
+
+	if err := registrarAuditoria(tx, registro); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// AnonimizarUsuario substitui o nome e o email do usuário e o desativa, registrando a
+// operação na auditoria. As vendas em que ele atuou como vendedor são mantidas.
+func (r *PrivacidadeRepositoryImpl) AnonimizarUsuario(usuarioID string, registro *domain.RegistroAuditoria) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := anonimizarUsuario(tx, usuarioID); err != nil {
+		return err
+	}
+	if err := registrarAuditoria(tx, registro); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// anonimizarUsuario troca o email por um endereço inválido e único, já que o email dos
+// usuários não se repete, e apaga a senha e encerra as sessões, o que impede novos acessos
+func anonimizarUsuario(tx *sql.Tx, usuarioID string) error {
+	result, err := tx.Exec(`UPDATE usuarios SET nome = ?, email = ?, senha = '', ativo = 0 WHERE id = ?`,
+		domain.NomeUsuarioAnonimizado, "anonimizado-"+usuarioID+"@anonimizado.invalid", usuarioID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return domain.ErrUsuarioNaoEncontrado
+	}
+	return revogarSessoesUsuario(tx, usuarioID, time.Now())
+}
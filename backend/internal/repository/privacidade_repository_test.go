@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	"vendas/internal/domain"
+)
+
+// Depois da anonimização nenhum dado pessoal do cliente nem dos duplicados incorporados
+// a ele continua gravado, inclusive no registro das mesclagens e nos orçamentos
+func TestAnonimizarClienteMesclado(t *testing.T) {
+	db := bancoDeTeste(t)
+	clientes := NewClienteRepository(db)
+
+	cliente := &domain.Cliente{Nome: "Lucas F.", TipoPessoa: domain.PessoaFisica, CPF: "52998224725",
+		DataCriacao: time.Now()}
+	duplicado := &domain.Cliente{Nome: "Lucas Ferreira", Email: "lucas@cliente.com", Telefone: "(11) 98765-4321",
+		Endereco: "Rua das Flores, 10", TipoPessoa: domain.PessoaFisica, DataCriacao: time.Now()}
+	for _, c := range []*domain.Cliente{cliente, duplicado} {
+		if err := clientes.Create(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dados, err := json.Marshal(duplicado)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cliente.Email, cliente.Telefone, cliente.Endereco = duplicado.Email, duplicado.Telefone, duplicado.Endereco
+	agora := time.Now()
+	err = NewMesclagemRepository(db).Mesclar(cliente, duplicado.ID,
+		&domain.MesclagemCliente{ClienteID: cliente.ID, DuplicadoID: duplicado.ID, DadosDuplicado: dados, Data: agora},
+		&domain.RegistroAuditoria{Acao: domain.AuditoriaMesclagem, Entidade: domain.EntidadeCliente,
+			EntidadeID: cliente.ID, Detalhes: "cliente " + duplicado.ID + " incorporado ao cadastro", Data: agora})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orcamento := &domain.Orcamento{
+		ClienteID:   cliente.ID,
+		VendedorID:  "vendedor",
+		LocalID:     domain.LocalPrincipal,
+		Status:      domain.OrcamentoAberto,
+		ValidoAte:   agora.AddDate(0, 0, 7),
+		Observacoes: "entregar para Lucas Ferreira, Rua das Flores, 10",
+		DataCriacao: agora,
+		Items:       []domain.ItemVenda{{ProdutoID: produtoComEstoque(t, db, 5), Quantidade: 1, PrecoUnitario: 10}},
+	}
+	if err := NewOrcamentoRepository(db).Create(orcamento); err != nil {
+		t.Fatal(err)
+	}
+
+	err = NewPrivacidadeRepository(db).AnonimizarCliente(cliente, &domain.RegistroAuditoria{
+		Acao:       domain.AuditoriaAnonimizacao,
+		Entidade:   domain.EntidadeCliente,
+		EntidadeID: cliente.ID,
+		Detalhes:   "dados pessoais do cliente anonimizados",
+		Data:       time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gravados := map[string]string{
+		"clientes":            `SELECT nome || email || telefone || endereco || cpf FROM clientes WHERE id = ?`,
+		"mesclagens_clientes": `SELECT dados_duplicado FROM mesclagens_clientes WHERE cliente_id = ?`,
+		"auditoria":           `SELECT group_concat(detalhes) FROM auditoria WHERE entidade_id = ?`,
+		"orcamentos":          `SELECT group_concat(observacoes) FROM orcamentos WHERE cliente_id = ?`,
+	}
+	pessoais := []string{"Lucas", "lucas@cliente.com", "98765-4321", "Rua das Flores", "52998224725"}
+	for tabela, query := range gravados {
+		var texto string
+		if err := db.QueryRow(query, cliente.ID).Scan(&texto); err != nil {
+			t.Fatalf("%s: %v", tabela, err)
+		}
+		for _, dado := range pessoais {
+			if strings.Contains(texto, dado) {
+				t.Errorf("%s ainda guarda %q depois da anonimização: %s", tabela, dado, texto)
+			}
+		}
+	}
+
+	mesclagens, err := NewMesclagemRepository(db).GetByCliente(cliente.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mesclagens) != 1 || mesclagens[0].DuplicadoID != duplicado.ID {
+		t.Errorf("mesclagens depois da anonimização = %+v, esperado a do duplicado %s", mesclagens, duplicado.ID)
+	}
+}
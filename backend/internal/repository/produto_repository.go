@@ -7,13 +7,26 @@ import (
 )
 
 type ProdutoRepository interface {
-	Create(produto *domain.Produto) error
+	Create(produto *domain.Produto, usuarioID string) error
 	GetByID(id string) (*domain.Produto, error)
 	GetAll() ([]domain.Produto, error)
 	Update(produto *domain.Produto) error
 	Delete(id string) error
 }
 
+// colunasProduto lista as colunas lidas por scanProduto, na mesma ordem
+const colunasProduto = `id, nome, descricao, preco, custo, quantidade, estoque_minimo, estoque_maximo, prazo_entrega_dias, data_criacao, categoria`
+
+// scanner é atendido por *sql.Row e *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanProduto(row scanner, produto *domain.Produto) error {
+	return row.Scan(&produto.ID, &produto.Nome, &produto.Descricao, &produto.Preco, &produto.Custo, &produto.Quantidade,
+		&produto.EstoqueMinimo, &produto.EstoqueMaximo, &produto.PrazoEntregaDias, &produto.DataCriacao, &produto.Categoria)
+}
+
 type ProdutoRepositoryImpl struct {
 	db *sql.DB
 }
@@ -22,31 +35,55 @@ func NewProdutoRepository(db *sql.DB) *ProdutoRepositoryImpl {
 	return &ProdutoRepositoryImpl{db: db}
 }
 
-func (r *ProdutoRepositoryImpl) Create(produto *domain.Produto) error {
+// Create grava o produto com estoque zerado e lança a quantidade inicial como entrada
+// pelo custo informado
+func (r *ProdutoRepositoryImpl) Create(produto *domain.Produto, usuarioID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// Gera UUID para o produto
 	produto.ID = utils.GenerateUUID()
 
-	query := `INSERT INTO produtos (id, nome, descricao, preco, quantidade, data_criacao) VALUES (?, ?, ?, ?, ?, ?)`
-	_, err := r.db.Exec(query, produto.ID, produto.Nome, produto.Descricao, produto.Preco, produto.Quantidade, produto.DataCriacao)
+	query := `INSERT INTO produtos (id, nome, descricao, preco, custo, quantidade, estoque_minimo, estoque_maximo, prazo_entrega_dias, data_criacao,
+			categoria)
+		VALUES (?, ?, ?, ?, ?, 0, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, produto.ID, produto.Nome, produto.Descricao, produto.Preco, produto.Custo,
+		produto.EstoqueMinimo, produto.EstoqueMaximo, produto.PrazoEntregaDias, produto.DataCriacao, produto.Categoria)
 	if err != nil {
 		return err
 	}
 
-	return nil
+	if produto.Quantidade > 0 {
+		err := movimentarEstoque(tx, &domain.MovimentacaoEstoque{
+			ProdutoID:     produto.ID,
+			Tipo:          domain.MovimentacaoEntrada,
+			Quantidade:    produto.Quantidade,
+			UsuarioID:     usuarioID,
+			Motivo:        "saldo inicial",
+			CustoUnitario: produto.Custo,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 func (r *ProdutoRepositoryImpl) GetByID(id string) (*domain.Produto, error) {
 	produto := &domain.Produto{}
-	query := `SELECT id, nome, descricao, preco, quantidade, data_criacao FROM produtos WHERE id = ?`
-	err := r.db.QueryRow(query, id).Scan(&produto.ID, &produto.Nome, &produto.Descricao, &produto.Preco, &produto.Quantidade, &produto.DataCriacao)
-	if err != nil {
+	query := `SELECT ` + colunasProduto + ` FROM produtos WHERE id = ?`
+	if err := scanProduto(r.db.QueryRow(query, id), produto); err != nil {
 		return nil, err
 	}
 	return produto, nil
 }
 
 func (r *ProdutoRepositoryImpl) GetAll() ([]domain.Produto, error) {
-	query := `SELECT id, nome, descricao, preco, quantidade, data_criacao FROM produtos`
+	query := `SELECT ` + colunasProduto + ` FROM produtos`
 	rows, err := r.db.Query(query)
 	if err != nil {
 		return nil, err
@@ -56,8 +93,7 @@ func (r *ProdutoRepositoryImpl) GetAll() ([]domain.Produto, error) {
 	var produtos []domain.Produto
 	for rows.Next() {
 		var produto domain.Produto
-		err := rows.Scan(&produto.ID, &produto.Nome, &produto.Descricao, &produto.Preco, &produto.Quantidade, &produto.DataCriacao)
-		if err != nil {
+		if err := scanProduto(rows, &produto); err != nil {
 			return nil, err
 		}
 		produtos = append(produtos, produto)
@@ -65,9 +101,13 @@ func (r *ProdutoRepositoryImpl) GetAll() ([]domain.Produto, error) {
 	return produtos, nil
 }
 
+// Update altera os dados cadastrais do produto. A quantidade e o custo médio só mudam
+// por movimentações de estoque.
 func (r *ProdutoRepositoryImpl) Update(produto *domain.Produto) error {
-	query := `UPDATE produtos SET nome = ?, descricao = ?, preco = ?, quantidade = ? WHERE id = ?`
-	_, err := r.db.Exec(query, produto.Nome, produto.Descricao, produto.Preco, produto.Quantidade, produto.ID)
+	query := `UPDATE produtos SET nome = ?, descricao = ?, preco = ?, estoque_minimo = ?, estoque_maximo = ?, prazo_entrega_dias = ?,
+		categoria = ? WHERE id = ?`
+	_, err := r.db.Exec(query, produto.Nome, produto.Descricao, produto.Preco,
+		produto.EstoqueMinimo, produto.EstoqueMaximo, produto.PrazoEntregaDias, produto.Categoria, produto.ID)
 	return err
 }
 
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type ReservaRepository interface {
+	Reservar(origem domain.OrigemReserva, reservas []domain.ReservaEstoque, agora time.Time) error
+	GetAtivas(filtro domain.FiltroReservas, agora time.Time) ([]domain.ReservaEstoque, error)
+	GetPorOrigem(origem domain.OrigemReserva, agora time.Time) ([]domain.ReservaEstoque, error)
+	Liberar(origem domain.OrigemReserva, agora time.Time) (int64, error)
+	ExpirarVencidas(agora time.Time) (int64, error)
+}
+
+type ReservaRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewReservaRepository(db *sql.DB) *ReservaRepositoryImpl {
+	return &ReservaRepositoryImpl{db: db}
+}
+
+// condicaoReservaAtiva seleciona as reservas que ainda seguram estoque: ativas e não vencidas.
+// Depende do instante da consulta como argumento.
+const condicaoReservaAtiva = `r.status = 'ativa' AND julianday(r.expira_em) > julianday(?)`
+
+// condicaoOrigem seleciona as reservas da venda ou do orçamento informados. Depende dos
+// argumentos venda, venda, orçamento e orçamento.
+const condicaoOrigem = `((? <> '' AND COALESCE(r.venda_id, '') = ?) OR (? <> '' AND COALESCE(r.orcamento_id, '') = ?))`
+
+const selectReservas = `SELECT r.id, r.local_id, r.produto_id, COALESCE(p.nome, ''), r.quantidade, COALESCE(r.venda_id, ''),
+		COALESCE(r.orcamento_id, ''), r.usuario_id, r.status, r.data_criacao, r.expira_em, r.data_liberacao
+	FROM reservas_estoque r
+	LEFT JOIN produtos p ON p.id = r.produto_id`
+
+// Reservar substitui as reservas ativas da origem pelas informadas em uma transação,
+// falhando com domain.ErrEstoqueIndisponivel quando o saldo do local, descontadas as
+// reservas de outras origens, não atende a algum produto. As reservas anteriores são
+// liberadas primeiro, o que também bloqueia o banco para outras reservas até o fim.
+func (r *ReservaRepositoryImpl) Reservar(origem domain.OrigemReserva, reservas []domain.ReservaEstoque, agora time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := liberarReservas(tx, origem, domain.ReservaLiberada, agora); err != nil {
+		return err
+	}
+
+	for i := range reservas {
+		reserva := &reservas[i]
+		disponivel, err := estoqueDisponivel(tx, reserva.LocalID, reserva.ProdutoID, origem, agora)
+		if err != nil {
+			return err
+		}
+		if disponivel < reserva.Quantidade {
+			return fmt.Errorf("%w: produto %s no local %s. Disponível: %d, Solicitado: %d", domain.ErrEstoqueIndisponivel,
+				reserva.ProdutoID, reserva.LocalID, disponivel, reserva.Quantidade)
+		}
+
+		reserva.ID = utils.GenerateUUID()
+		reserva.VendaID = origem.VendaID
+		reserva.OrcamentoID = origem.OrcamentoID
+		reserva.Status = domain.ReservaAtiva
+		reserva.DataCriacao = agora
+		_, err = tx.Exec(`INSERT INTO reservas_estoque (id, local_id, produto_id, quantidade, venda_id, orcamento_id, usuario_id,
+				status, data_criacao, expira_em)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`, reserva.ID, reserva.LocalID, reserva.ProdutoID, reserva.Quantidade,
+			referencia(reserva.VendaID), referencia(reserva.OrcamentoID), reserva.UsuarioID, reserva.Status,
+			reserva.DataCriacao, reserva.ExpiraEm)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetAtivas lista as reservas que ainda seguram estoque, das que vencem primeiro para as últimas
+func (r *ReservaRepositoryImpl) GetAtivas(filtro domain.FiltroReservas, agora time.Time) ([]domain.ReservaEstoque, error) {
+	query := selectReservas + ` WHERE ` + condicaoReservaAtiva + ` AND (? = '' OR r.local_id = ?) AND (? = '' OR r.produto_id = ?)
+		ORDER BY julianday(r.expira_em)`
+	return buscarReservas(r.db, query, agora, filtro.LocalID, filtro.LocalID, filtro.ProdutoID, filtro.ProdutoID)
+}
+
+// GetPorOrigem lista as reservas ativas da venda ou do orçamento
+func (r *ReservaRepositoryImpl) GetPorOrigem(origem domain.OrigemReserva, agora time.Time) ([]domain.ReservaEstoque, error) {
+	query := selectReservas + ` WHERE ` + condicaoReservaAtiva + ` AND ` + condicaoOrigem + ` ORDER BY p.nome`
+	return buscarReservas(r.db, query, agora, origem.VendaID, origem.VendaID, origem.OrcamentoID, origem.OrcamentoID)
+}
+
+// Liberar desfaz as reservas ativas da venda ou do orçamento e informa quantas foram liberadas
+func (r *ReservaRepositoryImpl) Liberar(origem domain.OrigemReserva, agora time.Time) (int64, error) {
+	return liberarReservas(r.db, origem, domain.ReservaLiberada, agora)
+}
+
+// ExpirarVencidas marca como expiradas as reservas ativas que já venceram. As reservas
+// vencidas deixam de segurar estoque na hora; a marcação apenas registra o fim delas.
+func (r *ReservaRepositoryImpl) ExpirarVencidas(agora time.Time) (int64, error) {
+	result, err := r.db.Exec(`UPDATE reservas_estoque SET status = ?, data_liberacao = expira_em
+		WHERE status = ? AND julianday(expira_em) <= julianday(?)`, domain.ReservaExpirada, domain.ReservaAtiva, agora)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// executor é atendido tanto por *sql.DB quanto por *sql.Tx
+type executor interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// liberarReservas encerra com o status informado as reservas ativas da origem
+func liberarReservas(db executor, origem domain.OrigemReserva, status domain.StatusReserva, agora time.Time) (int64, error) {
+	result, err := db.Exec(`UPDATE reservas_estoque AS r SET status = ?, data_liberacao = ?
+		WHERE r.status = ? AND `+condicaoOrigem, status, agora, domain.ReservaAtiva,
+		origem.VendaID, origem.VendaID, origem.OrcamentoID, origem.OrcamentoID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// quantidadeReservada soma as reservas que seguram o produto no local, exceto as da
+// própria origem
+func quantidadeReservada(db consulta, localID, produtoID string, propria domain.OrigemReserva, agora time.Time) (int, error) {
+	var reservado int
+	err := db.QueryRow(`SELECT COALESCE(SUM(r.quantidade), 0) FROM reservas_estoque r
+		WHERE r.local_id = ? AND r.produto_id = ? AND `+condicaoReservaAtiva+` AND NOT `+condicaoOrigem,
+		localID, produtoID, agora, propria.VendaID, propria.VendaID, propria.OrcamentoID, propria.OrcamentoID).Scan(&reservado)
+	return reservado, err
+}
+
+// estoqueDisponivel é o saldo do produto no local menos as reservas de outras origens
+func estoqueDisponivel(db consulta, localID, produtoID string, propria domain.OrigemReserva, agora time.Time) (int, error) {
+	var saldo int
+	err := db.QueryRow(`SELECT COALESCE(SUM(quantidade), 0) FROM saldos_estoque WHERE local_id = ? AND produto_id = ?`,
+		localID, produtoID).Scan(&saldo)
+	if err != nil {
+		return 0, err
+	}
+	reservado, err := quantidadeReservada(db, localID, produtoID, propria, agora)
+	if err != nil {
+		return 0, err
+	}
+	return saldo - reservado, nil
+}
+
+func buscarReservas(db *sql.DB, query string, args ...interface{}) ([]domain.ReservaEstoque, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reservas := []domain.ReservaEstoque{}
+	for rows.Next() {
+		var reserva domain.ReservaEstoque
+		var dataLiberacao sql.NullTime
+		err := rows.Scan(&reserva.ID, &reserva.LocalID, &reserva.ProdutoID, &reserva.ProdutoNome, &reserva.Quantidade,
+			&reserva.VendaID, &reserva.OrcamentoID, &reserva.UsuarioID, &reserva.Status, &reserva.DataCriacao,
+			&reserva.ExpiraEm, &dataLiberacao)
+		if err != nil {
+			return nil, err
+		}
+		if dataLiberacao.Valid {
+			reserva.DataLiberacao = &dataLiberacao.Time
+		}
+		reservas = append(reservas, reserva)
+	}
+	return reservas, rows.Err()
+}
@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+	"vendas/internal/domain"
+)
+
+// produtoComEstoque cadastra um produto com a quantidade informada no local principal
+func produtoComEstoque(t *testing.T, db *sql.DB, quantidade int) string {
+	t.Helper()
+	produto := &domain.Produto{Nome: "Produto", Preco: 10, Quantidade: quantidade, DataCriacao: time.Now()}
+	if err := NewProdutoRepository(db).Create(produto, "admin"); err != nil {
+		t.Fatal(err)
+	}
+	return produto.ID
+}
+
+func reserva(produtoID string, quantidade int, expiraEm time.Time) []domain.ReservaEstoque {
+	return []domain.ReservaEstoque{{
+		LocalID:    domain.LocalPrincipal,
+		ProdutoID:  produtoID,
+		Quantidade: quantidade,
+		UsuarioID:  "vendedor",
+		ExpiraEm:   expiraEm,
+	}}
+}
+
+func disponivel(t *testing.T, db *sql.DB, produtoID string, propria domain.OrigemReserva) int {
+	t.Helper()
+	quantidade, err := NewLocalRepository(db).GetDisponivel(domain.LocalPrincipal, produtoID, propria)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return quantidade
+}
+
+func TestReservarDescontaDoDisponivel(t *testing.T) {
+	db := bancoDeTeste(t)
+	repo := NewReservaRepository(db)
+	produtoID := produtoComEstoque(t, db, 10)
+	agora := time.Now()
+	vendaA := domain.OrigemReserva{VendaID: "venda-a"}
+	vendaB := domain.OrigemReserva{VendaID: "venda-b"}
+	orcamento := domain.OrigemReserva{OrcamentoID: "orcamento-a"}
+
+	if err := repo.Reservar(vendaA, reserva(produtoID, 6, agora.Add(time.Hour)), agora); err != nil {
+		t.Fatal(err)
+	}
+
+	casos := []struct {
+		nome     string
+		origem   domain.OrigemReserva
+		esperado int
+	}{
+		{"sem origem", domain.OrigemReserva{}, 4},
+		{"a própria venda", vendaA, 10},
+		{"outra venda", vendaB, 4},
+		{"um orçamento", orcamento, 4},
+	}
+	for _, caso := range casos {
+		if obtido := disponivel(t, db, produtoID, caso.origem); obtido != caso.esperado {
+			t.Errorf("disponível para %s = %d, esperado %d", caso.nome, obtido, caso.esperado)
+		}
+	}
+
+	// Outra origem não consegue reservar o que já está reservado
+	err := repo.Reservar(vendaB, reserva(produtoID, 5, agora.Add(time.Hour)), agora)
+	if !errors.Is(err, domain.ErrEstoqueIndisponivel) {
+		t.Fatalf("reserva acima do disponível retornou %v, esperado %v", err, domain.ErrEstoqueIndisponivel)
+	}
+	if err := repo.Reservar(orcamento, reserva(produtoID, 4, agora.Add(time.Hour)), agora); err != nil {
+		t.Fatalf("reserva do restante: %v", err)
+	}
+	if obtido := disponivel(t, db, produtoID, domain.OrigemReserva{}); obtido != 0 {
+		t.Errorf("disponível depois das duas reservas = %d, esperado 0", obtido)
+	}
+}
+
+// Reservar de novo substitui as reservas da origem em vez de somar a elas
+func TestReservarSubstituiReservasDaOrigem(t *testing.T) {
+	db := bancoDeTeste(t)
+	repo := NewReservaRepository(db)
+	produtoID := produtoComEstoque(t, db, 10)
+	agora := time.Now()
+	venda := domain.OrigemReserva{VendaID: "venda-a"}
+
+	if err := repo.Reservar(venda, reserva(produtoID, 8, agora.Add(time.Hour)), agora); err != nil {
+		t.Fatal(err)
+	}
+	// A nova reserva cabe porque a anterior da mesma venda é liberada antes
+	if err := repo.Reservar(venda, reserva(produtoID, 10, agora.Add(time.Hour)), agora); err != nil {
+		t.Fatalf("nova reserva da mesma venda: %v", err)
+	}
+
+	reservas, err := repo.GetPorOrigem(venda, agora)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reservas) != 1 || reservas[0].Quantidade != 10 {
+		t.Errorf("reservas ativas da venda = %+v, esperado uma reserva de 10", reservas)
+	}
+	if obtido := disponivel(t, db, produtoID, domain.OrigemReserva{}); obtido != 0 {
+		t.Errorf("disponível = %d, esperado 0", obtido)
+	}
+}
+
+func TestReservasLiberadasEVencidasNaoSeguramEstoque(t *testing.T) {
+	db := bancoDeTeste(t)
+	repo := NewReservaRepository(db)
+	produtoID := produtoComEstoque(t, db, 10)
+	agora := time.Now()
+	liberada := domain.OrigemReserva{VendaID: "venda-liberada"}
+	vencida := domain.OrigemReserva{OrcamentoID: "orcamento-vencido"}
+
+	if err := repo.Reservar(liberada, reserva(produtoID, 3, agora.Add(time.Hour)), agora); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Reservar(vencida, reserva(produtoID, 5, agora.Add(time.Minute)), agora); err != nil {
+		t.Fatal(err)
+	}
+	if obtido := disponivel(t, db, produtoID, domain.OrigemReserva{}); obtido != 2 {
+		t.Fatalf("disponível com as duas reservas = %d, esperado 2", obtido)
+	}
+
+	liberadas, err := repo.Liberar(liberada, agora)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if liberadas != 1 {
+		t.Errorf("%d reservas liberadas, esperado 1", liberadas)
+	}
+
+	// A reserva vencida deixa de contar assim que o prazo passa, antes mesmo de expirada
+	depois := agora.Add(2 * time.Minute)
+	ativas, err := repo.GetAtivas(domain.FiltroReservas{ProdutoID: produtoID}, depois)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ativas) != 0 {
+		t.Errorf("reservas ativas depois do prazo = %+v, esperado nenhuma", ativas)
+	}
+	expiradas, err := repo.ExpirarVencidas(depois)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expiradas != 1 {
+		t.Errorf("%d reservas expiradas, esperado 1", expiradas)
+	}
+	if obtido := disponivel(t, db, produtoID, domain.OrigemReserva{}); obtido != 10 {
+		t.Errorf("disponível depois de liberar e expirar = %d, esperado 10", obtido)
+	}
+}
+
+// Saídas de vendas e transferências respeitam as reservas de outras origens; ajustes
+// de inventário não
+func TestMovimentarEstoqueRespeitaReservas(t *testing.T) {
+	db := bancoDeTeste(t)
+	produtoID := produtoComEstoque(t, db, 10)
+	agora := time.Now()
+	if err := NewReservaRepository(db).Reservar(domain.OrigemReserva{VendaID: "venda-a"},
+		reserva(produtoID, 7, agora.Add(time.Hour)), agora); err != nil {
+		t.Fatal(err)
+	}
+	movimentacoes := NewMovimentacaoRepository(db)
+
+	casos := []struct {
+		nome       string
+		tipo       domain.TipoMovimentacao
+		quantidade int
+		vendaID    string
+		aceita     bool
+	}{
+		{"transferência acima do livre", domain.MovimentacaoTransferenciaSaida, -4, "", false},
+		{"venda de outra origem acima do livre", domain.MovimentacaoSaidaVenda, -4, "venda-b", false},
+		{"ajuste de inventário", domain.MovimentacaoAjuste, -1, "", true},
+		{"venda dona da reserva", domain.MovimentacaoSaidaVenda, -7, "venda-a", true},
+	}
+	for _, caso := range casos {
+		err := movimentacoes.Registrar(&domain.MovimentacaoEstoque{
+			ProdutoID:  produtoID,
+			Tipo:       caso.tipo,
+			Quantidade: caso.quantidade,
+			UsuarioID:  "admin",
+			VendaID:    caso.vendaID,
+		})
+		if caso.aceita && err != nil {
+			t.Errorf("%s: %v", caso.nome, err)
+		}
+		if !caso.aceita && err == nil {
+			t.Errorf("%s: saída aceita, esperado erro", caso.nome)
+		}
+	}
+
+	saldo, err := NewLocalRepository(db).GetSaldo(domain.LocalPrincipal, produtoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saldo != 2 {
+		t.Errorf("saldo final = %d, esperado 2", saldo)
+	}
+}
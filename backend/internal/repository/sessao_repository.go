@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type SessaoRepository interface {
+	Criar(token *domain.RefreshToken) error
+	GetByHash(hash string) (*domain.RefreshToken, error)
+	Rotacionar(anteriorID string, novo *domain.RefreshToken) error
+	RevogarSessao(sessaoID string, quando time.Time) error
+	RevogarUsuario(usuarioID string, quando time.Time) error
+	SessaoAtiva(usuarioID, sessaoID string) (bool, error)
+	RemoverVencidos(antes time.Time) (int64, error)
+}
+
+type SessaoRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewSessaoRepository(db *sql.DB) *SessaoRepositoryImpl {
+	return &SessaoRepositoryImpl{db: db}
+}
+
+func (r *SessaoRepositoryImpl) Criar(token *domain.RefreshToken) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := inserirRefreshToken(tx, token); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *SessaoRepositoryImpl) GetByHash(hash string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	var substituidoPor sql.NullString
+	var revogadoEm sql.NullTime
+	err := r.db.QueryRow(`SELECT id, usuario_id, sessao_id, token_hash, data_criacao, expira_em, substituido_por, revogado_em
+		FROM refresh_tokens
+		WHERE token_hash = ?`, hash).Scan(&token.ID, &token.UsuarioID, &token.SessaoID, &token.TokenHash,
+		&token.DataCriacao, &token.ExpiraEm, &substituidoPor, &revogadoEm)
+	if err != nil {
+		return nil, err
+	}
+	token.SubstituidoPor = substituidoPor.String
+	if revogadoEm.Valid {
+		token.RevogadoEm = &revogadoEm.Time
+	}
+	return &token, nil
+}
+
+// Rotacionar marca o token anterior como substituído e grava o novo na mesma transação. Se
+// o anterior já tiver sido substituído ou revogado, por exemplo por uma renovação
+// concorrente com o mesmo token, nada é gravado e o reuso é informado.
+func (r *SessaoRepositoryImpl) Rotacionar(anteriorID string, novo *domain.RefreshToken) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	novo.ID = utils.GenerateUUID()
+	result, err := tx.Exec(`UPDATE refresh_tokens SET substituido_por = ?
+		WHERE id = ? AND substituido_por IS NULL AND revogado_em IS NULL`, novo.ID, anteriorID)
+	if err != nil {
+		return err
+	}
+	alterados, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if alterados == 0 {
+		return domain.ErrRefreshTokenReutilizado
+	}
+
+	if err := inserirRefreshToken(tx, novo); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RevogarSessao revoga todos os refresh tokens da sessão, o que também invalida os access
+// tokens emitidos por ela
+func (r *SessaoRepositoryImpl) RevogarSessao(sessaoID string, quando time.Time) error {
+	_, err := r.db.Exec(`UPDATE refresh_tokens SET revogado_em = ? WHERE sessao_id = ? AND revogado_em IS NULL`,
+		quando, sessaoID)
+	return err
+}
+
+// RevogarUsuario encerra todas as sessões do usuário
+func (r *SessaoRepositoryImpl) RevogarUsuario(usuarioID string, quando time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := revogarSessoesUsuario(tx, usuarioID, quando); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SessaoAtiva informa se a sessão pertence ao usuário e ainda tem algum refresh token não
+// revogado
+func (r *SessaoRepositoryImpl) SessaoAtiva(usuarioID, sessaoID string) (bool, error) {
+	var ativa bool
+	err := r.db.QueryRow(`SELECT EXISTS (
+			SELECT 1 FROM refresh_tokens WHERE sessao_id = ? AND usuario_id = ? AND revogado_em IS NULL
+		)`, sessaoID, usuarioID).Scan(&ativa)
+	return ativa, err
+}
+
+// RemoverVencidos apaga os refresh tokens vencidos antes da data informada. Um token vencido
+// não renova mais a sessão, então guardá-lo só serviria para detectar reuso.
+func (r *SessaoRepositoryImpl) RemoverVencidos(antes time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM refresh_tokens WHERE expira_em < ?`, antes)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func inserirRefreshToken(tx *sql.Tx, token *domain.RefreshToken) error {
+	if token.ID == "" {
+		token.ID = utils.GenerateUUID()
+	}
+	_, err := tx.Exec(`INSERT INTO refresh_tokens (id, usuario_id, sessao_id, token_hash, data_criacao, expira_em)
+		VALUES (?, ?, ?, ?, ?, ?)`,
+		token.ID, token.UsuarioID, token.SessaoID, token.TokenHash, token.DataCriacao, token.ExpiraEm)
+	return err
+}
+
+// revogarSessoesUsuario revoga os refresh tokens ainda válidos do usuário, encerrando todas
+// as suas sessões
+func revogarSessoesUsuario(tx *sql.Tx, usuarioID string, quando time.Time) error {
+	_, err := tx.Exec(`UPDATE refresh_tokens SET revogado_em = ? WHERE usuario_id = ? AND revogado_em IS NULL`,
+		quando, usuarioID)
+	return err
+}
@@ -0,0 +1,251 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/utils"
+)
+
+type TransferenciaRepository interface {
+	Create(transferencia *domain.Transferencia) error
+	GetByID(id string) (*domain.Transferencia, error)
+	GetAll(status domain.StatusTransferencia) ([]domain.Transferencia, error)
+	Receber(id, usuarioID string) error
+	Cancelar(id, usuarioID string) error
+	GetQuantidadesEmTransito() (map[string]int, error)
+}
+
+type TransferenciaRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewTransferenciaRepository(db *sql.DB) *TransferenciaRepositoryImpl {
+	return &TransferenciaRepositoryImpl{db: db}
+}
+
+// Create grava a transferência e retira os itens do estoque da origem na mesma transação.
+// Até o recebimento as quantidades ficam em trânsito, fora do saldo de qualquer local.
+func (r *TransferenciaRepositoryImpl) Create(transferencia *domain.Transferencia) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Gera UUID para a transferência
+	transferencia.ID = utils.GenerateUUID()
+
+	query := `INSERT INTO transferencias (id, origem_id, destino_id, status, observacao, usuario_id, data_envio)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, transferencia.ID, transferencia.OrigemID, transferencia.DestinoID, transferencia.Status,
+		transferencia.Observacao, transferencia.UsuarioID, transferencia.DataEnvio)
+	if err != nil {
+		return err
+	}
+
+	for i := range transferencia.Itens {
+		item := &transferencia.Itens[i]
+		item.ID = utils.GenerateUUID()
+		item.TransferenciaID = transferencia.ID
+
+		query := `INSERT INTO itens_transferencia (id, transferencia_id, produto_id, quantidade) VALUES (?, ?, ?, ?)`
+		_, err := tx.Exec(query, item.ID, item.TransferenciaID, item.ProdutoID, item.Quantidade)
+		if err != nil {
+			return err
+		}
+
+		err = movimentarEstoque(tx, &domain.MovimentacaoEstoque{
+			ProdutoID:       item.ProdutoID,
+			LocalID:         transferencia.OrigemID,
+			Tipo:            domain.MovimentacaoTransferenciaSaida,
+			Quantidade:      -item.Quantidade,
+			UsuarioID:       transferencia.UsuarioID,
+			Motivo:          "envio de transferência",
+			TransferenciaID: transferencia.ID,
+			Data:            transferencia.DataEnvio,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *TransferenciaRepositoryImpl) GetByID(id string) (*domain.Transferencia, error) {
+	transferencias, err := r.buscar(`WHERE id = ?`, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(transferencias) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return &transferencias[0], nil
+}
+
+func (r *TransferenciaRepositoryImpl) GetAll(status domain.StatusTransferencia) ([]domain.Transferencia, error) {
+	if status != "" {
+		return r.buscar(`WHERE status = ?`, status)
+	}
+	return r.buscar("")
+}
+
+// Receber dá entrada dos itens em trânsito no estoque do destino
+func (r *TransferenciaRepositoryImpl) Receber(id, usuarioID string) error {
+	return r.encerrar(id, domain.TransferenciaRecebida, usuarioID)
+}
+
+// Cancelar devolve os itens em trânsito ao estoque da origem
+func (r *TransferenciaRepositoryImpl) Cancelar(id, usuarioID string) error {
+	return r.encerrar(id, domain.TransferenciaCancelada, usuarioID)
+}
+
+// encerrar tira a transferência do trânsito e lança a entrada dos itens no destino, quando
+// recebida, ou de volta na origem, quando cancelada. A condição sobre o status impede que
+// duas operações concorrentes encerrem a mesma transferência.
+func (r *TransferenciaRepositoryImpl) encerrar(id string, novo domain.StatusTransferencia, usuarioID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var origemID, destinoID string
+	err = tx.QueryRow(`SELECT origem_id, destino_id FROM transferencias WHERE id = ?`, id).Scan(&origemID, &destinoID)
+	if err != nil {
+		return err
+	}
+
+	agora := time.Now()
+	localID, motivo := destinoID, "recebimento de transferência"
+	if novo == domain.TransferenciaCancelada {
+		localID, motivo = origemID, "cancelamento de transferência"
+	}
+
+	result, err := tx.Exec(`UPDATE transferencias SET status = ?, data_recebimento = ? WHERE id = ? AND status = ?`,
+		novo, agora, id, domain.TransferenciaEmTransito)
+	if err != nil {
+		return err
+	}
+	if err := verificarAlteracao(result); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: a transferência não está mais em trânsito", domain.ErrTransicaoStatusInvalida)
+		}
+		return err
+	}
+
+	itens, err := buscarItensTransferencia(tx, id)
+	if err != nil {
+		return err
+	}
+	for _, item := range itens {
+		err := movimentarEstoque(tx, &domain.MovimentacaoEstoque{
+			ProdutoID:       item.ProdutoID,
+			LocalID:         localID,
+			Tipo:            domain.MovimentacaoTransferenciaEntrada,
+			Quantidade:      item.Quantidade,
+			UsuarioID:       usuarioID,
+			Motivo:          motivo,
+			TransferenciaID: id,
+			Data:            agora,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetQuantidadesEmTransito soma, por produto, os itens das transferências ainda não
+// recebidas nem canceladas, que saíram da origem e não entraram em nenhum local
+func (r *TransferenciaRepositoryImpl) GetQuantidadesEmTransito() (map[string]int, error) {
+	query := `
+		SELECT i.produto_id, SUM(i.quantidade)
+		FROM itens_transferencia i
+		JOIN transferencias t ON t.id = i.transferencia_id
+		WHERE t.status = ?
+		GROUP BY i.produto_id
+	`
+	rows, err := r.db.Query(query, domain.TransferenciaEmTransito)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emTransito := make(map[string]int)
+	for rows.Next() {
+		var produtoID string
+		var quantidade int
+		if err := rows.Scan(&produtoID, &quantidade); err != nil {
+			return nil, err
+		}
+		emTransito[produtoID] = quantidade
+	}
+	return emTransito, rows.Err()
+}
+
+// buscar carrega as transferências que atendem à condição, com os itens
+func (r *TransferenciaRepositoryImpl) buscar(where string, args ...interface{}) ([]domain.Transferencia, error) {
+	query := `SELECT id, origem_id, destino_id, status, observacao, usuario_id, data_envio, data_recebimento
+		FROM transferencias ` + where + ` ORDER BY data_envio DESC`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transferencias []domain.Transferencia
+	for rows.Next() {
+		var t domain.Transferencia
+		var dataRecebimento sql.NullTime
+		err := rows.Scan(&t.ID, &t.OrigemID, &t.DestinoID, &t.Status, &t.Observacao, &t.UsuarioID, &t.DataEnvio, &dataRecebimento)
+		if err != nil {
+			return nil, err
+		}
+		if dataRecebimento.Valid {
+			t.DataRecebimento = &dataRecebimento.Time
+		}
+		transferencias = append(transferencias, t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range transferencias {
+		transferencias[i].Itens, err = buscarItensTransferencia(r.db, transferencias[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return transferencias, nil
+}
+
+// consulta é atendida tanto por *sql.DB quanto por *sql.Tx
+type consulta interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+func buscarItensTransferencia(db consulta, transferenciaID string) ([]domain.ItemTransferencia, error) {
+	rows, err := db.Query(`SELECT id, transferencia_id, produto_id, quantidade FROM itens_transferencia WHERE transferencia_id = ?`,
+		transferenciaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var itens []domain.ItemTransferencia
+	for rows.Next() {
+		var item domain.ItemTransferencia
+		if err := rows.Scan(&item.ID, &item.TransferenciaID, &item.ProdutoID, &item.Quantidade); err != nil {
+			return nil, err
+		}
+		itens = append(itens, item)
+	}
+	return itens, rows.Err()
+}
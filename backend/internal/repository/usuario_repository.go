@@ -2,7 +2,6 @@ package repository
 
 import (
 	"database/sql"
-	"errors"
 	"vendas/internal/domain"
 )
 
@@ -48,7 +47,7 @@ func (r *UsuarioRepository) GetByID(id string) (*domain.Usuario, error) {
 		&usuario.DataCriacao,
 	)
 	if err == sql.ErrNoRows {
-		return nil, errors.New("usuário não encontrado")
+		return nil, domain.ErrUsuarioNaoEncontrado
 	}
 	if err != nil {
 		return nil, err
@@ -73,7 +72,7 @@ func (r *UsuarioRepository) GetByEmail(email string) (*domain.Usuario, error) {
 		&usuario.DataCriacao,
 	)
 	if err == sql.ErrNoRows {
-		return nil, errors.New("usuário não encontrado")
+		return nil, domain.ErrUsuarioNaoEncontrado
 	}
 	if err != nil {
 		return nil, err
@@ -136,7 +135,7 @@ func (r *UsuarioRepository) Update(usuario *domain.Usuario) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("usuário não encontrado")
+		return domain.ErrUsuarioNaoEncontrado
 	}
 
 	return nil
@@ -155,7 +154,7 @@ func (r *UsuarioRepository) Delete(id string) error {
 	}
 
 	if rowsAffected == 0 {
-		return errors.New("usuário não encontrado")
+		return domain.ErrUsuarioNaoEncontrado
 	}
 
 	return nil
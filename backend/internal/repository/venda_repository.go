@@ -2,19 +2,32 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"time"
 	"vendas/internal/domain"
 	"vendas/internal/utils"
 )
 
+// EfeitoEstoque indica como uma mudança de status afeta o estoque dos itens da venda
+type EfeitoEstoque int
+
+const (
+	SemEfeitoEstoque EfeitoEstoque = iota
+	BaixarEstoque
+	EstornarEstoque
+)
+
 type VendaRepository interface {
-	Create(venda *domain.Venda) error
+	Create(venda *domain.Venda, usuarioID string) error
 	GetByID(id string) (*domain.Venda, error)
 	GetAll() ([]domain.Venda, error)
-	Update(venda *domain.Venda) error
-	Delete(id string) error
+	Update(venda *domain.Venda, usuarioID string) error
+	AlterarStatus(venda *domain.Venda, anterior domain.StatusVenda, efeito EfeitoEstoque, usuarioID, motivo string) error
+	GetHistoricoStatus(vendaID string) ([]domain.HistoricoStatusVenda, error)
 	GetVendasPorCliente(cliente string) ([]domain.Venda, error)
 	GetVendasPorPeriodo(inicio, fim int64) ([]domain.Venda, error)
+	GetQuantidadesVendidas(dias int) (map[string]int, error)
 }
 
 type VendaRepositoryImpl struct {
@@ -25,7 +38,7 @@ func NewVendaRepository(db *sql.DB) *VendaRepositoryImpl {
 	return &VendaRepositoryImpl{db: db}
 }
 
-func (r *VendaRepositoryImpl) Create(venda *domain.Venda) error {
+func (r *VendaRepositoryImpl) Create(venda *domain.Venda, usuarioID string) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return err
@@ -36,49 +49,51 @@ func (r *VendaRepositoryImpl) Create(venda *domain.Venda) error {
 	venda.ID = utils.GenerateUUID()
 
 	// Insere a venda
-	query := `INSERT INTO vendas (id, cliente_id, vendedor_id, data_venda, valor_total, data_criacao) VALUES (?, ?, ?, ?, ?, ?)`
-	_, err = tx.Exec(query, venda.ID, venda.ClienteID, venda.VendedorID, venda.DataVenda, venda.ValorTotal, venda.DataCriacao)
+	numeroParcelas, taxaJuros, primeiroVencimento := colunasParcelamento(venda)
+	query := `INSERT INTO vendas (id, cliente_id, vendedor_id, data_venda, status, subtotal, valor_desconto, valor_total, data_criacao,
+			numero_parcelas, taxa_juros, primeiro_vencimento, local_id, liberacao_credito, orcamento_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, venda.ID, venda.ClienteID, venda.VendedorID, venda.DataVenda, venda.Status,
+		venda.Subtotal, venda.ValorDesconto, venda.ValorTotal, venda.DataCriacao,
+		numeroParcelas, taxaJuros, primeiroVencimento, venda.LocalID, referencia(venda.LiberacaoCredito),
+		referencia(venda.OrcamentoID))
 	if err != nil {
 		return err
 	}
 
-	// Insere os itens da venda
-	for i := range venda.Items {
-		// Gera UUID para o item
-		venda.Items[i].ID = utils.GenerateUUID()
-
-		// Insere o item
-		query = `INSERT INTO itens_venda (id, venda_id, produto_id, quantidade, preco_unitario) 
-			VALUES (?, ?, ?, ?, ?)`
-		_, err = tx.Exec(query,
-			venda.Items[i].ID,
-			venda.ID,
-			venda.Items[i].ProdutoID,
-			venda.Items[i].Quantidade,
-			venda.Items[i].PrecoUnitario)
-		if err != nil {
+	// O orçamento é convertido na mesma transação da venda, e as reservas dele passam para
+	// a venda antes da baixa, que as consome
+	if venda.OrcamentoID != "" {
+		if err := converterOrcamento(tx, venda.OrcamentoID, venda.ID, venda.DataCriacao); err != nil {
 			return err
 		}
-
-		// Atualiza o estoque do produto
-		query = `UPDATE produtos 
-			SET quantidade = quantidade - ? 
-			WHERE id = ? AND quantidade >= ?`
-		result, err := tx.Exec(query,
-			venda.Items[i].Quantidade,
-			venda.Items[i].ProdutoID,
-			venda.Items[i].Quantidade)
+		_, err := tx.Exec(`UPDATE reservas_estoque SET venda_id = ? WHERE orcamento_id = ? AND status = ?`,
+			venda.ID, venda.OrcamentoID, domain.ReservaAtiva)
 		if err != nil {
 			return err
 		}
+	}
 
-		// Verifica se o produto foi atualizado
-		rows, err := result.RowsAffected()
-		if err != nil {
+	// Insere os itens da venda
+	if err := inserirItensVenda(tx, venda); err != nil {
+		return err
+	}
+
+	// Rascunhos não movimentam o estoque até serem confirmados. A baixa confere o saldo
+	// de cada produto no local da venda.
+	if venda.Status.BaixaEstoque() {
+		if err := baixarEstoqueVenda(tx, venda.ID, usuarioID, "venda registrada"); err != nil {
 			return err
 		}
-		if rows == 0 {
-			return fmt.Errorf("estoque insuficiente para o produto %s", venda.Items[i].ProdutoID)
+	}
+
+	if err := inserirParcelas(tx, venda); err != nil {
+		return err
+	}
+
+	if venda.Status == domain.StatusConfirmada {
+		if err := acumularPontosVenda(tx, venda, usuarioID); err != nil {
+			return err
 		}
 	}
 
@@ -89,32 +104,48 @@ func (r *VendaRepositoryImpl) GetByID(id string) (*domain.Venda, error) {
 	var venda domain.Venda
 	var clienteID, vendedorID string
 	var clienteNome, vendedorNome string
+	var numeroParcelas int
+	var taxaJuros float64
+	var primeiroVencimento sql.NullTime
+	var liberacaoCredito, orcamentoID sql.NullString
 
 	// Busca os dados da venda
 	err := r.db.QueryRow(`
-		SELECT v.id, v.cliente_id, v.vendedor_id, v.data_venda, v.valor_total, v.data_criacao,
-			   c.nome as cliente_nome, vd.nome as vendedor_nome
+		SELECT v.id, v.cliente_id, v.vendedor_id, v.data_venda, v.status, v.subtotal, v.valor_desconto, v.valor_total, v.data_criacao,
+			   v.numero_parcelas, v.taxa_juros, v.primeiro_vencimento, v.local_id, v.liberacao_credito, v.orcamento_id,
+			   COALESCE(c.nome, '') as cliente_nome, COALESCE(vd.nome, '') as vendedor_nome
 		FROM vendas v
-		LEFT JOIN usuarios c ON v.cliente_id = c.id
+		LEFT JOIN clientes c ON v.cliente_id = c.id
 		LEFT JOIN usuarios vd ON v.vendedor_id = vd.id
 		WHERE v.id = ?
-	`, id).Scan(&venda.ID, &clienteID, &vendedorID, &venda.DataVenda, &venda.ValorTotal, &venda.DataCriacao,
-		&clienteNome, &vendedorNome)
+	`, id).Scan(&venda.ID, &clienteID, &vendedorID, &venda.DataVenda, &venda.Status, &venda.Subtotal, &venda.ValorDesconto,
+		&venda.ValorTotal, &venda.DataCriacao, &numeroParcelas, &taxaJuros, &primeiroVencimento, &venda.LocalID,
+		&liberacaoCredito, &orcamentoID, &clienteNome, &vendedorNome)
 
 	if err != nil {
 		return nil, err
 	}
+	venda.LiberacaoCredito = liberacaoCredito.String
+	venda.OrcamentoID = orcamentoID.String
+
+	if numeroParcelas > 0 {
+		venda.Parcelamento = &domain.PlanoParcelamento{
+			NumeroParcelas:     numeroParcelas,
+			TaxaJuros:          taxaJuros,
+			PrimeiroVencimento: primeiroVencimento.Time,
+		}
+	}
 
 	// Busca os itens da venda
 	rows, err := r.db.Query(`
-		SELECT iv.id, iv.produto_id, iv.quantidade, iv.preco_unitario,
+		SELECT iv.id, iv.produto_id, iv.quantidade, iv.preco_unitario, iv.custo_unitario, iv.subtotal, iv.valor_desconto, iv.total,
 			   COALESCE(p.id, '') as produto_id,
 			   COALESCE(p.nome, 'Produto não encontrado') as produto_nome,
 			   COALESCE(p.descricao, '') as produto_descricao,
 			   COALESCE(p.preco, 0) as produto_preco,
 			   COALESCE(p.quantidade, 0) as produto_quantidade,
 			   COALESCE(p.imagem_url, '') as produto_imagem_url,
-			   COALESCE(p.data_criacao, datetime('now')) as produto_data_criacao
+			   p.data_criacao as produto_data_criacao
 		FROM itens_venda iv
 		LEFT JOIN produtos p ON iv.produto_id = p.id
 		WHERE iv.venda_id = ?
@@ -126,7 +157,9 @@ func (r *VendaRepositoryImpl) GetByID(id string) (*domain.Venda, error) {
 	defer rows.Close()
 
 	// Adiciona os dados do cliente e vendedor
-	venda.Cliente = &domain.Usuario{
+	venda.ClienteID = clienteID
+	venda.VendedorID = vendedorID
+	venda.Cliente = &domain.Cliente{
 		ID:   clienteID,
 		Nome: clienteNome,
 	}
@@ -139,25 +172,48 @@ func (r *VendaRepositoryImpl) GetByID(id string) (*domain.Venda, error) {
 	for rows.Next() {
 		var item domain.ItemVenda
 		var produto domain.Produto
+		var produtoDataCriacao sql.NullTime
 		err := rows.Scan(
 			&item.ID,
 			&item.ProdutoID,
 			&item.Quantidade,
 			&item.PrecoUnitario,
+			&item.CustoUnitario,
+			&item.Subtotal,
+			&item.ValorDesconto,
+			&item.Total,
 			&produto.ID,
 			&produto.Nome,
 			&produto.Descricao,
 			&produto.Preco,
 			&produto.Quantidade,
 			&produto.ImagemURL,
-			&produto.DataCriacao,
+			&produtoDataCriacao,
 		)
 		if err != nil {
 			return nil, err
 		}
+		produto.DataCriacao = produtoDataCriacao.Time
 		item.Produto = &produto
 		venda.Items = append(venda.Items, item)
 	}
+	rows.Close()
+
+	venda.Parcelas, err = buscarParcelas(r.db, selectParcelas+` WHERE venda_id = ? ORDER BY numero`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pontos de fidelidade do último crédito da venda
+	var expiracaoPontos sql.NullTime
+	err = r.db.QueryRow(`SELECT pontos, data_expiracao FROM pontos_fidelidade WHERE venda_id = ? AND tipo = ?
+		ORDER BY data_lancamento DESC LIMIT 1`, id, domain.PontosAcumulo).Scan(&venda.PontosFidelidade, &expiracaoPontos)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if expiracaoPontos.Valid {
+		venda.ExpiracaoPontos = &expiracaoPontos.Time
+	}
 
 	return &venda, nil
 }
@@ -169,13 +225,18 @@ func (r *VendaRepositoryImpl) GetAll() ([]domain.Venda, error) {
 			v.cliente_id, 
 			v.vendedor_id, 
 			v.data_venda, 
+			v.status, 
+			v.subtotal, 
+			v.valor_desconto, 
 			v.valor_total, 
 			v.data_criacao,
+			v.local_id,
 			c.nome as cliente_nome,
-			u.nome as vendedor_nome
+			COALESCE(u.nome, '') as vendedor_nome
 		FROM vendas v
-		JOIN usuarios c ON c.id = v.cliente_id
-		JOIN usuarios u ON u.id = v.vendedor_id
+		JOIN clientes c ON c.id = v.cliente_id
+		-- Vendas antigas podem não ter vendedor registrado
+		LEFT JOIN usuarios u ON u.id = v.vendedor_id
 	`
 	rows, err := r.db.Query(query)
 	if err != nil {
@@ -192,8 +253,12 @@ func (r *VendaRepositoryImpl) GetAll() ([]domain.Venda, error) {
 			&venda.ClienteID,
 			&venda.VendedorID,
 			&venda.DataVenda,
+			&venda.Status,
+			&venda.Subtotal,
+			&venda.ValorDesconto,
 			&venda.ValorTotal,
 			&venda.DataCriacao,
+			&venda.LocalID,
 			&clienteNome,
 			&vendedorNome,
 		)
@@ -202,7 +267,7 @@ func (r *VendaRepositoryImpl) GetAll() ([]domain.Venda, error) {
 		}
 
 		// Adiciona os dados do cliente e vendedor
-		venda.Cliente = &domain.Usuario{
+		venda.Cliente = &domain.Cliente{
 			ID:   venda.ClienteID,
 			Nome: clienteNome,
 		}
@@ -211,7 +276,8 @@ func (r *VendaRepositoryImpl) GetAll() ([]domain.Venda, error) {
 			Nome: vendedorNome,
 		}
 
-		query = `SELECT id, produto_id, quantidade, preco_unitario FROM itens_venda WHERE venda_id = ?`
+		query = `SELECT id, produto_id, quantidade, preco_unitario, custo_unitario, subtotal, valor_desconto, total
+			FROM itens_venda WHERE venda_id = ?`
 		itemRows, err := r.db.Query(query, venda.ID)
 		if err != nil {
 			return nil, err
@@ -219,7 +285,8 @@ func (r *VendaRepositoryImpl) GetAll() ([]domain.Venda, error) {
 
 		for itemRows.Next() {
 			var item domain.ItemVenda
-			err := itemRows.Scan(&item.ID, &item.ProdutoID, &item.Quantidade, &item.PrecoUnitario)
+			err := itemRows.Scan(&item.ID, &item.ProdutoID, &item.Quantidade, &item.PrecoUnitario, &item.CustoUnitario,
+				&item.Subtotal, &item.ValorDesconto, &item.Total)
 			if err != nil {
 				itemRows.Close()
 				return nil, err
@@ -233,40 +300,56 @@ func (r *VendaRepositoryImpl) GetAll() ([]domain.Venda, error) {
 	return vendas, nil
 }
 
-func (r *VendaRepositoryImpl) Update(venda *domain.Venda) error {
+func (r *VendaRepositoryImpl) Update(venda *domain.Venda, usuarioID string) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	// Restaurar estoque dos itens antigos
-	query := `SELECT produto_id, quantidade FROM itens_venda WHERE venda_id = ?`
-	rows, err := tx.Query(query, venda.ID)
+	var status domain.StatusVenda
+	err = tx.QueryRow(`SELECT status FROM vendas WHERE id = ?`, venda.ID).Scan(&status)
 	if err != nil {
 		return err
 	}
+	venda.Status = status
 
-	for rows.Next() {
-		var produtoID, quantidade int64
-		err := rows.Scan(&produtoID, &quantidade)
-		if err != nil {
-			rows.Close()
-			return err
-		}
+	// Os itens de vendas com devoluções estão referenciados pelas devoluções
+	var devolucoes int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM devolucoes WHERE venda_id = ?`, venda.ID).Scan(&devolucoes)
+	if err != nil {
+		return err
+	}
+	if devolucoes > 0 {
+		return errors.New("venda com devoluções não pode ser editada")
+	}
 
-		query = `UPDATE produtos SET quantidade = quantidade + ? WHERE id = ?`
-		_, err = tx.Exec(query, quantidade, produtoID)
-		if err != nil {
-			rows.Close()
+	// As parcelas são geradas novamente, desde que nenhuma tenha sido recebida
+	var parcelasPagas int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM parcelas WHERE venda_id = ? AND status = ?`,
+		venda.ID, domain.ParcelaPaga).Scan(&parcelasPagas)
+	if err != nil {
+		return err
+	}
+	if parcelasPagas > 0 {
+		return errors.New("venda com parcelas recebidas não pode ser editada")
+	}
+
+	// Restaurar estoque dos itens antigos no local anterior da venda
+	if status.BaixaEstoque() {
+		if err := estornarEstoqueVenda(tx, venda.ID, usuarioID, "edição da venda"); err != nil {
 			return err
 		}
 	}
-	rows.Close()
 
 	// Atualizar venda
-	query = `UPDATE vendas SET cliente_id = ?, vendedor_id = ?, data_venda = ?, valor_total = ? WHERE id = ?`
-	_, err = tx.Exec(query, venda.ClienteID, venda.VendedorID, venda.DataVenda, venda.ValorTotal, venda.ID)
+	numeroParcelas, taxaJuros, primeiroVencimento := colunasParcelamento(venda)
+	query := `UPDATE vendas SET cliente_id = ?, vendedor_id = ?, data_venda = ?, subtotal = ?, valor_desconto = ?, valor_total = ?,
+		numero_parcelas = ?, taxa_juros = ?, primeiro_vencimento = ?, local_id = ?,
+		liberacao_credito = COALESCE(?, liberacao_credito) WHERE id = ?`
+	_, err = tx.Exec(query, venda.ClienteID, venda.VendedorID, venda.DataVenda,
+		venda.Subtotal, venda.ValorDesconto, venda.ValorTotal, numeroParcelas, taxaJuros, primeiroVencimento, venda.LocalID,
+		referencia(venda.LiberacaoCredito), venda.ID)
 	if err != nil {
 		return err
 	}
@@ -279,16 +362,36 @@ func (r *VendaRepositoryImpl) Update(venda *domain.Venda) error {
 	}
 
 	// Inserir novos itens
-	for _, item := range venda.Items {
-		query = `INSERT INTO itens_venda (venda_id, produto_id, quantidade, preco_unitario) VALUES (?, ?, ?, ?)`
-		_, err = tx.Exec(query, venda.ID, item.ProdutoID, item.Quantidade, item.PrecoUnitario)
-		if err != nil {
+	if err := inserirItensVenda(tx, venda); err != nil {
+		return err
+	}
+
+	if status.BaixaEstoque() {
+		if err := baixarEstoqueVenda(tx, venda.ID, usuarioID, "edição da venda"); err != nil {
 			return err
 		}
+	}
 
-		query = `UPDATE produtos SET quantidade = quantidade - ? WHERE id = ?`
-		_, err = tx.Exec(query, item.Quantidade, item.ProdutoID)
-		if err != nil {
+	// As reservas do rascunho valiam para os itens anteriores e precisam ser refeitas
+	if status == domain.StatusRascunho {
+		if _, err := liberarReservas(tx, domain.OrigemReserva{VendaID: venda.ID}, domain.ReservaLiberada, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM parcelas WHERE venda_id = ?`, venda.ID); err != nil {
+		return err
+	}
+	if err := inserirParcelas(tx, venda); err != nil {
+		return err
+	}
+
+	// Os pontos da venda confirmada são recalculados sobre o novo total
+	if status == domain.StatusConfirmada {
+		if err := estornarPontosVenda(tx, venda.ID, usuarioID, "edição da venda"); err != nil {
+			return err
+		}
+		if err := acumularPontosVenda(tx, venda, usuarioID); err != nil {
 			return err
 		}
 	}
@@ -296,59 +399,296 @@ func (r *VendaRepositoryImpl) Update(venda *domain.Venda) error {
 	return tx.Commit()
 }
 
-func (r *VendaRepositoryImpl) Delete(id string) error {
+// AlterarStatus muda o status da venda para venda.Status, desde que ela ainda esteja
+// no status anterior informado, aplicando o efeito no estoque na mesma transação.
+// Na confirmação, as parcelas geradas em venda.Parcelas também são gravadas.
+func (r *VendaRepositoryImpl) AlterarStatus(venda *domain.Venda, anterior domain.StatusVenda, efeito EfeitoEstoque, usuarioID, motivo string) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
-	// Restaurar estoque
-	query := `SELECT produto_id, quantidade FROM itens_venda WHERE venda_id = ?`
-	rows, err := tx.Query(query, id)
-	if err != nil {
+	// O dinheiro recebido só volta ao cliente pela devolução, que registra o reembolso; o
+	// cancelamento deixaria os pagamentos contados no caixa sem nenhum estorno
+	if venda.Status == domain.StatusCancelada {
+		recebido, err := valorRecebidoVenda(tx, venda.ID)
+		if err != nil {
+			return err
+		}
+		if recebido > 0 {
+			return fmt.Errorf("%w: a venda já recebeu %.2f em pagamentos; registre uma devolução para reembolsar o cliente",
+				domain.ErrTransicaoStatusInvalida, recebido)
+		}
+
+		// Os pontos usados para pagar a venda voltam ao cliente antes do estorno dos pontos
+		// gerados por ela, que assim alcança também os que ele usou no próprio pagamento
+		if err := devolverPontosResgatados(tx, venda.ID, venda.ExpiracaoPontos, usuarioID, motivo); err != nil {
+			return err
+		}
+	}
+
+	if err := mudarStatusVenda(tx, venda.ID, anterior, venda.Status, usuarioID, motivo); err != nil {
 		return err
 	}
 
+	switch efeito {
+	case BaixarEstoque:
+		if err := baixarEstoqueVenda(tx, venda.ID, usuarioID, motivo); err != nil {
+			return err
+		}
+	case EstornarEstoque:
+		if err := estornarEstoqueVenda(tx, venda.ID, usuarioID, motivo); err != nil {
+			return err
+		}
+	}
+
+	if venda.Status == domain.StatusConfirmada {
+		if err := inserirParcelas(tx, venda); err != nil {
+			return err
+		}
+		if err := acumularPontosVenda(tx, venda, usuarioID); err != nil {
+			return err
+		}
+	}
+
+	// As reservas do rascunho cancelado deixam de segurar o estoque
+	if venda.Status == domain.StatusCancelada {
+		if _, err := liberarReservas(tx, domain.OrigemReserva{VendaID: venda.ID}, domain.ReservaLiberada, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if venda.LiberacaoCredito != "" {
+		_, err := tx.Exec(`UPDATE vendas SET liberacao_credito = ? WHERE id = ?`, venda.LiberacaoCredito, venda.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *VendaRepositoryImpl) GetHistoricoStatus(vendaID string) ([]domain.HistoricoStatusVenda, error) {
+	query := `SELECT id, venda_id, status_anterior, status_novo, usuario_id, motivo, data
+		FROM historico_status_venda WHERE venda_id = ? ORDER BY data`
+	rows, err := r.db.Query(query, vendaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var historico []domain.HistoricoStatusVenda
 	for rows.Next() {
-		var produtoID string
-		var quantidade int
-		err := rows.Scan(&produtoID, &quantidade)
+		var h domain.HistoricoStatusVenda
+		err := rows.Scan(&h.ID, &h.VendaID, &h.StatusAnterior, &h.StatusNovo, &h.UsuarioID, &h.Motivo, &h.Data)
 		if err != nil {
-			rows.Close()
+			return nil, err
+		}
+		historico = append(historico, h)
+	}
+	return historico, nil
+}
+
+// mudarStatusVenda atualiza o status da venda e grava o histórico. A condição sobre o
+// status anterior impede que duas operações concorrentes apliquem a mesma transição.
+func mudarStatusVenda(tx *sql.Tx, vendaID string, anterior, novo domain.StatusVenda, usuarioID, motivo string) error {
+	result, err := tx.Exec(`UPDATE vendas SET status = ? WHERE id = ? AND status = ?`, novo, vendaID, anterior)
+	if err != nil {
+		return err
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return errors.New("a venda foi alterada por outra operação, tente novamente")
+	}
+
+	// Vendas canceladas ou devolvidas deixam de ter valores a receber e perdem os pontos
+	// de fidelidade que geraram
+	if novo == domain.StatusCancelada || novo == domain.StatusDevolvida {
+		if err := cancelarParcelasAbertas(tx, vendaID); err != nil {
+			return err
+		}
+		if err := estornarPontosVenda(tx, vendaID, usuarioID, motivo); err != nil {
 			return err
 		}
+	}
+
+	query := `INSERT INTO historico_status_venda (id, venda_id, status_anterior, status_novo, usuario_id, motivo, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, utils.GenerateUUID(), vendaID, anterior, novo, usuarioID, motivo, time.Now())
+	return err
+}
+
+// colunasParcelamento retorna os valores gravados do plano de parcelamento da venda.
+// Vendas à vista ficam com zero parcelas e sem primeiro vencimento.
+func colunasParcelamento(venda *domain.Venda) (int, float64, interface{}) {
+	if venda.Parcelamento == nil {
+		return 0, 0, nil
+	}
+	plano := venda.Parcelamento
+	return plano.NumeroParcelas, plano.TaxaJuros, plano.PrimeiroVencimento
+}
 
-		query = `UPDATE produtos SET quantidade = quantidade + ? WHERE id = ?`
-		_, err = tx.Exec(query, quantidade, produtoID)
+// inserirItensVenda grava os itens da venda gerando um UUID para cada um
+func inserirItensVenda(tx *sql.Tx, venda *domain.Venda) error {
+	for i := range venda.Items {
+		item := &venda.Items[i]
+		item.ID = utils.GenerateUUID()
+		item.VendaID = venda.ID
+
+		query := `INSERT INTO itens_venda (id, venda_id, produto_id, quantidade, preco_unitario, custo_unitario, subtotal, valor_desconto, total)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		_, err := tx.Exec(query, item.ID, venda.ID, item.ProdutoID, item.Quantidade, item.PrecoUnitario, item.CustoUnitario,
+			item.Subtotal, item.ValorDesconto, item.Total)
 		if err != nil {
-			rows.Close()
 			return err
 		}
 	}
-	rows.Close()
+	return nil
+}
 
-	// Remover itens
-	query = `DELETE FROM itens_venda WHERE venda_id = ?`
-	_, err = tx.Exec(query, id)
+// itensEstoqueVenda retorna as quantidades por produto dos itens gravados da venda,
+// descontando o que já voltou ao estoque por devoluções
+func itensEstoqueVenda(tx *sql.Tx, vendaID string) ([]domain.ItemVenda, error) {
+	rows, err := tx.Query(`
+		SELECT iv.produto_id, iv.quantidade - COALESCE((
+			SELECT SUM(idv.quantidade) FROM itens_devolucao idv WHERE idv.item_venda_id = iv.id
+		), 0)
+		FROM itens_venda iv
+		WHERE iv.venda_id = ?
+	`, vendaID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var itens []domain.ItemVenda
+	for rows.Next() {
+		var item domain.ItemVenda
+		if err := rows.Scan(&item.ProdutoID, &item.Quantidade); err != nil {
+			return nil, err
+		}
+		itens = append(itens, item)
+	}
+	return itens, rows.Err()
+}
+
+// localVenda retorna o local de estoque gravado na venda
+func localVenda(tx *sql.Tx, vendaID string) (string, error) {
+	var localID string
+	err := tx.QueryRow(`SELECT local_id FROM vendas WHERE id = ?`, vendaID).Scan(&localID)
+	return localID, err
+}
+
+// baixarEstoqueVenda retira os itens da venda do estoque do local dela, falhando se
+// algum produto não tiver saldo livre de reservas de outras origens no local. As reservas
+// da venda são consumidas pela baixa. O custo médio de cada produto no momento da baixa
+// fica registrado no item para o cálculo da margem.
+func baixarEstoqueVenda(tx *sql.Tx, vendaID, usuarioID, motivo string) error {
+	localID, err := localVenda(tx, vendaID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec(`UPDATE itens_venda SET custo_unitario = COALESCE((SELECT custo FROM produtos WHERE id = itens_venda.produto_id), 0)
+		WHERE venda_id = ?`, vendaID)
 	if err != nil {
 		return err
 	}
+	itens, err := itensEstoqueVenda(tx, vendaID)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range itens {
+		err := movimentarEstoque(tx, &domain.MovimentacaoEstoque{
+			ProdutoID:  item.ProdutoID,
+			LocalID:    localID,
+			Tipo:       domain.MovimentacaoSaidaVenda,
+			Quantidade: -item.Quantidade,
+			UsuarioID:  usuarioID,
+			Motivo:     motivo,
+			VendaID:    vendaID,
+		})
+		if err != nil {
+			return err
+		}
+	}
 
-	// Remover venda
-	query = `DELETE FROM vendas WHERE id = ?`
-	_, err = tx.Exec(query, id)
+	_, err = liberarReservas(tx, domain.OrigemReserva{VendaID: vendaID}, domain.ReservaConsumida, time.Now())
+	return err
+}
+
+// estornarEstoqueVenda devolve os itens da venda ao estoque do local dela
+func estornarEstoqueVenda(tx *sql.Tx, vendaID, usuarioID, motivo string) error {
+	localID, err := localVenda(tx, vendaID)
+	if err != nil {
+		return err
+	}
+	itens, err := itensEstoqueVenda(tx, vendaID)
 	if err != nil {
 		return err
 	}
 
-	return tx.Commit()
+	for _, item := range itens {
+		if item.Quantidade <= 0 {
+			continue
+		}
+
+		err := movimentarEstoque(tx, &domain.MovimentacaoEstoque{
+			ProdutoID:  item.ProdutoID,
+			LocalID:    localID,
+			Tipo:       domain.MovimentacaoEstornoVenda,
+			Quantidade: item.Quantidade,
+			UsuarioID:  usuarioID,
+			Motivo:     motivo,
+			VendaID:    vendaID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // Métodos adicionais específicos para vendas
 
+// GetQuantidadesVendidas soma, por produto, as unidades vendidas nos últimos dias,
+// descontando as devoluções. Rascunhos e vendas canceladas não entram na conta.
+func (r *VendaRepositoryImpl) GetQuantidadesVendidas(dias int) (map[string]int, error) {
+	query := `
+		SELECT iv.produto_id, SUM(iv.quantidade - COALESCE((
+			SELECT SUM(idv.quantidade) FROM itens_devolucao idv WHERE idv.item_venda_id = iv.id
+		), 0))
+		FROM itens_venda iv
+		JOIN vendas v ON v.id = iv.venda_id
+		WHERE v.status IN (?, ?, ?)
+		AND julianday(v.data_venda) >= julianday('now', ?)
+		GROUP BY iv.produto_id
+	`
+	rows, err := r.db.Query(query, domain.StatusConfirmada, domain.StatusPaga, domain.StatusDevolvida, fmt.Sprintf("-%d days", dias))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	vendidas := make(map[string]int)
+	for rows.Next() {
+		var produtoID string
+		var quantidade int
+		if err := rows.Scan(&produtoID, &quantidade); err != nil {
+			return nil, err
+		}
+		vendidas[produtoID] = quantidade
+	}
+	return vendidas, rows.Err()
+}
+
 func (r *VendaRepositoryImpl) GetVendasPorCliente(cliente string) ([]domain.Venda, error) {
-	query := `SELECT id, cliente_id, vendedor_id, data_venda, valor_total, data_criacao FROM vendas WHERE cliente_id = ?`
+	query := `SELECT id, cliente_id, vendedor_id, data_venda, status, subtotal, valor_desconto, valor_total, data_criacao, local_id FROM vendas WHERE cliente_id = ?`
 	rows, err := r.db.Query(query, cliente)
 	if err != nil {
 		return nil, err
@@ -358,12 +698,14 @@ func (r *VendaRepositoryImpl) GetVendasPorCliente(cliente string) ([]domain.Vend
 	var vendas []domain.Venda
 	for rows.Next() {
 		var venda domain.Venda
-		err := rows.Scan(&venda.ID, &venda.ClienteID, &venda.VendedorID, &venda.DataVenda, &venda.ValorTotal, &venda.DataCriacao)
+		err := rows.Scan(&venda.ID, &venda.ClienteID, &venda.VendedorID, &venda.DataVenda, &venda.Status,
+			&venda.Subtotal, &venda.ValorDesconto, &venda.ValorTotal, &venda.DataCriacao, &venda.LocalID)
 		if err != nil {
 			return nil, err
 		}
 
-		query = `SELECT produto_id, quantidade, preco_unitario FROM itens_venda WHERE venda_id = ?`
+		query = `SELECT id, produto_id, quantidade, preco_unitario, custo_unitario, subtotal, valor_desconto, total
+			FROM itens_venda WHERE venda_id = ?`
 		itemRows, err := r.db.Query(query, venda.ID)
 		if err != nil {
 			return nil, err
@@ -371,7 +713,8 @@ func (r *VendaRepositoryImpl) GetVendasPorCliente(cliente string) ([]domain.Vend
 
 		for itemRows.Next() {
 			var item domain.ItemVenda
-			err := itemRows.Scan(&item.ProdutoID, &item.Quantidade, &item.PrecoUnitario)
+			err := itemRows.Scan(&item.ID, &item.ProdutoID, &item.Quantidade, &item.PrecoUnitario, &item.CustoUnitario,
+				&item.Subtotal, &item.ValorDesconto, &item.Total)
 			if err != nil {
 				itemRows.Close()
 				return nil, err
@@ -386,7 +729,7 @@ func (r *VendaRepositoryImpl) GetVendasPorCliente(cliente string) ([]domain.Vend
 }
 
 func (r *VendaRepositoryImpl) GetVendasPorPeriodo(inicio, fim int64) ([]domain.Venda, error) {
-	query := `SELECT id, cliente_id, vendedor_id, data_venda, valor_total, data_criacao FROM vendas WHERE data_venda BETWEEN ? AND ?`
+	query := `SELECT id, cliente_id, vendedor_id, data_venda, status, subtotal, valor_desconto, valor_total, data_criacao, local_id FROM vendas WHERE data_venda BETWEEN ? AND ?`
 	rows, err := r.db.Query(query, inicio, fim)
 	if err != nil {
 		return nil, err
@@ -396,12 +739,14 @@ func (r *VendaRepositoryImpl) GetVendasPorPeriodo(inicio, fim int64) ([]domain.V
 	var vendas []domain.Venda
 	for rows.Next() {
 		var venda domain.Venda
-		err := rows.Scan(&venda.ID, &venda.ClienteID, &venda.VendedorID, &venda.DataVenda, &venda.ValorTotal, &venda.DataCriacao)
+		err := rows.Scan(&venda.ID, &venda.ClienteID, &venda.VendedorID, &venda.DataVenda, &venda.Status,
+			&venda.Subtotal, &venda.ValorDesconto, &venda.ValorTotal, &venda.DataCriacao, &venda.LocalID)
 		if err != nil {
 			return nil, err
 		}
 
-		query = `SELECT produto_id, quantidade, preco_unitario FROM itens_venda WHERE venda_id = ?`
+		query = `SELECT id, produto_id, quantidade, preco_unitario, custo_unitario, subtotal, valor_desconto, total
+			FROM itens_venda WHERE venda_id = ?`
 		itemRows, err := r.db.Query(query, venda.ID)
 		if err != nil {
 			return nil, err
@@ -409,7 +754,8 @@ func (r *VendaRepositoryImpl) GetVendasPorPeriodo(inicio, fim int64) ([]domain.V
 
 		for itemRows.Next() {
 			var item domain.ItemVenda
-			err := itemRows.Scan(&item.ProdutoID, &item.Quantidade, &item.PrecoUnitario)
+			err := itemRows.Scan(&item.ID, &item.ProdutoID, &item.Quantidade, &item.PrecoUnitario, &item.CustoUnitario,
+				&item.Subtotal, &item.ValorDesconto, &item.Total)
 			if err != nil {
 				itemRows.Close()
 				return nil, err
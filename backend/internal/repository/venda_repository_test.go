@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+	"vendas/internal/domain"
+)
+
+func orcamentoAberto(t *testing.T, db *sql.DB, produtoID string, quantidade int) *domain.Orcamento {
+	t.Helper()
+	agora := time.Now()
+	orcamento := &domain.Orcamento{
+		ClienteID:   "cliente",
+		VendedorID:  "vendedor",
+		LocalID:     domain.LocalPrincipal,
+		Status:      domain.OrcamentoAberto,
+		ValidoAte:   agora.AddDate(0, 0, 7),
+		DataCriacao: agora,
+		Items:       []domain.ItemVenda{{ProdutoID: produtoID, Quantidade: quantidade, PrecoUnitario: 10}},
+	}
+	if err := NewOrcamentoRepository(db).Create(orcamento); err != nil {
+		t.Fatal(err)
+	}
+	return orcamento
+}
+
+func vendaDoOrcamento(orcamento *domain.Orcamento, status domain.StatusVenda) *domain.Venda {
+	agora := time.Now()
+	return &domain.Venda{
+		ClienteID:   orcamento.ClienteID,
+		VendedorID:  orcamento.VendedorID,
+		LocalID:     orcamento.LocalID,
+		OrcamentoID: orcamento.ID,
+		Status:      status,
+		DataVenda:   agora,
+		DataCriacao: agora,
+		Items:       orcamento.Items,
+	}
+}
+
+// A venda e a conversão do orçamento são gravadas juntas: cada orçamento gera uma única
+// venda e fica vinculado a ela
+func TestCreateConverteOrcamento(t *testing.T) {
+	db := bancoDeTeste(t)
+	vendas := NewVendaRepository(db)
+	orcamentos := NewOrcamentoRepository(db)
+	orcamento := orcamentoAberto(t, db, produtoComEstoque(t, db, 10), 2)
+
+	venda := vendaDoOrcamento(orcamento, domain.StatusRascunho)
+	if err := vendas.Create(venda, "vendedor"); err != nil {
+		t.Fatal(err)
+	}
+
+	convertido, err := orcamentos.GetByID(orcamento.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if convertido.Status != domain.OrcamentoConvertido || convertido.VendaID != venda.ID || convertido.DataConversao == nil {
+		t.Errorf("orçamento depois da conversão = %s, venda %q, conversão %v; esperado convertido na venda %s",
+			convertido.Status, convertido.VendaID, convertido.DataConversao, venda.ID)
+	}
+
+	err = vendas.Create(vendaDoOrcamento(orcamento, domain.StatusRascunho), "vendedor")
+	if !errors.Is(err, domain.ErrOrcamentoIndisponivel) {
+		t.Fatalf("segunda conversão retornou %v, esperado %v", err, domain.ErrOrcamentoIndisponivel)
+	}
+	var quantidade int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM vendas WHERE orcamento_id = ?`, orcamento.ID).Scan(&quantidade); err != nil {
+		t.Fatal(err)
+	}
+	if quantidade != 1 {
+		t.Errorf("%d vendas geradas pelo orçamento, esperado 1", quantidade)
+	}
+}
+
+// Uma venda recusada na gravação não deixa o orçamento marcado como convertido
+func TestCreateRecusadaMantemOrcamentoAberto(t *testing.T) {
+	db := bancoDeTeste(t)
+	orcamento := orcamentoAberto(t, db, produtoComEstoque(t, db, 1), 5)
+
+	if err := NewVendaRepository(db).Create(vendaDoOrcamento(orcamento, domain.StatusConfirmada), "vendedor"); err == nil {
+		t.Fatal("venda sem estoque gravada, esperado erro")
+	}
+
+	aberto, err := NewOrcamentoRepository(db).GetByID(orcamento.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aberto.Status != domain.OrcamentoAberto || aberto.VendaID != "" {
+		t.Errorf("orçamento depois da venda recusada = %s, venda %q; esperado aberto sem venda", aberto.Status, aberto.VendaID)
+	}
+}
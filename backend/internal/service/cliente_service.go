@@ -1,18 +1,22 @@
 package service
 
 import (
+	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 	"vendas/internal/domain"
 	"vendas/internal/repository"
+	"vendas/internal/utils"
 )
 
 type ClienteService struct {
-	repo *repository.ClienteRepository
+	repo        repository.ClienteRepository
+	usuarioRepo domain.UsuarioRepository
 }
 
-func NewClienteService(repo *repository.ClienteRepository) *ClienteService {
-	return &ClienteService{repo: repo}
+func NewClienteService(repo repository.ClienteRepository, usuarioRepo domain.UsuarioRepository) *ClienteService {
+	return &ClienteService{repo: repo, usuarioRepo: usuarioRepo}
 }
 
 func (s *ClienteService) CreateCliente(cliente *domain.Cliente) error {
@@ -22,8 +26,18 @@ func (s *ClienteService) CreateCliente(cliente *domain.Cliente) error {
 	if cliente.Email == "" {
 		return errors.New("email do cliente é obrigatório")
 	}
-	if cliente.CPF == "" {
-		return errors.New("CPF do cliente é obrigatório")
+	if err := validarDocumentos(cliente, false); err != nil {
+		return err
+	}
+	if err := s.verificarDocumentoUnico(cliente); err != nil {
+		return err
+	}
+	if err := s.verificarUsuario(cliente); err != nil {
+		return err
+	}
+
+	if cliente.LimiteCredito < 0 {
+		return fmt.Errorf("%w: limite de crédito não pode ser negativo", domain.ErrClienteInvalido)
 	}
 
 	// Define a data de criação automaticamente
@@ -38,7 +52,7 @@ func (s *ClienteService) GetCliente(id string) (*domain.Cliente, error) {
 }
 
 func (s *ClienteService) GetClienteByCPF(cpf string) (*domain.Cliente, error) {
-	return s.repo.GetByCPF(cpf)
+	return s.repo.GetByCPF(utils.NormalizeDocument(cpf))
 }
 
 func (s *ClienteService) ListClientes() ([]domain.Cliente, error) {
@@ -49,28 +63,204 @@ func (s *ClienteService) UpdateCliente(cliente *domain.Cliente) error {
 	if cliente.ID == "" {
 		return errors.New("id do cliente é obrigatório")
 	}
+
+	// Busca o cliente existente para manter a data de criação original
+	clienteExistente, err := s.repo.GetByID(cliente.ID)
+	if err != nil {
+		return err
+	}
+	if clienteExistente.DataAnonimizacao != nil {
+		return fmt.Errorf("%w: o cadastro não pode ser alterado", domain.ErrClienteAnonimizado)
+	}
+
 	if cliente.Nome == "" {
 		return errors.New("nome do cliente é obrigatório")
 	}
 	if cliente.Email == "" {
 		return errors.New("email do cliente é obrigatório")
 	}
-	if cliente.CPF == "" {
-		return errors.New("CPF do cliente é obrigatório")
-	}
-
-	// Busca o cliente existente para manter a data de criação original
-	clienteExistente, err := s.repo.GetByID(cliente.ID)
-	if err != nil {
+	// Cadastros que ainda não têm o documento, como os migrados dos usuários, podem ser
+	// alterados sem informá-lo; o documento informado é validado normalmente
+	if err := validarDocumentos(cliente, clienteExistente.DocumentoPendente); err != nil {
 		return err
 	}
+	if cliente.LimiteCredito < 0 {
+		return fmt.Errorf("%w: limite de crédito não pode ser negativo", domain.ErrClienteInvalido)
+	}
 
 	// Mantém a data de criação original
 	cliente.DataCriacao = clienteExistente.DataCriacao
 
+	if err := s.verificarDocumentoUnico(cliente); err != nil {
+		return err
+	}
+	// Vínculos já gravados continuam aceitos; só o usuário trocado é conferido
+	if cliente.UsuarioID != clienteExistente.UsuarioID {
+		if err := s.verificarUsuario(cliente); err != nil {
+			return err
+		}
+	}
+
 	return s.repo.Update(cliente)
 }
 
+// DeleteCliente remove o cliente; clientes com vendas não podem ser removidos
 func (s *ClienteService) DeleteCliente(id string) error {
+	if id == "" {
+		return errors.New("id do cliente é obrigatório")
+	}
+
 	return s.repo.Delete(id)
 }
+
+// GetCredito informa o limite, o saldo em aberto e o crédito ainda disponível do cliente
+func (s *ClienteService) GetCredito(id string) (*domain.CreditoCliente, error) {
+	cliente, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return consultarCredito(s.repo, cliente, "")
+}
+
+// consultarCredito monta a situação de crédito do cliente desconsiderando a venda informada
+// em exceto, de modo que uma venda em edição não seja contada duas vezes
+func consultarCredito(repo repository.ClienteRepository, cliente *domain.Cliente, exceto string) (*domain.CreditoCliente, error) {
+	saldo, err := repo.GetSaldoAberto(cliente.ID, exceto)
+	if err != nil {
+		return nil, err
+	}
+
+	credito := &domain.CreditoCliente{
+		ClienteID:   cliente.ID,
+		Limite:      cliente.LimiteCredito,
+		SaldoAberto: arredondar(saldo),
+	}
+	if cliente.LimiteCredito > 0 {
+		disponivel := arredondar(cliente.LimiteCredito - credito.SaldoAberto)
+		credito.Disponivel = &disponivel
+	}
+	return credito, nil
+}
+
+// validarDocumentos normaliza CPF, CNPJ e inscrição estadual, mantendo apenas os dígitos,
+// e confere os documentos exigidos pelo tipo de pessoa. Sem tipo informado, o cliente é
+// pessoa física. Com documentoOpcional, o CPF ou CNPJ pode ficar em branco.
+func validarDocumentos(cliente *domain.Cliente, documentoOpcional bool) error {
+	if cliente.TipoPessoa == "" {
+		cliente.TipoPessoa = domain.PessoaFisica
+	}
+	cliente.CPF = utils.NormalizeDocument(cliente.CPF)
+	cliente.CNPJ = utils.NormalizeDocument(cliente.CNPJ)
+	cliente.InscricaoEstadual = utils.NormalizeDocument(cliente.InscricaoEstadual)
+
+	switch cliente.TipoPessoa {
+	case domain.PessoaFisica:
+		if cliente.CPF == "" && !documentoOpcional {
+			return fmt.Errorf("%w: CPF é obrigatório para pessoa física", domain.ErrClienteInvalido)
+		}
+		if cliente.CPF != "" && !utils.ValidateCPF(cliente.CPF) {
+			return fmt.Errorf("%w: CPF %s inválido", domain.ErrClienteInvalido, cliente.CPF)
+		}
+		if cliente.CNPJ != "" || cliente.InscricaoEstadual != "" || cliente.IsentoIE {
+			return fmt.Errorf("%w: CNPJ e inscrição estadual se aplicam apenas a pessoa jurídica", domain.ErrClienteInvalido)
+		}
+	case domain.PessoaJuridica:
+		if cliente.CNPJ == "" && !documentoOpcional {
+			return fmt.Errorf("%w: CNPJ é obrigatório para pessoa jurídica", domain.ErrClienteInvalido)
+		}
+		if cliente.CNPJ != "" && !utils.ValidateCNPJ(cliente.CNPJ) {
+			return fmt.Errorf("%w: CNPJ %s inválido", domain.ErrClienteInvalido, cliente.CNPJ)
+		}
+		if cliente.CPF != "" {
+			return fmt.Errorf("%w: CPF se aplica apenas a pessoa física", domain.ErrClienteInvalido)
+		}
+		if cliente.IsentoIE && cliente.InscricaoEstadual != "" {
+			return fmt.Errorf("%w: cliente isento não deve informar a inscrição estadual", domain.ErrClienteInvalido)
+		}
+		if !cliente.IsentoIE && cliente.InscricaoEstadual == "" {
+			return fmt.Errorf("%w: informe a inscrição estadual ou marque o cliente como isento", domain.ErrClienteInvalido)
+		}
+	default:
+		return fmt.Errorf("%w: tipo de pessoa %s inválido", domain.ErrClienteInvalido, cliente.TipoPessoa)
+	}
+	return nil
+}
+
+// verificarDocumentoUnico garante que o CPF ou CNPJ já normalizado não pertence a outro
+// cliente. Cadastros sem documento não são conferidos.
+func (s *ClienteService) verificarDocumentoUnico(cliente *domain.Cliente) error {
+	if cliente.SemDocumento() {
+		return nil
+	}
+
+	var existente *domain.Cliente
+	var err error
+	if cliente.TipoPessoa == domain.PessoaJuridica {
+		existente, err = s.repo.GetByCNPJ(cliente.CNPJ)
+	} else {
+		existente, err = s.repo.GetByCPF(cliente.CPF)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existente.ID != cliente.ID {
+		if cliente.TipoPessoa == domain.PessoaJuridica {
+			return fmt.Errorf("%w: CNPJ %s", domain.ErrDocumentoJaCadastrado, cliente.CNPJ)
+		}
+		return fmt.Errorf("%w: CPF %s", domain.ErrDocumentoJaCadastrado, cliente.CPF)
+	}
+	return nil
+}
+
+// verificarUsuario confere se o usuário de acesso vinculado ao cliente existe, tem o papel
+// cliente e ainda não pertence a outro cliente
+func (s *ClienteService) verificarUsuario(cliente *domain.Cliente) error {
+	if cliente.UsuarioID == "" {
+		return nil
+	}
+
+	usuario, err := s.usuarioRepo.GetByID(cliente.UsuarioID)
+	if errors.Is(err, domain.ErrUsuarioNaoEncontrado) {
+		return fmt.Errorf("%w: usuário %s não encontrado", domain.ErrClienteInvalido, cliente.UsuarioID)
+	}
+	if err != nil {
+		return err
+	}
+	if usuario.Role != domain.RoleCliente {
+		return fmt.Errorf("%w: o usuário %s não tem o papel %s", domain.ErrClienteInvalido, usuario.ID, domain.RoleCliente)
+	}
+
+	existente, err := s.repo.GetByUsuario(cliente.UsuarioID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if existente.ID != cliente.ID {
+		return fmt.Errorf("%w: usuário %s", domain.ErrUsuarioJaVinculado, cliente.UsuarioID)
+	}
+	return nil
+}
+
+// validarCliente confere se o cliente informado na venda está cadastrado
+func validarCliente(repo repository.ClienteRepository, clienteID string) error {
+	if clienteID == "" {
+		return errors.New("cliente é obrigatório")
+	}
+
+	cliente, err := repo.GetByID(clienteID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("cliente %s não encontrado", clienteID)
+	}
+	if err != nil {
+		return err
+	}
+	if cliente.DataAnonimizacao != nil {
+		return fmt.Errorf("%w: não é possível registrar vendas para o cliente %s", domain.ErrClienteAnonimizado, clienteID)
+	}
+	return nil
+}
@@ -0,0 +1,485 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+type ComissaoService struct {
+	comissaoRepo repository.ComissaoRepository
+	produtoRepo  repository.ProdutoRepository
+	usuarioRepo  domain.UsuarioRepository
+}
+
+func NewComissaoService(comissaoRepo repository.ComissaoRepository, produtoRepo repository.ProdutoRepository, usuarioRepo domain.UsuarioRepository) *ComissaoService {
+	return &ComissaoService{
+		comissaoRepo: comissaoRepo,
+		produtoRepo:  produtoRepo,
+		usuarioRepo:  usuarioRepo,
+	}
+}
+
+func (s *ComissaoService) GetRegras() ([]domain.RegraComissao, error) {
+	return s.comissaoRepo.GetRegras()
+}
+
+func (s *ComissaoService) GetRegra(id string) (*domain.RegraComissao, error) {
+	return s.comissaoRepo.GetRegra(id)
+}
+
+func (s *ComissaoService) CreateRegra(dto domain.SalvarRegraComissaoDTO) (*domain.RegraComissao, error) {
+	regra, err := s.montarRegraComissao(dto)
+	if err != nil {
+		return nil, err
+	}
+	regra.DataCriacao = time.Now()
+
+	if err := s.comissaoRepo.CreateRegra(regra); err != nil {
+		return nil, err
+	}
+	return regra, nil
+}
+
+// UpdateRegra substitui os dados da regra. Extratos já fechados mantêm a comissão que
+// apuraram; os meses em aberto passam a usar a regra alterada.
+func (s *ComissaoService) UpdateRegra(id string, dto domain.SalvarRegraComissaoDTO) (*domain.RegraComissao, error) {
+	atual, err := s.comissaoRepo.GetRegra(id)
+	if err != nil {
+		return nil, err
+	}
+
+	regra, err := s.montarRegraComissao(dto)
+	if err != nil {
+		return nil, err
+	}
+	regra.ID = atual.ID
+	regra.DataCriacao = atual.DataCriacao
+
+	if err := s.comissaoRepo.UpdateRegra(regra); err != nil {
+		return nil, err
+	}
+	return regra, nil
+}
+
+func (s *ComissaoService) DeleteRegra(id string) error {
+	if id == "" {
+		return errors.New("id da regra é obrigatório")
+	}
+	return s.comissaoRepo.DeleteRegra(id)
+}
+
+// GetExtratos retorna o extrato de cada vendedor no mês, sem as linhas. Quem não
+// administra as comissões recebe apenas o próprio extrato. Sem mês informado, vale o
+// mês corrente.
+func (s *ComissaoService) GetExtratos(mes string, operador domain.Operador) ([]domain.ExtratoComissao, error) {
+	if mes == "" {
+		mes = time.Now().Format(formatoMes)
+	}
+	inicio, err := inicioDoMes(mes)
+	if err != nil {
+		return nil, err
+	}
+
+	var vendedores []domain.Usuario
+	if operador.Role.TemPermissao(domain.PermComissoesGerenciar) {
+		usuarios, err := s.usuarioRepo.GetAll()
+		if err != nil {
+			return nil, err
+		}
+		for _, usuario := range usuarios {
+			if usuario.Role.TemPermissao(domain.PermVendasRegistrar) {
+				vendedores = append(vendedores, usuario)
+			}
+		}
+	} else {
+		vendedor, err := s.usuarioRepo.GetByID(operador.UsuarioID)
+		if err != nil {
+			return nil, err
+		}
+		vendedores = append(vendedores, *vendedor)
+	}
+
+	extratos := []domain.ExtratoComissao{}
+	for i := range vendedores {
+		extrato, err := s.extrato(&vendedores[i], mes, inicio)
+		if err != nil {
+			return nil, err
+		}
+		// Na visão de todos os vendedores, omite quem não teve movimento no mês
+		semMovimento := extrato.Status == domain.ExtratoAberto && len(extrato.Itens) == 0
+		if semMovimento && operador.Role.TemPermissao(domain.PermComissoesGerenciar) {
+			continue
+		}
+		extrato.Itens = nil
+		extratos = append(extratos, *extrato)
+	}
+	return extratos, nil
+}
+
+// GetExtrato retorna o extrato do vendedor no mês com as suas linhas. Meses fechados
+// são lidos como foram gravados; os demais são apurados na hora. Quem não administra as
+// comissões consulta apenas o próprio extrato.
+func (s *ComissaoService) GetExtrato(vendedorID, mes string, operador domain.Operador) (*domain.ExtratoComissao, error) {
+	if err := acessoComissao(vendedorID, operador); err != nil {
+		return nil, err
+	}
+	inicio, err := inicioDoMes(mes)
+	if err != nil {
+		return nil, err
+	}
+	vendedor, err := s.usuarioRepo.GetByID(vendedorID)
+	if err != nil {
+		return nil, err
+	}
+	return s.extrato(vendedor, mes, inicio)
+}
+
+// Fechar congela o extrato do vendedor em um mês já encerrado. Devoluções, cancelamentos
+// e alterações posteriores nas vendas do mês não mudam o extrato fechado: a diferença
+// entra como ajuste no próximo mês que for fechado.
+func (s *ComissaoService) Fechar(vendedorID, mes string, operador domain.Operador) (*domain.ExtratoComissao, error) {
+	inicio, err := inicioDoMes(mes)
+	if err != nil {
+		return nil, err
+	}
+	agora := time.Now()
+	if agora.Before(inicio.AddDate(0, 1, 0)) {
+		return nil, fmt.Errorf("%w: o mês %s ainda não terminou", domain.ErrFechamentoComissao, mes)
+	}
+
+	vendedor, err := s.usuarioRepo.GetByID(vendedorID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.comissaoRepo.GetExtrato(vendedorID, mes); err == nil {
+		return nil, fmt.Errorf("%w: o mês %s já foi fechado", domain.ErrFechamentoComissao, mes)
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	extrato, err := s.apurar(vendedor, mes, inicio)
+	if err != nil {
+		return nil, err
+	}
+	extrato.Status = domain.ExtratoFechado
+	extrato.DataFechamento = &agora
+	extrato.FechadoPor = operador.UsuarioID
+
+	if err := s.comissaoRepo.Fechar(extrato); err != nil {
+		return nil, err
+	}
+	return extrato, nil
+}
+
+// Aprovar libera para pagamento o extrato fechado do vendedor no mês
+func (s *ComissaoService) Aprovar(vendedorID, mes string, operador domain.Operador) (*domain.ExtratoComissao, error) {
+	if _, err := inicioDoMes(mes); err != nil {
+		return nil, err
+	}
+	extrato, err := s.comissaoRepo.GetExtrato(vendedorID, mes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: o mês %s precisa ser fechado antes da aprovação", domain.ErrFechamentoComissao, mes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if extrato.Status != domain.ExtratoFechado {
+		return nil, fmt.Errorf("%w: o extrato de %s já foi aprovado", domain.ErrFechamentoComissao, mes)
+	}
+
+	if err := s.comissaoRepo.Aprovar(extrato.ID, operador.UsuarioID, time.Now()); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: o extrato de %s já foi aprovado", domain.ErrFechamentoComissao, mes)
+		}
+		return nil, err
+	}
+	return s.comissaoRepo.GetExtrato(vendedorID, mes)
+}
+
+// extrato retorna o extrato gravado no fechamento do mês ou, se ele ainda não foi
+// fechado, o apura
+func (s *ComissaoService) extrato(vendedor *domain.Usuario, mes string, inicio time.Time) (*domain.ExtratoComissao, error) {
+	fechado, err := s.comissaoRepo.GetExtrato(vendedor.ID, mes)
+	if err == nil {
+		return fechado, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return s.apurar(vendedor, mes, inicio)
+}
+
+// apurar calcula a comissão das vendas do vendedor no mês, líquidas de devoluções, e
+// acrescenta os ajustes pendentes dos meses fechados anteriores
+func (s *ComissaoService) apurar(vendedor *domain.Usuario, mes string, inicio time.Time) (*domain.ExtratoComissao, error) {
+	regras, err := s.comissaoRepo.GetRegras()
+	if err != nil {
+		return nil, err
+	}
+	fim := inicio.AddDate(0, 1, 0)
+	bases, err := s.comissaoRepo.GetBases(vendedor.ID, inicio, fim)
+	if err != nil {
+		return nil, err
+	}
+
+	extrato := &domain.ExtratoComissao{
+		VendedorID:   vendedor.ID,
+		VendedorNome: vendedor.Nome,
+		Mes:          mes,
+		Status:       domain.ExtratoAberto,
+	}
+	for _, base := range bases {
+		extrato.TotalVendas += base.Valor
+	}
+	extrato.TotalVendas = arredondar(extrato.TotalVendas)
+	extrato.PercentualBase = percentualGeral(regras, fim.Add(-time.Nanosecond), extrato.TotalVendas)
+
+	for _, base := range bases {
+		if arredondar(base.Valor) == 0 {
+			continue
+		}
+		geral := percentualGeral(regras, base.DataVenda, extrato.TotalVendas)
+		extrato.Itens = append(extrato.Itens, linhaComissao(base, base.Valor, percentualComissao(regras, base, geral), false))
+	}
+
+	ajustes, err := s.ajustes(vendedor.ID, mes, inicio, regras)
+	if err != nil {
+		return nil, err
+	}
+	extrato.Itens = append(extrato.Itens, ajustes...)
+
+	for _, item := range extrato.Itens {
+		extrato.ValorComissao += item.Valor
+		if item.Ajuste {
+			extrato.ValorAjustes += item.Valor
+		}
+	}
+	extrato.ValorComissao = arredondar(extrato.ValorComissao)
+	extrato.ValorAjustes = arredondar(extrato.ValorAjustes)
+	return extrato, nil
+}
+
+// ajustes compara o que já foi comissionado nos meses fechados antes do mês informado com
+// as vendas desses meses como estão hoje. Itens devolvidos, cancelados, removidos ou
+// passados a outro vendedor geram ajustes negativos pelo percentual original; itens que
+// passaram a contar depois do fechamento, como os de uma venda transferida para o
+// vendedor, geram ajustes positivos pelas regras do mês da venda.
+func (s *ComissaoService) ajustes(vendedorID, mes string, inicio time.Time, regras []domain.RegraComissao) ([]domain.ItemComissao, error) {
+	fechados, err := s.comissaoRepo.GetExtratos(vendedorID, "")
+	if err != nil {
+		return nil, err
+	}
+	percentualDoMes := make(map[string]float64)
+	primeiro := inicio
+	for _, fechado := range fechados {
+		if fechado.Mes >= mes {
+			continue
+		}
+		percentualDoMes[fechado.Mes] = fechado.PercentualBase
+		if inicioFechado, err := inicioDoMes(fechado.Mes); err == nil && inicioFechado.Before(primeiro) {
+			primeiro = inicioFechado
+		}
+	}
+	if len(percentualDoMes) == 0 {
+		return nil, nil
+	}
+
+	comissionado, err := s.comissaoRepo.GetComissionado(vendedorID, mes)
+	if err != nil {
+		return nil, err
+	}
+	bases, err := s.comissaoRepo.GetBases(vendedorID, primeiro, inicio)
+	if err != nil {
+		return nil, err
+	}
+	atuais := make(map[string]domain.BaseComissao, len(bases))
+	for _, base := range bases {
+		atuais[base.ItemVendaID] = base
+	}
+
+	var ajustes []domain.ItemComissao
+	lancados := make(map[string]bool, len(comissionado))
+	for _, item := range comissionado {
+		lancados[item.ItemVendaID] = true
+		diferenca := arredondar(atuais[item.ItemVendaID].Valor - item.Base)
+		if diferenca == 0 {
+			continue
+		}
+		base := domain.BaseComissao{
+			VendaID:     item.VendaID,
+			ItemVendaID: item.ItemVendaID,
+			ProdutoID:   item.ProdutoID,
+			DataVenda:   item.DataVenda,
+		}
+		ajustes = append(ajustes, linhaComissao(base, diferenca, item.Percentual, true))
+	}
+	for _, base := range bases {
+		if lancados[base.ItemVendaID] || arredondar(base.Valor) == 0 {
+			continue
+		}
+		geral, fechado := percentualDoMes[base.DataVenda.In(time.Local).Format(formatoMes)]
+		if !fechado {
+			continue
+		}
+		ajustes = append(ajustes, linhaComissao(base, base.Valor, percentualComissao(regras, base, geral), true))
+	}
+
+	sort.Slice(ajustes, func(i, j int) bool {
+		if !ajustes[i].DataVenda.Equal(ajustes[j].DataVenda) {
+			return ajustes[i].DataVenda.Before(ajustes[j].DataVenda)
+		}
+		if ajustes[i].VendaID != ajustes[j].VendaID {
+			return ajustes[i].VendaID < ajustes[j].VendaID
+		}
+		return ajustes[i].ItemVendaID < ajustes[j].ItemVendaID
+	})
+	return ajustes, nil
+}
+
+// montarRegraComissao valida os campos exigidos pelo tipo da regra
+func (s *ComissaoService) montarRegraComissao(dto domain.SalvarRegraComissaoDTO) (*domain.RegraComissao, error) {
+	if dto.Descricao == "" {
+		return nil, fmt.Errorf("%w: descrição é obrigatória", domain.ErrRegraComissaoInvalida)
+	}
+	if !dto.Tipo.Valida() {
+		return nil, fmt.Errorf("%w: tipo %q desconhecido", domain.ErrRegraComissaoInvalida, dto.Tipo)
+	}
+	if dto.Percentual <= 0 || dto.Percentual > 100 {
+		return nil, fmt.Errorf("%w: o percentual deve ser maior que zero e no máximo 100", domain.ErrRegraComissaoInvalida)
+	}
+	if dto.Inicio != nil && dto.Fim != nil && dto.Fim.Before(*dto.Inicio) {
+		return nil, fmt.Errorf("%w: o fim da vigência é anterior ao início", domain.ErrRegraComissaoInvalida)
+	}
+	if dto.Tipo != domain.ComissaoProduto && dto.ProdutoID != "" {
+		return nil, fmt.Errorf("%w: apenas regras por produto indicam o produto", domain.ErrRegraComissaoInvalida)
+	}
+	if dto.Tipo != domain.ComissaoCategoria && dto.Categoria != "" {
+		return nil, fmt.Errorf("%w: apenas regras por categoria indicam a categoria", domain.ErrRegraComissaoInvalida)
+	}
+	if dto.Tipo != domain.ComissaoMeta && dto.MetaMensal != 0 {
+		return nil, fmt.Errorf("%w: apenas faixas por meta indicam a meta mensal", domain.ErrRegraComissaoInvalida)
+	}
+
+	switch dto.Tipo {
+	case domain.ComissaoProduto:
+		if dto.ProdutoID == "" {
+			return nil, fmt.Errorf("%w: produto é obrigatório", domain.ErrRegraComissaoInvalida)
+		}
+		if _, err := s.produtoRepo.GetByID(dto.ProdutoID); errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: produto %s não encontrado", domain.ErrRegraComissaoInvalida, dto.ProdutoID)
+		} else if err != nil {
+			return nil, err
+		}
+	case domain.ComissaoCategoria:
+		if dto.Categoria == "" {
+			return nil, fmt.Errorf("%w: categoria é obrigatória", domain.ErrRegraComissaoInvalida)
+		}
+	case domain.ComissaoMeta:
+		if dto.MetaMensal <= 0 {
+			return nil, fmt.Errorf("%w: a meta mensal deve ser maior que zero", domain.ErrRegraComissaoInvalida)
+		}
+	}
+
+	return &domain.RegraComissao{
+		Descricao:  dto.Descricao,
+		Tipo:       dto.Tipo,
+		ProdutoID:  dto.ProdutoID,
+		Categoria:  dto.Categoria,
+		Percentual: dto.Percentual,
+		MetaMensal: dto.MetaMensal,
+		Inicio:     dto.Inicio,
+		Fim:        dto.Fim,
+		Ativa:      dto.Ativa == nil || *dto.Ativa,
+	}, nil
+}
+
+// formatoMes é o layout dos meses de referência dos extratos (AAAA-MM)
+const formatoMes = "2006-01"
+
+// inicioDoMes interpreta o mês de referência no fuso do servidor
+func inicioDoMes(mes string) (time.Time, error) {
+	inicio, err := time.ParseInLocation(formatoMes, mes, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q, use o formato AAAA-MM", domain.ErrMesComissaoInvalido, mes)
+	}
+	return inicio, nil
+}
+
+// acessoComissao permite a cada vendedor consultar apenas o próprio extrato, salvo a
+// quem administra as comissões
+func acessoComissao(vendedorID string, operador domain.Operador) error {
+	if vendedorID == operador.UsuarioID || operador.Role.TemPermissao(domain.PermComissoesGerenciar) {
+		return nil
+	}
+	return fmt.Errorf("%w: extrato de comissão de outro vendedor", domain.ErrAcessoNegado)
+}
+
+// percentualGeral é o maior percentual entre as regras gerais e as faixas cuja meta
+// mensal o total líquido do vendedor no mês atingiu, consideradas as regras vigentes
+// na data informada
+func percentualGeral(regras []domain.RegraComissao, data time.Time, totalMes float64) float64 {
+	var percentual float64
+	for _, regra := range regras {
+		if !regra.Vigente(data) {
+			continue
+		}
+		switch regra.Tipo {
+		case domain.ComissaoPercentual:
+			percentual = math.Max(percentual, regra.Percentual)
+		case domain.ComissaoMeta:
+			if totalMes >= regra.MetaMensal {
+				percentual = math.Max(percentual, regra.Percentual)
+			}
+		}
+	}
+	return percentual
+}
+
+// percentualComissao escolhe o percentual do item: a maior regra do produto vigente na
+// data da venda, senão a maior regra da categoria, senão o percentual geral do mês
+func percentualComissao(regras []domain.RegraComissao, base domain.BaseComissao, geral float64) float64 {
+	var porProduto, porCategoria float64
+	for _, regra := range regras {
+		if !regra.Vigente(base.DataVenda) {
+			continue
+		}
+		switch regra.Tipo {
+		case domain.ComissaoProduto:
+			if regra.ProdutoID == base.ProdutoID {
+				porProduto = math.Max(porProduto, regra.Percentual)
+			}
+		case domain.ComissaoCategoria:
+			if base.Categoria != "" && regra.Categoria == base.Categoria {
+				porCategoria = math.Max(porCategoria, regra.Percentual)
+			}
+		}
+	}
+	switch {
+	case porProduto > 0:
+		return porProduto
+	case porCategoria > 0:
+		return porCategoria
+	default:
+		return geral
+	}
+}
+
+func linhaComissao(base domain.BaseComissao, valorBase, percentual float64, ajuste bool) domain.ItemComissao {
+	valorBase = arredondar(valorBase)
+	return domain.ItemComissao{
+		VendaID:     base.VendaID,
+		ItemVendaID: base.ItemVendaID,
+		ProdutoID:   base.ProdutoID,
+		DataVenda:   base.DataVenda,
+		Base:        valorBase,
+		Percentual:  percentual,
+		Valor:       arredondar(valorBase * percentual / 100),
+		Ajuste:      ajuste,
+	}
+}
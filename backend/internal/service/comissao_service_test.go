@@ -0,0 +1,194 @@
+package service
+
+import (
+	"testing"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+func data(texto string) time.Time {
+	d, err := time.ParseInLocation("2006-01-02", texto, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func ptrData(texto string) *time.Time {
+	d := data(texto)
+	return &d
+}
+
+func TestPercentualGeral(t *testing.T) {
+	regras := []domain.RegraComissao{
+		{Tipo: domain.ComissaoPercentual, Percentual: 2, Ativa: true},
+		{Tipo: domain.ComissaoMeta, Percentual: 4, MetaMensal: 5000, Ativa: true},
+		{Tipo: domain.ComissaoMeta, Percentual: 6, MetaMensal: 10000, Ativa: true},
+		// Regras inativas ou fora da vigência não contam
+		{Tipo: domain.ComissaoPercentual, Percentual: 10, Ativa: false},
+		{Tipo: domain.ComissaoMeta, Percentual: 8, MetaMensal: 1000, Ativa: true, Fim: ptrData("2025-12-31")},
+		{Tipo: domain.ComissaoMeta, Percentual: 9, MetaMensal: 1000, Ativa: true, Inicio: ptrData("2026-03-01")},
+	}
+
+	casos := []struct {
+		nome     string
+		total    float64
+		esperado float64
+	}{
+		{"sem vendas fica com a regra geral", 0, 2},
+		{"abaixo da primeira faixa", 4999.99, 2},
+		{"exatamente na primeira faixa", 5000, 4},
+		{"entre as faixas", 9999.99, 4},
+		{"na faixa mais alta", 10000, 6},
+		{"acima da faixa mais alta", 50000, 6},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if obtido := percentualGeral(regras, data("2026-02-15"), caso.total); obtido != caso.esperado {
+				t.Errorf("percentualGeral com total %.2f = %v, esperado %v", caso.total, obtido, caso.esperado)
+			}
+		})
+	}
+
+	if obtido := percentualGeral(nil, data("2026-02-15"), 50000); obtido != 0 {
+		t.Errorf("percentualGeral sem regras = %v, esperado 0", obtido)
+	}
+}
+
+func TestPercentualComissao(t *testing.T) {
+	regras := []domain.RegraComissao{
+		{Tipo: domain.ComissaoProduto, ProdutoID: "notebook", Percentual: 3, Ativa: true},
+		{Tipo: domain.ComissaoProduto, ProdutoID: "notebook", Percentual: 1, Ativa: true},
+		{Tipo: domain.ComissaoProduto, ProdutoID: "mouse", Percentual: 12, Ativa: true, Inicio: ptrData("2026-02-10")},
+		{Tipo: domain.ComissaoCategoria, Categoria: "informatica", Percentual: 7, Ativa: true},
+		{Tipo: domain.ComissaoCategoria, Categoria: "informatica", Percentual: 9, Ativa: false},
+		{Tipo: domain.ComissaoCategoria, Categoria: "papelaria", Percentual: 5, Ativa: true, Fim: ptrData("2026-01-31")},
+	}
+	const geral = 4
+
+	casos := []struct {
+		nome     string
+		base     domain.BaseComissao
+		esperado float64
+	}{
+		{"a regra do produto vence a da categoria, mesmo menor",
+			domain.BaseComissao{ProdutoID: "notebook", Categoria: "informatica", DataVenda: data("2026-02-15")}, 3},
+		{"a regra do produto vence o percentual geral",
+			domain.BaseComissao{ProdutoID: "notebook", DataVenda: data("2026-02-15")}, 3},
+		{"regra do produto ainda não vigente cai na categoria",
+			domain.BaseComissao{ProdutoID: "mouse", Categoria: "informatica", DataVenda: data("2026-02-09")}, 7},
+		{"regra do produto vigente",
+			domain.BaseComissao{ProdutoID: "mouse", Categoria: "informatica", DataVenda: data("2026-02-10")}, 12},
+		{"sem regra do produto vale a maior regra ativa da categoria",
+			domain.BaseComissao{ProdutoID: "teclado", Categoria: "informatica", DataVenda: data("2026-02-15")}, 7},
+		{"regra da categoria vencida cai no percentual geral",
+			domain.BaseComissao{ProdutoID: "caderno", Categoria: "papelaria", DataVenda: data("2026-02-15")}, geral},
+		{"produto sem categoria fica com o percentual geral",
+			domain.BaseComissao{ProdutoID: "caneta", DataVenda: data("2026-02-15")}, geral},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if obtido := percentualComissao(regras, caso.base, geral); obtido != caso.esperado {
+				t.Errorf("percentualComissao = %v, esperado %v", obtido, caso.esperado)
+			}
+		})
+	}
+}
+
+// comissaoRepoFalso devolve regras, bases e extratos fixos; as bases são indexadas
+// pelo mês de início do período consultado
+type comissaoRepoFalso struct {
+	repository.ComissaoRepository
+	regras       []domain.RegraComissao
+	bases        map[string][]domain.BaseComissao
+	extratos     []domain.ExtratoComissao
+	comissionado []domain.ItemComissao
+}
+
+func (r *comissaoRepoFalso) GetRegras() ([]domain.RegraComissao, error) {
+	return r.regras, nil
+}
+
+func (r *comissaoRepoFalso) GetBases(vendedorID string, inicio, fim time.Time) ([]domain.BaseComissao, error) {
+	return r.bases[inicio.Format(formatoMes)], nil
+}
+
+func (r *comissaoRepoFalso) GetExtratos(vendedorID, mes string) ([]domain.ExtratoComissao, error) {
+	return r.extratos, nil
+}
+
+func (r *comissaoRepoFalso) GetComissionado(vendedorID, antesDe string) ([]domain.ItemComissao, error) {
+	return r.comissionado, nil
+}
+
+func TestApurarComissao(t *testing.T) {
+	repo := &comissaoRepoFalso{
+		regras: []domain.RegraComissao{
+			{Tipo: domain.ComissaoPercentual, Percentual: 2, Ativa: true},
+			{Tipo: domain.ComissaoMeta, Percentual: 5, MetaMensal: 1000, Ativa: true},
+			{Tipo: domain.ComissaoProduto, ProdutoID: "notebook", Percentual: 1, Ativa: true},
+		},
+		bases: map[string][]domain.BaseComissao{
+			"2026-02": {
+				{VendaID: "v1", ItemVendaID: "i1", ProdutoID: "notebook", DataVenda: data("2026-02-03"), Valor: 800},
+				{VendaID: "v2", ItemVendaID: "i2", ProdutoID: "mouse", DataVenda: data("2026-02-20"), Valor: 300},
+				// Item totalmente devolvido não gera linha, mas também não soma no total
+				{VendaID: "v3", ItemVendaID: "i3", ProdutoID: "mouse", DataVenda: data("2026-02-21"), Valor: 0},
+			},
+			// Venda de janeiro, já fechado, parcialmente devolvida depois do fechamento
+			"2026-01": {
+				{VendaID: "v0", ItemVendaID: "i0", ProdutoID: "mouse", DataVenda: data("2026-01-10"), Valor: 60},
+			},
+		},
+		extratos: []domain.ExtratoComissao{{Mes: "2026-01", Status: domain.ExtratoFechado, PercentualBase: 3}},
+		comissionado: []domain.ItemComissao{
+			{VendaID: "v0", ItemVendaID: "i0", ProdutoID: "mouse", DataVenda: data("2026-01-10"), Base: 100, Percentual: 3},
+		},
+	}
+	s := &ComissaoService{comissaoRepo: repo}
+
+	extrato, err := s.apurar(&domain.Usuario{ID: "vendedor-1", Nome: "Vendedor"}, "2026-02", data("2026-02-01"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if extrato.TotalVendas != 1100 {
+		t.Errorf("total de vendas = %v, esperado 1100", extrato.TotalVendas)
+	}
+	// O total do mês atingiu a meta, então a faixa de 5% vale para o mês inteiro,
+	// inclusive para as vendas anteriores ao dia em que a meta foi batida
+	if extrato.PercentualBase != 5 {
+		t.Errorf("percentual base = %v, esperado 5", extrato.PercentualBase)
+	}
+
+	esperados := []struct {
+		itemVendaID string
+		percentual  float64
+		valor       float64
+		ajuste      bool
+	}{
+		{"i1", 1, 8, false},
+		{"i2", 5, 15, false},
+		{"i0", 3, -1.2, true},
+	}
+	if len(extrato.Itens) != len(esperados) {
+		t.Fatalf("%d linhas no extrato, esperado %d: %+v", len(extrato.Itens), len(esperados), extrato.Itens)
+	}
+	for i, esperado := range esperados {
+		item := extrato.Itens[i]
+		if item.ItemVendaID != esperado.itemVendaID || item.Percentual != esperado.percentual ||
+			item.Valor != esperado.valor || item.Ajuste != esperado.ajuste {
+			t.Errorf("linha %d = %+v, esperado %+v", i, item, esperado)
+		}
+	}
+
+	if extrato.ValorAjustes != -1.2 {
+		t.Errorf("valor dos ajustes = %v, esperado -1.2", extrato.ValorAjustes)
+	}
+	if extrato.ValorComissao != 21.8 {
+		t.Errorf("valor da comissão = %v, esperado 21.8", extrato.ValorComissao)
+	}
+}
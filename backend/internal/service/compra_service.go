@@ -0,0 +1,164 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+type CompraService struct {
+	pedidoRepo     repository.PedidoCompraRepository
+	fornecedorRepo repository.FornecedorRepository
+	produtoRepo    repository.ProdutoRepository
+	localRepo      repository.LocalRepository
+}
+
+func NewCompraService(pedidoRepo repository.PedidoCompraRepository, fornecedorRepo repository.FornecedorRepository, produtoRepo repository.ProdutoRepository, localRepo repository.LocalRepository) *CompraService {
+	return &CompraService{
+		pedidoRepo:     pedidoRepo,
+		fornecedorRepo: fornecedorRepo,
+		produtoRepo:    produtoRepo,
+		localRepo:      localRepo,
+	}
+}
+
+func (s *CompraService) GetAll(fornecedorID string, status domain.StatusPedidoCompra) ([]domain.PedidoCompra, error) {
+	return s.pedidoRepo.GetAll(fornecedorID, status)
+}
+
+func (s *CompraService) GetByID(id string) (*domain.PedidoCompra, error) {
+	return s.pedidoRepo.GetByID(id)
+}
+
+// Create emite um pedido de compra para um fornecedor ativo com o custo previsto de cada item.
+// Sem local de entrega informado, as mercadorias são recebidas no local principal.
+func (s *CompraService) Create(dto domain.CreatePedidoCompraDTO, operador domain.Operador) (*domain.PedidoCompra, error) {
+	if dto.FornecedorID == "" {
+		return nil, errors.New("fornecedor é obrigatório")
+	}
+	if len(dto.Itens) == 0 {
+		return nil, errors.New("pedido de compra deve ter pelo menos um item")
+	}
+
+	fornecedor, err := s.fornecedorRepo.GetByID(dto.FornecedorID)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao buscar fornecedor %s: %v", dto.FornecedorID, err)
+	}
+	if !fornecedor.Ativo {
+		return nil, fmt.Errorf("fornecedor %s está inativo", fornecedor.Nome)
+	}
+
+	localID, err := validarLocal(s.localRepo, dto.LocalID)
+	if err != nil {
+		return nil, err
+	}
+
+	pedido := &domain.PedidoCompra{
+		FornecedorID:    fornecedor.ID,
+		LocalID:         localID,
+		Fornecedor:      fornecedor,
+		Status:          domain.PedidoCompraAberto,
+		Observacao:      dto.Observacao,
+		UsuarioID:       operador.UsuarioID,
+		PrevisaoEntrega: dto.PrevisaoEntrega,
+		DataPedido:      time.Now(),
+	}
+
+	for _, itemDTO := range dto.Itens {
+		if itemDTO.ProdutoID == "" {
+			return nil, errors.New("id do produto é obrigatório")
+		}
+		if itemDTO.Quantidade <= 0 {
+			return nil, errors.New("quantidade deve ser maior que zero")
+		}
+		if itemDTO.CustoUnitario <= 0 {
+			return nil, errors.New("custo unitário deve ser maior que zero")
+		}
+		if _, err := s.produtoRepo.GetByID(itemDTO.ProdutoID); err != nil {
+			return nil, fmt.Errorf("erro ao buscar produto %s: %v", itemDTO.ProdutoID, err)
+		}
+
+		item := domain.ItemPedidoCompra{
+			ProdutoID:     itemDTO.ProdutoID,
+			Quantidade:    itemDTO.Quantidade,
+			CustoUnitario: arredondar(itemDTO.CustoUnitario),
+		}
+		item.Total = arredondar(float64(item.Quantidade) * item.CustoUnitario)
+		pedido.ValorTotal += item.Total
+		pedido.Itens = append(pedido.Itens, item)
+	}
+	pedido.ValorTotal = arredondar(pedido.ValorTotal)
+
+	if err := s.pedidoRepo.Create(pedido); err != nil {
+		return nil, err
+	}
+	return pedido, nil
+}
+
+// Receber registra a chegada das mercadorias do pedido. Cada item recebido entra no estoque
+// e passa a definir o custo do produto; sem itens informados recebe todo o saldo pendente
+// pelo custo previsto.
+func (s *CompraService) Receber(id string, recebimentos []domain.RecebimentoItem, operador domain.Operador) (*domain.PedidoCompra, error) {
+	pedido, err := s.pedidoRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !pedido.Status.Pendente() {
+		return nil, fmt.Errorf("%w: pedido com status %s não aceita recebimentos", domain.ErrTransicaoStatusInvalida, pedido.Status)
+	}
+
+	itens := make(map[string]domain.ItemPedidoCompra, len(pedido.Itens))
+	for _, item := range pedido.Itens {
+		itens[item.ID] = item
+	}
+
+	if len(recebimentos) == 0 {
+		for _, item := range pedido.Itens {
+			if pendente := item.Pendente(); pendente > 0 {
+				recebimentos = append(recebimentos, domain.RecebimentoItem{ItemID: item.ID, Quantidade: pendente})
+			}
+		}
+	}
+
+	for i := range recebimentos {
+		recebimento := &recebimentos[i]
+		item, ok := itens[recebimento.ItemID]
+		if !ok {
+			return nil, fmt.Errorf("item %s não pertence ao pedido", recebimento.ItemID)
+		}
+		if recebimento.Quantidade <= 0 {
+			return nil, errors.New("quantidade recebida deve ser maior que zero")
+		}
+		if recebimento.CustoUnitario < 0 {
+			return nil, errors.New("custo unitário não pode ser negativo")
+		}
+		if recebimento.CustoUnitario == 0 {
+			recebimento.CustoUnitario = item.CustoUnitario
+		}
+		recebimento.CustoUnitario = arredondar(recebimento.CustoUnitario)
+		recebimento.ProdutoID = item.ProdutoID
+	}
+
+	if _, err := s.pedidoRepo.Receber(id, recebimentos, operador.UsuarioID); err != nil {
+		return nil, err
+	}
+
+	return s.pedidoRepo.GetByID(id)
+}
+
+// Cancelar encerra o pedido; as quantidades ainda pendentes deixam de ser esperadas
+func (s *CompraService) Cancelar(id string) (*domain.PedidoCompra, error) {
+	if id == "" {
+		return nil, errors.New("id do pedido é obrigatório")
+	}
+	if _, err := s.pedidoRepo.GetByID(id); err != nil {
+		return nil, err
+	}
+
+	if err := s.pedidoRepo.Cancelar(id); err != nil {
+		return nil, err
+	}
+	return s.pedidoRepo.GetByID(id)
+}
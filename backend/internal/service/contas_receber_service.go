@@ -0,0 +1,175 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+// MaxParcelas limita o número de parcelas de uma venda a prazo
+const MaxParcelas = 48
+
+type ContasReceberService struct {
+	parcelaRepo repository.ParcelaRepository
+	vendaRepo   repository.VendaRepository
+}
+
+func NewContasReceberService(parcelaRepo repository.ParcelaRepository, vendaRepo repository.VendaRepository) *ContasReceberService {
+	return &ContasReceberService{
+		parcelaRepo: parcelaRepo,
+		vendaRepo:   vendaRepo,
+	}
+}
+
+// Listar retorna as parcelas a receber de acordo com o filtro, calculando os dias de atraso.
+// Sem domain.PermVendasTodas, o operador vê apenas as parcelas das vendas que registrou.
+func (s *ContasReceberService) Listar(filtro domain.FiltroContasReceber, operador domain.Operador) ([]domain.Parcela, error) {
+	if !operador.Role.TemPermissao(domain.PermVendasTodas) {
+		filtro.VendedorID = operador.UsuarioID
+	}
+	parcelas, err := s.parcelaRepo.Listar(filtro)
+	if err != nil {
+		return nil, err
+	}
+	calcularAtraso(parcelas, time.Now())
+	return parcelas, nil
+}
+
+func (s *ContasReceberService) GetByVenda(vendaID string) ([]domain.Parcela, error) {
+	if _, err := s.vendaRepo.GetByID(vendaID); err != nil {
+		return nil, err
+	}
+
+	parcelas, err := s.parcelaRepo.GetByVenda(vendaID)
+	if err != nil {
+		return nil, err
+	}
+	calcularAtraso(parcelas, time.Now())
+	return parcelas, nil
+}
+
+// Quitar registra o recebimento de uma parcela em aberto. Sem valor informado considera o
+// valor da parcela; valores maiores cobrem juros e multa de atraso. Retorna true quando a
+// parcela era a última em aberto e a venda passou para paga.
+func (s *ContasReceberService) Quitar(id string, dto domain.QuitarParcelaDTO, operador domain.Operador) (*domain.Parcela, bool, error) {
+	if id == "" {
+		return nil, false, errors.New("id da parcela é obrigatório")
+	}
+	if !dto.Forma.Valida() {
+		return nil, false, fmt.Errorf("forma de pagamento inválida: %s", dto.Forma)
+	}
+	if dto.Forma == domain.FormaPontos {
+		return nil, false, errors.New("pontos de fidelidade só podem pagar vendas à vista")
+	}
+
+	parcela, err := s.parcelaRepo.GetByID(id)
+	if err != nil {
+		return nil, false, err
+	}
+	if parcela.Status != domain.ParcelaAberta {
+		return nil, false, fmt.Errorf("%w: parcela com status %s não pode ser quitada", domain.ErrTransicaoStatusInvalida, parcela.Status)
+	}
+
+	valorPago := parcela.Valor
+	if dto.ValorPago > 0 {
+		valorPago = arredondar(dto.ValorPago)
+	}
+	if valorPago < parcela.Valor {
+		return nil, false, fmt.Errorf("valor pago de %.2f menor que o valor de %.2f da parcela", valorPago, parcela.Valor)
+	}
+
+	agora := time.Now()
+	parcela.ValorPago = valorPago
+	parcela.FormaPagamento = dto.Forma
+	parcela.DataPagamento = &agora
+
+	quitada, err := s.parcelaRepo.Quitar(parcela, operador.UsuarioID)
+	if err != nil {
+		return nil, false, err
+	}
+	return parcela, quitada, nil
+}
+
+// validarPlanoParcelamento confere o plano informado na venda. Sem primeiro vencimento, a
+// primeira parcela vence um mês depois da venda. Os vencimentos guardam apenas a data.
+func validarPlanoParcelamento(plano *domain.PlanoParcelamento, dataVenda time.Time) error {
+	if plano.NumeroParcelas <= 0 {
+		return errors.New("número de parcelas deve ser maior que zero")
+	}
+	if plano.NumeroParcelas > MaxParcelas {
+		return fmt.Errorf("número de parcelas não pode passar de %d", MaxParcelas)
+	}
+	if plano.TaxaJuros < 0 {
+		return errors.New("taxa de juros não pode ser negativa")
+	}
+
+	if plano.PrimeiroVencimento.IsZero() {
+		plano.PrimeiroVencimento = somarMeses(dataVenda, 1)
+	}
+	plano.PrimeiroVencimento = apenasData(plano.PrimeiroVencimento)
+	if plano.PrimeiroVencimento.Before(apenasData(dataVenda)) {
+		return errors.New("primeiro vencimento não pode ser anterior à data da venda")
+	}
+	return nil
+}
+
+// gerarParcelas divide o valor pela tabela Price: parcelas iguais com juros compostos
+// mensais. A última parcela absorve a diferença de arredondamento.
+func gerarParcelas(valor float64, plano domain.PlanoParcelamento) []domain.Parcela {
+	n := plano.NumeroParcelas
+	taxa := plano.TaxaJuros / 100
+
+	total := valor
+	valorParcela := arredondar(valor / float64(n))
+	if taxa > 0 {
+		prestacao := valor * taxa / (1 - math.Pow(1+taxa, -float64(n)))
+		valorParcela = arredondar(prestacao)
+		total = arredondar(prestacao * float64(n))
+	}
+
+	parcelas := make([]domain.Parcela, n)
+	for i := range parcelas {
+		parcelas[i] = domain.Parcela{
+			Numero:         i + 1,
+			Valor:          valorParcela,
+			DataVencimento: somarMeses(plano.PrimeiroVencimento, i),
+			Status:         domain.ParcelaAberta,
+		}
+	}
+	parcelas[n-1].Valor = arredondar(total - valorParcela*float64(n-1))
+
+	return parcelas
+}
+
+// calcularAtraso preenche os dias de atraso das parcelas em aberto já vencidas
+func calcularAtraso(parcelas []domain.Parcela, agora time.Time) {
+	hoje := apenasData(agora.UTC())
+	for i := range parcelas {
+		parcela := &parcelas[i]
+		if parcela.Status != domain.ParcelaAberta {
+			continue
+		}
+		if dias := int(hoje.Sub(apenasData(parcela.DataVencimento)).Hours() / 24); dias > 0 {
+			parcela.DiasAtraso = dias
+		}
+	}
+}
+
+// somarMeses avança a data em meses mantendo o dia, ou o último dia quando o mês é mais curto
+func somarMeses(data time.Time, meses int) time.Time {
+	ano, mes, dia := data.Date()
+	ultimoDia := time.Date(ano, mes+time.Month(meses)+1, 0, 0, 0, 0, 0, data.Location()).Day()
+	if dia > ultimoDia {
+		dia = ultimoDia
+	}
+	return time.Date(ano, mes+time.Month(meses), dia, data.Hour(), data.Minute(), data.Second(), data.Nanosecond(), data.Location())
+}
+
+// apenasData descarta o horário, mantendo o dia em UTC
+func apenasData(data time.Time) time.Time {
+	ano, mes, dia := data.Date()
+	return time.Date(ano, mes, dia, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+type DevolucaoService struct {
+	vendaRepo     repository.VendaRepository
+	devolucaoRepo repository.DevolucaoRepository
+	programa      domain.ProgramaFidelidade
+}
+
+func NewDevolucaoService(vendaRepo repository.VendaRepository, devolucaoRepo repository.DevolucaoRepository) *DevolucaoService {
+	return &DevolucaoService{
+		vendaRepo:     vendaRepo,
+		devolucaoRepo: devolucaoRepo,
+		programa:      ProgramaFidelidadePadrao,
+	}
+}
+
+// Registrar devolve parte dos itens de uma venda confirmada ou paga. As quantidades são
+// validadas contra o vendido menos o que já foi devolvido, e o reembolso de cada item
+// é proporcional ao valor líquido pago por ele. Os pontos de fidelidade gerados pela venda
+// são estornados na proporção do reembolso; na devolução total, os pontos usados para
+// pagá-la voltam ao cliente com uma nova validade.
+func (s *DevolucaoService) Registrar(vendaID string, itens []domain.CreateItemDevolucaoDTO, operador domain.Operador, motivo string) (*domain.Devolucao, error) {
+	if vendaID == "" {
+		return nil, errors.New("id da venda é obrigatório")
+	}
+	if len(itens) == 0 {
+		return nil, errors.New("devolução deve ter pelo menos um item")
+	}
+
+	venda, err := s.vendaRepo.GetByID(vendaID)
+	if err != nil {
+		return nil, err
+	}
+	if !venda.Status.BaixaEstoque() {
+		return nil, fmt.Errorf("%w: venda com status %s não aceita devoluções", domain.ErrTransicaoStatusInvalida, venda.Status)
+	}
+
+	devolvidos, err := s.devolucaoRepo.GetItensDevolvidos(vendaID)
+	if err != nil {
+		return nil, err
+	}
+
+	itensVenda := make(map[string]domain.ItemVenda, len(venda.Items))
+	for _, item := range venda.Items {
+		itensVenda[item.ID] = item
+	}
+
+	devolucao := &domain.Devolucao{
+		VendaID:       vendaID,
+		UsuarioID:     operador.UsuarioID,
+		Motivo:        motivo,
+		DataDevolucao: time.Now(),
+	}
+
+	solicitado := make(map[string]int)
+	for _, dto := range itens {
+		if dto.Quantidade <= 0 {
+			return nil, errors.New("quantidade deve ser maior que zero")
+		}
+
+		itemVenda, ok := itensVenda[dto.ItemVendaID]
+		if !ok {
+			return nil, fmt.Errorf("item %s não pertence à venda", dto.ItemVendaID)
+		}
+
+		solicitado[dto.ItemVendaID] += dto.Quantidade
+		jaDevolvido := devolvidos[dto.ItemVendaID]
+		restante := itemVenda.Quantidade - jaDevolvido.Quantidade - (solicitado[dto.ItemVendaID] - dto.Quantidade)
+		if dto.Quantidade > restante {
+			return nil, fmt.Errorf("quantidade devolvida do item %s excede o saldo de %d", dto.ItemVendaID, restante)
+		}
+
+		// A última unidade leva o saldo restante para que o total reembolsado feche com o item
+		reembolso := arredondar(itemVenda.Total * float64(dto.Quantidade) / float64(itemVenda.Quantidade))
+		if dto.Quantidade == restante {
+			reembolso = arredondar(itemVenda.Total - jaDevolvido.ValorReembolso)
+		}
+
+		jaDevolvido.Quantidade += dto.Quantidade
+		jaDevolvido.ValorReembolso += reembolso
+		devolvidos[dto.ItemVendaID] = jaDevolvido
+
+		devolucao.Itens = append(devolucao.Itens, domain.ItemDevolucao{
+			ItemVendaID:    dto.ItemVendaID,
+			ProdutoID:      itemVenda.ProdutoID,
+			Quantidade:     dto.Quantidade,
+			ValorReembolso: reembolso,
+		})
+		devolucao.ValorReembolso += reembolso
+	}
+	devolucao.ValorReembolso = arredondar(devolucao.ValorReembolso)
+
+	// Quando todos os itens voltaram a venda é considerada devolvida
+	devolucaoTotal := true
+	for _, item := range venda.Items {
+		if devolvidos[item.ID].Quantidade < item.Quantidade {
+			devolucaoTotal = false
+			break
+		}
+	}
+
+	expiracao := expiracaoPontos(s.programa, time.Now())
+	if err := s.devolucaoRepo.Create(devolucao, venda.Status, devolucaoTotal, expiracao); err != nil {
+		return nil, err
+	}
+
+	return devolucao, nil
+}
+
+// DevolverTotal devolve todos os itens que ainda não foram devolvidos da venda
+func (s *DevolucaoService) DevolverTotal(vendaID string, operador domain.Operador, motivo string) (*domain.Devolucao, error) {
+	venda, err := s.vendaRepo.GetByID(vendaID)
+	if err != nil {
+		return nil, err
+	}
+
+	devolvidos, err := s.devolucaoRepo.GetItensDevolvidos(vendaID)
+	if err != nil {
+		return nil, err
+	}
+
+	var itens []domain.CreateItemDevolucaoDTO
+	for _, item := range venda.Items {
+		if restante := item.Quantidade - devolvidos[item.ID].Quantidade; restante > 0 {
+			itens = append(itens, domain.CreateItemDevolucaoDTO{ItemVendaID: item.ID, Quantidade: restante})
+		}
+	}
+	if len(itens) == 0 {
+		return nil, fmt.Errorf("%w: todos os itens da venda já foram devolvidos", domain.ErrTransicaoStatusInvalida)
+	}
+
+	return s.Registrar(vendaID, itens, operador, motivo)
+}
+
+func (s *DevolucaoService) GetByVenda(vendaID string) ([]domain.Devolucao, error) {
+	if _, err := s.vendaRepo.GetByID(vendaID); err != nil {
+		return nil, err
+	}
+	return s.devolucaoRepo.GetByVenda(vendaID)
+}
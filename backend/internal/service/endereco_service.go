@@ -0,0 +1,148 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"vendas/internal/cep"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+// ufs são as siglas aceitas no campo UF dos endereços
+var ufs = map[string]bool{
+	"AC": true, "AL": true, "AP": true, "AM": true, "BA": true, "CE": true, "DF": true, "ES": true, "GO": true,
+	"MA": true, "MT": true, "MS": true, "MG": true, "PA": true, "PB": true, "PR": true, "PE": true, "PI": true,
+	"RJ": true, "RN": true, "RS": true, "RO": true, "RR": true, "SC": true, "SP": true, "SE": true, "TO": true,
+}
+
+type EnderecoService struct {
+	repo        repository.EnderecoRepository
+	clienteRepo repository.ClienteRepository
+	resolver    cep.Resolver
+}
+
+func NewEnderecoService(repo repository.EnderecoRepository, clienteRepo repository.ClienteRepository, resolver cep.Resolver) *EnderecoService {
+	return &EnderecoService{
+		repo:        repo,
+		clienteRepo: clienteRepo,
+		resolver:    resolver,
+	}
+}
+
+// CreateEndereco cadastra um endereço para o cliente, completando pelo CEP os campos
+// não informados
+func (s *EnderecoService) CreateEndereco(endereco *domain.Endereco) error {
+	cliente, err := s.clienteRepo.GetByID(endereco.ClienteID)
+	if err != nil {
+		return err
+	}
+	if cliente.DataAnonimizacao != nil {
+		return fmt.Errorf("%w: não é possível cadastrar endereços", domain.ErrClienteAnonimizado)
+	}
+	if err := s.completarEndereco(endereco); err != nil {
+		return err
+	}
+
+	endereco.DataCriacao = time.Now()
+	return s.repo.Create(endereco)
+}
+
+// GetEndereco retorna o endereço apenas quando ele pertence ao cliente informado
+func (s *EnderecoService) GetEndereco(clienteID, id string) (*domain.Endereco, error) {
+	endereco, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if endereco.ClienteID != clienteID {
+		return nil, sql.ErrNoRows
+	}
+	return endereco, nil
+}
+
+func (s *EnderecoService) ListEnderecos(clienteID string) ([]domain.Endereco, error) {
+	if _, err := s.clienteRepo.GetByID(clienteID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetByCliente(clienteID)
+}
+
+// UpdateEndereco substitui os dados do endereço, completando pelo CEP os campos não
+// informados
+func (s *EnderecoService) UpdateEndereco(endereco *domain.Endereco) error {
+	atual, err := s.GetEndereco(endereco.ClienteID, endereco.ID)
+	if err != nil {
+		return err
+	}
+	if err := s.completarEndereco(endereco); err != nil {
+		return err
+	}
+
+	endereco.DataCriacao = atual.DataCriacao
+	return s.repo.Update(endereco)
+}
+
+func (s *EnderecoService) DeleteEndereco(clienteID, id string) error {
+	if _, err := s.GetEndereco(clienteID, id); err != nil {
+		return err
+	}
+	return s.repo.Delete(id)
+}
+
+// BuscarCEP consulta o endereço de um CEP no resolver configurado
+func (s *EnderecoService) BuscarCEP(codigo string) (*domain.Endereco, error) {
+	return s.resolver.Buscar(codigo)
+}
+
+// completarEndereco normaliza o CEP e a UF e preenche logradouro, bairro, cidade e UF
+// vazios com o resultado do CEP. Se o CEP não puder ser consultado, o endereço ainda é
+// aceito quando logradouro, cidade e UF foram informados.
+func (s *EnderecoService) completarEndereco(endereco *domain.Endereco) error {
+	if !endereco.Tipo.Valida() {
+		return fmt.Errorf("%w: tipo de endereço %s inválido", domain.ErrEnderecoInvalido, endereco.Tipo)
+	}
+
+	codigo, err := cep.Normalizar(endereco.CEP)
+	if err != nil {
+		return fmt.Errorf("%w: %v", domain.ErrEnderecoInvalido, err)
+	}
+	endereco.CEP = codigo
+
+	if endereco.Logradouro == "" || endereco.Bairro == "" || endereco.Cidade == "" || endereco.UF == "" {
+		encontrado, err := s.resolver.Buscar(codigo)
+		switch {
+		case err == nil:
+			preencher(&endereco.Logradouro, encontrado.Logradouro)
+			preencher(&endereco.Bairro, encontrado.Bairro)
+			preencher(&endereco.Cidade, encontrado.Cidade)
+			preencher(&endereco.UF, encontrado.UF)
+		case endereco.Logradouro != "" && endereco.Cidade != "" && endereco.UF != "":
+			// Os campos essenciais foram informados; o bairro fica em branco
+		case errors.Is(err, cep.ErrCEPNaoEncontrado):
+			return fmt.Errorf("%w: CEP %s não encontrado, informe logradouro, cidade e UF", domain.ErrEnderecoInvalido, codigo)
+		default:
+			return err
+		}
+	}
+
+	endereco.UF = strings.ToUpper(strings.TrimSpace(endereco.UF))
+	if !ufs[endereco.UF] {
+		return fmt.Errorf("%w: UF %s inválida", domain.ErrEnderecoInvalido, endereco.UF)
+	}
+	if endereco.Logradouro == "" || endereco.Cidade == "" {
+		return fmt.Errorf("%w: logradouro e cidade são obrigatórios", domain.ErrEnderecoInvalido)
+	}
+	if endereco.Numero == "" {
+		return fmt.Errorf("%w: número é obrigatório, use S/N quando não houver", domain.ErrEnderecoInvalido)
+	}
+	return nil
+}
+
+// preencher atribui o valor ao campo apenas quando ele está vazio
+func preencher(campo *string, valor string) {
+	if *campo == "" {
+		*campo = valor
+	}
+}
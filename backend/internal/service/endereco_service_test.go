@@ -0,0 +1,82 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"vendas/internal/cep"
+	"vendas/internal/domain"
+)
+
+func TestCompletarEndereco(t *testing.T) {
+	s := &EnderecoService{resolver: cep.NewTabela([]domain.Endereco{
+		{CEP: "01310-100", Logradouro: "Avenida Paulista", Bairro: "Bela Vista", Cidade: "São Paulo", UF: "SP"},
+	})}
+
+	casos := []struct {
+		nome     string
+		endereco domain.Endereco
+		esperado domain.Endereco
+		err      error
+	}{
+		{
+			nome:     "completa pelo CEP",
+			endereco: domain.Endereco{Tipo: domain.EnderecoEntrega, CEP: "01310-100", Numero: "1000"},
+			esperado: domain.Endereco{CEP: "01310100", Logradouro: "Avenida Paulista", Bairro: "Bela Vista", Cidade: "São Paulo", UF: "SP"},
+		},
+		{
+			nome: "mantém os campos informados",
+			endereco: domain.Endereco{Tipo: domain.EnderecoEntrega, CEP: "01310100", Numero: "10", Logradouro: "Alameda Santos",
+				UF: "sp"},
+			esperado: domain.Endereco{CEP: "01310100", Logradouro: "Alameda Santos", Bairro: "Bela Vista", Cidade: "São Paulo", UF: "SP"},
+		},
+		{
+			nome: "CEP desconhecido com os campos essenciais",
+			endereco: domain.Endereco{Tipo: domain.EnderecoCobranca, CEP: "99999-999", Numero: "S/N", Logradouro: "Rua Nova",
+				Cidade: "Campinas", UF: "SP"},
+			esperado: domain.Endereco{CEP: "99999999", Logradouro: "Rua Nova", Cidade: "Campinas", UF: "SP"},
+		},
+		{
+			nome:     "CEP desconhecido sem os campos essenciais",
+			endereco: domain.Endereco{Tipo: domain.EnderecoEntrega, CEP: "99999999", Numero: "1"},
+			err:      domain.ErrEnderecoInvalido,
+		},
+		{
+			nome:     "CEP inválido",
+			endereco: domain.Endereco{Tipo: domain.EnderecoEntrega, CEP: "1234", Numero: "1"},
+			err:      domain.ErrEnderecoInvalido,
+		},
+		{
+			nome: "UF inválida",
+			endereco: domain.Endereco{Tipo: domain.EnderecoEntrega, CEP: "01310100", Numero: "1", Logradouro: "Rua A",
+				Cidade: "Cidade", UF: "XX"},
+			err: domain.ErrEnderecoInvalido,
+		},
+		{
+			nome:     "sem número",
+			endereco: domain.Endereco{Tipo: domain.EnderecoEntrega, CEP: "01310100"},
+			err:      domain.ErrEnderecoInvalido,
+		},
+		{
+			nome:     "tipo inválido",
+			endereco: domain.Endereco{Tipo: "comercial", CEP: "01310100", Numero: "1"},
+			err:      domain.ErrEnderecoInvalido,
+		},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			endereco := caso.endereco
+			err := s.completarEndereco(&endereco)
+			if !errors.Is(err, caso.err) {
+				t.Fatalf("completarEndereco retornou o erro %v, esperado %v", err, caso.err)
+			}
+			if err != nil {
+				return
+			}
+			if endereco.CEP != caso.esperado.CEP || endereco.Logradouro != caso.esperado.Logradouro ||
+				endereco.Bairro != caso.esperado.Bairro || endereco.Cidade != caso.esperado.Cidade || endereco.UF != caso.esperado.UF {
+				t.Errorf("completarEndereco resultou em %+v, esperado %+v", endereco, caso.esperado)
+			}
+		})
+	}
+}
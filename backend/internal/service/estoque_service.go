@@ -0,0 +1,234 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+// Limites do período usado para medir o ritmo de vendas na sugestão de reposição
+const (
+	DiasReposicaoPadrao = 30
+	DiasReposicaoMaximo = 365
+)
+
+type EstoqueService struct {
+	movimentacaoRepo  repository.MovimentacaoRepository
+	produtoRepo       repository.ProdutoRepository
+	vendaRepo         repository.VendaRepository
+	pedidoCompraRepo  repository.PedidoCompraRepository
+	localRepo         repository.LocalRepository
+	transferenciaRepo repository.TransferenciaRepository
+}
+
+func NewEstoqueService(
+	movimentacaoRepo repository.MovimentacaoRepository,
+	produtoRepo repository.ProdutoRepository,
+	vendaRepo repository.VendaRepository,
+	pedidoCompraRepo repository.PedidoCompraRepository,
+	localRepo repository.LocalRepository,
+	transferenciaRepo repository.TransferenciaRepository,
+) *EstoqueService {
+	return &EstoqueService{
+		movimentacaoRepo:  movimentacaoRepo,
+		produtoRepo:       produtoRepo,
+		vendaRepo:         vendaRepo,
+		pedidoCompraRepo:  pedidoCompraRepo,
+		localRepo:         localRepo,
+		transferenciaRepo: transferenciaRepo,
+	}
+}
+
+// Movimentar lança uma entrada, um ajuste ou uma perda no estoque do produto em um local,
+// o principal quando não informado. Ajustes e perdas precisam de motivo para que o
+// histórico explique a diferença. Entradas com custo unitário atualizam o custo médio.
+func (s *EstoqueService) Movimentar(produtoID string, dto domain.CreateMovimentacaoDTO, operador domain.Operador) (*domain.MovimentacaoEstoque, error) {
+	if produtoID == "" {
+		return nil, errors.New("id do produto é obrigatório")
+	}
+	if !dto.Tipo.Manual() {
+		return nil, fmt.Errorf("tipo de movimentação inválido: %s", dto.Tipo)
+	}
+	if dto.Quantidade == 0 {
+		return nil, errors.New("quantidade não pode ser zero")
+	}
+
+	quantidade := dto.Quantidade
+	switch dto.Tipo {
+	case domain.MovimentacaoEntrada, domain.MovimentacaoPerda:
+		if dto.Quantidade < 0 {
+			return nil, errors.New("quantidade deve ser maior que zero")
+		}
+		if dto.Tipo == domain.MovimentacaoPerda {
+			quantidade = -dto.Quantidade
+		}
+	}
+	if dto.Tipo != domain.MovimentacaoEntrada && dto.Motivo == "" {
+		return nil, errors.New("motivo é obrigatório para ajustes e perdas")
+	}
+	if dto.CustoUnitario < 0 {
+		return nil, errors.New("custo unitário não pode ser negativo")
+	}
+	if dto.CustoUnitario > 0 && dto.Tipo != domain.MovimentacaoEntrada {
+		return nil, errors.New("custo unitário só pode ser informado em entradas")
+	}
+
+	if _, err := s.produtoRepo.GetByID(produtoID); err != nil {
+		return nil, err
+	}
+	localID, err := validarLocal(s.localRepo, dto.LocalID)
+	if err != nil {
+		return nil, err
+	}
+
+	movimentacao := &domain.MovimentacaoEstoque{
+		ProdutoID:  produtoID,
+		LocalID:    localID,
+		Tipo:       dto.Tipo,
+		Quantidade: quantidade,
+		UsuarioID:  operador.UsuarioID,
+		Motivo:     dto.Motivo,
+		Data:       time.Now(),
+
+		CustoUnitario: arredondar(dto.CustoUnitario),
+	}
+	if err := s.movimentacaoRepo.Registrar(movimentacao); err != nil {
+		return nil, err
+	}
+
+	return movimentacao, nil
+}
+
+// GetSaldos retorna o saldo do produto em cada local
+func (s *EstoqueService) GetSaldos(produtoID string) ([]domain.SaldoEstoque, error) {
+	if _, err := s.produtoRepo.GetByID(produtoID); err != nil {
+		return nil, err
+	}
+	return s.localRepo.GetSaldosProduto(produtoID)
+}
+
+// GetExtrato retorna o histórico de movimentações do produto e os saldos por local,
+// conferindo a soma das movimentações e a dos saldos com a quantidade atual
+func (s *EstoqueService) GetExtrato(produtoID string) (*domain.ExtratoEstoque, error) {
+	produto, err := s.produtoRepo.GetByID(produtoID)
+	if err != nil {
+		return nil, err
+	}
+
+	movimentacoes, err := s.movimentacaoRepo.GetByProduto(produtoID)
+	if err != nil {
+		return nil, err
+	}
+
+	saldo, err := s.movimentacaoRepo.GetSaldo(produtoID)
+	if err != nil {
+		return nil, err
+	}
+
+	saldos, err := s.localRepo.GetSaldosProduto(produtoID)
+	if err != nil {
+		return nil, err
+	}
+	var somaLocais int
+	for _, saldoLocal := range saldos {
+		somaLocais += saldoLocal.Quantidade
+	}
+
+	return &domain.ExtratoEstoque{
+		ProdutoID:          produtoID,
+		Quantidade:         produto.Quantidade,
+		SaldoMovimentacoes: saldo,
+		Conciliado:         saldo == produto.Quantidade && somaLocais == produto.Quantidade,
+		Saldos:             saldos,
+		Movimentacoes:      movimentacoes,
+	}, nil
+}
+
+// GetDivergencias confere a quantidade de cada produto com o histórico de movimentações e
+// com a soma dos saldos por local.
+// As diferenças não são corrigidas: cabe a quem confere o estoque lançar o ajuste com o motivo.
+func (s *EstoqueService) GetDivergencias() ([]domain.DivergenciaEstoque, error) {
+	return s.movimentacaoRepo.GetDivergencias()
+}
+
+// SugerirReposicao monta a lista de compras a partir da média diária de vendas dos últimos
+// dias. O ponto de reposição é o estoque mínimo (ou EstoqueMinimoPadrao) mais o consumo
+// esperado durante o prazo de entrega; produtos cujo estoque somado ao que já foi pedido e
+// ao que está em trânsito entre locais, já retirado da origem, não passa desse ponto recebem uma sugestão que leva o saldo ao estoque máximo ou, sem
+// máximo definido, cobre mais um período igual ao analisado. Sem período informado,
+// considera DiasReposicaoPadrao.
+func (s *EstoqueService) SugerirReposicao(dias int) ([]domain.SugestaoReposicao, error) {
+	if dias == 0 {
+		dias = DiasReposicaoPadrao
+	}
+	if dias < 0 || dias > DiasReposicaoMaximo {
+		return nil, fmt.Errorf("período deve estar entre 1 e %d dias", DiasReposicaoMaximo)
+	}
+
+	produtos, err := s.produtoRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	vendidas, err := s.vendaRepo.GetQuantidadesVendidas(dias)
+	if err != nil {
+		return nil, err
+	}
+	pedidas, err := s.pedidoCompraRepo.GetQuantidadesPendentes()
+	if err != nil {
+		return nil, err
+	}
+	emTransito, err := s.transferenciaRepo.GetQuantidadesEmTransito()
+	if err != nil {
+		return nil, err
+	}
+
+	sugestoes := []domain.SugestaoReposicao{}
+	for _, produto := range produtos {
+		vendido := vendidas[produto.ID]
+		media := float64(vendido) / float64(dias)
+		pontoReposicao := produto.LimiteEstoqueBaixo() + int(math.Ceil(media*float64(produto.PrazoEntregaDias)))
+
+		disponivel := produto.Quantidade + pedidas[produto.ID] + emTransito[produto.ID]
+		if disponivel > pontoReposicao {
+			continue
+		}
+
+		alvo := produto.EstoqueMaximo
+		if alvo <= 0 {
+			alvo = pontoReposicao + int(math.Ceil(media*float64(dias)))
+		}
+		sugerida := alvo - disponivel
+		if sugerida <= 0 {
+			continue
+		}
+
+		sugestoes = append(sugestoes, domain.SugestaoReposicao{
+			ProdutoID:          produto.ID,
+			Nome:               produto.Nome,
+			Quantidade:         produto.Quantidade,
+			QuantidadePedida:   pedidas[produto.ID],
+			QuantidadeTransito: emTransito[produto.ID],
+			EstoqueMinimo:      produto.LimiteEstoqueBaixo(),
+			EstoqueMaximo:      produto.EstoqueMaximo,
+			PrazoEntregaDias:   produto.PrazoEntregaDias,
+			VendidoPeriodo:     vendido,
+			MediaDiaria:        math.Round(media*100) / 100,
+			PontoReposicao:     pontoReposicao,
+			QuantidadeSugerida: sugerida,
+		})
+	}
+
+	// Os produtos mais abaixo do ponto de reposição vêm primeiro
+	folga := func(s domain.SugestaoReposicao) int {
+		return s.Quantidade + s.QuantidadePedida + s.QuantidadeTransito - s.PontoReposicao
+	}
+	sort.SliceStable(sugestoes, func(i, j int) bool {
+		return folga(sugestoes[i]) < folga(sugestoes[j])
+	})
+
+	return sugestoes, nil
+}
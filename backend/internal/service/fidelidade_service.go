@@ -0,0 +1,213 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+// ProgramaFidelidadePadrao define quanto vale cada ponto no resgate e por quantos dias os
+// pontos acumulados podem ser usados
+var ProgramaFidelidadePadrao = domain.ProgramaFidelidade{
+	ValorPonto:   0.05,
+	ValidadeDias: 365,
+}
+
+type FidelidadeService struct {
+	fidelidadeRepo repository.FidelidadeRepository
+	clienteRepo    repository.ClienteRepository
+	programa       domain.ProgramaFidelidade
+}
+
+func NewFidelidadeService(fidelidadeRepo repository.FidelidadeRepository, clienteRepo repository.ClienteRepository) *FidelidadeService {
+	return &FidelidadeService{
+		fidelidadeRepo: fidelidadeRepo,
+		clienteRepo:    clienteRepo,
+		programa:       ProgramaFidelidadePadrao,
+	}
+}
+
+// GetExtrato retorna o saldo de pontos válidos do cliente, o valor dele em reais, os
+// pontos prestes a vencer e o histórico de lançamentos
+func (s *FidelidadeService) GetExtrato(clienteID string) (*domain.ExtratoPontos, error) {
+	if _, err := s.clienteRepo.GetByID(clienteID); err != nil {
+		return nil, err
+	}
+
+	agora := time.Now()
+	saldo, aExpirar, err := s.fidelidadeRepo.GetSaldo(clienteID, agora, agora.AddDate(0, 0, domain.DiasAvisoExpiracao))
+	if err != nil {
+		return nil, err
+	}
+	movimentos, err := s.fidelidadeRepo.GetMovimentos(clienteID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.ExtratoPontos{
+		ClienteID:  clienteID,
+		Saldo:      saldo,
+		ValorSaldo: arredondar(float64(saldo) * s.programa.ValorPonto),
+		AExpirar:   aExpirar,
+		Movimentos: movimentos,
+	}, nil
+}
+
+// GetExtratoProprio retorna o extrato de pontos do cliente vinculado ao usuário do
+// operador; sql.ErrNoRows quando o usuário não é um cliente
+func (s *FidelidadeService) GetExtratoProprio(operador domain.Operador) (*domain.ExtratoPontos, error) {
+	cliente, err := s.clienteRepo.GetByUsuario(operador.UsuarioID)
+	if err != nil {
+		return nil, err
+	}
+	return s.GetExtrato(cliente.ID)
+}
+
+// ExpirarPontos lança a expiração dos pontos vencidos até a data informada. Os pontos
+// vencidos já deixam de contar no saldo antes disso; a expiração apenas os registra no
+// extrato. Retorna quantos lotes de pontos expiraram.
+func (s *FidelidadeService) ExpirarPontos(agora time.Time) (int, error) {
+	return s.fidelidadeRepo.Expirar(agora)
+}
+
+func (s *FidelidadeService) GetRegras() ([]domain.RegraFidelidade, error) {
+	return s.fidelidadeRepo.GetRegras()
+}
+
+func (s *FidelidadeService) GetRegra(id string) (*domain.RegraFidelidade, error) {
+	return s.fidelidadeRepo.GetRegra(id)
+}
+
+func (s *FidelidadeService) CreateRegra(dto domain.SalvarRegraFidelidadeDTO) (*domain.RegraFidelidade, error) {
+	regra, err := montarRegraFidelidade(dto)
+	if err != nil {
+		return nil, err
+	}
+	regra.DataCriacao = time.Now()
+
+	if err := s.fidelidadeRepo.CreateRegra(regra); err != nil {
+		return nil, err
+	}
+	return regra, nil
+}
+
+// UpdateRegra substitui os dados da regra. Vendas já confirmadas mantêm os pontos que
+// receberam.
+func (s *FidelidadeService) UpdateRegra(id string, dto domain.SalvarRegraFidelidadeDTO) (*domain.RegraFidelidade, error) {
+	atual, err := s.fidelidadeRepo.GetRegra(id)
+	if err != nil {
+		return nil, err
+	}
+
+	regra, err := montarRegraFidelidade(dto)
+	if err != nil {
+		return nil, err
+	}
+	regra.ID = atual.ID
+	regra.DataCriacao = atual.DataCriacao
+
+	if err := s.fidelidadeRepo.UpdateRegra(regra); err != nil {
+		return nil, err
+	}
+	return regra, nil
+}
+
+func (s *FidelidadeService) DeleteRegra(id string) error {
+	if id == "" {
+		return errors.New("id da regra é obrigatório")
+	}
+	return s.fidelidadeRepo.DeleteRegra(id)
+}
+
+// montarRegraFidelidade valida os campos exigidos pelo tipo da regra
+func montarRegraFidelidade(dto domain.SalvarRegraFidelidadeDTO) (*domain.RegraFidelidade, error) {
+	if dto.Descricao == "" {
+		return nil, fmt.Errorf("%w: descrição é obrigatória", domain.ErrRegraFidelidadeInvalida)
+	}
+	if !dto.Tipo.Valida() {
+		return nil, fmt.Errorf("%w: tipo %q desconhecido", domain.ErrRegraFidelidadeInvalida, dto.Tipo)
+	}
+	if dto.Inicio != nil && dto.Fim != nil && dto.Fim.Before(*dto.Inicio) {
+		return nil, fmt.Errorf("%w: o fim da vigência é anterior ao início", domain.ErrRegraFidelidadeInvalida)
+	}
+
+	regra := &domain.RegraFidelidade{
+		Descricao: dto.Descricao,
+		Tipo:      dto.Tipo,
+		Categoria: dto.Categoria,
+		Inicio:    dto.Inicio,
+		Fim:       dto.Fim,
+		Ativa:     dto.Ativa == nil || *dto.Ativa,
+	}
+
+	switch dto.Tipo {
+	case domain.RegraPorValor, domain.RegraPorCategoria:
+		if dto.PontosPorReal <= 0 {
+			return nil, fmt.Errorf("%w: pontos por real deve ser maior que zero", domain.ErrRegraFidelidadeInvalida)
+		}
+		if dto.Tipo == domain.RegraPorCategoria && dto.Categoria == "" {
+			return nil, fmt.Errorf("%w: categoria é obrigatória", domain.ErrRegraFidelidadeInvalida)
+		}
+		if dto.Tipo == domain.RegraPorValor && dto.Categoria != "" {
+			return nil, fmt.Errorf("%w: regras por valor valem para todas as categorias", domain.ErrRegraFidelidadeInvalida)
+		}
+		regra.PontosPorReal = dto.PontosPorReal
+	case domain.RegraMultiplicador:
+		if dto.Multiplicador < 1 {
+			return nil, fmt.Errorf("%w: multiplicador deve ser pelo menos 1", domain.ErrRegraFidelidadeInvalida)
+		}
+		regra.Multiplicador = dto.Multiplicador
+	}
+	return regra, nil
+}
+
+// calcularPontos soma os pontos de cada item da venda pelo seu total, já com os descontos.
+// Cada item recebe a maior taxa entre as regras por categoria da sua categoria ou, sem
+// nenhuma, a maior taxa entre as regras por valor; em seguida, o maior multiplicador
+// que se aplique a ele. Valem as regras vigentes na data da venda e o total é truncado.
+func calcularPontos(itens []domain.ItemVenda, dataVenda time.Time, categorias map[string]string, regras []domain.RegraFidelidade) int {
+	var pontos float64
+	for _, item := range itens {
+		categoria := categorias[item.ProdutoID]
+
+		var taxaValor, taxaCategoria float64
+		multiplicador := 1.0
+		for _, regra := range regras {
+			if !regra.Vigente(dataVenda) {
+				continue
+			}
+			switch regra.Tipo {
+			case domain.RegraPorValor:
+				taxaValor = math.Max(taxaValor, regra.PontosPorReal)
+			case domain.RegraPorCategoria:
+				if regra.Categoria == categoria {
+					taxaCategoria = math.Max(taxaCategoria, regra.PontosPorReal)
+				}
+			case domain.RegraMultiplicador:
+				if regra.Categoria == "" || regra.Categoria == categoria {
+					multiplicador = math.Max(multiplicador, regra.Multiplicador)
+				}
+			}
+		}
+
+		taxa := taxaValor
+		if taxaCategoria > 0 {
+			taxa = taxaCategoria
+		}
+		pontos += item.Total * taxa * multiplicador
+	}
+	return int(math.Floor(arredondar(pontos)))
+}
+
+// expiracaoPontos retorna a data de vencimento dos pontos creditados agora, ou nil quando
+// o programa não define validade
+func expiracaoPontos(programa domain.ProgramaFidelidade, agora time.Time) *time.Time {
+	if programa.ValidadeDias <= 0 {
+		return nil
+	}
+	expiracao := agora.AddDate(0, 0, programa.ValidadeDias)
+	return &expiracao
+}
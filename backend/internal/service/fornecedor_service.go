@@ -0,0 +1,67 @@
+package service
+
+import (
+	"errors"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+type FornecedorService struct {
+	repo repository.FornecedorRepository
+}
+
+func NewFornecedorService(repo repository.FornecedorRepository) *FornecedorService {
+	return &FornecedorService{repo: repo}
+}
+
+func (s *FornecedorService) GetAll() ([]domain.Fornecedor, error) {
+	return s.repo.GetAll()
+}
+
+func (s *FornecedorService) GetByID(id string) (*domain.Fornecedor, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *FornecedorService) Create(fornecedor *domain.Fornecedor) error {
+	if fornecedor.Nome == "" {
+		return errors.New("nome do fornecedor é obrigatório")
+	}
+
+	fornecedor.Ativo = true
+	fornecedor.DataCriacao = time.Now()
+
+	return s.repo.Create(fornecedor)
+}
+
+// Update altera os dados do fornecedor. A situação só muda quando ativo é informado, o que
+// permite reativar um fornecedor inativado.
+func (s *FornecedorService) Update(fornecedor *domain.Fornecedor, ativo *bool) error {
+	if fornecedor.ID == "" {
+		return errors.New("id do fornecedor é obrigatório")
+	}
+	if fornecedor.Nome == "" {
+		return errors.New("nome do fornecedor é obrigatório")
+	}
+
+	atual, err := s.repo.GetByID(fornecedor.ID)
+	if err != nil {
+		return err
+	}
+	fornecedor.Ativo = atual.Ativo
+	if ativo != nil {
+		fornecedor.Ativo = *ativo
+	}
+	fornecedor.DataCriacao = atual.DataCriacao
+
+	return s.repo.Update(fornecedor)
+}
+
+// Delete inativa o fornecedor, que deixa de aceitar novos pedidos de compra
+func (s *FornecedorService) Delete(id string) error {
+	if id == "" {
+		return errors.New("id do fornecedor é obrigatório")
+	}
+
+	return s.repo.Delete(id)
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+type LocalService struct {
+	repo repository.LocalRepository
+}
+
+func NewLocalService(repo repository.LocalRepository) *LocalService {
+	return &LocalService{repo: repo}
+}
+
+func (s *LocalService) GetAll() ([]domain.LocalEstoque, error) {
+	return s.repo.GetAll()
+}
+
+func (s *LocalService) GetByID(id string) (*domain.LocalEstoque, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *LocalService) Create(dto domain.CreateLocalEstoqueDTO) (*domain.LocalEstoque, error) {
+	local := &domain.LocalEstoque{
+		Nome:        dto.Nome,
+		Tipo:        dto.Tipo,
+		Ativo:       true,
+		DataCriacao: time.Now(),
+	}
+	if err := validarCadastroLocal(local); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(local); err != nil {
+		return nil, err
+	}
+	return local, nil
+}
+
+// Update altera o cadastro do local. O local principal não pode ser inativado porque
+// recebe as movimentações que não informam local.
+func (s *LocalService) Update(local *domain.LocalEstoque) error {
+	if local.ID == "" {
+		return errors.New("id do local é obrigatório")
+	}
+	if err := validarCadastroLocal(local); err != nil {
+		return err
+	}
+	if local.ID == domain.LocalPrincipal && !local.Ativo {
+		return errors.New("o local principal não pode ser inativado")
+	}
+
+	return s.repo.Update(local)
+}
+
+// GetSaldos retorna os produtos com saldo no local
+func (s *LocalService) GetSaldos(localID string) ([]domain.SaldoEstoque, error) {
+	if _, err := s.repo.GetByID(localID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetSaldosLocal(localID)
+}
+
+func validarCadastroLocal(local *domain.LocalEstoque) error {
+	if local.Nome == "" {
+		return errors.New("nome do local é obrigatório")
+	}
+	if local.Tipo != domain.LocalDeposito && local.Tipo != domain.LocalLoja {
+		return fmt.Errorf("tipo de local inválido: %s", local.Tipo)
+	}
+	return nil
+}
+
+// validarLocal confere se o local informado existe e está ativo. Sem local informado,
+// usa o local principal.
+func validarLocal(repo repository.LocalRepository, localID string) (string, error) {
+	if localID == "" {
+		localID = domain.LocalPrincipal
+	}
+
+	local, err := repo.GetByID(localID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("local %s não encontrado", localID)
+	}
+	if err != nil {
+		return "", err
+	}
+	if !local.Ativo {
+		return "", fmt.Errorf("local %s está inativo", local.Nome)
+	}
+	return local.ID, nil
+}
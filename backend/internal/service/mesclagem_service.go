@@ -0,0 +1,316 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+	"vendas/internal/utils"
+)
+
+// Pesos de cada coincidência na pontuação de duplicidade. Documentos iguais bastam para
+// apontar a duplicidade; email, telefone e nome só somam o suficiente em conjunto.
+const (
+	pesoDocumento = 100
+	pesoEmail     = 40
+	pesoTelefone  = 30
+	pesoNome      = 40
+
+	// similaridadeMinimaNomes é a semelhança a partir da qual os nomes contam na pontuação
+	similaridadeMinimaNomes = 0.8
+)
+
+type MesclagemService struct {
+	clienteRepo   repository.ClienteRepository
+	mesclagemRepo repository.MesclagemRepository
+}
+
+func NewMesclagemService(clienteRepo repository.ClienteRepository, mesclagemRepo repository.MesclagemRepository) *MesclagemService {
+	return &MesclagemService{
+		clienteRepo:   clienteRepo,
+		mesclagemRepo: mesclagemRepo,
+	}
+}
+
+// BuscarDuplicados compara todos os clientes entre si e retorna os pares com pontuação a
+// partir do mínimo informado, dos mais prováveis para os menos. Clientes anonimizados
+// ficam de fora.
+func (s *MesclagemService) BuscarDuplicados(minimo int) ([]domain.CandidatoDuplicado, error) {
+	clientes, err := s.clientesAtivos()
+	if err != nil {
+		return nil, err
+	}
+
+	candidatos := []domain.CandidatoDuplicado{}
+	for i := range clientes {
+		for j := i + 1; j < len(clientes); j++ {
+			if candidato, ok := avaliarDuplicidade(clientes[i], clientes[j], minimo); ok {
+				candidatos = append(candidatos, candidato)
+			}
+		}
+	}
+	ordenarCandidatos(candidatos)
+	return candidatos, nil
+}
+
+// DuplicadosDoCliente retorna os prováveis duplicados de um cliente
+func (s *MesclagemService) DuplicadosDoCliente(clienteID string, minimo int) ([]domain.CandidatoDuplicado, error) {
+	cliente, err := s.clienteRepo.GetByID(clienteID)
+	if err != nil {
+		return nil, err
+	}
+	clientes, err := s.clientesAtivos()
+	if err != nil {
+		return nil, err
+	}
+
+	candidatos := []domain.CandidatoDuplicado{}
+	for _, outro := range clientes {
+		if outro.ID == cliente.ID {
+			continue
+		}
+		if candidato, ok := avaliarDuplicidade(*cliente, outro, minimo); ok {
+			candidatos = append(candidatos, candidato)
+		}
+	}
+	ordenarCandidatos(candidatos)
+	return candidatos, nil
+}
+
+// Mesclar incorpora o duplicado ao cliente informado. Vendas, parcelas, endereços e pontos
+// do duplicado passam para o cliente, que herda os dados de contato e documentos que não
+// tiver; o duplicado é removido e o seu cadastro fica guardado no registro da mesclagem até
+// que o cliente seja anonimizado. Clientes com CPFs, CNPJs ou usuários diferentes são
+// pessoas distintas e não são mesclados.
+func (s *MesclagemService) Mesclar(clienteID, duplicadoID string, operador domain.Operador) (*domain.Cliente, error) {
+	if duplicadoID == "" {
+		return nil, fmt.Errorf("%w: informe o cliente duplicado", domain.ErrMesclagemInvalida)
+	}
+	if clienteID == duplicadoID {
+		return nil, fmt.Errorf("%w: um cliente não pode ser mesclado com ele mesmo", domain.ErrMesclagemInvalida)
+	}
+
+	cliente, err := s.clienteRepo.GetByID(clienteID)
+	if err != nil {
+		return nil, err
+	}
+	duplicado, err := s.clienteRepo.GetByID(duplicadoID)
+	if err != nil {
+		return nil, err
+	}
+	if cliente.DataAnonimizacao != nil || duplicado.DataAnonimizacao != nil {
+		return nil, fmt.Errorf("%w: clientes anonimizados não podem ser mesclados", domain.ErrClienteAnonimizado)
+	}
+	if err := completarCliente(cliente, duplicado); err != nil {
+		return nil, err
+	}
+
+	dados, err := json.Marshal(duplicado)
+	if err != nil {
+		return nil, err
+	}
+	agora := time.Now()
+	mesclagem := &domain.MesclagemCliente{
+		ClienteID:      cliente.ID,
+		DuplicadoID:    duplicado.ID,
+		DadosDuplicado: dados,
+		UsuarioID:      operador.UsuarioID,
+		Data:           agora,
+	}
+	registro := &domain.RegistroAuditoria{
+		Acao:       domain.AuditoriaMesclagem,
+		Entidade:   domain.EntidadeCliente,
+		EntidadeID: cliente.ID,
+		UsuarioID:  operador.UsuarioID,
+		Detalhes:   fmt.Sprintf("cliente %s incorporado ao cadastro", duplicado.ID),
+		Data:       agora,
+	}
+	if err := s.mesclagemRepo.Mesclar(cliente, duplicado.ID, mesclagem, registro); err != nil {
+		return nil, err
+	}
+	return s.clienteRepo.GetByID(cliente.ID)
+}
+
+// GetMesclagens lista os duplicados já incorporados ao cliente
+func (s *MesclagemService) GetMesclagens(clienteID string) ([]domain.MesclagemCliente, error) {
+	if _, err := s.clienteRepo.GetByID(clienteID); err != nil {
+		return nil, err
+	}
+	return s.mesclagemRepo.GetByCliente(clienteID)
+}
+
+func (s *MesclagemService) clientesAtivos() ([]domain.Cliente, error) {
+	todos, err := s.clienteRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	var clientes []domain.Cliente
+	for _, cliente := range todos {
+		if cliente.DataAnonimizacao == nil {
+			clientes = append(clientes, cliente)
+		}
+	}
+	return clientes, nil
+}
+
+// completarCliente confere se os dois cadastros podem ser da mesma pessoa e preenche os
+// campos vazios do cliente com os do duplicado
+func completarCliente(cliente, duplicado *domain.Cliente) error {
+	if cliente.TipoPessoa != duplicado.TipoPessoa {
+		return fmt.Errorf("%w: os clientes têm tipos de pessoa diferentes", domain.ErrMesclagemInvalida)
+	}
+	if cliente.CPF != "" && duplicado.CPF != "" && cliente.CPF != duplicado.CPF {
+		return fmt.Errorf("%w: os clientes têm CPFs diferentes", domain.ErrMesclagemInvalida)
+	}
+	if cliente.CNPJ != "" && duplicado.CNPJ != "" && cliente.CNPJ != duplicado.CNPJ {
+		return fmt.Errorf("%w: os clientes têm CNPJs diferentes", domain.ErrMesclagemInvalida)
+	}
+	if cliente.UsuarioID != "" && duplicado.UsuarioID != "" && cliente.UsuarioID != duplicado.UsuarioID {
+		return fmt.Errorf("%w: os clientes estão vinculados a usuários diferentes", domain.ErrMesclagemInvalida)
+	}
+
+	preencher := func(campo *string, valor string) {
+		if *campo == "" {
+			*campo = valor
+		}
+	}
+	preencher(&cliente.Email, duplicado.Email)
+	preencher(&cliente.Telefone, duplicado.Telefone)
+	preencher(&cliente.Endereco, duplicado.Endereco)
+	preencher(&cliente.CPF, duplicado.CPF)
+	preencher(&cliente.CNPJ, duplicado.CNPJ)
+	preencher(&cliente.UsuarioID, duplicado.UsuarioID)
+	if cliente.InscricaoEstadual == "" && !cliente.IsentoIE {
+		cliente.InscricaoEstadual = duplicado.InscricaoEstadual
+		cliente.IsentoIE = duplicado.IsentoIE
+	}
+	return nil
+}
+
+// avaliarDuplicidade pontua o par de clientes e informa se ele atinge o mínimo
+func avaliarDuplicidade(a, b domain.Cliente, minimo int) (domain.CandidatoDuplicado, bool) {
+	pontuacao, motivos := pontuarDuplicidade(a, b)
+	if pontuacao == 0 || pontuacao < minimo {
+		return domain.CandidatoDuplicado{}, false
+	}
+	return domain.CandidatoDuplicado{Cliente: a, Duplicado: b, Pontuacao: pontuacao, Motivos: motivos}, true
+}
+
+// pontuarDuplicidade soma os pesos das coincidências entre os dois cadastros, até 100.
+// Documentos diferentes identificam pessoas distintas e zeram a pontuação.
+func pontuarDuplicidade(a, b domain.Cliente) (int, []string) {
+	if (a.CPF != "" && b.CPF != "" && a.CPF != b.CPF) || (a.CNPJ != "" && b.CNPJ != "" && a.CNPJ != b.CNPJ) {
+		return 0, nil
+	}
+
+	var pontuacao int
+	motivos := []string{}
+	if a.CPF != "" && a.CPF == b.CPF {
+		pontuacao += pesoDocumento
+		motivos = append(motivos, "mesmo CPF")
+	}
+	if a.CNPJ != "" && a.CNPJ == b.CNPJ {
+		pontuacao += pesoDocumento
+		motivos = append(motivos, "mesmo CNPJ")
+	}
+	if email := normalizarEmail(a.Email); email != "" && email == normalizarEmail(b.Email) {
+		pontuacao += pesoEmail
+		motivos = append(motivos, "mesmo email")
+	}
+	if telefone := normalizarTelefone(a.Telefone); telefone != "" && telefone == normalizarTelefone(b.Telefone) {
+		pontuacao += pesoTelefone
+		motivos = append(motivos, "mesmo telefone")
+	}
+	if similaridade := similaridadeNomes(a.Nome, b.Nome); similaridade >= similaridadeMinimaNomes {
+		pontuacao += int(math.Round(similaridade * pesoNome))
+		motivos = append(motivos, fmt.Sprintf("nomes %.0f%% semelhantes", similaridade*100))
+	}
+	return int(math.Min(float64(pontuacao), 100)), motivos
+}
+
+func ordenarCandidatos(candidatos []domain.CandidatoDuplicado) {
+	sort.SliceStable(candidatos, func(i, j int) bool { return candidatos[i].Pontuacao > candidatos[j].Pontuacao })
+}
+
+// normalizarEmail ignora maiúsculas, espaços e o sufixo após "+" no nome do usuário
+func normalizarEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	usuario, dominio, ok := strings.Cut(email, "@")
+	if !ok {
+		return email
+	}
+	usuario, _, _ = strings.Cut(usuario, "+")
+	return usuario + "@" + dominio
+}
+
+// normalizarTelefone compara apenas os oito últimos dígitos, o que ignora DDI, DDD e o nono
+// dígito dos celulares, cadastrados de forma irregular
+func normalizarTelefone(telefone string) string {
+	digitos := utils.NormalizeDocument(telefone)
+	if len(digitos) < 8 {
+		return ""
+	}
+	return digitos[len(digitos)-8:]
+}
+
+// acentos mapeia as letras acentuadas do português para a letra sem acento
+var acentos = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// normalizarNome separa as palavras do nome em minúsculas, sem acentos e sem pontuação
+func normalizarNome(nome string) []string {
+	nome = acentos.Replace(strings.ToLower(nome))
+	return strings.FieldsFunc(nome, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+}
+
+// similaridadeNomes compara os nomes pela distância de edição, de 0 a 1. As palavras
+// também são comparadas em ordem alfabética, o que aproxima "Silva, Ana" de "Ana Silva".
+func similaridadeNomes(a, b string) float64 {
+	palavrasA, palavrasB := normalizarNome(a), normalizarNome(b)
+	if len(palavrasA) == 0 || len(palavrasB) == 0 {
+		return 0
+	}
+	similaridade := similaridadeTextos(strings.Join(palavrasA, " "), strings.Join(palavrasB, " "))
+
+	sort.Strings(palavrasA)
+	sort.Strings(palavrasB)
+	return math.Max(similaridade, similaridadeTextos(strings.Join(palavrasA, " "), strings.Join(palavrasB, " ")))
+}
+
+// similaridadeTextos é 1 menos a distância de Levenshtein dividida pelo tamanho do maior texto
+func similaridadeTextos(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	maior := math.Max(float64(len(ra)), float64(len(rb)))
+	if maior == 0 {
+		return 1
+	}
+
+	anterior := make([]int, len(rb)+1)
+	atual := make([]int, len(rb)+1)
+	for j := range anterior {
+		anterior[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		atual[0] = i
+		for j := 1; j <= len(rb); j++ {
+			custo := 1
+			if ra[i-1] == rb[j-1] {
+				custo = 0
+			}
+			atual[j] = min(anterior[j]+1, atual[j-1]+1, anterior[j-1]+custo)
+		}
+		anterior, atual = atual, anterior
+	}
+	return 1 - float64(anterior[len(rb)])/maior
+}
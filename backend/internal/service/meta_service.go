@@ -0,0 +1,277 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+type MetaService struct {
+	metaRepo    repository.MetaRepository
+	usuarioRepo domain.UsuarioRepository
+	localRepo   repository.LocalRepository
+}
+
+func NewMetaService(metaRepo repository.MetaRepository, usuarioRepo domain.UsuarioRepository, localRepo repository.LocalRepository) *MetaService {
+	return &MetaService{
+		metaRepo:    metaRepo,
+		usuarioRepo: usuarioRepo,
+		localRepo:   localRepo,
+	}
+}
+
+// GetAll lista as metas que atendem ao filtro. Quem não gerencia as metas não vê as
+// metas individuais de outros vendedores.
+func (s *MetaService) GetAll(filtro domain.FiltroMetas, operador domain.Operador) ([]domain.Meta, error) {
+	if filtro.Data != nil {
+		dia := inicioDoDia(*filtro.Data)
+		filtro.Data = &dia
+	}
+	metas, err := s.metaRepo.GetAll(filtro)
+	if err != nil {
+		return nil, err
+	}
+
+	visiveis := metas[:0]
+	for _, meta := range metas {
+		if acessoMeta(&meta, operador) == nil {
+			visiveis = append(visiveis, meta)
+		}
+	}
+	return visiveis, nil
+}
+
+func (s *MetaService) GetByID(id string, operador domain.Operador) (*domain.Meta, error) {
+	meta, err := s.metaRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := acessoMeta(meta, operador); err != nil {
+		return nil, err
+	}
+	return meta, nil
+}
+
+func (s *MetaService) Create(dto domain.SalvarMetaDTO) (*domain.Meta, error) {
+	meta, err := s.montarMeta(dto)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verificarSobreposicao(meta); err != nil {
+		return nil, err
+	}
+	meta.DataCriacao = time.Now()
+
+	if err := s.metaRepo.Create(meta); err != nil {
+		return nil, err
+	}
+	return s.metaRepo.GetByID(meta.ID)
+}
+
+func (s *MetaService) Update(id string, dto domain.SalvarMetaDTO) (*domain.Meta, error) {
+	atual, err := s.metaRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	meta, err := s.montarMeta(dto)
+	if err != nil {
+		return nil, err
+	}
+	meta.ID = atual.ID
+	meta.DataCriacao = atual.DataCriacao
+	if err := s.verificarSobreposicao(meta); err != nil {
+		return nil, err
+	}
+
+	if err := s.metaRepo.Update(meta); err != nil {
+		return nil, err
+	}
+	return s.metaRepo.GetByID(meta.ID)
+}
+
+func (s *MetaService) Delete(id string) error {
+	if id == "" {
+		return errors.New("id da meta é obrigatório")
+	}
+	return s.metaRepo.Delete(id)
+}
+
+// GetProgresso compara as vendas realizadas no período da meta com o valor esperado
+func (s *MetaService) GetProgresso(id string, operador domain.Operador) (*domain.ProgressoMeta, error) {
+	meta, err := s.GetByID(id, operador)
+	if err != nil {
+		return nil, err
+	}
+	return s.progresso(meta, time.Now())
+}
+
+// GetProgressos calcula o progresso das metas que atendem ao filtro. Sem data no filtro,
+// são consideradas as metas em vigor hoje.
+func (s *MetaService) GetProgressos(filtro domain.FiltroMetas, operador domain.Operador) ([]domain.ProgressoMeta, error) {
+	agora := time.Now()
+	if filtro.Data == nil {
+		filtro.Data = &agora
+	}
+	metas, err := s.GetAll(filtro, operador)
+	if err != nil {
+		return nil, err
+	}
+
+	progressos := make([]domain.ProgressoMeta, 0, len(metas))
+	for i := range metas {
+		progresso, err := s.progresso(&metas[i], agora)
+		if err != nil {
+			return nil, err
+		}
+		progressos = append(progressos, *progresso)
+	}
+	return progressos, nil
+}
+
+// progresso soma o realizado no período da meta e projeta o resultado do fim do período
+// pelo ritmo diário até agora. Antes do início não há projeção; depois do fim, a projeção
+// é o próprio realizado.
+func (s *MetaService) progresso(meta *domain.Meta, agora time.Time) (*domain.ProgressoMeta, error) {
+	fim := meta.Fim.AddDate(0, 0, 1)
+	realizado, err := s.metaRepo.GetRealizado(meta, meta.Inicio, fim)
+	if err != nil {
+		return nil, err
+	}
+
+	progresso := &domain.ProgressoMeta{
+		Meta:       *meta,
+		Realizado:  arredondar(realizado),
+		DiasTotais: diasEntre(meta.Inicio, fim),
+	}
+	switch {
+	case agora.Before(meta.Inicio):
+		// O período ainda não começou: nada decorrido nem projetado
+	case !agora.Before(fim):
+		progresso.DiasDecorridos = progresso.DiasTotais
+		progresso.Projecao = progresso.Realizado
+	default:
+		progresso.DiasDecorridos = diasEntre(meta.Inicio, inicioDoDia(agora)) + 1
+		progresso.Projecao = arredondar(realizado / float64(progresso.DiasDecorridos) * float64(progresso.DiasTotais))
+	}
+	progresso.PercentualAtingido = arredondar(progresso.Realizado / meta.Valor * 100)
+	progresso.PercentualProjetado = arredondar(progresso.Projecao / meta.Valor * 100)
+	return progresso, nil
+}
+
+// montarMeta valida o alvo exigido pelo tipo da meta e normaliza o período para dias inteiros
+func (s *MetaService) montarMeta(dto domain.SalvarMetaDTO) (*domain.Meta, error) {
+	if !dto.Tipo.Valida() {
+		return nil, fmt.Errorf("%w: tipo %q desconhecido", domain.ErrMetaInvalida, dto.Tipo)
+	}
+	if dto.Valor <= 0 {
+		return nil, fmt.Errorf("%w: o valor da meta deve ser maior que zero", domain.ErrMetaInvalida)
+	}
+
+	meta := &domain.Meta{
+		Descricao: dto.Descricao,
+		Tipo:      dto.Tipo,
+		Valor:     dto.Valor,
+	}
+
+	switch {
+	case dto.Mes != "" && (dto.Inicio != nil || dto.Fim != nil):
+		return nil, fmt.Errorf("%w: informe o mês ou o início e o fim, não ambos", domain.ErrMetaInvalida)
+	case dto.Mes != "":
+		inicio, err := time.ParseInLocation(formatoMes, dto.Mes, time.Local)
+		if err != nil {
+			return nil, fmt.Errorf("%w: mês %q inválido, use o formato AAAA-MM", domain.ErrMetaInvalida, dto.Mes)
+		}
+		meta.Inicio = inicio
+		meta.Fim = inicio.AddDate(0, 1, -1)
+	case dto.Inicio != nil && dto.Fim != nil:
+		meta.Inicio = inicioDoDia(*dto.Inicio)
+		meta.Fim = inicioDoDia(*dto.Fim)
+		if meta.Fim.Before(meta.Inicio) {
+			return nil, fmt.Errorf("%w: o fim do período é anterior ao início", domain.ErrMetaInvalida)
+		}
+	default:
+		return nil, fmt.Errorf("%w: informe o mês ou o início e o fim do período", domain.ErrMetaInvalida)
+	}
+
+	if dto.Tipo != domain.MetaVendedor && dto.VendedorID != "" {
+		return nil, fmt.Errorf("%w: apenas metas de vendedor indicam o vendedor", domain.ErrMetaInvalida)
+	}
+	if dto.Tipo != domain.MetaLoja && dto.LocalID != "" {
+		return nil, fmt.Errorf("%w: apenas metas de loja indicam o local", domain.ErrMetaInvalida)
+	}
+	if dto.Tipo != domain.MetaCategoria && dto.Categoria != "" {
+		return nil, fmt.Errorf("%w: apenas metas de categoria indicam a categoria", domain.ErrMetaInvalida)
+	}
+
+	switch dto.Tipo {
+	case domain.MetaVendedor:
+		if dto.VendedorID == "" {
+			return nil, fmt.Errorf("%w: vendedor é obrigatório", domain.ErrMetaInvalida)
+		}
+		vendedor, err := s.usuarioRepo.GetByID(dto.VendedorID)
+		if errors.Is(err, domain.ErrUsuarioNaoEncontrado) {
+			return nil, fmt.Errorf("%w: usuário %s não encontrado", domain.ErrMetaInvalida, dto.VendedorID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !vendedor.Role.TemPermissao(domain.PermVendasRegistrar) {
+			return nil, fmt.Errorf("%w: usuário %s não registra vendas", domain.ErrMetaInvalida, dto.VendedorID)
+		}
+		meta.VendedorID = vendedor.ID
+	case domain.MetaLoja:
+		if dto.LocalID == "" {
+			return nil, fmt.Errorf("%w: local é obrigatório", domain.ErrMetaInvalida)
+		}
+		if _, err := s.localRepo.GetByID(dto.LocalID); errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: local %s não encontrado", domain.ErrMetaInvalida, dto.LocalID)
+		} else if err != nil {
+			return nil, err
+		}
+		meta.LocalID = dto.LocalID
+	case domain.MetaCategoria:
+		if dto.Categoria == "" {
+			return nil, fmt.Errorf("%w: categoria é obrigatória", domain.ErrMetaInvalida)
+		}
+		meta.Categoria = dto.Categoria
+	}
+	return meta, nil
+}
+
+// verificarSobreposicao recusa duas metas para o mesmo alvo com períodos em comum, que
+// tornariam o acompanhamento ambíguo
+func (s *MetaService) verificarSobreposicao(meta *domain.Meta) error {
+	existe, err := s.metaRepo.ExisteSobreposicao(meta)
+	if err != nil {
+		return err
+	}
+	if existe {
+		return fmt.Errorf("%w: já existe meta para o mesmo alvo em parte do período", domain.ErrMetaInvalida)
+	}
+	return nil
+}
+
+// acessoMeta oculta as metas individuais de outros vendedores de quem não gerencia as metas
+func acessoMeta(meta *domain.Meta, operador domain.Operador) error {
+	if meta.Tipo != domain.MetaVendedor || meta.VendedorID == operador.UsuarioID ||
+		operador.Role.TemPermissao(domain.PermMetasGerenciar) {
+		return nil
+	}
+	return fmt.Errorf("%w: meta de outro vendedor", domain.ErrAcessoNegado)
+}
+
+// inicioDoDia retorna a meia-noite do dia da data, no fuso do servidor
+func inicioDoDia(data time.Time) time.Time {
+	ano, mes, dia := data.In(time.Local).Date()
+	return time.Date(ano, mes, dia, 0, 0, 0, 0, time.Local)
+}
+
+// diasEntre conta os dias de calendário de inicio até fim, ambos à meia-noite
+func diasEntre(inicio, fim time.Time) int {
+	return int(math.Round(fim.Sub(inicio).Hours() / 24))
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+type OrcamentoService struct {
+	orcamentoRepo repository.OrcamentoRepository
+	produtoRepo   repository.ProdutoRepository
+	clienteRepo   repository.ClienteRepository
+	localRepo     repository.LocalRepository
+	vendaService  *VendaService
+	precificador  *Precificador
+}
+
+func NewOrcamentoService(orcamentoRepo repository.OrcamentoRepository, produtoRepo repository.ProdutoRepository, clienteRepo repository.ClienteRepository, localRepo repository.LocalRepository, vendaService *VendaService) *OrcamentoService {
+	return &OrcamentoService{
+		orcamentoRepo: orcamentoRepo,
+		produtoRepo:   produtoRepo,
+		clienteRepo:   clienteRepo,
+		localRepo:     localRepo,
+		vendaService:  vendaService,
+		precificador:  NewPrecificador(LimitesDescontoPadrao),
+	}
+}
+
+// GetAll lista os orçamentos do operador ou, com domain.PermVendasTodas, de todos os vendedores
+func (s *OrcamentoService) GetAll(operador domain.Operador) ([]domain.Orcamento, error) {
+	vendedorID := operador.UsuarioID
+	if operador.Role.TemPermissao(domain.PermVendasTodas) {
+		vendedorID = ""
+	}
+	orcamentos, err := s.orcamentoRepo.GetAll(vendedorID)
+	if err != nil {
+		return nil, err
+	}
+
+	agora := time.Now()
+	for i := range orcamentos {
+		marcarVencido(&orcamentos[i], agora)
+	}
+	return orcamentos, nil
+}
+
+func (s *OrcamentoService) GetByID(id string, operador domain.Operador) (*domain.Orcamento, error) {
+	orcamento, err := s.orcamentoRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := acessoOrcamento(orcamento, operador); err != nil {
+		return nil, err
+	}
+	marcarVencido(orcamento, time.Now())
+	return orcamento, nil
+}
+
+// Create registra o orçamento com os preços atuais dos produtos e os descontos permitidos
+// para o perfil de quem o elabora. O estoque não é conferido nem reservado: a
+// disponibilidade é verificada apenas na conversão em venda.
+func (s *OrcamentoService) Create(dto domain.CreateOrcamentoDTO, operador domain.Operador) (*domain.Orcamento, error) {
+	if len(dto.Itens) == 0 {
+		return nil, errors.New("orçamento deve ter pelo menos um item")
+	}
+
+	agora := time.Now()
+	validoAte := inicioDoDia(agora).AddDate(0, 0, domain.ValidadeOrcamentoPadrao)
+	if dto.ValidoAte != nil {
+		validoAte = inicioDoDia(*dto.ValidoAte)
+		if validoAte.Before(inicioDoDia(agora)) {
+			return nil, errors.New("a validade do orçamento não pode ser anterior a hoje")
+		}
+	}
+
+	// O cálculo é o mesmo de uma venda, feito sobre uma venda provisória que não é gravada
+	venda := &domain.Venda{ClienteID: dto.Cliente, Items: make([]domain.ItemVenda, len(dto.Itens))}
+	if dto.Desconto > 0 {
+		venda.Desconto = &domain.Desconto{Tipo: dto.TipoDesconto, Valor: dto.Desconto}
+	}
+	if err := s.vendaService.definirVendedor(venda, dto.VendedorID, operador); err != nil {
+		return nil, err
+	}
+	if err := validarCliente(s.clienteRepo, dto.Cliente); err != nil {
+		return nil, err
+	}
+	localID, err := validarLocal(s.localRepo, dto.LocalID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, itemDTO := range dto.Itens {
+		if itemDTO.ProdutoID == "" {
+			return nil, errors.New("id do produto é obrigatório")
+		}
+		if itemDTO.Quantidade <= 0 {
+			return nil, errors.New("quantidade deve ser maior que zero")
+		}
+		produto, err := s.produtoRepo.GetByID(itemDTO.ProdutoID)
+		if err != nil || produto == nil {
+			return nil, fmt.Errorf("produto %s não encontrado", itemDTO.ProdutoID)
+		}
+		venda.Items[i] = domain.ItemVenda{
+			ProdutoID:     produto.ID,
+			Quantidade:    itemDTO.Quantidade,
+			PrecoUnitario: produto.Preco,
+			Desconto:      itemDTO.Desconto,
+		}
+	}
+	if err := s.precificador.Calcular(venda, operador.Role); err != nil {
+		return nil, err
+	}
+
+	orcamento := &domain.Orcamento{
+		ClienteID:     venda.ClienteID,
+		VendedorID:    venda.VendedorID,
+		LocalID:       localID,
+		Status:        domain.OrcamentoAberto,
+		ValidoAte:     validoAte,
+		Desconto:      venda.Desconto,
+		Subtotal:      venda.Subtotal,
+		ValorDesconto: venda.ValorDesconto,
+		ValorTotal:    venda.ValorTotal,
+		Observacoes:   dto.Observacoes,
+		DataCriacao:   agora,
+		Items:         venda.Items,
+	}
+	if err := s.orcamentoRepo.Create(orcamento); err != nil {
+		return nil, err
+	}
+	return s.orcamentoRepo.GetByID(orcamento.ID)
+}
+
+// Converter gera a venda do orçamento aberto e dentro da validade por VendaService.Create,
+// que confere de novo os preços, os descontos, o estoque e o limite de crédito. As reservas
+// de estoque do orçamento passam para a venda. O orçamento é marcado como convertido na
+// mesma transação que grava a venda, de modo que cada orçamento gera no máximo uma venda e
+// uma venda recusada o deixa aberto.
+func (s *OrcamentoService) Converter(id string, dto domain.ConverterOrcamentoDTO, operador domain.Operador) (*domain.ConversaoOrcamento, error) {
+	orcamento, err := s.GetByID(id, operador)
+	if err != nil {
+		return nil, err
+	}
+	if orcamento.Status != domain.OrcamentoAberto {
+		return nil, fmt.Errorf("%w: orçamento %s", domain.ErrOrcamentoIndisponivel, orcamento.Status)
+	}
+
+	itens := make([]domain.ItemVenda, len(orcamento.Items))
+	for i, item := range orcamento.Items {
+		itens[i] = domain.ItemVenda{
+			ProdutoID:  item.ProdutoID,
+			Quantidade: item.Quantidade,
+			Desconto:   item.Desconto,
+		}
+	}
+	venda := &domain.Venda{
+		ClienteID:    orcamento.ClienteID,
+		VendedorID:   orcamento.VendedorID,
+		LocalID:      orcamento.LocalID,
+		OrcamentoID:  orcamento.ID,
+		Desconto:     orcamento.Desconto,
+		Parcelamento: dto.Parcelamento,
+		Items:        itens,
+	}
+	if dto.LocalID != "" {
+		venda.LocalID = dto.LocalID
+	}
+	if dto.Rascunho {
+		venda.Status = domain.StatusRascunho
+	}
+
+	if err := s.vendaService.Create(venda, operador); err != nil {
+		return nil, err
+	}
+
+	convertido, err := s.orcamentoRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ConversaoOrcamento{
+		Orcamento:      convertido,
+		Venda:          venda,
+		DiferencaTotal: arredondar(venda.ValorTotal - orcamento.ValorTotal),
+	}, nil
+}
+
+// Cancelar descarta o orçamento aberto, mesmo que vencido
+func (s *OrcamentoService) Cancelar(id string, operador domain.Operador) (*domain.Orcamento, error) {
+	if _, err := s.GetByID(id, operador); err != nil {
+		return nil, err
+	}
+	cancelado, err := s.orcamentoRepo.Cancelar(id)
+	if err != nil {
+		return nil, err
+	}
+	if !cancelado {
+		return nil, fmt.Errorf("%w: apenas orçamentos abertos podem ser cancelados", domain.ErrOrcamentoIndisponivel)
+	}
+	return s.orcamentoRepo.GetByID(id)
+}
+
+// acessoOrcamento restringe o orçamento ao vendedor que o elaborou, exceto para quem
+// alcança as vendas de todos os vendedores
+func acessoOrcamento(orcamento *domain.Orcamento, operador domain.Operador) error {
+	if orcamento.VendedorID == operador.UsuarioID || operador.Role.TemPermissao(domain.PermVendasTodas) {
+		return nil
+	}
+	return fmt.Errorf("%w: orçamento de outro vendedor", domain.ErrAcessoNegado)
+}
+
+// marcarVencido apresenta como vencido o orçamento aberto que passou da validade
+func marcarVencido(orcamento *domain.Orcamento, agora time.Time) {
+	if orcamento.Vencido(agora) {
+		orcamento.Status = domain.OrcamentoVencido
+	}
+}
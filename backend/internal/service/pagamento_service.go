@@ -0,0 +1,142 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+type PagamentoService struct {
+	pagamentoRepo repository.PagamentoRepository
+	programa      domain.ProgramaFidelidade
+}
+
+func NewPagamentoService(pagamentoRepo repository.PagamentoRepository) *PagamentoService {
+	return &PagamentoService{
+		pagamentoRepo: pagamentoRepo,
+		programa:      ProgramaFidelidadePadrao,
+	}
+}
+
+// Registrar recebe um ou mais pagamentos para a venda, permitindo dividir o valor entre
+// formas diferentes. Pagamentos em dinheiro calculam o troco e pagamentos com pontos de
+// fidelidade debitam os pontos do cliente; quando a soma quita o saldo a venda passa para
+// o status paga.
+func (s *PagamentoService) Registrar(vendaID string, dtos []domain.CreatePagamentoDTO, operador domain.Operador) (*domain.SaldoVenda, []domain.Pagamento, error) {
+	if vendaID == "" {
+		return nil, nil, errors.New("id da venda é obrigatório")
+	}
+	if len(dtos) == 0 {
+		return nil, nil, errors.New("informe pelo menos um pagamento")
+	}
+
+	saldo, err := s.GetSaldo(vendaID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if saldo.Status != domain.StatusConfirmada {
+		return nil, nil, fmt.Errorf("%w: venda com status %s não aceita pagamentos", domain.ErrTransicaoStatusInvalida, saldo.Status)
+	}
+
+	agora := time.Now()
+	var total float64
+	pagamentos := make([]domain.Pagamento, len(dtos))
+	for i, dto := range dtos {
+		pagamento, err := montarPagamento(dto, s.programa)
+		if err != nil {
+			return nil, nil, fmt.Errorf("pagamento %d: %v", i+1, err)
+		}
+		pagamento.UsuarioID = operador.UsuarioID
+		pagamento.DataPagamento = agora
+		pagamentos[i] = *pagamento
+		total += pagamento.Valor
+	}
+
+	if arredondar(total-saldo.Saldo) > 0 {
+		return nil, nil, fmt.Errorf("pagamentos de %.2f excedem o saldo de %.2f da venda", total, saldo.Saldo)
+	}
+
+	if _, err := s.pagamentoRepo.Registrar(vendaID, pagamentos, operador.UsuarioID); err != nil {
+		return nil, nil, err
+	}
+
+	saldo, err = s.GetSaldo(vendaID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return saldo, pagamentos, nil
+}
+
+// GetSaldo retorna os pagamentos da venda e quanto ainda falta pagar
+func (s *PagamentoService) GetSaldo(vendaID string) (*domain.SaldoVenda, error) {
+	saldo, err := s.pagamentoRepo.GetSaldo(vendaID)
+	if err != nil {
+		return nil, err
+	}
+	saldo.Saldo = arredondar(saldo.ValorTotal - saldo.ValorDevolvido - saldo.ValorPago)
+
+	saldo.Pagamentos, err = s.pagamentoRepo.GetByVenda(vendaID)
+	if err != nil {
+		return nil, err
+	}
+	return saldo, nil
+}
+
+// montarPagamento valida os dados de acordo com a forma de pagamento. Pagamentos com
+// pontos convertem o valor em pontos pelo valor do ponto no programa, arredondando para cima.
+func montarPagamento(dto domain.CreatePagamentoDTO, programa domain.ProgramaFidelidade) (*domain.Pagamento, error) {
+	if !dto.Forma.Valida() {
+		return nil, fmt.Errorf("forma de pagamento inválida: %s", dto.Forma)
+	}
+	if dto.Valor <= 0 {
+		return nil, errors.New("valor deve ser maior que zero")
+	}
+
+	pagamento := &domain.Pagamento{
+		Forma:             dto.Forma,
+		Valor:             arredondar(dto.Valor),
+		ValorRecebido:     arredondar(dto.Valor),
+		Parcelas:          1,
+		CodigoAutorizacao: dto.CodigoAutorizacao,
+	}
+
+	switch dto.Forma {
+	case domain.FormaDinheiro:
+		if dto.Parcelas > 1 {
+			return nil, errors.New("parcelamento só é permitido no cartão de crédito")
+		}
+
+		// Sem valor recebido informado, considera o valor exato
+		if dto.ValorRecebido > 0 {
+			if dto.ValorRecebido < dto.Valor {
+				return nil, fmt.Errorf("valor recebido de %.2f menor que o valor de %.2f", dto.ValorRecebido, dto.Valor)
+			}
+			pagamento.ValorRecebido = arredondar(dto.ValorRecebido)
+			pagamento.Troco = arredondar(dto.ValorRecebido - dto.Valor)
+		}
+	case domain.FormaCartaoCredito:
+		if dto.Parcelas > 0 {
+			pagamento.Parcelas = dto.Parcelas
+		}
+	case domain.FormaPontos:
+		if dto.ValorRecebido > 0 && dto.ValorRecebido != dto.Valor {
+			return nil, errors.New("troco só é permitido em pagamentos em dinheiro")
+		}
+		if dto.Parcelas > 1 {
+			return nil, errors.New("parcelamento só é permitido no cartão de crédito")
+		}
+		pagamento.Pontos = int(math.Ceil(arredondar(pagamento.Valor / programa.ValorPonto)))
+	default:
+		if dto.ValorRecebido > 0 && dto.ValorRecebido != dto.Valor {
+			return nil, errors.New("troco só é permitido em pagamentos em dinheiro")
+		}
+		if dto.Parcelas > 1 {
+			return nil, errors.New("parcelamento só é permitido no cartão de crédito")
+		}
+	}
+
+	return pagamento, nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"fmt"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+// PermissaoService mantém a matriz de permissões de cada papel. A matriz fica gravada no
+// banco e é copiada para domain a cada alteração, onde as rotas a consultam.
+type PermissaoService struct {
+	repo repository.PermissaoRepository
+}
+
+func NewPermissaoService(repo repository.PermissaoRepository) *PermissaoService {
+	return &PermissaoService{repo: repo}
+}
+
+// Carregar passa a usar a matriz gravada no banco
+func (s *PermissaoService) Carregar() error {
+	matriz, err := s.repo.GetMatriz()
+	if err != nil {
+		return err
+	}
+	domain.DefinirPermissoes(matriz)
+	return nil
+}
+
+// GetMatriz retorna as permissões em uso de cada papel
+func (s *PermissaoService) GetMatriz() map[domain.Role][]domain.Permissao {
+	matriz := domain.PermissoesPorRole()
+	for _, role := range domain.Roles {
+		if matriz[role] == nil {
+			matriz[role] = []domain.Permissao{}
+		}
+	}
+	return matriz
+}
+
+// AlterarRole substitui as permissões do papel e passa a aplicá-las imediatamente. O
+// administrador não pode perder a permissão de alterar a matriz, o que impediria desfazer
+// a alteração.
+func (s *PermissaoService) AlterarRole(role domain.Role, permissoes []domain.Permissao) (map[domain.Role][]domain.Permissao, error) {
+	if !roleConhecido(role) {
+		return nil, fmt.Errorf("%w: papel desconhecido: %s", domain.ErrPermissoesInvalidas, role)
+	}
+	for _, permissao := range permissoes {
+		if !permissao.Valida() {
+			return nil, fmt.Errorf("%w: permissão desconhecida: %s", domain.ErrPermissoesInvalidas, permissao)
+		}
+	}
+	if role == domain.RoleAdmin && !contemPermissao(permissoes, domain.PermPermissoesGerenciar) {
+		return nil, fmt.Errorf("%w: o administrador deve manter a permissão %s", domain.ErrPermissoesInvalidas,
+			domain.PermPermissoesGerenciar)
+	}
+
+	if err := s.repo.SalvarRole(role, permissoes); err != nil {
+		return nil, err
+	}
+	if err := s.Carregar(); err != nil {
+		return nil, err
+	}
+	return s.GetMatriz(), nil
+}
+
+func roleConhecido(role domain.Role) bool {
+	for _, r := range domain.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func contemPermissao(permissoes []domain.Permissao, permissao domain.Permissao) bool {
+	for _, p := range permissoes {
+		if p == permissao {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"vendas/internal/domain"
+)
+
+// LimitesDescontoPadrao define o desconto máximo, em percentual do subtotal,
+// que cada perfil pode conceder em uma venda
+var LimitesDescontoPadrao = map[domain.Role]float64{
+	domain.RoleAdmin:    100,
+	domain.RoleVendedor: 10,
+	domain.RoleCliente:  0,
+}
+
+// Precificador calcula subtotal, descontos e total de uma venda
+type Precificador struct {
+	limites map[domain.Role]float64
+}
+
+func NewPrecificador(limites map[domain.Role]float64) *Precificador {
+	return &Precificador{limites: limites}
+}
+
+// Calcular aplica os descontos dos itens e da venda e valida o limite do perfil.
+// O preço unitário de cada item já deve estar definido. O desconto da venda é
+// rateado entre os itens de forma proporcional, assim a soma dos itens sempre
+// confere com os totais gravados na venda.
+func (p *Precificador) Calcular(venda *domain.Venda, role domain.Role) error {
+	var subtotal, totalItens float64
+	for i := range venda.Items {
+		item := &venda.Items[i]
+		item.Subtotal = arredondar(float64(item.Quantidade) * item.PrecoUnitario)
+
+		desconto, err := calcularDesconto(item.Desconto, item.Subtotal)
+		if err != nil {
+			return fmt.Errorf("item %d: %w", i+1, err)
+		}
+
+		item.ValorDesconto = desconto
+		item.Total = arredondar(item.Subtotal - desconto)
+		subtotal += item.Subtotal
+		totalItens += item.Total
+	}
+	subtotal = arredondar(subtotal)
+	totalItens = arredondar(totalItens)
+
+	// Desconto da venda incide sobre o valor dos itens já com os seus descontos
+	descontoVenda, err := calcularDesconto(venda.Desconto, totalItens)
+	if err != nil {
+		return fmt.Errorf("venda: %w", err)
+	}
+	if descontoVenda > 0 {
+		ratearDesconto(venda.Items, descontoVenda, totalItens)
+	}
+
+	var valorDesconto float64
+	for _, item := range venda.Items {
+		valorDesconto += item.ValorDesconto
+	}
+
+	venda.Subtotal = subtotal
+	venda.ValorDesconto = arredondar(valorDesconto)
+	venda.ValorTotal = arredondar(subtotal - venda.ValorDesconto)
+
+	return p.validarLimite(venda, role)
+}
+
+func (p *Precificador) validarLimite(venda *domain.Venda, role domain.Role) error {
+	if venda.ValorDesconto == 0 || venda.Subtotal == 0 {
+		return nil
+	}
+
+	limite := p.limites[role]
+	percentual := venda.ValorDesconto / venda.Subtotal * 100
+	if percentual > limite+0.005 {
+		return &domain.ErroLimiteDesconto{Percentual: percentual, Limite: limite, Role: role}
+	}
+	return nil
+}
+
+// calcularDesconto converte o desconto solicitado em valor monetário sobre a base informada
+func calcularDesconto(desconto *domain.Desconto, base float64) (float64, error) {
+	if desconto == nil || desconto.Valor == 0 {
+		return 0, nil
+	}
+	if desconto.Valor < 0 {
+		return 0, fmt.Errorf("%w: não pode ser negativo", domain.ErrDescontoInvalido)
+	}
+
+	switch desconto.Tipo {
+	case domain.DescontoPercentual, "":
+		if desconto.Valor > 100 {
+			return 0, fmt.Errorf("%w: o percentual não pode ser maior que 100", domain.ErrDescontoInvalido)
+		}
+		return arredondar(base * desconto.Valor / 100), nil
+	case domain.DescontoValor:
+		if desconto.Valor > base {
+			return 0, fmt.Errorf("%w: %.2f é maior que o valor de %.2f", domain.ErrDescontoInvalido, desconto.Valor, base)
+		}
+		return arredondar(desconto.Valor), nil
+	default:
+		return 0, fmt.Errorf("%w: tipo %s", domain.ErrDescontoInvalido, desconto.Tipo)
+	}
+}
+
+// ratearDesconto distribui o desconto da venda entre os itens proporcionalmente
+// ao total de cada um. A diferença de arredondamento fica no último item com valor.
+func ratearDesconto(itens []domain.ItemVenda, desconto, totalItens float64) {
+	ultimo := -1
+	for i := range itens {
+		if itens[i].Total > 0 {
+			ultimo = i
+		}
+	}
+	if ultimo < 0 {
+		return
+	}
+
+	restante := desconto
+	for i := range itens {
+		if itens[i].Total <= 0 {
+			continue
+		}
+
+		parcela := restante
+		if i != ultimo {
+			parcela = arredondar(desconto * itens[i].Total / totalItens)
+		}
+
+		itens[i].ValorDesconto = arredondar(itens[i].ValorDesconto + parcela)
+		itens[i].Total = arredondar(itens[i].Total - parcela)
+		restante = arredondar(restante - parcela)
+	}
+}
+
+// arredondar arredonda o valor para centavos
+func arredondar(valor float64) float64 {
+	return math.Round(valor*100) / 100
+}
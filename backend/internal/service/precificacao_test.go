@@ -0,0 +1,42 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"vendas/internal/domain"
+)
+
+// Os descontos recusados voltam com erros do domínio, que a API responde como erro do cliente
+func TestCalcularDescontoRecusado(t *testing.T) {
+	precificador := NewPrecificador(LimitesDescontoPadrao)
+	venda := func(desconto domain.Desconto) *domain.Venda {
+		return &domain.Venda{Items: []domain.ItemVenda{{Quantidade: 2, PrecoUnitario: 50, Desconto: &desconto}}}
+	}
+
+	casos := []struct {
+		nome     string
+		desconto domain.Desconto
+	}{
+		{"negativo", domain.Desconto{Tipo: domain.DescontoPercentual, Valor: -5}},
+		{"percentual acima de 100", domain.Desconto{Tipo: domain.DescontoPercentual, Valor: 120}},
+		{"valor acima do item", domain.Desconto{Tipo: domain.DescontoValor, Valor: 150}},
+		{"tipo desconhecido", domain.Desconto{Tipo: "brinde", Valor: 10}},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			err := precificador.Calcular(venda(caso.desconto), domain.RoleAdmin)
+			if !errors.Is(err, domain.ErrDescontoInvalido) {
+				t.Errorf("Calcular retornou %v, esperado %v", err, domain.ErrDescontoInvalido)
+			}
+		})
+	}
+
+	err := precificador.Calcular(venda(domain.Desconto{Tipo: domain.DescontoPercentual, Valor: 15}), domain.RoleVendedor)
+	var limite *domain.ErroLimiteDesconto
+	if !errors.As(err, &limite) {
+		t.Fatalf("Calcular retornou %v, esperado o erro de limite de desconto", err)
+	}
+	if limite.Limite != LimitesDescontoPadrao[domain.RoleVendedor] || limite.Role != domain.RoleVendedor {
+		t.Errorf("limite recusado = %+v, esperado o do perfil vendedor", limite)
+	}
+}
@@ -0,0 +1,290 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+// PrivacidadeService atende aos pedidos dos titulares previstos na LGPD: a exportação de
+// todos os dados ligados a um cliente ou usuário e a anonimização dos seus dados pessoais.
+// As duas operações ficam registradas na trilha de auditoria.
+type PrivacidadeService struct {
+	privacidadeRepo repository.PrivacidadeRepository
+	auditoriaRepo   repository.AuditoriaRepository
+	clienteRepo     repository.ClienteRepository
+	usuarioRepo     domain.UsuarioRepository
+	enderecoRepo    repository.EnderecoRepository
+	vendaRepo       repository.VendaRepository
+	pagamentoRepo   repository.PagamentoRepository
+	parcelaRepo     repository.ParcelaRepository
+	devolucaoRepo   repository.DevolucaoRepository
+	fidelidadeRepo  repository.FidelidadeRepository
+	mesclagemRepo   repository.MesclagemRepository
+	orcamentoRepo   repository.OrcamentoRepository
+}
+
+func NewPrivacidadeService(
+	privacidadeRepo repository.PrivacidadeRepository,
+	auditoriaRepo repository.AuditoriaRepository,
+	clienteRepo repository.ClienteRepository,
+	usuarioRepo domain.UsuarioRepository,
+	enderecoRepo repository.EnderecoRepository,
+	vendaRepo repository.VendaRepository,
+	pagamentoRepo repository.PagamentoRepository,
+	parcelaRepo repository.ParcelaRepository,
+	devolucaoRepo repository.DevolucaoRepository,
+	fidelidadeRepo repository.FidelidadeRepository,
+	mesclagemRepo repository.MesclagemRepository,
+	orcamentoRepo repository.OrcamentoRepository,
+) *PrivacidadeService {
+	return &PrivacidadeService{
+		privacidadeRepo: privacidadeRepo,
+		auditoriaRepo:   auditoriaRepo,
+		clienteRepo:     clienteRepo,
+		usuarioRepo:     usuarioRepo,
+		enderecoRepo:    enderecoRepo,
+		vendaRepo:       vendaRepo,
+		pagamentoRepo:   pagamentoRepo,
+		parcelaRepo:     parcelaRepo,
+		devolucaoRepo:   devolucaoRepo,
+		fidelidadeRepo:  fidelidadeRepo,
+		mesclagemRepo:   mesclagemRepo,
+		orcamentoRepo:   orcamentoRepo,
+	}
+}
+
+// ExportarCliente reúne os dados do cliente, do usuário vinculado a ele, os endereços, as
+// vendas com itens, pagamentos, parcelas e devoluções, os pontos de fidelidade, os
+// orçamentos, os duplicados incorporados ao cadastro e a trilha de auditoria, já incluindo
+// o registro desta exportação
+func (s *PrivacidadeService) ExportarCliente(clienteID string, operador domain.Operador) (*domain.DadosTitular, error) {
+	cliente, err := s.clienteRepo.GetByID(clienteID)
+	if err != nil {
+		return nil, err
+	}
+	usuario, err := s.buscarUsuario(cliente.UsuarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.auditoriaRepo.Registrar(&domain.RegistroAuditoria{
+		Acao:       domain.AuditoriaExportacao,
+		Entidade:   domain.EntidadeCliente,
+		EntidadeID: cliente.ID,
+		UsuarioID:  operador.UsuarioID,
+		Detalhes:   "exportação dos dados pessoais do cliente",
+		Data:       time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.montarDados(cliente, usuario)
+}
+
+// ExportarUsuario reúne os dados do usuário e, quando ele tem cadastro de cliente, os
+// dados desse cadastro
+func (s *PrivacidadeService) ExportarUsuario(usuarioID string, operador domain.Operador) (*domain.DadosTitular, error) {
+	usuario, err := s.usuarioRepo.GetByID(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+	cliente, err := s.clienteRepo.GetByUsuario(usuario.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	err = s.auditoriaRepo.Registrar(&domain.RegistroAuditoria{
+		Acao:       domain.AuditoriaExportacao,
+		Entidade:   domain.EntidadeUsuario,
+		EntidadeID: usuario.ID,
+		UsuarioID:  operador.UsuarioID,
+		Detalhes:   "exportação dos dados pessoais do usuário",
+		Data:       time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.montarDados(cliente, usuario)
+}
+
+// AnonimizarCliente substitui os dados pessoais do cliente e do usuário vinculado a ele,
+// mantendo as vendas para os registros fiscais. Clientes com valores em aberto não podem
+// ser anonimizados, já que os dados ainda são necessários para a cobrança.
+func (s *PrivacidadeService) AnonimizarCliente(clienteID string, operador domain.Operador) (*domain.Cliente, error) {
+	cliente, err := s.clienteRepo.GetByID(clienteID)
+	if err != nil {
+		return nil, err
+	}
+	if cliente.DataAnonimizacao != nil {
+		return nil, fmt.Errorf("%w: os dados já foram anonimizados", domain.ErrClienteAnonimizado)
+	}
+	if cliente.UsuarioID != "" && cliente.UsuarioID == operador.UsuarioID {
+		return nil, fmt.Errorf("%w: não é possível anonimizar o próprio usuário", domain.ErrAnonimizacaoBloqueada)
+	}
+
+	saldo, err := s.clienteRepo.GetSaldoAberto(cliente.ID, "")
+	if err != nil {
+		return nil, err
+	}
+	if arredondar(saldo) > 0 {
+		return nil, fmt.Errorf("%w: o cliente tem %.2f em aberto", domain.ErrAnonimizacaoBloqueada, saldo)
+	}
+
+	detalhes := "dados pessoais do cliente anonimizados"
+	if cliente.UsuarioID != "" {
+		detalhes += fmt.Sprintf(", incluindo o usuário %s", cliente.UsuarioID)
+	}
+	err = s.privacidadeRepo.AnonimizarCliente(cliente, &domain.RegistroAuditoria{
+		Acao:       domain.AuditoriaAnonimizacao,
+		Entidade:   domain.EntidadeCliente,
+		EntidadeID: cliente.ID,
+		UsuarioID:  operador.UsuarioID,
+		Detalhes:   detalhes,
+		Data:       time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.clienteRepo.GetByID(cliente.ID)
+}
+
+// AnonimizarUsuario substitui os dados pessoais do usuário e o desativa. Quando o usuário
+// tem cadastro de cliente, o cadastro inteiro é anonimizado.
+func (s *PrivacidadeService) AnonimizarUsuario(usuarioID string, operador domain.Operador) error {
+	usuario, err := s.usuarioRepo.GetByID(usuarioID)
+	if err != nil {
+		return err
+	}
+	if usuario.ID == operador.UsuarioID {
+		return fmt.Errorf("%w: não é possível anonimizar o próprio usuário", domain.ErrAnonimizacaoBloqueada)
+	}
+
+	cliente, err := s.clienteRepo.GetByUsuario(usuario.ID)
+	if err == nil {
+		_, err = s.AnonimizarCliente(cliente.ID, operador)
+		return err
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if usuario.Nome == domain.NomeUsuarioAnonimizado && !usuario.Ativo {
+		return fmt.Errorf("%w: os dados do usuário já foram anonimizados", domain.ErrAnonimizacaoBloqueada)
+	}
+	return s.privacidadeRepo.AnonimizarUsuario(usuario.ID, &domain.RegistroAuditoria{
+		Acao:       domain.AuditoriaAnonimizacao,
+		Entidade:   domain.EntidadeUsuario,
+		EntidadeID: usuario.ID,
+		UsuarioID:  operador.UsuarioID,
+		Detalhes:   "dados pessoais do usuário anonimizados",
+		Data:       time.Now(),
+	})
+}
+
+// ListarAuditoria consulta a trilha de auditoria
+func (s *PrivacidadeService) ListarAuditoria(filtro domain.FiltroAuditoria) ([]domain.RegistroAuditoria, error) {
+	return s.auditoriaRepo.Listar(filtro)
+}
+
+// buscarUsuario retorna o usuário vinculado ao cliente, ou nil quando não há vínculo
+func (s *PrivacidadeService) buscarUsuario(usuarioID string) (*domain.Usuario, error) {
+	if usuarioID == "" {
+		return nil, nil
+	}
+	usuario, err := s.usuarioRepo.GetByID(usuarioID)
+	if errors.Is(err, domain.ErrUsuarioNaoEncontrado) {
+		return nil, nil
+	}
+	return usuario, err
+}
+
+// montarDados carrega tudo o que está ligado ao titular. O cliente ou o usuário podem ser
+// nulos, mas não os dois.
+func (s *PrivacidadeService) montarDados(cliente *domain.Cliente, usuario *domain.Usuario) (*domain.DadosTitular, error) {
+	dados := &domain.DadosTitular{
+		GeradoEm:   time.Now(),
+		Cliente:    cliente,
+		Usuario:    usuario,
+		Enderecos:  []domain.Endereco{},
+		Vendas:     []domain.VendaTitular{},
+		Fidelidade: []domain.MovimentoPontos{},
+		Orcamentos: []domain.Orcamento{},
+		Mesclagens: []domain.MesclagemCliente{},
+		Auditoria:  []domain.RegistroAuditoria{},
+	}
+
+	if usuario != nil {
+		registros, err := s.auditoriaRepo.Listar(domain.FiltroAuditoria{Entidade: domain.EntidadeUsuario, EntidadeID: usuario.ID})
+		if err != nil {
+			return nil, err
+		}
+		dados.Auditoria = append(dados.Auditoria, registros...)
+	}
+	if cliente == nil {
+		return dados, nil
+	}
+
+	registros, err := s.auditoriaRepo.Listar(domain.FiltroAuditoria{Entidade: domain.EntidadeCliente, EntidadeID: cliente.ID})
+	if err != nil {
+		return nil, err
+	}
+	dados.Auditoria = append(dados.Auditoria, registros...)
+	sort.SliceStable(dados.Auditoria, func(i, j int) bool { return dados.Auditoria[i].Data.After(dados.Auditoria[j].Data) })
+
+	enderecos, err := s.enderecoRepo.GetByCliente(cliente.ID)
+	if err != nil {
+		return nil, err
+	}
+	dados.Enderecos = append(dados.Enderecos, enderecos...)
+
+	vendas, err := s.vendaRepo.GetVendasPorCliente(cliente.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, venda := range vendas {
+		vendaTitular := domain.VendaTitular{Venda: venda}
+		if vendaTitular.Pagamentos, err = s.pagamentoRepo.GetByVenda(venda.ID); err != nil {
+			return nil, err
+		}
+		if vendaTitular.Parcelas, err = s.parcelaRepo.GetByVenda(venda.ID); err != nil {
+			return nil, err
+		}
+		if vendaTitular.Devolucoes, err = s.devolucaoRepo.GetByVenda(venda.ID); err != nil {
+			return nil, err
+		}
+		if vendaTitular.Historico, err = s.vendaRepo.GetHistoricoStatus(venda.ID); err != nil {
+			return nil, err
+		}
+		dados.Vendas = append(dados.Vendas, vendaTitular)
+	}
+
+	movimentos, err := s.fidelidadeRepo.GetMovimentos(cliente.ID)
+	if err != nil {
+		return nil, err
+	}
+	dados.Fidelidade = append(dados.Fidelidade, movimentos...)
+
+	orcamentos, err := s.orcamentoRepo.GetByCliente(cliente.ID)
+	if err != nil {
+		return nil, err
+	}
+	dados.Orcamentos = append(dados.Orcamentos, orcamentos...)
+
+	mesclagens, err := s.mesclagemRepo.GetByCliente(cliente.ID)
+	if err != nil {
+		return nil, err
+	}
+	dados.Mesclagens = append(dados.Mesclagens, mesclagens...)
+
+	segmento, err := s.clienteRepo.GetSegmento(cliente.ID)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	dados.Segmento = segmento
+	return dados, nil
+}
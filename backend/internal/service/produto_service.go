@@ -23,7 +23,8 @@ func (s *ProdutoService) GetByID(id string) (*domain.Produto, error) {
 	return s.repo.GetByID(id)
 }
 
-func (s *ProdutoService) Create(produto *domain.Produto) error {
+// Create cadastra o produto; a quantidade informada entra como saldo inicial do estoque
+func (s *ProdutoService) Create(produto *domain.Produto, operador domain.Operador) error {
 	if produto.Nome == "" {
 		return errors.New("nome do produto é obrigatório")
 	}
@@ -33,10 +34,18 @@ func (s *ProdutoService) Create(produto *domain.Produto) error {
 	if produto.Quantidade < 0 {
 		return errors.New("quantidade do produto não pode ser negativa")
 	}
+	if produto.Custo < 0 {
+		return errors.New("custo do produto não pode ser negativo")
+	}
+	if err := validarNiveisEstoque(produto); err != nil {
+		return err
+	}
 
-	return s.repo.Create(produto)
+	return s.repo.Create(produto, operador.UsuarioID)
 }
 
+// Update altera os dados cadastrais do produto. A quantidade em estoque é mantida;
+// para alterá-la deve ser lançada uma movimentação.
 func (s *ProdutoService) Update(produto *domain.Produto) error {
 	if produto.ID == "" {
 		return errors.New("id do produto é obrigatório")
@@ -47,9 +56,17 @@ func (s *ProdutoService) Update(produto *domain.Produto) error {
 	if produto.Preco <= 0 {
 		return errors.New("preço do produto deve ser maior que zero")
 	}
-	if produto.Quantidade < 0 {
-		return errors.New("quantidade do produto não pode ser negativa")
+	if err := validarNiveisEstoque(produto); err != nil {
+		return err
+	}
+
+	atual, err := s.repo.GetByID(produto.ID)
+	if err != nil {
+		return err
 	}
+	produto.Quantidade = atual.Quantidade
+	produto.Custo = atual.Custo
+	produto.DataCriacao = atual.DataCriacao
 
 	return s.repo.Update(produto)
 }
@@ -72,12 +89,15 @@ func (s *ProdutoService) CreateProduto(produto *domain.Produto) error {
 	if produto.Quantidade < 0 {
 		return errors.New("quantidade não pode ser negativa")
 	}
+	if err := validarNiveisEstoque(produto); err != nil {
+		return err
+	}
 
 	// Define a data de criação automaticamente
 	produto.DataCriacao = time.Now()
 
 	// O ID será definido pelo repositório
-	return s.repo.Create(produto)
+	return s.repo.Create(produto, "")
 }
 
 func (s *ProdutoService) GetProduto(id string) (*domain.Produto, error) {
@@ -95,8 +115,8 @@ func (s *ProdutoService) UpdateProduto(produto *domain.Produto) error {
 	if produto.Preco <= 0 {
 		return errors.New("preço do produto deve ser maior que zero")
 	}
-	if produto.Quantidade < 0 {
-		return errors.New("quantidade não pode ser negativa")
+	if err := validarNiveisEstoque(produto); err != nil {
+		return err
 	}
 
 	// Busca o produto existente para manter a data de criação original
@@ -105,8 +125,10 @@ func (s *ProdutoService) UpdateProduto(produto *domain.Produto) error {
 		return err
 	}
 
-	// Mantém a data de criação original
+	// Mantém a data de criação original, a quantidade e o custo, que só mudam por movimentações
 	produto.DataCriacao = produtoExistente.DataCriacao
+	produto.Quantidade = produtoExistente.Quantidade
+	produto.Custo = produtoExistente.Custo
 
 	return s.repo.Update(produto)
 }
@@ -114,3 +136,18 @@ func (s *ProdutoService) UpdateProduto(produto *domain.Produto) error {
 func (s *ProdutoService) DeleteProduto(id string) error {
 	return s.repo.Delete(id)
 }
+
+// validarNiveisEstoque confere os parâmetros de reposição do produto. Estoque máximo
+// zerado indica que o produto não tem máximo definido.
+func validarNiveisEstoque(produto *domain.Produto) error {
+	if produto.EstoqueMinimo < 0 || produto.EstoqueMaximo < 0 {
+		return errors.New("estoque mínimo e máximo não podem ser negativos")
+	}
+	if produto.EstoqueMaximo > 0 && produto.EstoqueMaximo < produto.EstoqueMinimo {
+		return errors.New("estoque máximo não pode ser menor que o estoque mínimo")
+	}
+	if produto.PrazoEntregaDias < 0 {
+		return errors.New("prazo de entrega não pode ser negativo")
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"fmt"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+type ReservaService struct {
+	reservaRepo      repository.ReservaRepository
+	vendaService     *VendaService
+	orcamentoService *OrcamentoService
+	prazoPadrao      time.Duration
+}
+
+// NewReservaService cria o serviço de reservas; prazoPadrao é por quanto tempo a reserva
+// segura o estoque quando o pedido não informa o prazo
+func NewReservaService(reservaRepo repository.ReservaRepository, vendaService *VendaService, orcamentoService *OrcamentoService, prazoPadrao time.Duration) *ReservaService {
+	if prazoPadrao <= 0 {
+		prazoPadrao = domain.PrazoReservaPadrao
+	}
+	return &ReservaService{
+		reservaRepo:      reservaRepo,
+		vendaService:     vendaService,
+		orcamentoService: orcamentoService,
+		prazoPadrao:      prazoPadrao,
+	}
+}
+
+// GetAtivas lista as reservas que seguram estoque agora
+func (s *ReservaService) GetAtivas(filtro domain.FiltroReservas) ([]domain.ReservaEstoque, error) {
+	return s.reservaRepo.GetAtivas(filtro, time.Now())
+}
+
+// GetReservasVenda lista as reservas ativas da venda
+func (s *ReservaService) GetReservasVenda(vendaID string, operador domain.Operador) ([]domain.ReservaEstoque, error) {
+	if _, err := s.vendaService.GetByID(vendaID, operador); err != nil {
+		return nil, err
+	}
+	return s.reservaRepo.GetPorOrigem(domain.OrigemReserva{VendaID: vendaID}, time.Now())
+}
+
+// GetReservasOrcamento lista as reservas ativas do orçamento
+func (s *ReservaService) GetReservasOrcamento(orcamentoID string, operador domain.Operador) ([]domain.ReservaEstoque, error) {
+	if _, err := s.orcamentoService.GetByID(orcamentoID, operador); err != nil {
+		return nil, err
+	}
+	return s.reservaRepo.GetPorOrigem(domain.OrigemReserva{OrcamentoID: orcamentoID}, time.Now())
+}
+
+// ReservarVenda segura no local da venda em rascunho as quantidades dos itens até a
+// confirmação, que consome as reservas, ou até o fim do prazo. Reservar de novo substitui
+// as reservas anteriores da venda e renova o prazo.
+func (s *ReservaService) ReservarVenda(vendaID string, dto domain.ReservarEstoqueDTO, operador domain.Operador) ([]domain.ReservaEstoque, error) {
+	venda, err := s.vendaService.GetByID(vendaID, operador)
+	if err != nil {
+		return nil, err
+	}
+	if venda.Status != domain.StatusRascunho {
+		return nil, fmt.Errorf("%w: apenas vendas em rascunho reservam estoque; a venda está %s", domain.ErrReservaInvalida, venda.Status)
+	}
+
+	agora := time.Now()
+	expiraEm := agora.Add(s.prazo(dto))
+	origem := domain.OrigemReserva{VendaID: venda.ID}
+	return s.reservar(origem, venda.LocalID, venda.Items, expiraEm, operador, agora)
+}
+
+// ReservarOrcamento segura no local do orçamento aberto as quantidades dos itens até a
+// conversão em venda, que herda as reservas, ou até o fim do prazo, limitado à validade
+// do orçamento. Reservar de novo substitui as reservas anteriores do orçamento.
+func (s *ReservaService) ReservarOrcamento(orcamentoID string, dto domain.ReservarEstoqueDTO, operador domain.Operador) ([]domain.ReservaEstoque, error) {
+	orcamento, err := s.orcamentoService.GetByID(orcamentoID, operador)
+	if err != nil {
+		return nil, err
+	}
+	if orcamento.Status != domain.OrcamentoAberto {
+		return nil, fmt.Errorf("%w: apenas orçamentos abertos reservam estoque; o orçamento está %s", domain.ErrReservaInvalida, orcamento.Status)
+	}
+
+	agora := time.Now()
+	expiraEm := agora.Add(s.prazo(dto))
+	if fimValidade := orcamento.ValidoAte.AddDate(0, 0, 1); expiraEm.After(fimValidade) {
+		expiraEm = fimValidade
+	}
+	origem := domain.OrigemReserva{OrcamentoID: orcamento.ID}
+	return s.reservar(origem, orcamento.LocalID, orcamento.Items, expiraEm, operador, agora)
+}
+
+// LiberarVenda desfaz as reservas ativas da venda
+func (s *ReservaService) LiberarVenda(vendaID string, operador domain.Operador) (int64, error) {
+	if _, err := s.vendaService.GetByID(vendaID, operador); err != nil {
+		return 0, err
+	}
+	return s.reservaRepo.Liberar(domain.OrigemReserva{VendaID: vendaID}, time.Now())
+}
+
+// LiberarOrcamento desfaz as reservas ativas do orçamento
+func (s *ReservaService) LiberarOrcamento(orcamentoID string, operador domain.Operador) (int64, error) {
+	if _, err := s.orcamentoService.GetByID(orcamentoID, operador); err != nil {
+		return 0, err
+	}
+	return s.reservaRepo.Liberar(domain.OrigemReserva{OrcamentoID: orcamentoID}, time.Now())
+}
+
+// ExpirarVencidas registra o fim das reservas que venceram até o momento informado e
+// retorna quantas foram expiradas
+func (s *ReservaService) ExpirarVencidas(agora time.Time) (int64, error) {
+	return s.reservaRepo.ExpirarVencidas(agora)
+}
+
+// prazo retorna o prazo pedido ou, sem ele, o configurado no servidor
+func (s *ReservaService) prazo(dto domain.ReservarEstoqueDTO) time.Duration {
+	if dto.Minutos > 0 {
+		return time.Duration(dto.Minutos) * time.Minute
+	}
+	return s.prazoPadrao
+}
+
+// reservar agrupa os itens por produto e grava uma reserva para cada um
+func (s *ReservaService) reservar(origem domain.OrigemReserva, localID string, itens []domain.ItemVenda, expiraEm time.Time,
+	operador domain.Operador, agora time.Time) ([]domain.ReservaEstoque, error) {
+	if !expiraEm.After(agora) {
+		return nil, fmt.Errorf("%w: o prazo da reserva já terminou", domain.ErrReservaInvalida)
+	}
+
+	var reservas []domain.ReservaEstoque
+	posicao := make(map[string]int)
+	for _, item := range itens {
+		if i, ok := posicao[item.ProdutoID]; ok {
+			reservas[i].Quantidade += item.Quantidade
+			continue
+		}
+		posicao[item.ProdutoID] = len(reservas)
+		reservas = append(reservas, domain.ReservaEstoque{
+			LocalID:    localID,
+			ProdutoID:  item.ProdutoID,
+			Quantidade: item.Quantidade,
+			UsuarioID:  operador.UsuarioID,
+			ExpiraEm:   expiraEm,
+		})
+	}
+
+	if err := s.reservaRepo.Reservar(origem, reservas, agora); err != nil {
+		return nil, err
+	}
+	return s.reservaRepo.GetPorOrigem(origem, agora)
+}
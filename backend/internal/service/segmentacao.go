@@ -0,0 +1,168 @@
+package service
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+	"vendas/internal/domain"
+)
+
+// LimiteProdutosFavoritos é a quantidade de produtos listados no resumo do cliente
+const LimiteProdutosFavoritos = 5
+
+// GetResumo consolida o histórico de compras do cliente: valor total, ticket médio,
+// primeira e última compra, produtos favoritos e o segmento da última segmentação
+func (s *ClienteService) GetResumo(id string) (*domain.ResumoCliente, error) {
+	if _, err := s.repo.GetByID(id); err != nil {
+		return nil, err
+	}
+
+	resumo, err := s.repo.GetResumoCompras(id)
+	if err != nil {
+		return nil, err
+	}
+	resumo.ValorTotal = arredondar(resumo.ValorTotal)
+	if resumo.TotalVendas > 0 {
+		resumo.TicketMedio = arredondar(resumo.ValorTotal / float64(resumo.TotalVendas))
+	}
+	if resumo.UltimaCompra != nil {
+		dias := int(time.Since(*resumo.UltimaCompra).Hours() / 24)
+		resumo.DiasSemComprar = &dias
+	}
+
+	resumo.ProdutosFavoritos, err = s.repo.GetProdutosFavoritos(id, LimiteProdutosFavoritos)
+	if err != nil {
+		return nil, err
+	}
+
+	segmento, err := s.repo.GetSegmento(id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	resumo.Segmento = segmento
+	return resumo, nil
+}
+
+// SegmentarClientes classifica todos os clientes pela recência, frequência e valor das
+// compras e grava o resultado, substituindo a segmentação anterior. As notas são dadas
+// pelo quintil de cada cliente entre os que já compraram.
+func (s *ClienteService) SegmentarClientes(agora time.Time) (*domain.ResultadoSegmentacao, error) {
+	metricas, err := s.repo.GetMetricasRFM(agora)
+	if err != nil {
+		return nil, err
+	}
+
+	var compradores []domain.MetricasRFM
+	for _, m := range metricas {
+		if m.Frequencia > 0 {
+			compradores = append(compradores, m)
+		}
+	}
+	recencias := make([]float64, len(compradores))
+	frequencias := make([]float64, len(compradores))
+	valores := make([]float64, len(compradores))
+	for i, m := range compradores {
+		// Quanto mais recente a compra, melhor a nota
+		recencias[i] = -float64(m.Recencia)
+		frequencias[i] = float64(m.Frequencia)
+		valores[i] = m.Monetario
+	}
+	notasR := notasQuintil(recencias)
+	notasF := notasQuintil(frequencias)
+	notasM := notasQuintil(valores)
+
+	resultado := &domain.ResultadoSegmentacao{
+		DataCalculo: agora,
+		Total:       len(metricas),
+		Segmentos:   make(map[domain.SegmentoRFM]int),
+	}
+	segmentos := make([]domain.SegmentoCliente, 0, len(metricas))
+	for i, m := range compradores {
+		m.Monetario = arredondar(m.Monetario)
+		segmento := domain.SegmentoCliente{
+			MetricasRFM: m,
+			NotaR:       notasR[i],
+			NotaF:       notasF[i],
+			NotaM:       notasM[i],
+			Segmento:    classificarRFM(notasR[i], notasF[i], notasM[i]),
+			DataCalculo: agora,
+		}
+		segmentos = append(segmentos, segmento)
+		resultado.Segmentos[segmento.Segmento]++
+	}
+	for _, m := range metricas {
+		if m.Frequencia == 0 {
+			segmentos = append(segmentos, domain.SegmentoCliente{
+				MetricasRFM: m,
+				Segmento:    domain.SegmentoSemCompras,
+				DataCalculo: agora,
+			})
+			resultado.Segmentos[domain.SegmentoSemCompras]++
+		}
+	}
+
+	if err := s.repo.SalvarSegmentos(segmentos); err != nil {
+		return nil, err
+	}
+	return resultado, nil
+}
+
+// ListSegmentos lista os clientes da última segmentação, opcionalmente de um só segmento
+func (s *ClienteService) ListSegmentos(segmento domain.SegmentoRFM) ([]domain.SegmentoCliente, error) {
+	if segmento != "" && !segmento.Valido() {
+		return nil, fmt.Errorf("%w: segmento %s desconhecido", domain.ErrClienteInvalido, segmento)
+	}
+	return s.repo.GetSegmentos(segmento)
+}
+
+// notasQuintil dá a cada valor uma nota de 1 a 5 conforme o quintil que ocupa na ordem
+// crescente. Valores iguais recebem a mesma nota, a da primeira posição que ocupam.
+func notasQuintil(valores []float64) []int {
+	ordem := make([]int, len(valores))
+	for i := range ordem {
+		ordem[i] = i
+	}
+	sort.SliceStable(ordem, func(a, b int) bool { return valores[ordem[a]] < valores[ordem[b]] })
+
+	notas := make([]int, len(valores))
+	for inicio := 0; inicio < len(ordem); {
+		fim := inicio
+		for fim+1 < len(ordem) && valores[ordem[fim+1]] == valores[ordem[inicio]] {
+			fim++
+		}
+		nota := inicio*5/len(ordem) + 1
+		for _, i := range ordem[inicio : fim+1] {
+			notas[i] = nota
+		}
+		inicio = fim + 1
+	}
+	return notas
+}
+
+// classificarRFM traduz as notas de recência, frequência e valor no segmento do cliente.
+// Frequência e valor entram pela média, arredondada para cima, já que ambos medem o
+// quanto o cliente compra; a recência separa os clientes ativos dos que se afastaram.
+func classificarRFM(r, f, m int) domain.SegmentoRFM {
+	fm := int(math.Ceil(float64(f+m) / 2))
+	switch {
+	case r >= 4 && fm >= 4:
+		return domain.SegmentoCampeoes
+	case r >= 3 && fm >= 3:
+		return domain.SegmentoFieis
+	case r >= 4 && f == 1:
+		return domain.SegmentoNovos
+	case r >= 3:
+		return domain.SegmentoPromissores
+	case fm >= 4:
+		return domain.SegmentoNaoPodePerder
+	case fm >= 3:
+		return domain.SegmentoEmRisco
+	case r == 2:
+		return domain.SegmentoHibernando
+	default:
+		return domain.SegmentoPerdidos
+	}
+}
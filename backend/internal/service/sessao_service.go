@@ -0,0 +1,194 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+	"vendas/internal/utils"
+)
+
+type SessaoService struct {
+	repo        repository.SessaoRepository
+	usuarioRepo domain.UsuarioRepository
+	secretKey   string
+}
+
+func NewSessaoService(repo repository.SessaoRepository, usuarioRepo domain.UsuarioRepository, secretKey string) *SessaoService {
+	return &SessaoService{
+		repo:        repo,
+		usuarioRepo: usuarioRepo,
+		secretKey:   secretKey,
+	}
+}
+
+// Iniciar abre uma sessão para o usuário já autenticado e emite o primeiro par de tokens
+func (s *SessaoService) Iniciar(usuario *domain.Usuario) (*domain.TokensSessao, error) {
+	refreshToken, registro, err := novoRefreshToken(usuario.ID, utils.GenerateUUID())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Criar(registro); err != nil {
+		return nil, err
+	}
+	return s.emitir(usuario, registro.SessaoID, refreshToken)
+}
+
+// Renovar troca o refresh token por um novo par de tokens. Cada refresh token vale uma
+// única vez: apresentar de novo um token já trocado indica que ele vazou, e a sessão
+// inteira é revogada, derrubando também quem estiver usando o token legítimo.
+func (s *SessaoService) Renovar(refreshToken string) (*domain.TokensSessao, *domain.Usuario, error) {
+	atual, err := s.repo.GetByHash(hashRefreshToken(refreshToken))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, fmt.Errorf("%w: refresh token desconhecido", domain.ErrSessaoInvalida)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	agora := time.Now()
+	if atual.RevogadoEm != nil {
+		return nil, nil, fmt.Errorf("%w: sessão encerrada", domain.ErrSessaoInvalida)
+	}
+	if atual.SubstituidoPor != "" {
+		return nil, nil, s.revogarPorReuso(atual.SessaoID, agora)
+	}
+	if agora.After(atual.ExpiraEm) {
+		return nil, nil, fmt.Errorf("%w: refresh token vencido", domain.ErrSessaoInvalida)
+	}
+
+	usuario, err := s.usuarioRepo.GetByID(atual.UsuarioID)
+	if errors.Is(err, domain.ErrUsuarioNaoEncontrado) {
+		return nil, nil, fmt.Errorf("%w: usuário não encontrado", domain.ErrSessaoInvalida)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !usuario.Ativo {
+		if err := s.repo.RevogarSessao(atual.SessaoID, agora); err != nil {
+			return nil, nil, err
+		}
+		return nil, nil, fmt.Errorf("%w: usuário inativo", domain.ErrSessaoInvalida)
+	}
+
+	novoToken, novo, err := novoRefreshToken(usuario.ID, atual.SessaoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.repo.Rotacionar(atual.ID, novo); err != nil {
+		if errors.Is(err, domain.ErrRefreshTokenReutilizado) {
+			return nil, nil, s.revogarPorReuso(atual.SessaoID, agora)
+		}
+		return nil, nil, err
+	}
+
+	tokens, err := s.emitir(usuario, atual.SessaoID, novoToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tokens, usuario, nil
+}
+
+// Encerrar revoga a sessão do usuário (logout). Os access tokens emitidos por ela deixam
+// de valer imediatamente.
+func (s *SessaoService) Encerrar(usuarioID, sessaoID string) error {
+	if sessaoID == "" {
+		return fmt.Errorf("%w: token sem sessão", domain.ErrSessaoInvalida)
+	}
+	ativa, err := s.repo.SessaoAtiva(usuarioID, sessaoID)
+	if err != nil {
+		return err
+	}
+	if !ativa {
+		return fmt.Errorf("%w: sessão encerrada", domain.ErrSessaoInvalida)
+	}
+	return s.repo.RevogarSessao(sessaoID, time.Now())
+}
+
+// EncerrarTodas revoga todas as sessões do usuário, como após a troca de senha ou a
+// desativação do cadastro
+func (s *SessaoService) EncerrarTodas(usuarioID string) error {
+	return s.repo.RevogarUsuario(usuarioID, time.Now())
+}
+
+// Verificar confirma, a cada requisição, que a sessão do access token não foi encerrada e
+// que o usuário ainda existe e está ativo. O usuário devolvido traz o papel atual, que
+// prevalece sobre o gravado no token.
+func (s *SessaoService) Verificar(usuarioID, sessaoID string) (*domain.Usuario, error) {
+	if sessaoID == "" {
+		return nil, fmt.Errorf("%w: token sem sessão", domain.ErrSessaoInvalida)
+	}
+	ativa, err := s.repo.SessaoAtiva(usuarioID, sessaoID)
+	if err != nil {
+		return nil, err
+	}
+	if !ativa {
+		return nil, fmt.Errorf("%w: sessão encerrada", domain.ErrSessaoInvalida)
+	}
+
+	usuario, err := s.usuarioRepo.GetByID(usuarioID)
+	if errors.Is(err, domain.ErrUsuarioNaoEncontrado) {
+		return nil, fmt.Errorf("%w: usuário não encontrado", domain.ErrSessaoInvalida)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !usuario.Ativo {
+		return nil, fmt.Errorf("%w: usuário inativo", domain.ErrSessaoInvalida)
+	}
+	return usuario, nil
+}
+
+// RemoverVencidos apaga os refresh tokens que já venceram
+func (s *SessaoService) RemoverVencidos(agora time.Time) (int64, error) {
+	return s.repo.RemoverVencidos(agora)
+}
+
+func (s *SessaoService) emitir(usuario *domain.Usuario, sessaoID, refreshToken string) (*domain.TokensSessao, error) {
+	accessToken, expiraEm, err := utils.GenerateToken(usuario.ID, string(usuario.Role), sessaoID, s.secretKey)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.TokensSessao{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiraEm:     expiraEm,
+	}, nil
+}
+
+func (s *SessaoService) revogarPorReuso(sessaoID string, agora time.Time) error {
+	if err := s.repo.RevogarSessao(sessaoID, agora); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w: a sessão foi encerrada por segurança", domain.ErrRefreshTokenReutilizado)
+}
+
+// novoRefreshToken sorteia um refresh token opaco e monta o registro que o guarda, do qual
+// consta apenas o hash
+func novoRefreshToken(usuarioID, sessaoID string) (string, *domain.RefreshToken, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", nil, err
+	}
+	token := base64.RawURLEncoding.EncodeToString(bytes)
+
+	agora := time.Now()
+	return token, &domain.RefreshToken{
+		UsuarioID:   usuarioID,
+		SessaoID:    sessaoID,
+		TokenHash:   hashRefreshToken(token),
+		DataCriacao: agora,
+		ExpiraEm:    agora.Add(domain.DuracaoRefreshToken),
+	}, nil
+}
+
+func hashRefreshToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
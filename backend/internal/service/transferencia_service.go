@@ -0,0 +1,134 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/repository"
+)
+
+type TransferenciaService struct {
+	transferenciaRepo repository.TransferenciaRepository
+	localRepo         repository.LocalRepository
+	produtoRepo       repository.ProdutoRepository
+}
+
+func NewTransferenciaService(transferenciaRepo repository.TransferenciaRepository, localRepo repository.LocalRepository, produtoRepo repository.ProdutoRepository) *TransferenciaService {
+	return &TransferenciaService{
+		transferenciaRepo: transferenciaRepo,
+		localRepo:         localRepo,
+		produtoRepo:       produtoRepo,
+	}
+}
+
+func (s *TransferenciaService) GetAll(status domain.StatusTransferencia) ([]domain.Transferencia, error) {
+	return s.transferenciaRepo.GetAll(status)
+}
+
+func (s *TransferenciaService) GetByID(id string) (*domain.Transferencia, error) {
+	return s.transferenciaRepo.GetByID(id)
+}
+
+// Create envia os itens da origem para o destino. Os itens saem do estoque da origem
+// imediatamente e ficam em trânsito até o recebimento.
+func (s *TransferenciaService) Create(dto domain.CreateTransferenciaDTO, operador domain.Operador) (*domain.Transferencia, error) {
+	if dto.OrigemID == "" || dto.DestinoID == "" {
+		return nil, errors.New("origem e destino são obrigatórios")
+	}
+	if dto.OrigemID == dto.DestinoID {
+		return nil, errors.New("origem e destino devem ser locais diferentes")
+	}
+	if len(dto.Itens) == 0 {
+		return nil, errors.New("transferência deve ter pelo menos um item")
+	}
+	if _, err := validarLocal(s.localRepo, dto.OrigemID); err != nil {
+		return nil, err
+	}
+	if _, err := validarLocal(s.localRepo, dto.DestinoID); err != nil {
+		return nil, err
+	}
+
+	transferencia := &domain.Transferencia{
+		OrigemID:   dto.OrigemID,
+		DestinoID:  dto.DestinoID,
+		Status:     domain.TransferenciaEmTransito,
+		Observacao: dto.Observacao,
+		UsuarioID:  operador.UsuarioID,
+		DataEnvio:  time.Now(),
+	}
+
+	quantidades := make(map[string]int)
+	for _, itemDTO := range dto.Itens {
+		if itemDTO.ProdutoID == "" {
+			return nil, errors.New("id do produto é obrigatório")
+		}
+		if itemDTO.Quantidade <= 0 {
+			return nil, errors.New("quantidade deve ser maior que zero")
+		}
+		if _, ok := quantidades[itemDTO.ProdutoID]; ok {
+			return nil, fmt.Errorf("produto %s informado mais de uma vez", itemDTO.ProdutoID)
+		}
+
+		produto, err := s.produtoRepo.GetByID(itemDTO.ProdutoID)
+		if err != nil {
+			return nil, fmt.Errorf("erro ao buscar produto %s: %v", itemDTO.ProdutoID, err)
+		}
+		// O que está reservado para vendas e orçamentos não pode sair da origem
+		disponivel, err := s.localRepo.GetDisponivel(dto.OrigemID, produto.ID, domain.OrigemReserva{})
+		if err != nil {
+			return nil, err
+		}
+		if disponivel < itemDTO.Quantidade {
+			return nil, fmt.Errorf("estoque insuficiente para o produto %s na origem. Disponível: %d, Solicitado: %d",
+				produto.Nome, disponivel, itemDTO.Quantidade)
+		}
+
+		quantidades[produto.ID] = itemDTO.Quantidade
+		transferencia.Itens = append(transferencia.Itens, domain.ItemTransferencia{
+			ProdutoID:  produto.ID,
+			Quantidade: itemDTO.Quantidade,
+		})
+	}
+
+	if err := s.transferenciaRepo.Create(transferencia); err != nil {
+		return nil, err
+	}
+	return transferencia, nil
+}
+
+// Receber registra a chegada da transferência, dando entrada dos itens no destino
+func (s *TransferenciaService) Receber(id string, operador domain.Operador) (*domain.Transferencia, error) {
+	if err := s.validarEmTransito(id); err != nil {
+		return nil, err
+	}
+	if err := s.transferenciaRepo.Receber(id, operador.UsuarioID); err != nil {
+		return nil, err
+	}
+	return s.transferenciaRepo.GetByID(id)
+}
+
+// Cancelar desfaz uma transferência em trânsito, devolvendo os itens à origem
+func (s *TransferenciaService) Cancelar(id string, operador domain.Operador) (*domain.Transferencia, error) {
+	if err := s.validarEmTransito(id); err != nil {
+		return nil, err
+	}
+	if err := s.transferenciaRepo.Cancelar(id, operador.UsuarioID); err != nil {
+		return nil, err
+	}
+	return s.transferenciaRepo.GetByID(id)
+}
+
+func (s *TransferenciaService) validarEmTransito(id string) error {
+	if id == "" {
+		return errors.New("id da transferência é obrigatório")
+	}
+	transferencia, err := s.transferenciaRepo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if transferencia.Status != domain.TransferenciaEmTransito {
+		return fmt.Errorf("%w: transferência com status %s não pode ser alterada", domain.ErrTransicaoStatusInvalida, transferencia.Status)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"time"
@@ -9,28 +10,157 @@ import (
 )
 
 type VendaService struct {
-	vendaRepo   repository.VendaRepository
-	produtoRepo repository.ProdutoRepository
+	vendaRepo     repository.VendaRepository
+	produtoRepo   repository.ProdutoRepository
+	pagamentoRepo repository.PagamentoRepository
+	localRepo     repository.LocalRepository
+	clienteRepo   repository.ClienteRepository
+	usuarioRepo   domain.UsuarioRepository
+	precificador  *Precificador
+
+	fidelidadeRepo repository.FidelidadeRepository
+	programa       domain.ProgramaFidelidade
 }
 
-func NewVendaService(vendaRepo repository.VendaRepository, produtoRepo repository.ProdutoRepository) *VendaService {
+func NewVendaService(vendaRepo repository.VendaRepository, produtoRepo repository.ProdutoRepository, pagamentoRepo repository.PagamentoRepository, localRepo repository.LocalRepository, clienteRepo repository.ClienteRepository, usuarioRepo domain.UsuarioRepository, fidelidadeRepo repository.FidelidadeRepository) *VendaService {
 	return &VendaService{
-		vendaRepo:   vendaRepo,
-		produtoRepo: produtoRepo,
+		vendaRepo:      vendaRepo,
+		produtoRepo:    produtoRepo,
+		pagamentoRepo:  pagamentoRepo,
+		localRepo:      localRepo,
+		clienteRepo:    clienteRepo,
+		usuarioRepo:    usuarioRepo,
+		precificador:   NewPrecificador(LimitesDescontoPadrao),
+		fidelidadeRepo: fidelidadeRepo,
+		programa:       ProgramaFidelidadePadrao,
+	}
+}
+
+// GetAll lista as vendas que o operador pode ver: todas com domain.PermVendasTodas; as que
+// registrou, para quem registra vendas; ou apenas as do próprio cliente
+func (s *VendaService) GetAll(operador domain.Operador) ([]domain.Venda, error) {
+	escopo, err := s.escopoDoOperador(operador)
+	if err != nil {
+		return nil, err
+	}
+	if escopo.clienteID != "" {
+		return s.vendaRepo.GetVendasPorCliente(escopo.clienteID)
+	}
+	vendas, err := s.vendaRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	return escopo.filtrar(vendas), nil
+}
+
+// GetByID busca a venda; vendas fora do alcance do operador são tratadas como inexistentes
+func (s *VendaService) GetByID(id string, operador domain.Operador) (*domain.Venda, error) {
+	venda, err := s.vendaRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	escopo, err := s.escopoDoOperador(operador)
+	if err != nil {
+		return nil, err
 	}
+	if !escopo.alcanca(venda) {
+		return nil, sql.ErrNoRows
+	}
+	return venda, nil
+}
+
+// VerificarAcesso confirma que a venda está ao alcance do operador, antes de consultar ou
+// alterar a venda, os pagamentos, as parcelas, as devoluções ou o histórico dela
+func (s *VendaService) VerificarAcesso(id string, operador domain.Operador) error {
+	if !operador.Role.TemPermissao(domain.PermVendasTodas) {
+		_, err := s.GetByID(id, operador)
+		return err
+	}
+	return nil
+}
+
+// escopoVendas restringe as vendas ao cliente ou ao vendedor informado; vazio, alcança todas
+type escopoVendas struct {
+	clienteID  string
+	vendedorID string
 }
 
-func (s *VendaService) GetAll() ([]domain.Venda, error) {
-	return s.vendaRepo.GetAll()
+func (e escopoVendas) alcanca(venda *domain.Venda) bool {
+	return (e.clienteID == "" || venda.ClienteID == e.clienteID) &&
+		(e.vendedorID == "" || venda.VendedorID == e.vendedorID)
 }
 
-func (s *VendaService) GetByID(id string) (*domain.Venda, error) {
-	return s.vendaRepo.GetByID(id)
+func (e escopoVendas) filtrar(vendas []domain.Venda) []domain.Venda {
+	if e.clienteID == "" && e.vendedorID == "" {
+		return vendas
+	}
+	alcancadas := []domain.Venda{}
+	for i := range vendas {
+		if e.alcanca(&vendas[i]) {
+			alcancadas = append(alcancadas, vendas[i])
+		}
+	}
+	return alcancadas
 }
 
-func (s *VendaService) Create(venda *domain.Venda) error {
-	if venda.ClienteID == "" {
-		return errors.New("cliente é obrigatório")
+// escopoDoOperador define as vendas que o operador alcança. Sem domain.PermVendasTodas,
+// quem registra vendas alcança as que registrou e os demais, as do próprio cliente; um
+// usuário sem cadastro de cliente não alcança nenhuma venda.
+func (s *VendaService) escopoDoOperador(operador domain.Operador) (escopoVendas, error) {
+	if operador.Role.TemPermissao(domain.PermVendasTodas) {
+		return escopoVendas{}, nil
+	}
+	if operador.Role.TemPermissao(domain.PermVendasRegistrar) {
+		return escopoVendas{vendedorID: operador.UsuarioID}, nil
+	}
+	cliente, err := s.clienteRepo.GetByUsuario(operador.UsuarioID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return escopoVendas{}, fmt.Errorf("%w: usuário sem cadastro de cliente", domain.ErrAcessoNegado)
+	}
+	if err != nil {
+		return escopoVendas{}, err
+	}
+	return escopoVendas{clienteID: cliente.ID}, nil
+}
+
+// definirVendedor registra a venda em nome do operador ou, quando ele tem
+// domain.PermVendasTodas, do vendedor informado, que deve estar ativo e poder registrar vendas
+func (s *VendaService) definirVendedor(venda *domain.Venda, vendedorID string, operador domain.Operador) error {
+	if vendedorID == "" || vendedorID == operador.UsuarioID {
+		venda.VendedorID = operador.UsuarioID
+		return nil
+	}
+	if !operador.Role.TemPermissao(domain.PermVendasTodas) {
+		return fmt.Errorf("%w: apenas administradores registram vendas em nome de outro vendedor", domain.ErrAcessoNegado)
+	}
+
+	vendedor, err := s.usuarioRepo.GetByID(vendedorID)
+	if errors.Is(err, domain.ErrUsuarioNaoEncontrado) {
+		return fmt.Errorf("%w: usuário %s não encontrado", domain.ErrVendedorInvalido, vendedorID)
+	}
+	if err != nil {
+		return err
+	}
+	if !vendedor.Ativo {
+		return fmt.Errorf("%w: usuário %s inativo", domain.ErrVendedorInvalido, vendedorID)
+	}
+	if !vendedor.Role.TemPermissao(domain.PermVendasRegistrar) {
+		return fmt.Errorf("%w: usuário %s não registra vendas", domain.ErrVendedorInvalido, vendedorID)
+	}
+	venda.VendedorID = vendedor.ID
+	return nil
+}
+
+// Create registra a venda aplicando os descontos permitidos para o perfil de quem a registra.
+// Vendas sem status definido são confirmadas e baixam o estoque imediatamente; rascunhos
+// só movimentam o estoque e geram as parcelas quando forem confirmados. Sem local informado,
+// os itens saem do local principal.
+func (s *VendaService) Create(venda *domain.Venda, operador domain.Operador) error {
+	if err := s.definirVendedor(venda, venda.VendedorID, operador); err != nil {
+		return err
+	}
+	if err := validarCliente(s.clienteRepo, venda.ClienteID); err != nil {
+		return err
 	}
 	if len(venda.Items) == 0 {
 		return errors.New("venda deve ter pelo menos um item")
@@ -38,9 +168,27 @@ func (s *VendaService) Create(venda *domain.Venda) error {
 
 	// Define a data da venda como o momento atual
 	venda.DataVenda = time.Now()
+	venda.DataCriacao = venda.DataVenda
 
-	var subtotal float64
-	// Validar disponibilidade de estoque e calcular valores
+	if venda.Status == "" {
+		venda.Status = domain.StatusConfirmada
+	}
+	if venda.Status != domain.StatusConfirmada && venda.Status != domain.StatusRascunho {
+		return fmt.Errorf("uma venda não pode ser criada com status %s", venda.Status)
+	}
+	if venda.Parcelamento != nil {
+		if err := validarPlanoParcelamento(venda.Parcelamento, venda.DataVenda); err != nil {
+			return err
+		}
+	}
+
+	localID, err := validarLocal(s.localRepo, venda.LocalID)
+	if err != nil {
+		return err
+	}
+	venda.LocalID = localID
+
+	// Validar disponibilidade de estoque e definir os preços
 	for i := range venda.Items {
 		if venda.Items[i].ProdutoID == "" {
 			return errors.New("id do produto é obrigatório")
@@ -58,37 +206,110 @@ func (s *VendaService) Create(venda *domain.Venda) error {
 			return fmt.Errorf("produto %s não encontrado", venda.Items[i].ProdutoID)
 		}
 
-		// Validar estoque disponível
-		if produto.Quantidade < venda.Items[i].Quantidade {
-			return fmt.Errorf("estoque insuficiente para o produto %s. Disponível: %d, Solicitado: %d",
-				produto.Nome, produto.Quantidade, venda.Items[i].Quantidade)
+		// Validar estoque disponível no local da venda, descontadas as reservas de outras
+		// vendas e orçamentos; as do orçamento convertido seguram o estoque para esta venda
+		if venda.Status.BaixaEstoque() {
+			disponivel, err := s.localRepo.GetDisponivel(venda.LocalID, produto.ID, domain.OrigemReserva{OrcamentoID: venda.OrcamentoID})
+			if err != nil {
+				return err
+			}
+			if disponivel < venda.Items[i].Quantidade {
+				return fmt.Errorf("estoque insuficiente para o produto %s. Disponível: %d, Solicitado: %d",
+					produto.Nome, disponivel, venda.Items[i].Quantidade)
+			}
 		}
 
-		// Define o preço unitário
+		// Define o preço unitário e o custo médio atual do produto
 		venda.Items[i].PrecoUnitario = produto.Preco
-		subtotal += float64(venda.Items[i].Quantidade) * venda.Items[i].PrecoUnitario
+		venda.Items[i].CustoUnitario = produto.Custo
+	}
+
+	// Calcula subtotal, descontos e total final
+	if err := s.precificador.Calcular(venda, operador.Role); err != nil {
+		return err
 	}
 
-	// Calcula o total final
-	venda.ValorTotal = subtotal
+	// Rascunhos não geram saldo em aberto; o limite é conferido na confirmação
+	if venda.Status == domain.StatusConfirmada {
+		if err := s.verificarLimiteCredito(venda, operador); err != nil {
+			return err
+		}
+	}
+
+	if venda.Parcelamento != nil && venda.Status == domain.StatusConfirmada {
+		venda.Parcelas = gerarParcelas(venda.ValorTotal, *venda.Parcelamento)
+	}
+
+	if venda.Status == domain.StatusConfirmada {
+		if err := s.pontuarVenda(venda, venda.DataVenda); err != nil {
+			return err
+		}
+	}
 
 	// Cria a venda em uma transação
-	return s.vendaRepo.Create(venda)
+	return s.vendaRepo.Create(venda, operador.UsuarioID)
 }
 
-func (s *VendaService) Update(venda *domain.Venda) error {
+// Update substitui os itens da venda. Apenas rascunhos e vendas confirmadas ainda não
+// pagas podem ser editados. Sem plano informado, mantém o parcelamento atual da venda
+// e gera novamente as parcelas das vendas confirmadas. Sem local informado, mantém o local atual.
+func (s *VendaService) Update(venda *domain.Venda, operador domain.Operador) error {
 	if venda.ID == "" {
 		return errors.New("id da venda é obrigatório")
 	}
-	if venda.ClienteID == "" {
-		return errors.New("cliente é obrigatório")
+
+	atual, err := s.vendaRepo.GetByID(venda.ID)
+	if err != nil {
+		return err
+	}
+	if atual.Status != domain.StatusRascunho && atual.Status != domain.StatusConfirmada {
+		return fmt.Errorf("venda com status %s não pode ser editada", atual.Status)
+	}
+	// Os pagamentos já registrados deixariam de bater com o novo total ou com as parcelas
+	if err := s.validarSemPagamentos(atual); err != nil {
+		return err
+	}
+	// O vendedor só muda quando informado; a troca segue as regras da criação da venda
+	vendedorID := venda.VendedorID
+	venda.VendedorID = atual.VendedorID
+	if vendedorID != "" && vendedorID != atual.VendedorID {
+		if err := s.definirVendedor(venda, vendedorID, operador); err != nil {
+			return err
+		}
+	}
+	if err := validarCliente(s.clienteRepo, venda.ClienteID); err != nil {
+		return err
 	}
 	if len(venda.Items) == 0 {
 		return errors.New("venda deve ter pelo menos um item")
 	}
 
-	var total float64
-	for _, item := range venda.Items {
+	if venda.Parcelamento == nil {
+		venda.Parcelamento = atual.Parcelamento
+	}
+	if venda.LocalID == "" {
+		venda.LocalID = atual.LocalID
+	}
+	if venda.LocalID, err = validarLocal(s.localRepo, venda.LocalID); err != nil {
+		return err
+	}
+	if venda.Parcelamento != nil {
+		if err := validarPlanoParcelamento(venda.Parcelamento, atual.DataVenda); err != nil {
+			return err
+		}
+	}
+
+	// A edição devolve ao local os itens já baixados pela venda antes de baixar os novos,
+	// então eles contam como disponíveis no mesmo local
+	baixados := make(map[string]int)
+	if atual.Status.BaixaEstoque() && atual.LocalID == venda.LocalID {
+		for _, item := range atual.Items {
+			baixados[item.ProdutoID] += item.Quantidade
+		}
+	}
+
+	for i := range venda.Items {
+		item := &venda.Items[i]
 		if item.ProdutoID == "" {
 			return errors.New("id do produto é obrigatório")
 		}
@@ -101,35 +322,242 @@ func (s *VendaService) Update(venda *domain.Venda) error {
 			return err
 		}
 
-		if produto.Quantidade < item.Quantidade {
-			return errors.New("quantidade insuficiente em estoque")
+		// Valida o estoque descontadas as reservas de outras vendas e orçamentos
+		if atual.Status.BaixaEstoque() {
+			disponivel, err := s.localRepo.GetDisponivel(venda.LocalID, produto.ID, domain.OrigemReserva{VendaID: venda.ID})
+			if err != nil {
+				return err
+			}
+			disponivel += baixados[produto.ID]
+			if disponivel < item.Quantidade {
+				return fmt.Errorf("estoque insuficiente para o produto %s. Disponível: %d, Solicitado: %d",
+					produto.Nome, disponivel, item.Quantidade)
+			}
 		}
 
 		item.PrecoUnitario = produto.Preco
-		total += float64(item.Quantidade) * item.PrecoUnitario
+		item.CustoUnitario = produto.Custo
 	}
 
-	venda.ValorTotal = total
-	return s.vendaRepo.Update(venda)
+	if err := s.precificador.Calcular(venda, operador.Role); err != nil {
+		return err
+	}
+
+	if atual.Status == domain.StatusConfirmada {
+		if err := s.verificarLimiteCredito(venda, operador); err != nil {
+			return err
+		}
+	}
+
+	if venda.Parcelamento != nil && atual.Status == domain.StatusConfirmada {
+		venda.Parcelas = gerarParcelas(venda.ValorTotal, *venda.Parcelamento)
+	}
+
+	if atual.Status == domain.StatusConfirmada {
+		if err := s.pontuarVenda(venda, atual.DataVenda); err != nil {
+			return err
+		}
+	}
+
+	return s.vendaRepo.Update(venda, operador.UsuarioID)
+}
+
+// Confirmar efetiva um rascunho, baixando os itens do estoque
+func (s *VendaService) Confirmar(id string, operador domain.Operador, motivo string) (*domain.Venda, error) {
+	return s.alterarStatus(id, domain.StatusConfirmada, operador, motivo)
+}
+
+// MarcarComoPaga registra que a venda confirmada foi paga. Só é aceito quando os
+// pagamentos registrados já cobrem o total da venda. Vendas parceladas passam para paga
+// automaticamente ao receber a última parcela.
+func (s *VendaService) MarcarComoPaga(id string, operador domain.Operador, motivo string) (*domain.Venda, error) {
+	venda, err := s.vendaRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(venda.Parcelas) > 0 {
+		return nil, fmt.Errorf("%w: venda parcelada é quitada pelo recebimento das parcelas", domain.ErrTransicaoStatusInvalida)
+	}
+
+	saldo, err := s.pagamentoRepo.GetSaldo(id)
+	if err != nil {
+		return nil, err
+	}
+
+	restante := arredondar(saldo.ValorTotal - saldo.ValorDevolvido - saldo.ValorPago)
+	if restante > 0 {
+		return nil, fmt.Errorf("%w: faltam %.2f em pagamentos para quitar a venda", domain.ErrTransicaoStatusInvalida, restante)
+	}
+
+	return s.alterarStatus(id, domain.StatusPaga, operador, motivo)
 }
 
-func (s *VendaService) Delete(id string) error {
+// Cancelar cancela a venda mantendo o seu histórico. Os itens voltam ao estoque
+// quando a venda já havia sido confirmada. Vendas que já receberam pagamentos não são
+// canceladas: o reembolso ao cliente é feito pela devolução.
+func (s *VendaService) Cancelar(id string, operador domain.Operador, motivo string) (*domain.Venda, error) {
+	return s.alterarStatus(id, domain.StatusCancelada, operador, motivo)
+}
+
+func (s *VendaService) GetHistoricoStatus(id string) ([]domain.HistoricoStatusVenda, error) {
+	if _, err := s.vendaRepo.GetByID(id); err != nil {
+		return nil, err
+	}
+	return s.vendaRepo.GetHistoricoStatus(id)
+}
+
+func (s *VendaService) alterarStatus(id string, novo domain.StatusVenda, operador domain.Operador, motivo string) (*domain.Venda, error) {
 	if id == "" {
-		return errors.New("id da venda é obrigatório")
+		return nil, errors.New("id da venda é obrigatório")
+	}
+
+	venda, err := s.vendaRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	anterior := venda.Status
+	if !anterior.PodeMudarPara(novo) {
+		return nil, fmt.Errorf("%w: de %s para %s", domain.ErrTransicaoStatusInvalida, anterior, novo)
+	}
+
+	// O estoque só sai na confirmação e só volta no cancelamento ou na devolução
+	efeito := repository.SemEfeitoEstoque
+	if !anterior.BaixaEstoque() && novo.BaixaEstoque() {
+		efeito = repository.BaixarEstoque
+	}
+	if anterior.BaixaEstoque() && (novo == domain.StatusCancelada || novo == domain.StatusDevolvida) {
+		efeito = repository.EstornarEstoque
+	}
+
+	if novo == domain.StatusConfirmada {
+		if err := s.verificarLimiteCredito(venda, operador); err != nil {
+			return nil, err
+		}
+	}
+
+	// As parcelas são geradas na confirmação, com o valor final da venda
+	if novo == domain.StatusConfirmada && venda.Parcelamento != nil {
+		venda.Parcelas = gerarParcelas(venda.ValorTotal, *venda.Parcelamento)
+	}
+
+	// A confirmação credita os pontos de fidelidade; no cancelamento, os pontos usados para
+	// pagar a venda voltam ao cliente com uma nova validade
+	switch novo {
+	case domain.StatusConfirmada:
+		if err := s.pontuarVenda(venda, venda.DataVenda); err != nil {
+			return nil, err
+		}
+	case domain.StatusCancelada:
+		venda.ExpiracaoPontos = expiracaoPontos(s.programa, time.Now())
+	}
+
+	venda.Status = novo
+	if err := s.vendaRepo.AlterarStatus(venda, anterior, efeito, operador.UsuarioID, motivo); err != nil {
+		return nil, err
+	}
+
+	return venda, nil
+}
+
+// verificarLimiteCredito recusa a venda que levaria o saldo em aberto do cliente além do
+// seu limite de crédito, a menos que um administrador tenha liberado a operação. Clientes
+// sem limite não são conferidos. A liberação fica registrada na venda.
+func (s *VendaService) verificarLimiteCredito(venda *domain.Venda, operador domain.Operador) error {
+	cliente, err := s.clienteRepo.GetByID(venda.ClienteID)
+	if err != nil {
+		return err
+	}
+	if cliente.LimiteCredito <= 0 {
+		return nil
+	}
+
+	credito, err := consultarCredito(s.clienteRepo, cliente, venda.ID)
+	if err != nil {
+		return err
+	}
+
+	excedente := arredondar(credito.SaldoAberto + venda.ValorTotal - credito.Limite)
+	if excedente <= 0 {
+		return nil
+	}
+	if operador.LiberacaoCredito != "" {
+		venda.LiberacaoCredito = operador.LiberacaoCredito
+		return nil
+	}
+
+	return &domain.ErroLimiteCredito{
+		ClienteID:   cliente.ID,
+		Limite:      credito.Limite,
+		SaldoAberto: credito.SaldoAberto,
+		ValorVenda:  venda.ValorTotal,
+		Excedente:   excedente,
+	}
+}
+
+// pontuarVenda calcula os pontos de fidelidade que a venda gera para o cliente pelas
+// regras vigentes na data da venda e a data em que eles vencem
+func (s *VendaService) pontuarVenda(venda *domain.Venda, dataVenda time.Time) error {
+	regras, err := s.fidelidadeRepo.GetRegras()
+	if err != nil {
+		return err
 	}
 
-	return s.vendaRepo.Delete(id)
+	categorias := make(map[string]string)
+	for _, item := range venda.Items {
+		if _, ok := categorias[item.ProdutoID]; ok {
+			continue
+		}
+		produto, err := s.produtoRepo.GetByID(item.ProdutoID)
+		if err != nil {
+			return fmt.Errorf("erro ao buscar produto %s: %v", item.ProdutoID, err)
+		}
+		categorias[item.ProdutoID] = produto.Categoria
+	}
+
+	venda.PontosFidelidade = calcularPontos(venda.Items, dataVenda, categorias, regras)
+	venda.ExpiracaoPontos = expiracaoPontos(s.programa, time.Now())
+	return nil
 }
 
-func (s *VendaService) GetVendasPorCliente(cliente string) ([]domain.Venda, error) {
+// validarSemPagamentos impede editar uma venda confirmada que já recebeu pagamentos. As
+// parcelas recebidas são conferidas na gravação da edição.
+func (s *VendaService) validarSemPagamentos(venda *domain.Venda) error {
+	if venda.Status != domain.StatusConfirmada {
+		return nil
+	}
+
+	saldo, err := s.pagamentoRepo.GetSaldo(venda.ID)
+	if err != nil {
+		return err
+	}
+	if saldo.ValorPago > 0 {
+		return errors.New("venda com pagamentos registrados não pode ser editada")
+	}
+	return nil
+}
+
+func (s *VendaService) GetVendasPorCliente(cliente string, operador domain.Operador) ([]domain.Venda, error) {
 	if cliente == "" {
 		return nil, errors.New("cliente é obrigatório")
 	}
 
-	return s.vendaRepo.GetVendasPorCliente(cliente)
+	escopo, err := s.escopoDoOperador(operador)
+	if err != nil {
+		return nil, err
+	}
+	if escopo.clienteID != "" && cliente != escopo.clienteID {
+		return nil, fmt.Errorf("%w: vendas de outro cliente", domain.ErrAcessoNegado)
+	}
+
+	vendas, err := s.vendaRepo.GetVendasPorCliente(cliente)
+	if err != nil {
+		return nil, err
+	}
+	return escopo.filtrar(vendas), nil
 }
 
-func (s *VendaService) GetVendasPorPeriodo(inicio, fim int64) ([]domain.Venda, error) {
+func (s *VendaService) GetVendasPorPeriodo(inicio, fim int64, operador domain.Operador) ([]domain.Venda, error) {
 	if inicio == 0 || fim == 0 {
 		return nil, errors.New("período é obrigatório")
 	}
@@ -137,5 +565,13 @@ func (s *VendaService) GetVendasPorPeriodo(inicio, fim int64) ([]domain.Venda, e
 		return nil, errors.New("data inicial deve ser menor que a data final")
 	}
 
-	return s.vendaRepo.GetVendasPorPeriodo(inicio, fim)
+	escopo, err := s.escopoDoOperador(operador)
+	if err != nil {
+		return nil, err
+	}
+	vendas, err := s.vendaRepo.GetVendasPorPeriodo(inicio, fim)
+	if err != nil {
+		return nil, err
+	}
+	return escopo.filtrar(vendas), nil
 }
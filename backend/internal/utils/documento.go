@@ -0,0 +1,76 @@
+package utils
+
+import "strings"
+
+// NormalizeDocument remove a pontuação de documentos como CPF, CNPJ e inscrição
+// estadual, mantendo apenas os dígitos
+func NormalizeDocument(documento string) string {
+	var b strings.Builder
+	for _, r := range documento {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// ValidateCPF confere o tamanho e os dígitos verificadores de um CPF já normalizado
+func ValidateCPF(cpf string) bool {
+	if len(cpf) != 11 || !somenteDigitos(cpf) || digitosRepetidos(cpf) {
+		return false
+	}
+
+	for _, tamanho := range []int{9, 10} {
+		soma := 0
+		for i := 0; i < tamanho; i++ {
+			soma += int(cpf[i]-'0') * (tamanho + 1 - i)
+		}
+		if digitoVerificador(soma) != int(cpf[tamanho]-'0') {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidateCNPJ confere o tamanho e os dígitos verificadores de um CNPJ já normalizado
+func ValidateCNPJ(cnpj string) bool {
+	if len(cnpj) != 14 || !somenteDigitos(cnpj) || digitosRepetidos(cnpj) {
+		return false
+	}
+
+	pesos := []int{6, 5, 4, 3, 2, 9, 8, 7, 6, 5, 4, 3, 2}
+	for _, tamanho := range []int{12, 13} {
+		soma := 0
+		for i := 0; i < tamanho; i++ {
+			soma += int(cnpj[i]-'0') * pesos[len(pesos)-tamanho+i]
+		}
+		if digitoVerificador(soma) != int(cnpj[tamanho]-'0') {
+			return false
+		}
+	}
+	return true
+}
+
+// digitoVerificador calcula o dígito pelo módulo 11, comum ao CPF e ao CNPJ
+func digitoVerificador(soma int) int {
+	resto := soma % 11
+	if resto < 2 {
+		return 0
+	}
+	return 11 - resto
+}
+
+func somenteDigitos(documento string) bool {
+	for _, r := range documento {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// digitosRepetidos identifica sequências como 111.111.111-11, que passam no cálculo
+// dos dígitos verificadores mas não são documentos válidos
+func digitosRepetidos(documento string) bool {
+	return strings.Count(documento, documento[:1]) == len(documento)
+}
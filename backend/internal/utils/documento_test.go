@@ -0,0 +1,102 @@
+package utils
+
+import "testing"
+
+func TestNormalizeDocument(t *testing.T) {
+	casos := []struct {
+		documento string
+		esperado  string
+	}{
+		{"529.982.247-25", "52998224725"},
+		{"11.222.333/0001-81", "11222333000181"},
+		{" 123 456 ", "123456"},
+		{"12.345.678-x", "12345678"},
+		{"", ""},
+	}
+
+	for _, caso := range casos {
+		if obtido := NormalizeDocument(caso.documento); obtido != caso.esperado {
+			t.Errorf("NormalizeDocument(%q) = %q, esperado %q", caso.documento, obtido, caso.esperado)
+		}
+	}
+}
+
+func TestValidateCPF(t *testing.T) {
+	casos := []struct {
+		nome   string
+		cpf    string
+		valido bool
+	}{
+		{"válido", "52998224725", true},
+		{"outro válido", "11144477735", true},
+		{"válido com dígito verificador zero", "12345678909", true},
+		{"primeiro dígito verificador errado", "52998224735", false},
+		{"segundo dígito verificador errado", "52998224726", false},
+		{"dígitos trocados", "25998224725", false},
+		{"dígitos repetidos", "11111111111", false},
+		{"zeros", "00000000000", false},
+		{"curto", "5299822472", false},
+		{"longo", "529982247250", false},
+		{"com letras", "5299822472a", false},
+		{"formatado sem normalizar", "529.982.247-25", false},
+		{"vazio", "", false},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if obtido := ValidateCPF(caso.cpf); obtido != caso.valido {
+				t.Errorf("ValidateCPF(%q) = %v, esperado %v", caso.cpf, obtido, caso.valido)
+			}
+		})
+	}
+}
+
+func TestValidateCNPJ(t *testing.T) {
+	casos := []struct {
+		nome   string
+		cnpj   string
+		valido bool
+	}{
+		{"válido", "11222333000181", true},
+		{"outro válido", "11444777000161", true},
+		{"válido com dígito verificador zero", "04252011000110", true},
+		{"primeiro dígito verificador errado", "11222333000191", false},
+		{"segundo dígito verificador errado", "11222333000182", false},
+		{"dígitos repetidos", "22222222222222", false},
+		{"zeros", "00000000000000", false},
+		{"curto", "1122233300018", false},
+		{"CPF no lugar do CNPJ", "52998224725", false},
+		{"com letras", "1122233300018a", false},
+		{"formatado sem normalizar", "11.222.333/0001-81", false},
+		{"vazio", "", false},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if obtido := ValidateCNPJ(caso.cnpj); obtido != caso.valido {
+				t.Errorf("ValidateCNPJ(%q) = %v, esperado %v", caso.cnpj, obtido, caso.valido)
+			}
+		})
+	}
+}
+
+// Os documentos digitados com pontuação são validados depois de normalizados
+func TestValidarDocumentoFormatado(t *testing.T) {
+	cpfs := []string{"529.982.247-25", "111.444.777-35", "123.456.789-09"}
+	for _, cpf := range cpfs {
+		if !ValidateCPF(NormalizeDocument(cpf)) {
+			t.Errorf("CPF %q deveria ser válido depois de normalizado", cpf)
+		}
+	}
+
+	cnpjs := []string{"11.222.333/0001-81", "11.444.777/0001-61", "04.252.011/0001-10"}
+	for _, cnpj := range cnpjs {
+		if !ValidateCNPJ(NormalizeDocument(cnpj)) {
+			t.Errorf("CNPJ %q deveria ser válido depois de normalizado", cnpj)
+		}
+	}
+
+	if ValidateCPF(NormalizeDocument("111.111.111-11")) {
+		t.Error("CPF com dígitos repetidos não deveria ser válido depois de normalizado")
+	}
+}
@@ -6,30 +6,45 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// DuracaoAccessToken é a validade dos access tokens; depois dela o cliente usa o refresh
+// token para obter outro
+const DuracaoAccessToken = 15 * time.Minute
+
 type Claims struct {
 	UserID string `json:"user_id"`
 	Role   string `json:"role"`
+	// SessaoID identifica a sessão (família de refresh tokens) que emitiu o token, para
+	// que ele deixe de valer assim que a sessão for encerrada
+	SessaoID string `json:"sid"`
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID, role, secretKey string) (string, error) {
+func GenerateToken(userID, role, sessaoID, secretKey string) (string, time.Time, error) {
+	agora := time.Now()
+	expiraEm := agora.Add(DuracaoAccessToken)
 	claims := Claims{
-		UserID: userID,
-		Role:   role,
+		UserID:   userID,
+		Role:     role,
+		SessaoID: sessaoID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        GenerateUUID(),
+			ExpiresAt: jwt.NewNumericDate(expiraEm),
+			IssuedAt:  jwt.NewNumericDate(agora),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secretKey))
+	assinado, err := token.SignedString([]byte(secretKey))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return assinado, expiraEm, nil
 }
 
 func ValidateToken(tokenString, secretKey string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(secretKey), nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
 		return nil, err
@@ -0,0 +1,243 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Lista as regras de comissão
+// @Description Retorna as regras de comissão de vendedores, ativas e inativas
+// @Tags comissoes
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.RegraComissao
+// @Router /comissoes/regras [get]
+func getRegrasComissao(service *service.ComissaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		regras, err := service.GetRegras()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, regras)
+	}
+}
+
+// @Summary Obtém uma regra de comissão por ID
+// @Description Retorna uma regra de comissão de vendedores
+// @Tags comissoes
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da regra"
+// @Success 200 {object} domain.RegraComissao
+// @Failure 404 {object} map[string]string
+// @Router /comissoes/regras/{id} [get]
+func getRegraComissao(service *service.ComissaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		regra, err := service.GetRegra(c.Param("id"))
+		if err != nil {
+			c.JSON(statusErroComissao(err), gin.H{"error": mensagemErroComissao(err, "regra não encontrada")})
+			return
+		}
+		c.JSON(http.StatusOK, regra)
+	}
+}
+
+// @Summary Cria uma regra de comissão
+// @Description Regras percentuais valem para qualquer produto; faixas por meta substituem o
+// @Description percentual geral quando as vendas líquidas do vendedor no mês atingem a meta,
+// @Description valendo o maior percentual; regras por produto e por categoria têm prioridade,
+// @Description nessa ordem, sobre o percentual geral. Apenas administradores
+// @Tags comissoes
+// @Accept json
+// @Produce json
+// @Param regra body domain.SalvarRegraComissaoDTO true "Dados da regra"
+// @Success 201 {object} domain.RegraComissao
+// @Failure 400 {object} map[string]string
+// @Router /comissoes/regras [post]
+func createRegraComissao(service *service.ComissaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var dto domain.SalvarRegraComissaoDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		regra, err := service.CreateRegra(dto)
+		if err != nil {
+			c.JSON(statusErroComissao(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, regra)
+	}
+}
+
+// @Summary Atualiza uma regra de comissão
+// @Description Substitui os dados da regra. Extratos já fechados não mudam; os meses em
+// @Description aberto passam a usar a regra alterada. Apenas administradores
+// @Tags comissoes
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da regra"
+// @Param regra body domain.SalvarRegraComissaoDTO true "Dados da regra"
+// @Success 200 {object} domain.RegraComissao
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /comissoes/regras/{id} [put]
+func updateRegraComissao(service *service.ComissaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var dto domain.SalvarRegraComissaoDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		regra, err := service.UpdateRegra(c.Param("id"), dto)
+		if err != nil {
+			c.JSON(statusErroComissao(err), gin.H{"error": mensagemErroComissao(err, "regra não encontrada")})
+			return
+		}
+		c.JSON(http.StatusOK, regra)
+	}
+}
+
+// @Summary Remove uma regra de comissão
+// @Description Remove a regra; para suspendê-la mantendo o cadastro, atualize-a como inativa.
+// @Description Apenas administradores
+// @Tags comissoes
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da regra"
+// @Success 204 "No Content"
+// @Failure 404 {object} map[string]string
+// @Router /comissoes/regras/{id} [delete]
+func deleteRegraComissao(service *service.ComissaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := service.DeleteRegra(c.Param("id")); err != nil {
+			c.JSON(statusErroComissao(err), gin.H{"error": mensagemErroComissao(err, "regra não encontrada")})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// @Summary Lista os extratos de comissão do mês
+// @Description Retorna, sem as linhas, o extrato de comissão de cada vendedor com vendas ou
+// @Description extrato fechado no mês. Vendedores recebem apenas o próprio extrato
+// @Tags comissoes
+// @Accept json
+// @Produce json
+// @Param mes query string false "Mês de referência (AAAA-MM); padrão: mês corrente"
+// @Success 200 {array} domain.ExtratoComissao
+// @Failure 400 {object} map[string]string
+// @Router /comissoes/extratos [get]
+func getExtratosComissao(service *service.ComissaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		extratos, err := service.GetExtratos(c.Query("mes"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroComissao(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, extratos)
+	}
+}
+
+// @Summary Extrato de comissão do vendedor no mês
+// @Description Retorna a comissão de cada item vendido no mês, sobre o valor líquido das
+// @Description devoluções, e os ajustes de meses já fechados. Meses em aberto são apurados
+// @Description na hora. Vendedores consultam apenas o próprio extrato
+// @Tags comissoes
+// @Accept json
+// @Produce json
+// @Param vendedorId path string true "ID do vendedor"
+// @Param mes path string true "Mês de referência (AAAA-MM)"
+// @Success 200 {object} domain.ExtratoComissao
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /comissoes/extratos/{vendedorId}/{mes} [get]
+func getExtratoComissao(service *service.ComissaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		extrato, err := service.GetExtrato(c.Param("vendedorId"), c.Param("mes"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroComissao(err), gin.H{"error": mensagemErroComissao(err, "vendedor não encontrado")})
+			return
+		}
+		c.JSON(http.StatusOK, extrato)
+	}
+}
+
+// @Summary Fecha o extrato de comissão do vendedor no mês
+// @Description Congela a comissão de um mês já encerrado. Mudanças posteriores nas vendas
+// @Description do mês entram como ajuste no próximo mês fechado. Apenas administradores
+// @Tags comissoes
+// @Accept json
+// @Produce json
+// @Param vendedorId path string true "ID do vendedor"
+// @Param mes path string true "Mês de referência (AAAA-MM)"
+// @Success 200 {object} domain.ExtratoComissao
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /comissoes/extratos/{vendedorId}/{mes}/fechar [post]
+func fecharExtratoComissao(service *service.ComissaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		extrato, err := service.Fechar(c.Param("vendedorId"), c.Param("mes"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroComissao(err), gin.H{"error": mensagemErroComissao(err, "vendedor não encontrado")})
+			return
+		}
+		c.JSON(http.StatusOK, extrato)
+	}
+}
+
+// @Summary Aprova o extrato de comissão do vendedor no mês
+// @Description Libera para pagamento um extrato fechado. Apenas administradores
+// @Tags comissoes
+// @Accept json
+// @Produce json
+// @Param vendedorId path string true "ID do vendedor"
+// @Param mes path string true "Mês de referência (AAAA-MM)"
+// @Success 200 {object} domain.ExtratoComissao
+// @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /comissoes/extratos/{vendedorId}/{mes}/aprovar [post]
+func aprovarExtratoComissao(service *service.ComissaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		extrato, err := service.Aprovar(c.Param("vendedorId"), c.Param("mes"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroComissao(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, extrato)
+	}
+}
+
+// statusErroComissao traduz os erros das comissões para o código HTTP adequado
+func statusErroComissao(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, domain.ErrUsuarioNaoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrRegraComissaoInvalida), errors.Is(err, domain.ErrMesComissaoInvalido):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrAcessoNegado):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrFechamentoComissao):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// mensagemErroComissao troca a mensagem genérica do banco para registros inexistentes
+func mensagemErroComissao(err error, naoEncontrado string) string {
+	if errors.Is(err, sql.ErrNoRows) || errors.Is(err, domain.ErrUsuarioNaoEncontrado) {
+		return naoEncontrado
+	}
+	return err.Error()
+}
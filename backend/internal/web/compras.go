@@ -0,0 +1,135 @@
+package web
+
+import (
+	"net/http"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Lista os pedidos de compra
+// @Description Retorna os pedidos de compra, do mais recente para o mais antigo
+// @Tags compras
+// @Accept json
+// @Produce json
+// @Param fornecedor_id query string false "ID do fornecedor"
+// @Param status query string false "Status do pedido (aberto, parcialmente_recebido, recebido, cancelado)"
+// @Success 200 {array} domain.PedidoCompra
+// @Router /pedidos-compra [get]
+func getPedidosCompra(service *service.CompraService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pedidos, err := service.GetAll(c.Query("fornecedor_id"), domain.StatusPedidoCompra(c.Query("status")))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, pedidos)
+	}
+}
+
+// @Summary Obtém um pedido de compra por ID
+// @Description Retorna o pedido de compra com os itens e as quantidades já recebidas
+// @Tags compras
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do pedido de compra"
+// @Success 200 {object} domain.PedidoCompra
+// @Failure 404 {object} map[string]string
+// @Router /pedidos-compra/{id} [get]
+func getPedidoCompra(service *service.CompraService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pedido, err := service.GetByID(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, pedido)
+	}
+}
+
+// @Summary Cria um pedido de compra
+// @Description Emite um pedido de compra para um fornecedor ativo com o custo previsto de cada item
+// @Tags compras
+// @Accept json
+// @Produce json
+// @Param pedido body domain.CreatePedidoCompraDTO true "Dados do pedido de compra"
+// @Success 201 {object} domain.PedidoCompra
+// @Failure 400 {object} map[string]string
+// @Router /pedidos-compra [post]
+func createPedidoCompra(service *service.CompraService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var dto domain.CreatePedidoCompraDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		pedido, err := service.Create(dto, operadorAtual(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, pedido)
+	}
+}
+
+// @Summary Recebe as mercadorias de um pedido de compra
+// @Description Dá entrada no estoque das quantidades recebidas e atualiza o custo dos produtos.
+// @Description Sem itens informados, recebe todo o saldo pendente pelo custo previsto
+// @Tags compras
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do pedido de compra"
+// @Param recebimento body domain.ReceberPedidoCompraDTO false "Itens recebidos"
+// @Success 200 {object} domain.PedidoCompra
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /pedidos-compra/{id}/receber [post]
+func receberPedidoCompra(service *service.CompraService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id inválido"})
+			return
+		}
+
+		// O corpo é opcional: sem itens todo o saldo pendente é recebido
+		var dto domain.ReceberPedidoCompraDTO
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&dto); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		pedido, err := service.Receber(id, dto.Itens, operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroTransicao(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, pedido)
+	}
+}
+
+// @Summary Cancela um pedido de compra
+// @Description Encerra um pedido pendente; o que já foi recebido permanece no estoque
+// @Tags compras
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do pedido de compra"
+// @Success 200 {object} domain.PedidoCompra
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /pedidos-compra/{id}/cancelar [post]
+func cancelarPedidoCompra(service *service.CompraService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pedido, err := service.Cancelar(c.Param("id"))
+		if err != nil {
+			c.JSON(statusErroTransicao(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, pedido)
+	}
+}
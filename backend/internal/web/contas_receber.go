@@ -0,0 +1,108 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Lista as contas a receber
+// @Description Retorna as parcelas das vendas a prazo, ordenadas pelo vencimento.
+// @Description As parcelas em aberto já vencidas informam os dias de atraso. Vendedores veem
+// @Description apenas as parcelas das vendas que registraram
+// @Tags contas-receber
+// @Accept json
+// @Produce json
+// @Param status query string false "Status da parcela (aberta, paga, cancelada)"
+// @Param vencidas query bool false "Somente parcelas em aberto vencidas"
+// @Param cliente_id query string false "ID do cliente"
+// @Success 200 {array} domain.Parcela
+// @Failure 400 {object} map[string]string
+// @Router /contas-receber [get]
+func getContasReceber(service *service.ContasReceberService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filtro := domain.FiltroContasReceber{
+			ClienteID: c.Query("cliente_id"),
+			Status:    domain.StatusParcela(c.Query("status")),
+		}
+
+		if vencidas := c.Query("vencidas"); vencidas != "" {
+			somenteVencidas, err := strconv.ParseBool(vencidas)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "parâmetro vencidas inválido"})
+				return
+			}
+			filtro.SomenteVencidas = somenteVencidas
+		}
+
+		parcelas, err := service.Listar(filtro, operadorAtual(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, parcelas)
+	}
+}
+
+// @Summary Quita uma parcela
+// @Description Registra o recebimento de uma parcela em aberto. Ao receber a última parcela
+// @Description a venda passa para paga
+// @Tags contas-receber
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da parcela"
+// @Param recebimento body domain.QuitarParcelaDTO true "Dados do recebimento"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /parcelas/{id}/quitar [post]
+func quitarParcela(service *service.ContasReceberService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id inválido"})
+			return
+		}
+
+		var dto domain.QuitarParcelaDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		parcela, vendaQuitada, err := service.Quitar(id, dto, operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroTransicao(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"parcela":       parcela,
+			"venda_quitada": vendaQuitada,
+		})
+	}
+}
+
+// @Summary Lista as parcelas de uma venda
+// @Description Retorna o plano de parcelas gerado na confirmação da venda
+// @Tags vendas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da venda"
+// @Success 200 {array} domain.Parcela
+// @Failure 404 {object} map[string]string
+// @Router /vendas/{id}/parcelas [get]
+func getParcelasVenda(service *service.ContasReceberService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parcelas, err := service.GetByVenda(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, parcelas)
+	}
+}
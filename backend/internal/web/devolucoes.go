@@ -0,0 +1,102 @@
+package web
+
+import (
+	"net/http"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Registra uma devolução parcial
+// @Description Devolve parte dos itens de uma venda, retornando-os ao estoque e registrando o reembolso.
+// @Description Os pontos de fidelidade gerados pela venda são estornados na proporção do reembolso
+// @Tags vendas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da venda"
+// @Param devolucao body domain.CreateDevolucaoDTO true "Itens e quantidades devolvidos"
+// @Success 201 {object} domain.Devolucao
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /vendas/{id}/devolucoes [post]
+func createDevolucao(service *service.DevolucaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id inválido"})
+			return
+		}
+
+		var dto domain.CreateDevolucaoDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		devolucao, err := service.Registrar(id, dto.Itens, operadorAtual(c), dto.Motivo)
+		if err != nil {
+			c.JSON(statusErroTransicao(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, devolucao)
+	}
+}
+
+// @Summary Lista as devoluções de uma venda
+// @Description Retorna as devoluções registradas para a venda com os seus itens
+// @Tags vendas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da venda"
+// @Success 200 {array} domain.Devolucao
+// @Failure 404 {object} map[string]string
+// @Router /vendas/{id}/devolucoes [get]
+func getDevolucoes(service *service.DevolucaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		devolucoes, err := service.GetByVenda(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, devolucoes)
+	}
+}
+
+// @Summary Devolve uma venda
+// @Description Registra a devolução de todos os itens ainda não devolvidos, retornando-os ao estoque.
+// @Description Os pontos gerados pela venda são estornados e os usados para pagá-la voltam ao cliente
+// @Tags vendas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da venda"
+// @Param motivo body domain.AlterarStatusVendaDTO false "Motivo da devolução"
+// @Success 201 {object} domain.Devolucao
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /vendas/{id}/devolver [post]
+func devolverVenda(service *service.DevolucaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id inválido"})
+			return
+		}
+
+		var dto domain.AlterarStatusVendaDTO
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&dto); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		devolucao, err := service.DevolverTotal(id, operadorAtual(c), dto.Motivo)
+		if err != nil {
+			c.JSON(statusErroTransicao(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, devolucao)
+	}
+}
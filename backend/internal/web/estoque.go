@@ -0,0 +1,139 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Lança uma movimentação de estoque
+// @Description Registra uma entrada, um ajuste ou uma perda no estoque do produto no local informado,
+// @Description ou no local principal. Saídas e devoluções são lançadas pelas operações de venda
+// @Tags produtos
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do produto"
+// @Param movimentacao body domain.CreateMovimentacaoDTO true "Dados da movimentação"
+// @Success 201 {object} domain.MovimentacaoEstoque
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /produtos/{id}/movimentacoes [post]
+func createMovimentacao(service *service.EstoqueService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id inválido"})
+			return
+		}
+
+		var dto domain.CreateMovimentacaoDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		movimentacao, err := service.Movimentar(id, dto, operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroTransicao(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, movimentacao)
+	}
+}
+
+// @Summary Lista as movimentações de estoque de um produto
+// @Description Retorna o histórico de movimentações e os saldos por local do produto, conferindo
+// @Description os saldos com a quantidade atual
+// @Tags produtos
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do produto"
+// @Success 200 {object} domain.ExtratoEstoque
+// @Failure 404 {object} map[string]string
+// @Router /produtos/{id}/movimentacoes [get]
+func getMovimentacoes(service *service.EstoqueService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		extrato, err := service.GetExtrato(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, extrato)
+	}
+}
+
+// @Summary Lista os saldos de um produto por local
+// @Description Retorna a quantidade do produto em cada depósito ou loja. Itens em transferência
+// @Description não aparecem em nenhum local até serem recebidos
+// @Tags produtos
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do produto"
+// @Success 200 {array} domain.SaldoEstoque
+// @Failure 404 {object} map[string]string
+// @Router /produtos/{id}/saldos [get]
+func getSaldosProduto(service *service.EstoqueService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		saldos, err := service.GetSaldos(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, saldos)
+	}
+}
+
+// @Summary Lista as divergências de estoque
+// @Description Lista os produtos cuja quantidade não bate com a soma das movimentações ou com a
+// @Description soma dos saldos por local. As diferenças não são corrigidas automaticamente e devem
+// @Description ser acertadas com ajustes
+// @Tags produtos
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.DivergenciaEstoque
+// @Router /estoque/divergencias [get]
+func getDivergenciasEstoque(service *service.EstoqueService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		divergencias, err := service.GetDivergencias()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, divergencias)
+	}
+}
+
+// @Summary Sugere a reposição de estoque
+// @Description Lista os produtos que atingiram o ponto de reposição, calculado com o estoque mínimo,
+// @Description o prazo de entrega e a média de vendas dos últimos dias, com a quantidade sugerida de compra.
+// @Description Os pedidos de compra pendentes e as transferências em trânsito contam como estoque
+// @Tags produtos
+// @Accept json
+// @Produce json
+// @Param dias query int false "Dias de vendas considerados (padrão 30)"
+// @Success 200 {array} domain.SugestaoReposicao
+// @Failure 400 {object} map[string]string
+// @Router /estoque/reposicao [get]
+func getSugestaoReposicao(service *service.EstoqueService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Sem o parâmetro, o service usa o período padrão
+		var dias int
+		if valor := c.Query("dias"); valor != "" {
+			var err error
+			if dias, err = strconv.Atoi(valor); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "parâmetro dias inválido"})
+				return
+			}
+		}
+
+		sugestoes, err := service.SugerirReposicao(dias)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, sugestoes)
+	}
+}
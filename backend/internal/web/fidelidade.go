@@ -0,0 +1,187 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Extrato de pontos de fidelidade do cliente
+// @Description Retorna o saldo de pontos válidos, o valor dele em reais, os pontos que vencem
+// @Description nos próximos 30 dias e o histórico de lançamentos do cliente
+// @Tags fidelidade
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do cliente"
+// @Success 200 {object} domain.ExtratoPontos
+// @Failure 404 {object} map[string]string
+// @Router /clientes/{id}/fidelidade [get]
+func getFidelidadeCliente(service *service.FidelidadeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		extrato, err := service.GetExtrato(c.Param("id"))
+		if err != nil {
+			c.JSON(statusErroFidelidade(err), gin.H{"error": mensagemErroFidelidade(err, "cliente não encontrado")})
+			return
+		}
+		c.JSON(http.StatusOK, extrato)
+	}
+}
+
+// @Summary Extrato de pontos de fidelidade do cliente autenticado
+// @Description Retorna o extrato de pontos do cliente vinculado ao usuário autenticado, para
+// @Description quem consulta os próprios pontos sem acesso ao cadastro de clientes
+// @Tags fidelidade
+// @Accept json
+// @Produce json
+// @Success 200 {object} domain.ExtratoPontos
+// @Failure 404 {object} map[string]string
+// @Router /fidelidade/extrato [get]
+func getFidelidadePropria(service *service.FidelidadeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		extrato, err := service.GetExtratoProprio(operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroFidelidade(err), gin.H{"error": mensagemErroFidelidade(err, "nenhum cliente vinculado ao usuário")})
+			return
+		}
+		c.JSON(http.StatusOK, extrato)
+	}
+}
+
+// @Summary Lista as regras de pontuação
+// @Description Retorna as regras do programa de fidelidade, ativas e inativas
+// @Tags fidelidade
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.RegraFidelidade
+// @Router /fidelidade/regras [get]
+func getRegrasFidelidade(service *service.FidelidadeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		regras, err := service.GetRegras()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, regras)
+	}
+}
+
+// @Summary Obtém uma regra de pontuação por ID
+// @Description Retorna uma regra do programa de fidelidade
+// @Tags fidelidade
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da regra"
+// @Success 200 {object} domain.RegraFidelidade
+// @Failure 404 {object} map[string]string
+// @Router /fidelidade/regras/{id} [get]
+func getRegraFidelidade(service *service.FidelidadeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		regra, err := service.GetRegra(c.Param("id"))
+		if err != nil {
+			c.JSON(statusErroFidelidade(err), gin.H{"error": mensagemErroFidelidade(err, "regra não encontrada")})
+			return
+		}
+		c.JSON(http.StatusOK, regra)
+	}
+}
+
+// @Summary Cria uma regra de pontuação
+// @Description Regras por valor concedem pontos por real gasto em qualquer produto; regras por
+// @Description categoria substituem a taxa por valor nos produtos da categoria; multiplicadores
+// @Description aumentam os pontos no período de vigência. Apenas administradores
+// @Tags fidelidade
+// @Accept json
+// @Produce json
+// @Param regra body domain.SalvarRegraFidelidadeDTO true "Dados da regra"
+// @Success 201 {object} domain.RegraFidelidade
+// @Failure 400 {object} map[string]string
+// @Router /fidelidade/regras [post]
+func createRegraFidelidade(service *service.FidelidadeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var dto domain.SalvarRegraFidelidadeDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		regra, err := service.CreateRegra(dto)
+		if err != nil {
+			c.JSON(statusErroFidelidade(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, regra)
+	}
+}
+
+// @Summary Atualiza uma regra de pontuação
+// @Description Substitui os dados da regra. Os pontos de vendas já confirmadas não mudam.
+// @Description Apenas administradores
+// @Tags fidelidade
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da regra"
+// @Param regra body domain.SalvarRegraFidelidadeDTO true "Dados da regra"
+// @Success 200 {object} domain.RegraFidelidade
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /fidelidade/regras/{id} [put]
+func updateRegraFidelidade(service *service.FidelidadeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var dto domain.SalvarRegraFidelidadeDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		regra, err := service.UpdateRegra(c.Param("id"), dto)
+		if err != nil {
+			c.JSON(statusErroFidelidade(err), gin.H{"error": mensagemErroFidelidade(err, "regra não encontrada")})
+			return
+		}
+		c.JSON(http.StatusOK, regra)
+	}
+}
+
+// @Summary Remove uma regra de pontuação
+// @Description Remove a regra; para suspendê-la mantendo o cadastro, atualize-a como inativa.
+// @Description Apenas administradores
+// @Tags fidelidade
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da regra"
+// @Success 204 "No Content"
+// @Failure 404 {object} map[string]string
+// @Router /fidelidade/regras/{id} [delete]
+func deleteRegraFidelidade(service *service.FidelidadeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := service.DeleteRegra(c.Param("id")); err != nil {
+			c.JSON(statusErroFidelidade(err), gin.H{"error": mensagemErroFidelidade(err, "regra não encontrada")})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// statusErroFidelidade traduz os erros do programa de fidelidade para o código HTTP adequado
+func statusErroFidelidade(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrRegraFidelidadeInvalida):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// mensagemErroFidelidade troca a mensagem genérica do banco para registros inexistentes
+func mensagemErroFidelidade(err error, naoEncontrado string) string {
+	if errors.Is(err, sql.ErrNoRows) {
+		return naoEncontrado
+	}
+	return err.Error()
+}
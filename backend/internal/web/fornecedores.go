@@ -0,0 +1,147 @@
+package web
+
+import (
+	"net/http"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Lista todos os fornecedores
+// @Description Retorna uma lista de todos os fornecedores cadastrados, ativos e inativos
+// @Tags fornecedores
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.Fornecedor
+// @Router /fornecedores [get]
+func getFornecedores(service *service.FornecedorService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fornecedores, err := service.GetAll()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, fornecedores)
+	}
+}
+
+// @Summary Obtém um fornecedor por ID
+// @Description Retorna um fornecedor específico pelo seu ID
+// @Tags fornecedores
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do fornecedor"
+// @Success 200 {object} domain.Fornecedor
+// @Failure 404 {object} map[string]string
+// @Router /fornecedores/{id} [get]
+func getFornecedor(service *service.FornecedorService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		fornecedor, err := service.GetByID(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, fornecedor)
+	}
+}
+
+// @Summary Cria um novo fornecedor
+// @Description Cria um novo fornecedor com os dados fornecidos
+// @Tags fornecedores
+// @Accept json
+// @Produce json
+// @Param fornecedor body domain.CreateFornecedorDTO true "Dados do fornecedor"
+// @Success 201 {object} domain.Fornecedor
+// @Failure 400 {object} map[string]string
+// @Router /fornecedores [post]
+func createFornecedor(service *service.FornecedorService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var dto domain.CreateFornecedorDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		fornecedor := &domain.Fornecedor{
+			Nome:     dto.Nome,
+			CNPJ:     dto.CNPJ,
+			Email:    dto.Email,
+			Telefone: dto.Telefone,
+			Contato:  dto.Contato,
+		}
+		if err := service.Create(fornecedor); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, fornecedor)
+	}
+}
+
+// @Summary Atualiza um fornecedor
+// @Description Atualiza os dados de um fornecedor existente. Sem o campo ativo a situação do
+// @Description fornecedor é mantida; com ativo true, ele é reativado
+// @Tags fornecedores
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do fornecedor"
+// @Param fornecedor body domain.UpdateFornecedorDTO true "Dados do fornecedor"
+// @Success 200 {object} domain.Fornecedor
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /fornecedores/{id} [put]
+func updateFornecedor(service *service.FornecedorService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id inválido"})
+			return
+		}
+
+		var dto domain.UpdateFornecedorDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		fornecedor := &domain.Fornecedor{
+			ID:       id,
+			Nome:     dto.Nome,
+			CNPJ:     dto.CNPJ,
+			Email:    dto.Email,
+			Telefone: dto.Telefone,
+			Contato:  dto.Contato,
+		}
+		if err := service.Update(fornecedor, dto.Ativo); err != nil {
+			c.JSON(statusErroTransicao(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		atualizado, err := service.GetByID(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, atualizado)
+	}
+}
+
+// @Summary Inativa um fornecedor
+// @Description Inativa o fornecedor, que deixa de aceitar pedidos de compra. O cadastro é
+// @Description mantido para os pedidos já emitidos
+// @Tags fornecedores
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do fornecedor"
+// @Success 204 "No Content"
+// @Failure 404 {object} map[string]string
+// @Router /fornecedores/{id} [delete]
+func deleteFornecedor(service *service.FornecedorService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := service.Delete(c.Param("id")); err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,134 @@
+package web
+
+import (
+	"net/http"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Lista os locais de estoque
+// @Description Retorna os depósitos e lojas cadastrados, ativos e inativos
+// @Tags locais
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.LocalEstoque
+// @Router /locais [get]
+func getLocais(service *service.LocalService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		locais, err := service.GetAll()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, locais)
+	}
+}
+
+// @Summary Obtém um local de estoque por ID
+// @Description Retorna um depósito ou loja pelo seu ID
+// @Tags locais
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do local"
+// @Success 200 {object} domain.LocalEstoque
+// @Failure 404 {object} map[string]string
+// @Router /locais/{id} [get]
+func getLocal(service *service.LocalService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		local, err := service.GetByID(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, local)
+	}
+}
+
+// @Summary Cria um local de estoque
+// @Description Cadastra um depósito ou uma loja
+// @Tags locais
+// @Accept json
+// @Produce json
+// @Param local body domain.CreateLocalEstoqueDTO true "Dados do local"
+// @Success 201 {object} domain.LocalEstoque
+// @Failure 400 {object} map[string]string
+// @Router /locais [post]
+func createLocal(service *service.LocalService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var dto domain.CreateLocalEstoqueDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		local, err := service.Create(dto)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, local)
+	}
+}
+
+// @Summary Atualiza um local de estoque
+// @Description Atualiza o nome, o tipo ou a situação do local. Locais inativos não recebem
+// @Description vendas, compras nem transferências; o local principal não pode ser inativado
+// @Tags locais
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do local"
+// @Param local body domain.LocalEstoque true "Dados do local"
+// @Success 200 {object} domain.LocalEstoque
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /locais/{id} [put]
+func updateLocal(service *service.LocalService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id inválido"})
+			return
+		}
+
+		var local domain.LocalEstoque
+		if err := c.ShouldBindJSON(&local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		local.ID = id
+		if err := service.Update(&local); err != nil {
+			c.JSON(statusErroTransicao(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		atualizado, err := service.GetByID(id)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, atualizado)
+	}
+}
+
+// @Summary Lista os saldos de um local de estoque
+// @Description Retorna os produtos com saldo no depósito ou loja
+// @Tags locais
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do local"
+// @Success 200 {array} domain.SaldoEstoque
+// @Failure 404 {object} map[string]string
+// @Router /locais/{id}/saldos [get]
+func getSaldosLocal(service *service.LocalService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		saldos, err := service.GetSaldos(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, saldos)
+	}
+}
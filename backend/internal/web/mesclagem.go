@@ -0,0 +1,148 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Lista prováveis clientes duplicados
+// @Description Compara os clientes por CPF, CNPJ, email, telefone e semelhança do nome e retorna os
+// @Description pares com pontuação a partir do mínimo informado, dos mais prováveis para os menos
+// @Tags clientes
+// @Produce json
+// @Param minimo query int false "Pontuação mínima, de 1 a 100 (padrão 50)"
+// @Success 200 {array} domain.CandidatoDuplicado
+// @Failure 400 {object} map[string]string
+// @Router /clientes/duplicados [get]
+func getClientesDuplicados(service *service.MesclagemService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		minimo, ok := pontuacaoMinima(c)
+		if !ok {
+			return
+		}
+		candidatos, err := service.BuscarDuplicados(minimo)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, candidatos)
+	}
+}
+
+// @Summary Lista prováveis duplicados de um cliente
+// @Description Retorna os clientes que provavelmente são a mesma pessoa que o cliente informado
+// @Tags clientes
+// @Produce json
+// @Param id path string true "ID do cliente"
+// @Param minimo query int false "Pontuação mínima, de 1 a 100 (padrão 50)"
+// @Success 200 {array} domain.CandidatoDuplicado
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /clientes/{id}/duplicados [get]
+func getDuplicadosCliente(service *service.MesclagemService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		minimo, ok := pontuacaoMinima(c)
+		if !ok {
+			return
+		}
+		candidatos, err := service.DuplicadosDoCliente(c.Param("id"), minimo)
+		if err != nil {
+			c.JSON(statusErroMesclagem(err), gin.H{"error": mensagemErroMesclagem(err)})
+			return
+		}
+		c.JSON(http.StatusOK, candidatos)
+	}
+}
+
+// @Summary Mescla um cliente duplicado
+// @Description Incorpora o duplicado ao cliente da rota em uma única transação: vendas, parcelas,
+// @Description endereços e pontos de fidelidade passam para o cliente, que herda os dados que não tiver,
+// @Description e o duplicado é removido. A mesclagem fica registrada com o cadastro original do
+// @Description duplicado. Apenas administradores
+// @Tags clientes
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do cliente mantido"
+// @Param mesclagem body domain.MesclarClientesDTO true "Cliente duplicado"
+// @Success 200 {object} domain.Cliente
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /clientes/{id}/mesclar [post]
+func mesclarClientes(service *service.MesclagemService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var dto domain.MesclarClientesDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		cliente, err := service.Mesclar(c.Param("id"), dto.DuplicadoID, operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroMesclagem(err), gin.H{"error": mensagemErroMesclagem(err)})
+			return
+		}
+		c.JSON(http.StatusOK, cliente)
+	}
+}
+
+// @Summary Lista as mesclagens do cliente
+// @Description Retorna os duplicados já incorporados ao cliente, com o cadastro original de cada um
+// @Tags clientes
+// @Produce json
+// @Param id path string true "ID do cliente"
+// @Success 200 {array} domain.MesclagemCliente
+// @Failure 404 {object} map[string]string
+// @Router /clientes/{id}/mesclagens [get]
+func getMesclagensCliente(service *service.MesclagemService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		mesclagens, err := service.GetMesclagens(c.Param("id"))
+		if err != nil {
+			c.JSON(statusErroMesclagem(err), gin.H{"error": mensagemErroMesclagem(err)})
+			return
+		}
+		c.JSON(http.StatusOK, mesclagens)
+	}
+}
+
+// pontuacaoMinima lê o parâmetro minimo, respondendo 400 quando ele for inválido
+func pontuacaoMinima(c *gin.Context) (int, bool) {
+	minimo := domain.PontuacaoMinimaDuplicidade
+	if valor := c.Query("minimo"); valor != "" {
+		var err error
+		minimo, err = strconv.Atoi(valor)
+		if err != nil || minimo < 1 || minimo > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "minimo deve ser um número de 1 a 100"})
+			return 0, false
+		}
+	}
+	return minimo, true
+}
+
+// statusErroMesclagem traduz os erros da mesclagem de clientes para o código HTTP adequado
+func statusErroMesclagem(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrMesclagemInvalida):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, domain.ErrClienteAnonimizado):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func mensagemErroMesclagem(err error) string {
+	if errors.Is(err, sql.ErrNoRows) {
+		return "cliente não encontrado"
+	}
+	return err.Error()
+}
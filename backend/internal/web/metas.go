@@ -0,0 +1,232 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Lista as metas de vendas
+// @Description Retorna as metas de vendedores, lojas e categorias que atendem aos filtros.
+// @Description Vendedores não veem as metas individuais dos colegas
+// @Tags metas
+// @Accept json
+// @Produce json
+// @Param tipo query string false "vendedor, loja ou categoria"
+// @Param vendedor_id query string false "ID do vendedor"
+// @Param local_id query string false "ID do local (loja)"
+// @Param categoria query string false "Categoria de produtos"
+// @Param data query string false "Somente metas em vigor no dia (AAAA-MM-DD)"
+// @Success 200 {array} domain.Meta
+// @Failure 400 {object} map[string]string
+// @Router /metas [get]
+func getMetas(service *service.MetaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filtro, ok := filtroMetas(c)
+		if !ok {
+			return
+		}
+
+		metas, err := service.GetAll(filtro, operadorAtual(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, metas)
+	}
+}
+
+// @Summary Progresso das metas de vendas
+// @Description Compara as vendas líquidas de devoluções com cada meta em vigor no dia (por
+// @Description padrão, hoje), com o percentual atingido e a projeção para o fim do período
+// @Description pelo ritmo diário atual. Usado no dashboard
+// @Tags metas
+// @Accept json
+// @Produce json
+// @Param tipo query string false "vendedor, loja ou categoria"
+// @Param vendedor_id query string false "ID do vendedor"
+// @Param local_id query string false "ID do local (loja)"
+// @Param categoria query string false "Categoria de produtos"
+// @Param data query string false "Dia de referência (AAAA-MM-DD); padrão: hoje"
+// @Success 200 {array} domain.ProgressoMeta
+// @Failure 400 {object} map[string]string
+// @Router /metas/progresso [get]
+func getProgressoMetas(service *service.MetaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filtro, ok := filtroMetas(c)
+		if !ok {
+			return
+		}
+
+		progressos, err := service.GetProgressos(filtro, operadorAtual(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, progressos)
+	}
+}
+
+// @Summary Obtém uma meta de vendas por ID
+// @Description Retorna uma meta de vendas
+// @Tags metas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da meta"
+// @Success 200 {object} domain.Meta
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /metas/{id} [get]
+func getMeta(service *service.MetaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		meta, err := service.GetByID(c.Param("id"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroMeta(err), gin.H{"error": mensagemErroMeta(err)})
+			return
+		}
+		c.JSON(http.StatusOK, meta)
+	}
+}
+
+// @Summary Progresso de uma meta de vendas
+// @Description Compara as vendas líquidas de devoluções no período com o valor da meta e
+// @Description projeta o resultado do fim do período pelo ritmo diário atual
+// @Tags metas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da meta"
+// @Success 200 {object} domain.ProgressoMeta
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /metas/{id}/progresso [get]
+func getProgressoMeta(service *service.MetaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		progresso, err := service.GetProgresso(c.Param("id"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroMeta(err), gin.H{"error": mensagemErroMeta(err)})
+			return
+		}
+		c.JSON(http.StatusOK, progresso)
+	}
+}
+
+// @Summary Cria uma meta de vendas
+// @Description Define o valor esperado de vendas de um vendedor, loja ou categoria em um mês
+// @Description ou período. Não pode haver duas metas para o mesmo alvo com períodos em comum.
+// @Description Apenas administradores
+// @Tags metas
+// @Accept json
+// @Produce json
+// @Param meta body domain.SalvarMetaDTO true "Dados da meta"
+// @Success 201 {object} domain.Meta
+// @Failure 400 {object} map[string]string
+// @Router /metas [post]
+func createMeta(service *service.MetaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var dto domain.SalvarMetaDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		meta, err := service.Create(dto)
+		if err != nil {
+			c.JSON(statusErroMeta(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, meta)
+	}
+}
+
+// @Summary Atualiza uma meta de vendas
+// @Description Substitui os dados da meta. Apenas administradores
+// @Tags metas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da meta"
+// @Param meta body domain.SalvarMetaDTO true "Dados da meta"
+// @Success 200 {object} domain.Meta
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /metas/{id} [put]
+func updateMeta(service *service.MetaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var dto domain.SalvarMetaDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		meta, err := service.Update(c.Param("id"), dto)
+		if err != nil {
+			c.JSON(statusErroMeta(err), gin.H{"error": mensagemErroMeta(err)})
+			return
+		}
+		c.JSON(http.StatusOK, meta)
+	}
+}
+
+// @Summary Remove uma meta de vendas
+// @Description Remove a meta. Apenas administradores
+// @Tags metas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da meta"
+// @Success 204 "No Content"
+// @Failure 404 {object} map[string]string
+// @Router /metas/{id} [delete]
+func deleteMeta(service *service.MetaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := service.Delete(c.Param("id")); err != nil {
+			c.JSON(statusErroMeta(err), gin.H{"error": mensagemErroMeta(err)})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// filtroMetas lê os filtros da listagem de metas; em caso de data inválida, responde 400
+func filtroMetas(c *gin.Context) (domain.FiltroMetas, bool) {
+	filtro := domain.FiltroMetas{
+		Tipo:       domain.TipoMeta(c.Query("tipo")),
+		VendedorID: c.Query("vendedor_id"),
+		LocalID:    c.Query("local_id"),
+		Categoria:  c.Query("categoria"),
+	}
+	if valor := c.Query("data"); valor != "" {
+		data, err := time.ParseInLocation("2006-01-02", valor, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "parâmetro data inválido, use o formato AAAA-MM-DD"})
+			return filtro, false
+		}
+		filtro.Data = &data
+	}
+	return filtro, true
+}
+
+// statusErroMeta traduz os erros das metas para o código HTTP adequado
+func statusErroMeta(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrMetaInvalida):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrAcessoNegado):
+		return http.StatusForbidden
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// mensagemErroMeta troca a mensagem genérica do banco para metas inexistentes
+func mensagemErroMeta(err error) string {
+	if errors.Is(err, sql.ErrNoRows) {
+		return "meta não encontrada"
+	}
+	return err.Error()
+}
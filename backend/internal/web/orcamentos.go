@@ -0,0 +1,255 @@
+package web
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"time"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Lista os orçamentos
+// @Description Retorna os orçamentos do vendedor autenticado ou, para administradores, de
+// @Description todos os vendedores. Orçamentos abertos fora da validade aparecem como vencidos
+// @Tags orcamentos
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.Orcamento
+// @Router /orcamentos [get]
+func getOrcamentos(service *service.OrcamentoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orcamentos, err := service.GetAll(operadorAtual(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, orcamentos)
+	}
+}
+
+// @Summary Obtém um orçamento por ID
+// @Description Retorna um orçamento com os itens. Vendedores só consultam os próprios orçamentos
+// @Tags orcamentos
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do orçamento"
+// @Success 200 {object} domain.Orcamento
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /orcamentos/{id} [get]
+func getOrcamento(service *service.OrcamentoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orcamento, err := service.GetByID(c.Param("id"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroOrcamento(err, http.StatusInternalServerError), gin.H{"error": mensagemErroOrcamento(err)})
+			return
+		}
+		c.JSON(http.StatusOK, orcamento)
+	}
+}
+
+// @Summary Resumo imprimível do orçamento
+// @Description Retorna uma página HTML com o cliente, os itens, os descontos, o total e a
+// @Description validade do orçamento, pronta para impressão ou envio ao cliente
+// @Tags orcamentos
+// @Produce html
+// @Param id path string true "ID do orçamento"
+// @Success 200 {string} string "Página HTML do orçamento"
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /orcamentos/{id}/resumo [get]
+func getResumoOrcamento(service *service.OrcamentoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orcamento, err := service.GetByID(c.Param("id"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroOrcamento(err, http.StatusInternalServerError), gin.H{"error": mensagemErroOrcamento(err)})
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := resumoOrcamento.Execute(&buf, orcamento); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", buf.Bytes())
+	}
+}
+
+// @Summary Cria um orçamento
+// @Description Registra uma proposta de preços para o cliente, com os preços atuais dos
+// @Description produtos e os descontos permitidos para o perfil do usuário. O estoque não é
+// @Description conferido nem reservado. Sem valido_ate, o orçamento vale por 15 dias
+// @Tags orcamentos
+// @Accept json
+// @Produce json
+// @Param orcamento body domain.CreateOrcamentoDTO true "Dados do orçamento"
+// @Success 201 {object} domain.Orcamento
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orcamentos [post]
+func createOrcamento(service *service.OrcamentoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var dto domain.CreateOrcamentoDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		orcamento, err := service.Create(dto, operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroOrcamento(err, http.StatusBadRequest), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, orcamento)
+	}
+}
+
+// @Summary Converte um orçamento em venda
+// @Description Gera a venda do orçamento aberto e dentro da validade como em POST /vendas:
+// @Description os preços atuais, os descontos, o estoque e o limite de crédito são conferidos
+// @Description de novo. A venda fica vinculada ao orçamento, e diferenca_total compara o
+// @Description total da venda com o orçado. Cada orçamento gera no máximo uma venda
+// @Tags orcamentos
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do orçamento"
+// @Param conversao body domain.ConverterOrcamentoDTO false "Dados complementares da venda"
+// @Param X-Autorizacao-Credito header string false "Token de um administrador que libera o limite de crédito"
+// @Success 201 {object} domain.ConversaoOrcamento
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
+// @Router /orcamentos/{id}/converter [post]
+func converterOrcamento(service *service.OrcamentoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var dto domain.ConverterOrcamentoDTO
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&dto); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		conversao, err := service.Converter(c.Param("id"), dto, operadorAtual(c))
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": mensagemErroOrcamento(err)})
+				return
+			}
+			c.JSON(statusErroOrcamento(err, statusErroVenda(err)), respostaErroVenda(err))
+			return
+		}
+		c.JSON(http.StatusCreated, conversao)
+	}
+}
+
+// @Summary Cancela um orçamento
+// @Description Descarta um orçamento aberto, mesmo que vencido
+// @Tags orcamentos
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do orçamento"
+// @Success 200 {object} domain.Orcamento
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orcamentos/{id}/cancelar [post]
+func cancelarOrcamento(service *service.OrcamentoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		orcamento, err := service.Cancelar(c.Param("id"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroOrcamento(err, http.StatusInternalServerError), gin.H{"error": mensagemErroOrcamento(err)})
+			return
+		}
+		c.JSON(http.StatusOK, orcamento)
+	}
+}
+
+// statusErroOrcamento traduz os erros dos orçamentos para o código HTTP adequado; os
+// demais erros recebem o código padrão informado
+func statusErroOrcamento(err error, padrao int) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrAcessoNegado):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrOrcamentoIndisponivel), errors.Is(err, domain.ErrClienteAnonimizado):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrVendedorInvalido):
+		return http.StatusUnprocessableEntity
+	default:
+		return padrao
+	}
+}
+
+// mensagemErroOrcamento troca a mensagem genérica do banco para orçamentos inexistentes
+func mensagemErroOrcamento(err error) string {
+	if errors.Is(err, sql.ErrNoRows) {
+		return "orçamento não encontrado"
+	}
+	return err.Error()
+}
+
+// resumoOrcamento é a página imprimível do orçamento
+var resumoOrcamento = template.Must(template.New("orcamento").Funcs(template.FuncMap{
+	"moeda": func(valor float64) string { return fmt.Sprintf("R$ %.2f", valor) },
+	"data":  func(data time.Time) string { return data.Format("02/01/2006") },
+}).Parse(`<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+<meta charset="utf-8">
+<title>Orçamento {{.ID}}</title>
+<style>
+	body { font-family: sans-serif; margin: 2em; color: #222; }
+	table { width: 100%; border-collapse: collapse; margin-top: 1em; }
+	th, td { padding: 0.4em; border-bottom: 1px solid #ccc; text-align: left; }
+	td.valor, th.valor { text-align: right; }
+	tfoot td { font-weight: bold; border-bottom: none; }
+</style>
+</head>
+<body>
+<h1>Orçamento</h1>
+<p>
+	<strong>Número:</strong> {{.ID}}<br>
+	<strong>Cliente:</strong> {{.ClienteNome}}<br>
+	<strong>Vendedor:</strong> {{.VendedorNome}}<br>
+	<strong>Emitido em:</strong> {{data .DataCriacao}}<br>
+	<strong>Válido até:</strong> {{data .ValidoAte}}<br>
+	<strong>Situação:</strong> {{.Status}}
+</p>
+<table>
+	<thead>
+		<tr><th>Produto</th><th class="valor">Qtd.</th><th class="valor">Preço unitário</th><th class="valor">Subtotal</th><th class="valor">Desconto</th><th class="valor">Total</th></tr>
+	</thead>
+	<tbody>
+	{{range .Items}}
+		<tr>
+			<td>{{if .Produto}}{{.Produto.Nome}}{{else}}{{.ProdutoID}}{{end}}</td>
+			<td class="valor">{{.Quantidade}}</td>
+			<td class="valor">{{moeda .PrecoUnitario}}</td>
+			<td class="valor">{{moeda .Subtotal}}</td>
+			<td class="valor">{{moeda .ValorDesconto}}</td>
+			<td class="valor">{{moeda .Total}}</td>
+		</tr>
+	{{end}}
+	</tbody>
+	<tfoot>
+		<tr><td colspan="5">Subtotal</td><td class="valor">{{moeda .Subtotal}}</td></tr>
+		<tr><td colspan="5">Descontos</td><td class="valor">{{moeda .ValorDesconto}}</td></tr>
+		<tr><td colspan="5">Total</td><td class="valor">{{moeda .ValorTotal}}</td></tr>
+	</tfoot>
+</table>
+{{if .Observacoes}}<p><strong>Observações:</strong> {{.Observacoes}}</p>{{end}}
+<p><small>Preços sujeitos a confirmação da disponibilidade em estoque no fechamento da venda.</small></p>
+</body>
+</html>
+`))
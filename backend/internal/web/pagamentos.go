@@ -0,0 +1,75 @@
+package web
+
+import (
+	"net/http"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Registra pagamentos de uma venda
+// @Description Registra um ou mais pagamentos (dinheiro, cartão, PIX, boleto ou pontos de fidelidade)
+// @Description para a venda. Pagamentos em dinheiro calculam o troco e pagamentos com pontos debitam
+// @Description do cliente os pontos equivalentes ao valor; quando a soma quita a venda ela passa para paga
+// @Tags vendas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da venda"
+// @Param pagamentos body domain.RegistrarPagamentosDTO true "Pagamentos recebidos"
+// @Success 201 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /vendas/{id}/pagamentos [post]
+func createPagamentos(service *service.PagamentoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id inválido"})
+			return
+		}
+
+		var dto domain.RegistrarPagamentosDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		saldo, pagamentos, err := service.Registrar(id, dto.Pagamentos, operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroTransicao(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		var troco float64
+		for _, pagamento := range pagamentos {
+			troco += pagamento.Troco
+		}
+
+		c.JSON(http.StatusCreated, gin.H{
+			"troco": troco,
+			"saldo": saldo,
+		})
+	}
+}
+
+// @Summary Lista os pagamentos de uma venda
+// @Description Retorna os pagamentos registrados e o saldo restante da venda
+// @Tags vendas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da venda"
+// @Success 200 {object} domain.SaldoVenda
+// @Failure 404 {object} map[string]string
+// @Router /vendas/{id}/pagamentos [get]
+func getPagamentos(service *service.PagamentoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		saldo, err := service.GetSaldo(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, saldo)
+	}
+}
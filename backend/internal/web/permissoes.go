@@ -0,0 +1,56 @@
+package web
+
+import (
+	"errors"
+	"net/http"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Lista as permissões de cada papel
+// @Description Retorna a matriz de permissões em uso, consultada por todas as rotas. Apenas administradores
+// @Tags permissoes
+// @Produce json
+// @Success 200 {object} map[string][]string
+// @Router /permissoes [get]
+func getPermissoes(service *service.PermissaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, service.GetMatriz())
+	}
+}
+
+// @Summary Altera as permissões de um papel
+// @Description Substitui todas as permissões do papel. A alteração vale a partir da próxima
+// @Description requisição, sem reiniciar o servidor. O administrador não pode perder a permissão
+// @Description permissoes:gerenciar. Apenas administradores
+// @Tags permissoes
+// @Accept json
+// @Produce json
+// @Param role path string true "Papel (admin, vendedor ou cliente)"
+// @Param permissoes body domain.AlterarPermissoesDTO true "Permissões do papel"
+// @Success 200 {object} map[string][]string
+// @Failure 400 {object} map[string]string
+// @Failure 422 {object} map[string]string
+// @Router /permissoes/{role} [put]
+func alterarPermissoes(service *service.PermissaoService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var dto domain.AlterarPermissoesDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		matriz, err := service.AlterarRole(domain.Role(c.Param("role")), dto.Permissoes)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, domain.ErrPermissoesInvalidas) {
+				status = http.StatusUnprocessableEntity
+			}
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, matriz)
+	}
+}
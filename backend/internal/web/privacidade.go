@@ -0,0 +1,216 @@
+package web
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Exporta os dados pessoais do cliente
+// @Description Reúne tudo o que está ligado ao cliente (cadastro, usuário, endereços, vendas com itens,
+// @Description pagamentos, parcelas e devoluções, pontos de fidelidade, orçamentos, duplicados incorporados e
+// @Description auditoria) para atender a um pedido de acesso do titular. Com formato=zip, devolve um arquivo
+// @Description por seção. Apenas administradores
+// @Tags privacidade
+// @Produce json
+// @Produce application/zip
+// @Param id path string true "ID do cliente"
+// @Param formato query string false "json (padrão) ou zip"
+// @Success 200 {object} domain.DadosTitular
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /clientes/{id}/dados-pessoais [get]
+func exportarDadosCliente(service *service.PrivacidadeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !formatoExportacaoValido(c) {
+			return
+		}
+		dados, err := service.ExportarCliente(c.Param("id"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroPrivacidade(err), gin.H{"error": mensagemErroPrivacidade(err)})
+			return
+		}
+		responderDadosTitular(c, dados, "cliente-"+c.Param("id"))
+	}
+}
+
+// @Summary Exporta os dados pessoais do usuário
+// @Description Reúne os dados do usuário e, quando ele tem cadastro de cliente, todos os dados desse
+// @Description cadastro. Com formato=zip, devolve um arquivo por seção. Apenas administradores
+// @Tags privacidade
+// @Produce json
+// @Produce application/zip
+// @Param id path string true "ID do usuário"
+// @Param formato query string false "json (padrão) ou zip"
+// @Success 200 {object} domain.DadosTitular
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /usuarios/{id}/dados-pessoais [get]
+func exportarDadosUsuario(service *service.PrivacidadeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !formatoExportacaoValido(c) {
+			return
+		}
+		dados, err := service.ExportarUsuario(c.Param("id"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroPrivacidade(err), gin.H{"error": mensagemErroPrivacidade(err)})
+			return
+		}
+		responderDadosTitular(c, dados, "usuario-"+c.Param("id"))
+	}
+}
+
+// @Summary Anonimiza os dados pessoais do cliente
+// @Description Substitui nome, email, telefone, endereço e CPF do cliente, remove os seus endereços e
+// @Description anonimiza o usuário vinculado. As vendas são mantidas para os registros fiscais. Clientes
+// @Description com valores em aberto não podem ser anonimizados. Apenas administradores
+// @Tags privacidade
+// @Produce json
+// @Param id path string true "ID do cliente"
+// @Success 200 {object} domain.Cliente
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /clientes/{id}/anonimizar [post]
+func anonimizarCliente(service *service.PrivacidadeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cliente, err := service.AnonimizarCliente(c.Param("id"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroPrivacidade(err), gin.H{"error": mensagemErroPrivacidade(err)})
+			return
+		}
+		c.JSON(http.StatusOK, cliente)
+	}
+}
+
+// @Summary Anonimiza os dados pessoais do usuário
+// @Description Substitui nome e email do usuário e o desativa; quando ele tem cadastro de cliente, o
+// @Description cadastro inteiro é anonimizado. Apenas administradores
+// @Tags privacidade
+// @Produce json
+// @Param id path string true "ID do usuário"
+// @Success 200 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /usuarios/{id}/anonimizar [post]
+func anonimizarUsuario(service *service.PrivacidadeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := service.AnonimizarUsuario(c.Param("id"), operadorAtual(c)); err != nil {
+			c.JSON(statusErroPrivacidade(err), gin.H{"error": mensagemErroPrivacidade(err)})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "dados do usuário anonimizados com sucesso"})
+	}
+}
+
+// @Summary Consulta a trilha de auditoria
+// @Description Lista as exportações e anonimizações de dados pessoais e as mesclagens de clientes, da
+// @Description mais recente para a mais antiga. Apenas administradores
+// @Tags privacidade
+// @Produce json
+// @Param entidade query string false "cliente ou usuario"
+// @Param entidade_id query string false "ID do cliente ou usuário"
+// @Param acao query string false "exportacao_dados, anonimizacao ou mesclagem"
+// @Success 200 {array} domain.RegistroAuditoria
+// @Router /auditoria [get]
+func getAuditoria(service *service.PrivacidadeService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		registros, err := service.ListarAuditoria(domain.FiltroAuditoria{
+			Entidade:   c.Query("entidade"),
+			EntidadeID: c.Query("entidade_id"),
+			Acao:       domain.AcaoAuditoria(c.Query("acao")),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, registros)
+	}
+}
+
+// formatoExportacaoValido recusa formatos de exportação desconhecidos antes que a
+// exportação seja registrada na auditoria
+func formatoExportacaoValido(c *gin.Context) bool {
+	switch c.DefaultQuery("formato", "json") {
+	case "json", "zip":
+		return true
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"error": "formato deve ser json ou zip"})
+	return false
+}
+
+// responderDadosTitular devolve os dados em JSON ou, com formato=zip, em um arquivo ZIP com
+// um JSON por seção
+func responderDadosTitular(c *gin.Context, dados *domain.DadosTitular, nome string) {
+	if c.Query("formato") != "zip" {
+		c.JSON(http.StatusOK, dados)
+		return
+	}
+
+	secoes := []struct {
+		arquivo  string
+		conteudo interface{}
+	}{
+		{"cliente.json", dados.Cliente},
+		{"usuario.json", dados.Usuario},
+		{"enderecos.json", dados.Enderecos},
+		{"vendas.json", dados.Vendas},
+		{"fidelidade.json", dados.Fidelidade},
+		{"orcamentos.json", dados.Orcamentos},
+		{"segmento.json", dados.Segmento},
+		{"mesclagens.json", dados.Mesclagens},
+		{"auditoria.json", dados.Auditoria},
+	}
+
+	var buf bytes.Buffer
+	arquivo := zip.NewWriter(&buf)
+	for _, secao := range secoes {
+		conteudo, err := json.MarshalIndent(secao.conteudo, "", "  ")
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		w, err := arquivo.CreateHeader(&zip.FileHeader{Name: secao.arquivo, Method: zip.Deflate, Modified: dados.GeradoEm})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		if _, err := w.Write(conteudo); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if err := arquivo.Close(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="dados-%s.zip"`, nome))
+	c.Data(http.StatusOK, "application/zip", buf.Bytes())
+}
+
+// statusErroPrivacidade traduz os erros de exportação e anonimização para o código HTTP adequado
+func statusErroPrivacidade(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows), errors.Is(err, domain.ErrUsuarioNaoEncontrado):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrClienteAnonimizado), errors.Is(err, domain.ErrAnonimizacaoBloqueada):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func mensagemErroPrivacidade(err error) string {
+	if errors.Is(err, sql.ErrNoRows) {
+		return "cliente não encontrado"
+	}
+	return err.Error()
+}
@@ -0,0 +1,211 @@
+package web
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Lista as reservas de estoque ativas
+// @Description Retorna as reservas que seguram estoque agora para vendas em rascunho e
+// @Description orçamentos, das que vencem primeiro para as últimas
+// @Tags estoque
+// @Accept json
+// @Produce json
+// @Param local_id query string false "ID do local"
+// @Param produto_id query string false "ID do produto"
+// @Success 200 {array} domain.ReservaEstoque
+// @Router /estoque/reservas [get]
+func getReservas(service *service.ReservaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reservas, err := service.GetAtivas(domain.FiltroReservas{
+			LocalID:   c.Query("local_id"),
+			ProdutoID: c.Query("produto_id"),
+		})
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, reservas)
+	}
+}
+
+// @Summary Lista as reservas de estoque da venda
+// @Description Retorna as reservas ativas de uma venda em rascunho
+// @Tags vendas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da venda"
+// @Success 200 {array} domain.ReservaEstoque
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /vendas/{id}/reserva [get]
+func getReservaVenda(service *service.ReservaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reservas, err := service.GetReservasVenda(c.Param("id"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroReserva(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, reservas)
+	}
+}
+
+// @Summary Reserva o estoque de uma venda em rascunho
+// @Description Segura no local da venda as quantidades dos itens até a confirmação ou até o
+// @Description fim do prazo. Enquanto ativa, a reserva não pode ser usada por outras vendas,
+// @Description orçamentos ou transferências. Reservar de novo substitui as reservas da venda;
+// @Description editar o rascunho libera as reservas. Sem minutos, vale o prazo do servidor
+// @Tags vendas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da venda"
+// @Param reserva body domain.ReservarEstoqueDTO false "Prazo da reserva"
+// @Success 201 {array} domain.ReservaEstoque
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /vendas/{id}/reserva [post]
+func reservarVenda(service *service.ReservaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dto, ok := reservarEstoqueDTO(c)
+		if !ok {
+			return
+		}
+
+		reservas, err := service.ReservarVenda(c.Param("id"), dto, operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroReserva(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, reservas)
+	}
+}
+
+// @Summary Libera o estoque reservado para a venda
+// @Description Desfaz as reservas ativas da venda
+// @Tags vendas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da venda"
+// @Success 204 "No Content"
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /vendas/{id}/reserva [delete]
+func liberarReservaVenda(service *service.ReservaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := service.LiberarVenda(c.Param("id"), operadorAtual(c)); err != nil {
+			c.JSON(statusErroReserva(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// @Summary Lista as reservas de estoque do orçamento
+// @Description Retorna as reservas ativas de um orçamento
+// @Tags orcamentos
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do orçamento"
+// @Success 200 {array} domain.ReservaEstoque
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /orcamentos/{id}/reserva [get]
+func getReservaOrcamento(service *service.ReservaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		reservas, err := service.GetReservasOrcamento(c.Param("id"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroReserva(err), gin.H{"error": mensagemErroOrcamento(err)})
+			return
+		}
+		c.JSON(http.StatusOK, reservas)
+	}
+}
+
+// @Summary Reserva o estoque de um orçamento
+// @Description Segura no local do orçamento aberto as quantidades dos itens até a conversão
+// @Description em venda, que herda as reservas, ou até o fim do prazo, limitado à validade do
+// @Description orçamento. Reservar de novo substitui as reservas do orçamento. Sem minutos,
+// @Description vale o prazo do servidor
+// @Tags orcamentos
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do orçamento"
+// @Param reserva body domain.ReservarEstoqueDTO false "Prazo da reserva"
+// @Success 201 {array} domain.ReservaEstoque
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /orcamentos/{id}/reserva [post]
+func reservarOrcamento(service *service.ReservaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		dto, ok := reservarEstoqueDTO(c)
+		if !ok {
+			return
+		}
+
+		reservas, err := service.ReservarOrcamento(c.Param("id"), dto, operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroReserva(err), gin.H{"error": mensagemErroOrcamento(err)})
+			return
+		}
+		c.JSON(http.StatusCreated, reservas)
+	}
+}
+
+// @Summary Libera o estoque reservado para o orçamento
+// @Description Desfaz as reservas ativas do orçamento
+// @Tags orcamentos
+// @Accept json
+// @Produce json
+// @Param id path string true "ID do orçamento"
+// @Success 204 "No Content"
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /orcamentos/{id}/reserva [delete]
+func liberarReservaOrcamento(service *service.ReservaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, err := service.LiberarOrcamento(c.Param("id"), operadorAtual(c)); err != nil {
+			c.JSON(statusErroReserva(err), gin.H{"error": mensagemErroOrcamento(err)})
+			return
+		}
+		c.Status(http.StatusNoContent)
+	}
+}
+
+// reservarEstoqueDTO lê o prazo opcional da reserva; em caso de corpo inválido, responde 400
+func reservarEstoqueDTO(c *gin.Context) (domain.ReservarEstoqueDTO, bool) {
+	var dto domain.ReservarEstoqueDTO
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return dto, false
+		}
+	}
+	if dto.Minutos < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "minutos deve ser maior que zero"})
+		return dto, false
+	}
+	return dto, true
+}
+
+// statusErroReserva traduz os erros das reservas para o código HTTP adequado
+func statusErroReserva(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrAcessoNegado):
+		return http.StatusForbidden
+	case errors.Is(err, domain.ErrReservaInvalida), errors.Is(err, domain.ErrEstoqueIndisponivel):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,6 +1,8 @@
 package web
 
 import (
+	"database/sql"
+	"errors"
 	"net/http"
 	"os"
 	"strconv"
@@ -77,7 +79,7 @@ func createProduto(service *service.ProdutoService) gin.HandlerFunc {
 			return
 		}
 
-		if err := service.Create(&produto); err != nil {
+		if err := service.Create(&produto, operadorAtual(c)); err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
@@ -86,7 +88,8 @@ func createProduto(service *service.ProdutoService) gin.HandlerFunc {
 }
 
 // @Summary Atualiza um produto
-// @Description Atualiza um produto existente com os dados fornecidos
+// @Description Atualiza um produto existente com os dados fornecidos. A quantidade em estoque
+// @Description e o custo médio não são alterados; eles mudam apenas por movimentações de estoque
 // @Tags produtos
 // @Accept json
 // @Produce json
@@ -146,17 +149,19 @@ func deleteProduto(service *service.ProdutoService) gin.HandlerFunc {
 }
 
 // @Summary Lista todas as vendas
-// @Description Retorna uma lista de todas as vendas cadastradas
+// @Description Retorna uma lista de todas as vendas cadastradas. Usuários com o papel cliente
+// @Description recebem apenas as próprias vendas
 // @Tags vendas
 // @Accept json
 // @Produce json
 // @Success 200 {array} domain.Venda
+// @Failure 403 {object} map[string]string
 // @Router /vendas [get]
 func getVendas(service *service.VendaService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		vendas, err := service.GetAll()
+		vendas, err := service.GetAll(operadorAtual(c))
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			c.JSON(statusErroAcessoVenda(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, vendas)
@@ -171,6 +176,7 @@ func getVendas(service *service.VendaService) gin.HandlerFunc {
 // @Param id path string true "ID da venda"
 // @Success 200 {object} domain.Venda
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Router /vendas/{id} [get]
 func getVenda(service *service.VendaService) gin.HandlerFunc {
@@ -181,9 +187,9 @@ func getVenda(service *service.VendaService) gin.HandlerFunc {
 			return
 		}
 
-		venda, err := service.GetByID(id)
+		venda, err := service.GetByID(id, operadorAtual(c))
 		if err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			c.JSON(statusErroAcessoVenda(err, http.StatusNotFound), gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, venda)
@@ -191,13 +197,24 @@ func getVenda(service *service.VendaService) gin.HandlerFunc {
 }
 
 // @Summary Cria uma nova venda
-// @Description Cria uma nova venda com os dados fornecidos
+// @Description Cria uma nova venda com os dados fornecidos. Descontos por item e da venda
+// @Description são limitados conforme o perfil do usuário autenticado. Vendas criadas como
+// @Description rascunho não baixam o estoque até serem confirmadas. Com parcelamento, as
+// @Description parcelas são geradas na confirmação da venda. Os itens saem do local informado,
+// @Description ou do local principal. Vendas confirmadas que ultrapassam o limite de crédito do
+// @Description cliente são recusadas com 422 e codigo limite_credito_excedido, a menos que o
+// @Description header X-Autorizacao-Credito traga o token de um administrador. O vendedor é o
+// @Description usuário autenticado; administradores podem informar vendedor_id para registrar
+// @Description a venda em nome de outro vendedor
 // @Tags vendas
 // @Accept json
 // @Produce json
 // @Param venda body domain.CreateVendaDTO true "Dados da venda"
+// @Param X-Autorizacao-Credito header string false "Token de um administrador que libera o limite de crédito"
 // @Success 201 {object} domain.Venda
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
 // @Router /vendas [post]
 func createVenda(service *service.VendaService) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -213,18 +230,28 @@ func createVenda(service *service.VendaService) gin.HandlerFunc {
 			itens[i] = domain.ItemVenda{
 				ProdutoID:  itemDTO.ProdutoID,
 				Quantidade: itemDTO.Quantidade,
+				Desconto:   itemDTO.Desconto,
 			}
 		}
 
 		// Cria a entidade Venda
 		venda := &domain.Venda{
-			ClienteID: dto.Cliente,
-			Items:     itens,
-			DataVenda: time.Now(),
+			ClienteID:    dto.Cliente,
+			Items:        itens,
+			DataVenda:    time.Now(),
+			Parcelamento: dto.Parcelamento,
+			LocalID:      dto.LocalID,
+			VendedorID:   dto.VendedorID,
+		}
+		if dto.Desconto > 0 {
+			venda.Desconto = &domain.Desconto{Tipo: dto.TipoDesconto, Valor: dto.Desconto}
+		}
+		if dto.Rascunho {
+			venda.Status = domain.StatusRascunho
 		}
 
-		if err := service.Create(venda); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := service.Create(venda, operadorAtual(c)); err != nil {
+			c.JSON(statusErroVenda(err), respostaErroVenda(err))
 			return
 		}
 		c.JSON(http.StatusCreated, venda)
@@ -232,15 +259,21 @@ func createVenda(service *service.VendaService) gin.HandlerFunc {
 }
 
 // @Summary Atualiza uma venda
-// @Description Atualiza uma venda existente com os dados fornecidos
+// @Description Atualiza uma venda existente com os dados fornecidos. O limite de crédito do
+// @Description cliente é conferido como na criação da venda. O vendedor é mantido, a menos que
+// @Description um administrador informe outro em vendedor_id. Vendedores só editam as próprias vendas.
+// @Description Vendas confirmadas que já receberam pagamentos não podem ser editadas
 // @Tags vendas
 // @Accept json
 // @Produce json
 // @Param id path string true "ID da venda"
 // @Param venda body domain.Venda true "Dados da venda"
+// @Param X-Autorizacao-Credito header string false "Token de um administrador que libera o limite de crédito"
 // @Success 200 {object} domain.Venda
 // @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
 // @Router /vendas/{id} [put]
 func updateVenda(service *service.VendaService) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -257,16 +290,17 @@ func updateVenda(service *service.VendaService) gin.HandlerFunc {
 		}
 
 		venda.ID = id
-		if err := service.Update(&venda); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if err := service.Update(&venda, operadorAtual(c)); err != nil {
+			c.JSON(statusErroVenda(err), respostaErroVenda(err))
 			return
 		}
 		c.JSON(http.StatusOK, venda)
 	}
 }
 
-// @Summary Remove uma venda
-// @Description Remove uma venda pelo seu ID
+// @Summary Cancela uma venda
+// @Description Mantido por compatibilidade: a venda não é mais removida, e sim cancelada, com a mesma
+// @Description permissão vendas:cancelar de POST /vendas/{id}/cancelar
 // @Tags vendas
 // @Accept json
 // @Produce json
@@ -274,6 +308,7 @@ func updateVenda(service *service.VendaService) gin.HandlerFunc {
 // @Success 204 "No Content"
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
 // @Router /vendas/{id} [delete]
 func deleteVenda(service *service.VendaService) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -283,29 +318,181 @@ func deleteVenda(service *service.VendaService) gin.HandlerFunc {
 			return
 		}
 
-		if err := service.Delete(id); err != nil {
-			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		if _, err := service.Cancelar(id, operadorAtual(c), ""); err != nil {
+			c.JSON(statusErroTransicao(err), gin.H{"error": err.Error()})
 			return
 		}
 		c.Status(http.StatusNoContent)
 	}
 }
 
+// @Summary Confirma uma venda
+// @Description Confirma um rascunho de venda, baixando os itens do estoque. Vendas que
+// @Description ultrapassam o limite de crédito do cliente exigem o header X-Autorizacao-Credito
+// @Tags vendas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da venda"
+// @Param motivo body domain.AlterarStatusVendaDTO false "Observação da mudança de status"
+// @Param X-Autorizacao-Credito header string false "Token de um administrador que libera o limite de crédito"
+// @Success 200 {object} domain.Venda
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 422 {object} map[string]interface{}
+// @Router /vendas/{id}/confirmar [post]
+func confirmarVenda(service *service.VendaService) gin.HandlerFunc {
+	return alterarStatusVenda(service.Confirmar)
+}
+
+// @Summary Marca uma venda como paga
+// @Description Registra que uma venda confirmada foi paga
+// @Tags vendas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da venda"
+// @Param motivo body domain.AlterarStatusVendaDTO false "Observação da mudança de status"
+// @Success 200 {object} domain.Venda
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /vendas/{id}/pagar [post]
+func pagarVenda(service *service.VendaService) gin.HandlerFunc {
+	return alterarStatusVenda(service.MarcarComoPaga)
+}
+
+// @Summary Cancela uma venda
+// @Description Cancela a venda mantendo o histórico; os itens de vendas confirmadas voltam ao estoque.
+// @Description Vendas com pagamentos recebidos não podem ser canceladas e devem ser devolvidas. Exige a
+// @Description permissão vendas:cancelar, a mesma da rota DELETE /vendas/{id}
+// @Tags vendas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da venda"
+// @Param motivo body domain.AlterarStatusVendaDTO false "Motivo do cancelamento"
+// @Success 200 {object} domain.Venda
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /vendas/{id}/cancelar [post]
+func cancelarVenda(service *service.VendaService) gin.HandlerFunc {
+	return alterarStatusVenda(service.Cancelar)
+}
+
+// @Summary Histórico de status de uma venda
+// @Description Retorna todas as mudanças de status da venda
+// @Tags vendas
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da venda"
+// @Success 200 {array} domain.HistoricoStatusVenda
+// @Failure 404 {object} map[string]string
+// @Router /vendas/{id}/historico [get]
+func getHistoricoVenda(service *service.VendaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		historico, err := service.GetHistoricoStatus(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, historico)
+	}
+}
+
+// alterarStatusVenda monta o handler comum às transições de status da venda
+func alterarStatusVenda(transicao func(id string, operador domain.Operador, motivo string) (*domain.Venda, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if id == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "id inválido"})
+			return
+		}
+
+		// O motivo é opcional, então o corpo pode vir vazio
+		var dto domain.AlterarStatusVendaDTO
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&dto); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+		}
+
+		venda, err := transicao(id, operadorAtual(c), dto.Motivo)
+		if err != nil {
+			c.JSON(statusErroTransicao(err), respostaErroVenda(err))
+			return
+		}
+		c.JSON(http.StatusOK, venda)
+	}
+}
+
+// statusErroTransicao traduz os erros de mudança de status para o código HTTP adequado
+func statusErroTransicao(err error) int {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrTransicaoStatusInvalida):
+		return http.StatusConflict
+	default:
+		return http.StatusUnprocessableEntity
+	}
+}
+
+// operadorAtual retorna o usuário autenticado pelo AuthMiddleware
+func operadorAtual(c *gin.Context) domain.Operador {
+	return domain.Operador{
+		UsuarioID:        c.GetString("usuario_id"),
+		Role:             domain.Role(c.GetString("role")),
+		LiberacaoCredito: c.GetString("liberacao_credito"),
+	}
+}
+
+// statusErroVenda responde a recusa por limite de crédito, o desconto inválido ou acima do
+// limite do perfil e o vendedor inválido com 422, a venda para cliente anonimizado com 409,
+// a venda em nome de outro vendedor sem permissão com 403 e os demais erros de gravação da
+// venda com 500
+func statusErroVenda(err error) int {
+	var limite *domain.ErroLimiteCredito
+	var limiteDesconto *domain.ErroLimiteDesconto
+	if errors.As(err, &limite) || errors.As(err, &limiteDesconto) ||
+		errors.Is(err, domain.ErrDescontoInvalido) || errors.Is(err, domain.ErrVendedorInvalido) {
+		return http.StatusUnprocessableEntity
+	}
+	switch {
+	case errors.Is(err, domain.ErrClienteAnonimizado):
+		return http.StatusConflict
+	case errors.Is(err, domain.ErrAcessoNegado):
+		return http.StatusForbidden
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+// respostaErroVenda monta o corpo de erro. A recusa por limite de crédito traz o código e
+// os valores envolvidos para que o front end possa exibi-los e pedir a liberação.
+func respostaErroVenda(err error) gin.H {
+	var limite *domain.ErroLimiteCredito
+	if errors.As(err, &limite) {
+		return gin.H{"error": err.Error(), "codigo": domain.CodigoLimiteCredito, "detalhes": limite}
+	}
+	return gin.H{"error": err.Error()}
+}
+
 // @Summary Lista vendas por cliente
-// @Description Retorna uma lista de vendas filtrada por cliente
+// @Description Retorna uma lista de vendas filtrada por cliente. Usuários com o papel cliente
+// @Description só podem consultar as próprias vendas
 // @Tags vendas
 // @Accept json
 // @Produce json
-// @Param cliente path string true "Nome do cliente"
+// @Param clienteId path string true "ID do cliente"
 // @Success 200 {array} domain.Venda
 // @Failure 400 {object} map[string]string
-// @Router /vendas/cliente/{cliente} [get]
+// @Failure 403 {object} map[string]string
+// @Router /vendas/cliente/{clienteId} [get]
 func getVendasPorCliente(service *service.VendaService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		cliente := c.Param("cliente")
-		vendas, err := service.GetVendasPorCliente(cliente)
+		cliente := c.Param("clienteId")
+		vendas, err := service.GetVendasPorCliente(cliente, operadorAtual(c))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.JSON(statusErroAcessoVenda(err, http.StatusBadRequest), gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, vendas)
@@ -313,7 +500,8 @@ func getVendasPorCliente(service *service.VendaService) gin.HandlerFunc {
 }
 
 // @Summary Lista vendas por período
-// @Description Retorna uma lista de vendas filtrada por período
+// @Description Retorna uma lista de vendas filtrada por período. Usuários com o papel cliente
+// @Description recebem apenas as próprias vendas
 // @Tags vendas
 // @Accept json
 // @Produce json
@@ -336,30 +524,82 @@ func getVendasPorPeriodo(service *service.VendaService) gin.HandlerFunc {
 			return
 		}
 
-		vendas, err := service.GetVendasPorPeriodo(inicio, fim)
+		vendas, err := service.GetVendasPorPeriodo(inicio, fim, operadorAtual(c))
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			c.JSON(statusErroAcessoVenda(err, http.StatusBadRequest), gin.H{"error": err.Error()})
 			return
 		}
 		c.JSON(http.StatusOK, vendas)
 	}
 }
 
-func SetupRoutes(router *gin.Engine, produtoService *service.ProdutoService, vendaService *service.VendaService) {
+// acessoVenda interrompe as consultas e alterações de uma venda fora do alcance do usuário,
+// como as vendas de outros vendedores ou, para quem tem o papel cliente, de outros clientes
+func acessoVenda(service *service.VendaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := service.VerificarAcesso(c.Param("id"), operadorAtual(c)); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				c.JSON(http.StatusNotFound, gin.H{"error": "venda não encontrada"})
+			} else {
+				c.JSON(statusErroAcessoVenda(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+			}
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// statusErroAcessoVenda responde com 403 ao acesso negado pelas regras de visibilidade das
+// vendas, com 404 à venda inexistente ou oculta e com o código padrão aos demais erros
+func statusErroAcessoVenda(err error, padrao int) int {
+	switch {
+	case errors.Is(err, domain.ErrAcessoNegado):
+		return http.StatusForbidden
+	case errors.Is(err, sql.ErrNoRows):
+		return http.StatusNotFound
+	default:
+		return padrao
+	}
+}
+
+func SetupRoutes(
+	router *gin.Engine,
+	produtoService *service.ProdutoService,
+	vendaService *service.VendaService,
+	devolucaoService *service.DevolucaoService,
+	pagamentoService *service.PagamentoService,
+	contasReceberService *service.ContasReceberService,
+	estoqueService *service.EstoqueService,
+	fornecedorService *service.FornecedorService,
+	compraService *service.CompraService,
+	localService *service.LocalService,
+	transferenciaService *service.TransferenciaService,
+	clienteService *service.ClienteService,
+	enderecoService *service.EnderecoService,
+	fidelidadeService *service.FidelidadeService,
+	privacidadeService *service.PrivacidadeService,
+	mesclagemService *service.MesclagemService,
+	sessaoService *service.SessaoService,
+	comissaoService *service.ComissaoService,
+	metaService *service.MetaService,
+	orcamentoService *service.OrcamentoService,
+	reservaService *service.ReservaService,
+	permissaoService *service.PermissaoService,
+) {
 	// Inicializa os repositories
 	usuarioRepo := repository.NewUsuarioRepository(database.DB)
-	clienteRepo := repository.NewClienteRepository(database.DB)
 
 	// Inicializa os services
 	usuarioService := service.NewUsuarioService(usuarioRepo)
-	clienteService := service.NewClienteService(clienteRepo)
 
 	// Inicializa os handlers
 	h := handlers.NewHandlers(
 		usuarioService,
 		clienteService,
+		enderecoService,
 		produtoService,
-		os.Getenv("JWT_SECRET_KEY"),
+		sessaoService,
 	)
 
 	// Inicializa o handler de relatórios
@@ -370,37 +610,183 @@ func SetupRoutes(router *gin.Engine, produtoService *service.ProdutoService, ven
 	{
 		// Rotas públicas
 		api.POST("/auth/login", h.Usuario.Login)
+		api.POST("/auth/refresh", h.Usuario.RenovarToken)
 
 		// Rotas protegidas
 		protected := api.Group("/")
-		protected.Use(middleware.AuthMiddleware(os.Getenv("JWT_SECRET_KEY")))
+		protected.Use(middleware.AuthMiddleware(os.Getenv("JWT_SECRET_KEY"), sessaoService))
+		protected.Use(middleware.LiberacaoCredito(os.Getenv("JWT_SECRET_KEY"), sessaoService))
 		{
-			// Rotas de usuários
+			// Sessão do usuário autenticado
+			protected.GET("/auth/user", h.Usuario.GetUsuarioAtual)
+			protected.GET("/auth/me", h.Usuario.GetUsuarioAtual)
+			protected.POST("/auth/logout", h.Usuario.Logout)
+
+			// Matriz de permissões de cada papel
+			protected.GET("/permissoes", middleware.RequirePermission(domain.PermPermissoesGerenciar), getPermissoes(permissaoService))
+			protected.PUT("/permissoes/:role", middleware.RequirePermission(domain.PermPermissoesGerenciar), alterarPermissoes(permissaoService))
+
+			// Rotas de usuários; qualquer usuário autenticado consulta o próprio cadastro
 			protected.GET("/usuarios/me", h.Usuario.GetUsuarioAtual)
-			protected.GET("/usuarios", h.Usuario.ListUsuarios)
-			protected.GET("/usuarios/:id", h.Usuario.GetUsuario)
-			protected.POST("/usuarios", h.Usuario.CreateUsuario)
-			protected.PUT("/usuarios/:id", h.Usuario.UpdateUsuario)
-			protected.DELETE("/usuarios/:id", h.Usuario.DeleteUsuario)
+			protected.GET("/usuarios", middleware.RequirePermission(domain.PermUsuariosGerenciar), h.Usuario.ListUsuarios)
+			protected.GET("/usuarios/:id", middleware.RequirePermission(domain.PermUsuariosGerenciar), h.Usuario.GetUsuario)
+			protected.POST("/usuarios", middleware.RequirePermission(domain.PermUsuariosGerenciar), h.Usuario.CreateUsuario)
+			protected.PUT("/usuarios/:id", middleware.RequirePermission(domain.PermUsuariosGerenciar), h.Usuario.UpdateUsuario)
+			protected.DELETE("/usuarios/:id", middleware.RequirePermission(domain.PermUsuariosGerenciar), h.Usuario.DeleteUsuario)
+			protected.GET("/usuarios/:id/dados-pessoais", middleware.RequirePermission(domain.PermPrivacidadeGerenciar), exportarDadosUsuario(privacidadeService))
+			protected.POST("/usuarios/:id/anonimizar", middleware.RequirePermission(domain.PermPrivacidadeGerenciar), anonimizarUsuario(privacidadeService))
+
+			// Rotas de clientes
+			clientes := protected.Group("/clientes")
+			clientes.Use(middleware.RequirePermission(domain.PermClientesLer))
+			{
+				clientes.GET("", h.Cliente.ListClientes)
+				clientes.GET("/:id", h.Cliente.GetCliente)
+				clientes.POST("", middleware.RequirePermission(domain.PermClientesEditar), h.Cliente.CreateCliente)
+				clientes.PUT("/:id", middleware.RequirePermission(domain.PermClientesEditar), h.Cliente.UpdateCliente)
+				clientes.DELETE("/:id", middleware.RequirePermission(domain.PermClientesEditar), h.Cliente.DeleteCliente)
+				clientes.GET("/:id/credito", h.Cliente.GetCredito)
+				clientes.GET("/:id/resumo", h.Cliente.GetResumo)
+				clientes.GET("/segmentos", h.Cliente.ListSegmentos)
+				clientes.POST("/segmentos/recalcular", middleware.RequirePermission(domain.PermClientesAdministrar), h.Cliente.SegmentarClientes)
+				clientes.GET("/:id/enderecos", h.Endereco.ListEnderecos)
+				clientes.GET("/:id/enderecos/:enderecoId", h.Endereco.GetEndereco)
+				clientes.POST("/:id/enderecos", middleware.RequirePermission(domain.PermClientesEditar), h.Endereco.CreateEndereco)
+				clientes.PUT("/:id/enderecos/:enderecoId", middleware.RequirePermission(domain.PermClientesEditar), h.Endereco.UpdateEndereco)
+				clientes.DELETE("/:id/enderecos/:enderecoId", middleware.RequirePermission(domain.PermClientesEditar), h.Endereco.DeleteEndereco)
+				clientes.GET("/:id/fidelidade", getFidelidadeCliente(fidelidadeService))
+				clientes.GET("/:id/dados-pessoais", middleware.RequirePermission(domain.PermPrivacidadeGerenciar), exportarDadosCliente(privacidadeService))
+				clientes.POST("/:id/anonimizar", middleware.RequirePermission(domain.PermPrivacidadeGerenciar), anonimizarCliente(privacidadeService))
+				clientes.GET("/duplicados", getClientesDuplicados(mesclagemService))
+				clientes.GET("/:id/duplicados", getDuplicadosCliente(mesclagemService))
+				clientes.GET("/:id/mesclagens", getMesclagensCliente(mesclagemService))
+				clientes.POST("/:id/mesclar", middleware.RequirePermission(domain.PermClientesAdministrar), mesclarClientes(mesclagemService))
+			}
+
+			// Trilha de auditoria das operações sobre dados pessoais
+			protected.GET("/auditoria", middleware.RequirePermission(domain.PermPrivacidadeGerenciar), getAuditoria(privacidadeService))
+
+			// Extrato de pontos do próprio cliente, sem acesso ao cadastro de clientes
+			protected.GET("/fidelidade/extrato", middleware.RequirePermission(domain.PermFidelidadeLer), getFidelidadePropria(fidelidadeService))
+
+			// Regras do programa de fidelidade
+			protected.GET("/fidelidade/regras", middleware.RequirePermission(domain.PermFidelidadeLer), getRegrasFidelidade(fidelidadeService))
+			protected.GET("/fidelidade/regras/:id", middleware.RequirePermission(domain.PermFidelidadeLer), getRegraFidelidade(fidelidadeService))
+			protected.POST("/fidelidade/regras", middleware.RequirePermission(domain.PermFidelidadeConfigurar), createRegraFidelidade(fidelidadeService))
+			protected.PUT("/fidelidade/regras/:id", middleware.RequirePermission(domain.PermFidelidadeConfigurar), updateRegraFidelidade(fidelidadeService))
+			protected.DELETE("/fidelidade/regras/:id", middleware.RequirePermission(domain.PermFidelidadeConfigurar), deleteRegraFidelidade(fidelidadeService))
+
+			// Consulta de CEP, usada no cadastro de endereços
+			protected.GET("/cep/:cep", middleware.RequirePermission(domain.PermClientesEditar), h.Endereco.BuscarCEP)
 
 			// Rotas de produtos
-			protected.GET("/produtos", getProdutos(produtoService))
-			protected.GET("/produtos/:id", getProduto(produtoService))
-			protected.POST("/produtos", createProduto(produtoService))
-			protected.PUT("/produtos/:id", updateProduto(produtoService))
-			protected.DELETE("/produtos/:id", deleteProduto(produtoService))
-
-			// Rotas de vendas
-			protected.GET("/vendas", getVendas(vendaService))
-			protected.GET("/vendas/:id", getVenda(vendaService))
-			protected.POST("/vendas", createVenda(vendaService))
-			protected.PUT("/vendas/:id", updateVenda(vendaService))
-			protected.DELETE("/vendas/:id", deleteVenda(vendaService))
-			protected.GET("/vendas/cliente/:clienteId", getVendasPorCliente(vendaService))
-			protected.GET("/vendas/periodo", getVendasPorPeriodo(vendaService))
+			protected.GET("/produtos", middleware.RequirePermission(domain.PermProdutosLer), getProdutos(produtoService))
+			protected.GET("/produtos/:id", middleware.RequirePermission(domain.PermProdutosLer), getProduto(produtoService))
+			protected.POST("/produtos", middleware.RequirePermission(domain.PermProdutosEditar), createProduto(produtoService))
+			protected.PUT("/produtos/:id", middleware.RequirePermission(domain.PermProdutosEditar), updateProduto(produtoService))
+			protected.DELETE("/produtos/:id", middleware.RequirePermission(domain.PermProdutosEditar), deleteProduto(produtoService))
+			protected.POST("/produtos/:id/movimentacoes", middleware.RequirePermission(domain.PermEstoqueMovimentar), createMovimentacao(estoqueService))
+			protected.GET("/produtos/:id/saldos", middleware.RequirePermission(domain.PermEstoqueLer), getSaldosProduto(estoqueService))
+			protected.GET("/produtos/:id/movimentacoes", middleware.RequirePermission(domain.PermEstoqueLer), getMovimentacoes(estoqueService))
+
+			// Rotas de vendas; sem PermVendasTodas, vendedores alcançam apenas as vendas que
+			// registraram e clientes apenas as próprias
+			protected.GET("/vendas", middleware.RequirePermission(domain.PermVendasLer), getVendas(vendaService))
+			protected.GET("/vendas/:id", middleware.RequirePermission(domain.PermVendasLer), getVenda(vendaService))
+			protected.POST("/vendas", middleware.RequirePermission(domain.PermVendasRegistrar), createVenda(vendaService))
+			protected.PUT("/vendas/:id", middleware.RequirePermission(domain.PermVendasRegistrar), acessoVenda(vendaService), updateVenda(vendaService))
+			protected.DELETE("/vendas/:id", middleware.RequirePermission(domain.PermVendasCancelar), acessoVenda(vendaService), deleteVenda(vendaService))
+			protected.POST("/vendas/:id/confirmar", middleware.RequirePermission(domain.PermVendasRegistrar), acessoVenda(vendaService), confirmarVenda(vendaService))
+			protected.POST("/vendas/:id/pagar", middleware.RequirePermission(domain.PermVendasRegistrar), acessoVenda(vendaService), pagarVenda(vendaService))
+			protected.POST("/vendas/:id/cancelar", middleware.RequirePermission(domain.PermVendasCancelar), acessoVenda(vendaService), cancelarVenda(vendaService))
+			protected.POST("/vendas/:id/devolver", middleware.RequirePermission(domain.PermVendasRegistrar), acessoVenda(vendaService), devolverVenda(devolucaoService))
+			protected.POST("/vendas/:id/devolucoes", middleware.RequirePermission(domain.PermVendasRegistrar), acessoVenda(vendaService), createDevolucao(devolucaoService))
+			protected.GET("/vendas/:id/devolucoes", middleware.RequirePermission(domain.PermVendasLer), acessoVenda(vendaService), getDevolucoes(devolucaoService))
+			protected.POST("/vendas/:id/pagamentos", middleware.RequirePermission(domain.PermVendasRegistrar), acessoVenda(vendaService), createPagamentos(pagamentoService))
+			protected.GET("/vendas/:id/pagamentos", middleware.RequirePermission(domain.PermVendasLer), acessoVenda(vendaService), getPagamentos(pagamentoService))
+			protected.GET("/vendas/:id/parcelas", middleware.RequirePermission(domain.PermVendasLer), acessoVenda(vendaService), getParcelasVenda(contasReceberService))
+			protected.GET("/vendas/:id/historico", middleware.RequirePermission(domain.PermVendasLer), acessoVenda(vendaService), getHistoricoVenda(vendaService))
+			protected.GET("/vendas/:id/reserva", middleware.RequirePermission(domain.PermVendasLer), acessoVenda(vendaService), getReservaVenda(reservaService))
+			protected.POST("/vendas/:id/reserva", middleware.RequirePermission(domain.PermVendasRegistrar), acessoVenda(vendaService), reservarVenda(reservaService))
+			protected.DELETE("/vendas/:id/reserva", middleware.RequirePermission(domain.PermVendasRegistrar), acessoVenda(vendaService), liberarReservaVenda(reservaService))
+			protected.GET("/vendas/cliente/:clienteId", middleware.RequirePermission(domain.PermVendasLer), getVendasPorCliente(vendaService))
+			protected.GET("/vendas/periodo/:inicio/:fim", middleware.RequirePermission(domain.PermVendasLer), getVendasPorPeriodo(vendaService))
+
+			// Rotas de estoque
+			protected.GET("/estoque/reposicao", middleware.RequirePermission(domain.PermEstoqueLer), getSugestaoReposicao(estoqueService))
+			protected.GET("/estoque/divergencias", middleware.RequirePermission(domain.PermEstoqueLer), getDivergenciasEstoque(estoqueService))
+			protected.GET("/estoque/reservas", middleware.RequirePermission(domain.PermEstoqueLer), getReservas(reservaService))
+
+			// Rotas de locais de estoque
+			protected.GET("/locais", middleware.RequirePermission(domain.PermEstoqueLer), getLocais(localService))
+			protected.GET("/locais/:id", middleware.RequirePermission(domain.PermEstoqueLer), getLocal(localService))
+			protected.POST("/locais", middleware.RequirePermission(domain.PermEstoqueMovimentar), createLocal(localService))
+			protected.PUT("/locais/:id", middleware.RequirePermission(domain.PermEstoqueMovimentar), updateLocal(localService))
+			protected.GET("/locais/:id/saldos", middleware.RequirePermission(domain.PermEstoqueLer), getSaldosLocal(localService))
+
+			// Rotas de transferências entre locais
+			protected.GET("/transferencias", middleware.RequirePermission(domain.PermEstoqueLer), getTransferencias(transferenciaService))
+			protected.GET("/transferencias/:id", middleware.RequirePermission(domain.PermEstoqueLer), getTransferencia(transferenciaService))
+			protected.POST("/transferencias", middleware.RequirePermission(domain.PermEstoqueMovimentar), createTransferencia(transferenciaService))
+			protected.POST("/transferencias/:id/receber", middleware.RequirePermission(domain.PermEstoqueMovimentar), receberTransferencia(transferenciaService))
+			protected.POST("/transferencias/:id/cancelar", middleware.RequirePermission(domain.PermEstoqueMovimentar), cancelarTransferencia(transferenciaService))
+
+			// Rotas de fornecedores
+			protected.GET("/fornecedores", middleware.RequirePermission(domain.PermComprasGerenciar), getFornecedores(fornecedorService))
+			protected.GET("/fornecedores/:id", middleware.RequirePermission(domain.PermComprasGerenciar), getFornecedor(fornecedorService))
+			protected.POST("/fornecedores", middleware.RequirePermission(domain.PermComprasGerenciar), createFornecedor(fornecedorService))
+			protected.PUT("/fornecedores/:id", middleware.RequirePermission(domain.PermComprasGerenciar), updateFornecedor(fornecedorService))
+			protected.DELETE("/fornecedores/:id", middleware.RequirePermission(domain.PermComprasGerenciar), deleteFornecedor(fornecedorService))
+
+			// Rotas de pedidos de compra
+			protected.GET("/pedidos-compra", middleware.RequirePermission(domain.PermComprasGerenciar), getPedidosCompra(compraService))
+			protected.GET("/pedidos-compra/:id", middleware.RequirePermission(domain.PermComprasGerenciar), getPedidoCompra(compraService))
+			protected.POST("/pedidos-compra", middleware.RequirePermission(domain.PermComprasGerenciar), createPedidoCompra(compraService))
+			protected.POST("/pedidos-compra/:id/receber", middleware.RequirePermission(domain.PermComprasGerenciar), receberPedidoCompra(compraService))
+			protected.POST("/pedidos-compra/:id/cancelar", middleware.RequirePermission(domain.PermComprasGerenciar), cancelarPedidoCompra(compraService))
+
+			// Rotas de contas a receber; sem PermVendasTodas, cada vendedor consulta apenas as
+			// parcelas das vendas que registrou
+			protected.GET("/contas-receber", middleware.RequirePermission(domain.PermFinanceiroLer), getContasReceber(contasReceberService))
+			protected.POST("/parcelas/:id/quitar", middleware.RequirePermission(domain.PermFinanceiroBaixar), quitarParcela(contasReceberService))
+
+			// Regras e extratos de comissão; sem PermComissoesGerenciar, cada vendedor consulta
+			// apenas o próprio extrato
+			protected.GET("/comissoes/regras", middleware.RequirePermission(domain.PermComissoesLer), getRegrasComissao(comissaoService))
+			protected.GET("/comissoes/regras/:id", middleware.RequirePermission(domain.PermComissoesLer), getRegraComissao(comissaoService))
+			protected.POST("/comissoes/regras", middleware.RequirePermission(domain.PermComissoesGerenciar), createRegraComissao(comissaoService))
+			protected.PUT("/comissoes/regras/:id", middleware.RequirePermission(domain.PermComissoesGerenciar), updateRegraComissao(comissaoService))
+			protected.DELETE("/comissoes/regras/:id", middleware.RequirePermission(domain.PermComissoesGerenciar), deleteRegraComissao(comissaoService))
+			protected.GET("/comissoes/extratos", middleware.RequirePermission(domain.PermComissoesLer), getExtratosComissao(comissaoService))
+			protected.GET("/comissoes/extratos/:vendedorId/:mes", middleware.RequirePermission(domain.PermComissoesLer), getExtratoComissao(comissaoService))
+			protected.POST("/comissoes/extratos/:vendedorId/:mes/fechar", middleware.RequirePermission(domain.PermComissoesGerenciar), fecharExtratoComissao(comissaoService))
+			protected.POST("/comissoes/extratos/:vendedorId/:mes/aprovar", middleware.RequirePermission(domain.PermComissoesGerenciar), aprovarExtratoComissao(comissaoService))
+
+			// Metas de vendas e o seu acompanhamento; sem PermMetasGerenciar, as metas
+			// individuais de outros vendedores ficam ocultas
+			protected.GET("/metas", middleware.RequirePermission(domain.PermMetasLer), getMetas(metaService))
+			protected.GET("/metas/progresso", middleware.RequirePermission(domain.PermMetasLer), getProgressoMetas(metaService))
+			protected.GET("/metas/:id", middleware.RequirePermission(domain.PermMetasLer), getMeta(metaService))
+			protected.GET("/metas/:id/progresso", middleware.RequirePermission(domain.PermMetasLer), getProgressoMeta(metaService))
+			protected.POST("/metas", middleware.RequirePermission(domain.PermMetasGerenciar), createMeta(metaService))
+			protected.PUT("/metas/:id", middleware.RequirePermission(domain.PermMetasGerenciar), updateMeta(metaService))
+			protected.DELETE("/metas/:id", middleware.RequirePermission(domain.PermMetasGerenciar), deleteMeta(metaService))
+
+			// Orçamentos; sem PermVendasTodas, vendedores alcançam apenas os que elaboraram. A
+			// conversão registra uma venda e por isso exige também PermVendasRegistrar
+			protected.GET("/orcamentos", middleware.RequirePermission(domain.PermOrcamentosGerenciar), getOrcamentos(orcamentoService))
+			protected.GET("/orcamentos/:id", middleware.RequirePermission(domain.PermOrcamentosGerenciar), getOrcamento(orcamentoService))
+			protected.GET("/orcamentos/:id/resumo", middleware.RequirePermission(domain.PermOrcamentosGerenciar), getResumoOrcamento(orcamentoService))
+			protected.POST("/orcamentos", middleware.RequirePermission(domain.PermOrcamentosGerenciar), createOrcamento(orcamentoService))
+			protected.POST("/orcamentos/:id/converter", middleware.RequirePermission(domain.PermOrcamentosGerenciar), middleware.RequirePermission(domain.PermVendasRegistrar), converterOrcamento(orcamentoService))
+			protected.POST("/orcamentos/:id/cancelar", middleware.RequirePermission(domain.PermOrcamentosGerenciar), cancelarOrcamento(orcamentoService))
+			protected.GET("/orcamentos/:id/reserva", middleware.RequirePermission(domain.PermOrcamentosGerenciar), getReservaOrcamento(reservaService))
+			protected.POST("/orcamentos/:id/reserva", middleware.RequirePermission(domain.PermOrcamentosGerenciar), reservarOrcamento(reservaService))
+			protected.DELETE("/orcamentos/:id/reserva", middleware.RequirePermission(domain.PermOrcamentosGerenciar), liberarReservaOrcamento(reservaService))
 
 			// Rotas de relatórios
-			protected.GET("/relatorios", relatorioHandler.GetRelatorio)
+			protected.GET("/relatorios", middleware.RequirePermission(domain.PermRelatoriosLer), relatorioHandler.GetRelatorio)
+			protected.GET("/relatorios/aging", middleware.RequirePermission(domain.PermRelatoriosLer), relatorioHandler.GetAgingRecebiveis)
 		}
 	}
 }
@@ -0,0 +1,116 @@
+package web
+
+import (
+	"net/http"
+	"vendas/internal/domain"
+	"vendas/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// @Summary Lista as transferências entre locais
+// @Description Retorna as transferências, da mais recente para a mais antiga
+// @Tags transferencias
+// @Accept json
+// @Produce json
+// @Param status query string false "Status da transferência (em_transito, recebida, cancelada)"
+// @Success 200 {array} domain.Transferencia
+// @Router /transferencias [get]
+func getTransferencias(service *service.TransferenciaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		transferencias, err := service.GetAll(domain.StatusTransferencia(c.Query("status")))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, transferencias)
+	}
+}
+
+// @Summary Obtém uma transferência por ID
+// @Description Retorna a transferência com os itens enviados
+// @Tags transferencias
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da transferência"
+// @Success 200 {object} domain.Transferencia
+// @Failure 404 {object} map[string]string
+// @Router /transferencias/{id} [get]
+func getTransferencia(service *service.TransferenciaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		transferencia, err := service.GetByID(c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, transferencia)
+	}
+}
+
+// @Summary Envia uma transferência entre locais
+// @Description Retira os itens do estoque da origem; eles ficam em trânsito até o recebimento no destino
+// @Tags transferencias
+// @Accept json
+// @Produce json
+// @Param transferencia body domain.CreateTransferenciaDTO true "Dados da transferência"
+// @Success 201 {object} domain.Transferencia
+// @Failure 400 {object} map[string]string
+// @Router /transferencias [post]
+func createTransferencia(service *service.TransferenciaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var dto domain.CreateTransferenciaDTO
+		if err := c.ShouldBindJSON(&dto); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		transferencia, err := service.Create(dto, operadorAtual(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusCreated, transferencia)
+	}
+}
+
+// @Summary Recebe uma transferência
+// @Description Dá entrada dos itens em trânsito no estoque do destino
+// @Tags transferencias
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da transferência"
+// @Success 200 {object} domain.Transferencia
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /transferencias/{id}/receber [post]
+func receberTransferencia(service *service.TransferenciaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		transferencia, err := service.Receber(c.Param("id"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroTransicao(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, transferencia)
+	}
+}
+
+// @Summary Cancela uma transferência
+// @Description Desfaz uma transferência em trânsito, devolvendo os itens ao estoque da origem
+// @Tags transferencias
+// @Accept json
+// @Produce json
+// @Param id path string true "ID da transferência"
+// @Success 200 {object} domain.Transferencia
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Router /transferencias/{id}/cancelar [post]
+func cancelarTransferencia(service *service.TransferenciaService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		transferencia, err := service.Cancelar(c.Param("id"), operadorAtual(c))
+		if err != nil {
+			c.JSON(statusErroTransicao(err), gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, transferencia)
+	}
+}
@@ -12,6 +12,7 @@ import {
     ListItem,
     ListItemText,
     Divider,
+    LinearProgress,
 } from '@mui/material';
 import {
     TrendingUp as TrendingUpIcon,
@@ -19,13 +20,27 @@ import {
     Inventory as InventoryIcon,
     Warning as WarningIcon,
 } from '@mui/icons-material';
-import { vendaService, produtoService } from '@/services/api';
+import { vendaService, produtoService, metaService } from '@/services/api';
 import Navbar from '@/components/Navbar';
 import Loading from '@/components/Loading';
 import ErrorMessage from '@/components/ErrorMessage';
 import { useLoading } from '@/hooks/useLoading';
 import { toast } from 'react-toastify';
 
+function descricaoMeta(meta) {
+    if (meta.descricao) {
+        return meta.descricao;
+    }
+    switch (meta.tipo) {
+        case 'vendedor':
+            return `Vendedor: ${meta.vendedor_nome || meta.vendedor_id}`;
+        case 'loja':
+            return `Loja: ${meta.local_nome || meta.local_id}`;
+        default:
+            return `Categoria: ${meta.categoria}`;
+    }
+}
+
 export default function Dashboard() {
     const [dados, setDados] = useState({
         totalVendas: 0,
@@ -34,14 +49,17 @@ export default function Dashboard() {
         vendasHoje: 0,
         ultimasVendas: [],
         produtosBaixaEstoque: [],
+        metas: [],
     });
     const { loading, withLoading } = useLoading();
 
     const loadDados = async () => {
         try {
-            const [vendasResponse, produtosResponse] = await Promise.all([
+            const [vendasResponse, produtosResponse, metasResponse] = await Promise.all([
                 vendaService.getAll(),
                 produtoService.getAll(),
+                // Perfis sem acesso às metas continuam vendo o restante do dashboard
+                metaService.getProgresso().catch(() => ({ data: [] })),
             ]);
 
             const vendas = vendasResponse.data;
@@ -74,6 +92,7 @@ export default function Dashboard() {
                 vendasHoje: vendasHoje.length,
                 ultimasVendas: vendas.slice(0, 5),
                 produtosBaixaEstoque: produtosBaixa.slice(0, 5),
+                metas: metasResponse.data,
             });
         } catch (error) {
             toast.error('Erro ao carregar dados do dashboard');
@@ -187,6 +206,46 @@ export default function Dashboard() {
                         </Card>
                     </Grid>
 
+                    {dados.metas.length > 0 && (
+                        <Grid item xs={12}>
+                            <Paper sx={{ p: 3 }}>
+                                <Typography variant="h6" gutterBottom>
+                                    Metas do Período
+                                </Typography>
+                                <List>
+                                    {dados.metas.map((progresso, index) => (
+                                        <div key={progresso.meta.id}>
+                                            <ListItem sx={{ display: 'block' }}>
+                                                <ListItemText
+                                                    primary={descricaoMeta(progresso.meta)}
+                                                    secondary={`R$ ${progresso.realizado.toFixed(
+                                                        2
+                                                    )} de R$ ${progresso.meta.valor.toFixed(
+                                                        2
+                                                    )} (${progresso.percentual_atingido.toFixed(
+                                                        1
+                                                    )}%) - projeção R$ ${progresso.projecao.toFixed(
+                                                        2
+                                                    )} (${progresso.percentual_projetado.toFixed(1)}%)`}
+                                                />
+                                                <LinearProgress
+                                                    variant="determinate"
+                                                    value={Math.min(progresso.percentual_atingido, 100)}
+                                                    color={
+                                                        progresso.percentual_projetado >= 100
+                                                            ? 'success'
+                                                            : 'warning'
+                                                    }
+                                                />
+                                            </ListItem>
+                                            {index < dados.metas.length - 1 && <Divider />}
+                                        </div>
+                                    ))}
+                                </List>
+                            </Paper>
+                        </Grid>
+                    )}
+
                     <Grid item xs={12} md={6}>
                         <Paper sx={{ p: 3 }}>
                             <Typography variant="h6" gutterBottom>
@@ -22,6 +22,7 @@ export function AuthProvider({ children }) {
                 })
                 .catch(() => {
                     Cookies.remove('token');
+                    Cookies.remove('refresh_token');
                     setUser(null);
                 })
                 .finally(() => {
@@ -35,8 +36,9 @@ export function AuthProvider({ children }) {
     const login = async (email, senha) => {
         try {
             const response = await authService.login(email, senha);
-            const { token, usuario } = response.data;
+            const { token, refresh_token, usuario } = response.data;
             Cookies.set('token', token);
+            Cookies.set('refresh_token', refresh_token);
             setUser(usuario);
             router.push('/');
         } catch (error) {
@@ -44,9 +46,14 @@ export function AuthProvider({ children }) {
         }
     };
 
-    const logout = () => {
-        authService.logout();
+    const logout = async () => {
+        try {
+            await authService.logout();
+        } catch {
+            // A sessão já pode ter sido encerrada no servidor
+        }
         Cookies.remove('token');
+        Cookies.remove('refresh_token');
         setUser(null);
         router.push('/login');
     };
@@ -62,6 +62,15 @@ export const relatorioService = {
     },
 };
 
+export const metaService = {
+    getAll: (params) => api.get('/metas', { params }),
+    getById: (id) => api.get(`/metas/${id}`),
+    create: (meta) => api.post('/metas', meta),
+    update: (id, meta) => api.put(`/metas/${id}`, meta),
+    delete: (id) => api.delete(`/metas/${id}`),
+    getProgresso: (params) => api.get('/metas/progresso', { params }),
+};
+
 export const configuracaoService = {
     getConfiguracoes: () => api.get('/configuracoes'),
     updateConfiguracoes: (configuracoes) => api.put('/configuracoes', configuracoes),
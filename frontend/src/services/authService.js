@@ -14,7 +14,7 @@ const authService = {
     },
 
     async logout() {
-        localStorage.removeItem('token');
+        await axios.post('/v1/auth/logout');
     }
 };
 
@@ -21,11 +21,44 @@ instance.interceptors.request.use(
     }
 );
 
+// Renovações concorrentes compartilham a mesma requisição, já que cada refresh token vale uma única vez
+let renovacao = null;
+
+const renovarToken = () => {
+    if (!renovacao) {
+        renovacao = axios
+            .post(`${process.env.NEXT_PUBLIC_API_URL}/api/v1/auth/refresh`, {
+                refresh_token: Cookies.get('refresh_token'),
+            })
+            .then((response) => {
+                Cookies.set('token', response.data.token);
+                Cookies.set('refresh_token', response.data.refresh_token);
+                return response.data.token;
+            })
+            .finally(() => {
+                renovacao = null;
+            });
+    }
+    return renovacao;
+};
+
 instance.interceptors.response.use(
     (response) => response,
-    (error) => {
+    async (error) => {
+        const original = error.config;
+        if (error.response?.status === 401 && Cookies.get('refresh_token') && !original._renovado) {
+            original._renovado = true;
+            try {
+                const token = await renovarToken();
+                original.headers.Authorization = `Bearer ${token}`;
+                return instance(original);
+            } catch {
+                // O refresh token também não vale mais; segue para o login
+            }
+        }
         if (error.response?.status === 401) {
             Cookies.remove('token');
+            Cookies.remove('refresh_token');
             window.location.href = '/login';
         }
         return Promise.reject(error);